@@ -1,117 +1,126 @@
+// Command migrate applies and reverts the database migrations:
+//
+//	migrate up [version]     apply pending migrations, up to version if given
+//	migrate down [steps]     revert the last applied migrations (1 by default)
+//	migrate redo             revert the last applied migration and apply it again
+//	migrate status           list migrations and whether they are applied
+//	migrate baseline version record migrations up to version as applied without
+//	                         running them, for a database created by hand
+//
+// Versions are file names without .sql, e.g. 009_add_document_sharing.
+//
+// A database built before migrations were tracked, by the former version of
+// this command, Backend/scripts/fix_migrations.go or psql, has no
+// schema_migrations table and up refuses to touch it. Record what it already
+// has, then apply the rest:
+//
+//	migrate baseline 009_add_document_sharing
+//	migrate up
+//
+// The former command applied 001, 002_sanda_club_schema, 003_payments_schedule
+// and 004 to 009, so 009 is the version for a database it built; pick a later
+// one if files after 009 were run by hand. baseline records every file up to
+// the version, 002_sanda_schema and 003_documents_schema included, so up never
+// runs them on such a database.
 package main
 
 import (
-	"database/sql"
+	"context"
 	"fmt"
-	"io/ioutil"
 	"log"
-	"path/filepath"
+	"os"
+	"strconv"
 
 	"east-eagles/backend/config"
+	"east-eagles/backend/internal/database"
+	"east-eagles/backend/migrations"
 
 	"github.com/joho/godotenv"
-
-	_ "github.com/lib/pq"
 )
 
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: migrate up [version] | down [steps] | redo | status | baseline <version>")
+	os.Exit(2)
+}
+
 func main() {
-	// Load .env file
+	if len(os.Args) < 2 {
+		usage()
+	}
+	command, args := os.Args[1], os.Args[2:]
+
+	if err := godotenv.Load("local.env"); err != nil {
+		log.Println("Note: local.env not found or error loading")
+	}
 	if err := godotenv.Load(); err != nil {
 		log.Println("No .env file found")
 	}
 
-	cfg := config.Load()
-	connStr := fmt.Sprintf(
-		"host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
-		cfg.DBHost, cfg.DBPort, cfg.DBUser, cfg.DBPassword, cfg.DBName,
-	)
-
-	db, err := sql.Open("postgres", connStr)
+	db, err := database.Connect(config.Load())
 	if err != nil {
 		log.Fatalf("Failed to connect to DB: %v", err)
 	}
 	defer db.Close()
 
-	if err := db.Ping(); err != nil {
-		log.Fatalf("Failed to ping DB: %v", err)
-	}
-
-	fmt.Println("Connected to database")
-
-	// Define the migration files in order
-	migrations := []string{
-		"project/migrations/001_init_schema.sql",
-		"project/migrations/002_sanda_club_schema.sql",
-		"project/migrations/003_payments_schedule.sql",
-		"project/migrations/004_fix_schema_mismatch.sql",
-		"project/migrations/005_add_is_active.sql",
-		"project/migrations/006_fix_attendance_table.sql",
-		"project/migrations/007_add_document_tags.sql",
-		"project/migrations/008_add_document_versions.sql",
-		"project/migrations/009_add_document_sharing.sql",
+	migrator, err := database.NewMigrator(db, migrations.FS)
+	if err != nil {
+		log.Fatalf("Failed to load migrations: %v", err)
 	}
+	ctx := context.Background()
 
-	// Run each migration in a separate transaction
-	for _, migrationFile := range migrations {
-		fmt.Printf("Running migration: %s\n", migrationFile)
-
-		// Get absolute path from current directory
-		path, _ := filepath.Abs(migrationFile)
-		content, err := ioutil.ReadFile(path)
-		if err != nil {
-			log.Printf("Failed to read migration file %s: %v", migrationFile, err)
-			continue
+	switch {
+	case command == "up" && len(args) <= 1:
+		target := ""
+		if len(args) == 1 {
+			target = args[0]
 		}
-
-		// Execute in a separate transaction
-		tx, err := db.Begin()
+		applied, err := migrator.Up(ctx, target)
 		if err != nil {
-			log.Printf("Failed to begin transaction for %s: %v", migrationFile, err)
-			continue
+			log.Fatalf("❌ %v", err)
 		}
+		fmt.Printf("%d migrations applied\n", len(applied))
 
-		_, err = tx.Exec(string(content))
+	case command == "down" && len(args) <= 1:
+		steps := 1
+		if len(args) == 1 {
+			if steps, err = strconv.Atoi(args[0]); err != nil || steps < 1 {
+				usage()
+			}
+		}
+		reverted, err := migrator.Down(ctx, steps)
 		if err != nil {
-			log.Printf("Error running migration %s: %v", migrationFile, err)
-			tx.Rollback()
-			// Continue with next migration
-			continue
+			log.Fatalf("❌ %v", err)
 		}
+		fmt.Printf("%d migrations reverted\n", len(reverted))
 
-		err = tx.Commit()
+	case command == "redo" && len(args) == 0:
+		version, err := migrator.Redo(ctx)
 		if err != nil {
-			log.Printf("Failed to commit transaction for %s: %v", migrationFile, err)
-		} else {
-			fmt.Printf("Successfully ran migration: %s\n", migrationFile)
+			log.Fatalf("❌ %v", err)
 		}
-	}
+		fmt.Printf("%s redone\n", version)
 
-	// Inspect athletes table
-	rows, err := db.Query("SELECT column_name, data_type FROM information_schema.columns WHERE table_name = 'athletes'")
-	if err != nil {
-		log.Printf("Failed to query columns: %v", err)
-	} else {
-		defer rows.Close()
-		fmt.Println("Columns in 'athletes' table:")
-		found := false
-		for rows.Next() {
-			found = true
-			var name, dtype string
-			rows.Scan(&name, &dtype)
-			fmt.Printf("- %s (%s)\n", name, dtype)
+	case command == "status" && len(args) == 0:
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			log.Fatalf("❌ %v", err)
 		}
-		if !found {
-			fmt.Println("Table 'athletes' does not exist.")
-			// Check if members exists
-			rows2, err := db.Query("SELECT column_name FROM information_schema.columns WHERE table_name = 'members'")
-			if err == nil {
-				defer rows2.Close()
-				if rows2.Next() {
-					fmt.Println("Table 'members' EXISTS.")
-				} else {
-					fmt.Println("Table 'members' does not exist.")
-				}
+		for _, s := range statuses {
+			appliedAt := ""
+			if s.AppliedAt != nil {
+				appliedAt = s.AppliedAt.Local().Format("02/01/2006 15:04")
 			}
+			fmt.Printf("%-10s %-16s %s\n", s.State, appliedAt, s.Version)
 		}
+
+	case command == "baseline" && len(args) == 1:
+		recorded, err := migrator.Baseline(ctx, args[0])
+		if err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		fmt.Printf("%d migrations recorded as applied\n", len(recorded))
+
+	default:
+		usage()
 	}
 }
@@ -0,0 +1,158 @@
+// Command schema-check compares the database with the columns the
+// repositories use (repository.Schema) and reports missing columns, type
+// mismatches, and columns or tables no repository uses. It exits with
+// status 1 when a repository would fail against the database.
+//
+// With -baseline, once the check passes, it writes the whole schema to
+// migrations/NNN_name.baseline.sql, NNN_name being the latest applied
+// migration. Fresh installs then create that schema in one step instead of
+// replaying every migration. CI regenerates the baseline from a replay of
+// the migrations and fails when the committed one differs, so a migration
+// goes in with the baseline it produces (the CI run uploads it).
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"east-eagles/backend/config"
+	"east-eagles/backend/internal/database"
+	"east-eagles/backend/internal/repository"
+
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	baseline := flag.Bool("baseline", false, "write a baseline migration when the check passes")
+	dir := flag.String("dir", "migrations", "directory the baseline migration is written to")
+	seed := flag.String("seed", "age_groups,weight_classes,belts,document_categories,document_tags",
+		"comma-separated reference tables whose rows go in the baseline, in insertion order")
+	flag.Parse()
+
+	if err := godotenv.Load("local.env"); err != nil {
+		log.Println("Note: local.env not found or error loading")
+	}
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found")
+	}
+
+	db, err := database.Connect(config.Load())
+	if err != nil {
+		log.Fatalf("Failed to connect to DB: %v", err)
+	}
+	defer db.Close()
+
+	tables, err := database.Columns(db)
+	if err != nil {
+		log.Fatalf("Failed to read the schema: %v", err)
+	}
+
+	problems := 0
+	used := map[string]map[string]bool{}
+	repo := ""
+	for _, t := range repository.Schema {
+		if t.Repository != repo {
+			repo = t.Repository
+			fmt.Println(repo)
+		}
+		used[t.Table] = map[string]bool{}
+
+		actual := map[string]database.Column{}
+		for _, c := range tables[t.Table] {
+			actual[c.Name] = c
+		}
+		if len(actual) == 0 {
+			fmt.Printf("  ❌ %s: table missing\n", t.Table)
+			problems++
+			continue
+		}
+
+		var names []string
+		for name := range t.Columns {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			used[t.Table][name] = true
+			c, ok := actual[name]
+			switch {
+			case !ok:
+				fmt.Printf("  ❌ %s.%s: missing\n", t.Table, name)
+				problems++
+			case c.Type != t.Columns[name]:
+				fmt.Printf("  ❌ %s.%s: %s expected, %s in the database\n", t.Table, name, t.Columns[name], c.SQLType)
+				problems++
+			}
+		}
+	}
+
+	var extraColumns, extraTables []string
+	for table, columns := range tables {
+		if table == "schema_migrations" {
+			continue
+		}
+		if used[table] == nil {
+			extraTables = append(extraTables, table)
+			continue
+		}
+		for _, c := range columns {
+			if !used[table][c.Name] {
+				extraColumns = append(extraColumns, fmt.Sprintf("%s.%s (%s)", table, c.Name, c.SQLType))
+			}
+		}
+	}
+	sort.Strings(extraColumns)
+	sort.Strings(extraTables)
+	if len(extraColumns) > 0 {
+		fmt.Println("Columns no repository uses")
+		for _, c := range extraColumns {
+			fmt.Printf("  ⚠️ %s\n", c)
+		}
+	}
+	if len(extraTables) > 0 {
+		fmt.Println("Tables no repository uses")
+		for _, t := range extraTables {
+			fmt.Printf("  ⚠️ %s\n", t)
+		}
+	}
+
+	if problems > 0 {
+		fmt.Printf("\n%d problems found\n", problems)
+		os.Exit(1)
+	}
+	fmt.Println("\n✅ The database has every column the repositories use")
+
+	if !*baseline {
+		return
+	}
+	var version string
+	if err := db.QueryRow(`SELECT MAX(version) FROM schema_migrations`).Scan(&version); err != nil {
+		log.Fatalf("❌ No migration history to name the baseline after (run cmd/migrate first): %v", err)
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "-- Migration: %s.baseline.sql\n", version)
+	fmt.Fprintf(&buf, "-- Description: Whole schema as of %s, applied instead of the migrations up to it on an empty database\n", version)
+	fmt.Fprintf(&buf, "-- Written by cmd/schema-check -baseline; regenerate it rather than editing it\n")
+	var seedTables []string
+	for _, t := range strings.Split(*seed, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			seedTables = append(seedTables, t)
+		}
+	}
+	if err := database.WriteBaseline(db, &buf, seedTables); err != nil {
+		log.Fatalf("❌ Failed to write the baseline: %v", err)
+	}
+
+	path := filepath.Join(*dir, version+".baseline.sql")
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	fmt.Printf("Baseline written to %s\n", path)
+}
@@ -1,16 +1,26 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"log"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+	_ "time/tzdata" // CLUB_TIMEZONE must load in images without a zoneinfo database
 
 	"east-eagles/backend/config"
 	"east-eagles/backend/internal/database"
 	"east-eagles/backend/internal/handlers"
 	"east-eagles/backend/internal/middleware"
+	"east-eagles/backend/internal/models"
 	"east-eagles/backend/internal/repository"
 	"east-eagles/backend/internal/services"
+	"east-eagles/backend/migrations"
 
 	"github.com/gorilla/mux"
 	"github.com/joho/godotenv"
@@ -29,6 +39,15 @@ func main() {
 
 	// Charger la configuration
 	cfg := config.Load()
+	migrate := flag.Bool("migrate", cfg.MigrateOnStart, "apply pending migrations before starting")
+	flag.Parse()
+
+	// Session, event and announcement times are wall-clock times in the club timezone
+	clubLocation, err := time.LoadLocation(cfg.ClubTimezone)
+	if err != nil {
+		log.Fatal("Invalid CLUB_TIMEZONE:", err)
+	}
+	models.SetClubLocation(clubLocation)
 
 	// Connexion à la base de données
 	db, err := database.Connect(cfg)
@@ -39,16 +58,35 @@ func main() {
 
 	log.Println("✅ Connexion à PostgreSQL réussie")
 
+	if *migrate {
+		migrator, err := database.NewMigrator(db, migrations.FS)
+		if err != nil {
+			log.Fatal("Failed to load migrations:", err)
+		}
+		applied, err := migrator.Up(context.Background(), "")
+		if err != nil {
+			log.Fatal("Migration failed:", err)
+		}
+		log.Printf("✅ Database schema up to date (%d migrations applied)", len(applied))
+	}
+
+	// Cancelled on SIGINT/SIGTERM to shut down gracefully
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	// Initialiser les repositories
 	athleteRepo := repository.NewAthleteRepository(db)
 	userRepo := repository.NewUserRepository(db)
 	trainingRepo := repository.NewTrainingRepository(db)
+	venueRepo := repository.NewVenueRepository(db)
+	bookingRepo := repository.NewBookingRepository(db)
 	documentRepo := repository.NewDocumentRepository(db)
-	// eventRepo := repository.NewEventRepository(db) // Legacy
-	// announcementRepo := repository.NewAnnouncementRepository(db) // Legacy
+	guardianRepo := repository.NewGuardianRepository(db)
+	eventRepo := repository.NewEventRepository(db)
+	announcementRepo := repository.NewAnnouncementRepository(db)
 
 	// Initialiser les services
-	authService := services.NewAuthService(userRepo, athleteRepo, cfg.JWTSecret)
+	authService := services.NewAuthService(userRepo, athleteRepo, guardianRepo, cfg.JWTSecret)
 
 	// Initialize Cloudinary service
 	cloudinaryService, err := services.NewCloudinaryService(
@@ -61,21 +99,184 @@ func main() {
 	}
 	log.Println("✅ Cloudinary service initialized")
 
+	// --- Notifications ---
+	templates, err := services.NewTemplateRegistry()
+	if err != nil {
+		log.Fatal("Failed to load notification templates:", err)
+	}
+	emailDriver, err := services.NewEmailDriver(cfg.NotifyEmailDriver, services.SMTPConfig{
+		Host:     cfg.SMTPHost,
+		Port:     cfg.SMTPPort,
+		Username: cfg.SMTPUsername,
+		Password: cfg.SMTPPassword,
+		From:     cfg.SMTPFrom,
+	})
+	if err != nil {
+		log.Fatal("Failed to initialize email driver:", err)
+	}
+	smsDriver, err := services.NewSMSDriver(cfg.NotifySMSDriver, services.HTTPSMSConfig{
+		URL:    cfg.SMSHTTPURL,
+		Token:  cfg.SMSHTTPToken,
+		Sender: cfg.SMSSender,
+	})
+	if err != nil {
+		log.Fatal("Failed to initialize SMS driver:", err)
+	}
+	for _, d := range []services.NotificationDriver{emailDriver, smsDriver} {
+		if d != nil {
+			log.Printf("📨 Notifications enabled (%s)", d.Name())
+		}
+	}
+	notificationRepo := repository.NewNotificationRepository(db)
+	notifier := services.NewNotifier(notificationRepo, templates, emailDriver, smsDriver, cfg.NotifyMaxAttempts)
+	notificationHub := services.NewNotificationHub(notificationRepo)
+	notificationHandler := handlers.NewNotificationHandler(notificationRepo, notifier, notificationHub, cfg.DocumentReminderDays, cfg.PaymentGraceDays)
+
+	// Background workers; shutdown waits for them
+	var workers sync.WaitGroup
+
+	// Deliver the outbox
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		notifier.Run(ctx, time.Duration(cfg.NotifyPollSeconds)*time.Second)
+	}()
+
+	// Push new in-app notifications to live streams
+	go notificationHub.Listen(database.DSN(cfg))
+
 	// Initialiser les handlers
-	athleteHandler := handlers.NewAthleteHandler(athleteRepo, cloudinaryService)
+	athleteHandler := handlers.NewAthleteHandler(athleteRepo, cloudinaryService, notifier)
 	authHandler := handlers.NewAuthHandler(authService)
-	trainingHandler := handlers.NewTrainingHandler(trainingRepo)
-	documentHandler := handlers.NewDocumentHandler(documentRepo, athleteRepo, userRepo, cloudinaryService)
-	// eventHandler := handlers.NewEventHandler(eventRepo)
-	// announcementHandler := handlers.NewAnnouncementHandler(announcementRepo)
+	userHandler := handlers.NewUserHandler(userRepo, athleteRepo)
+	guardianHandler := handlers.NewGuardianHandler(guardianRepo, userRepo)
+	trainingHandler := handlers.NewTrainingHandler(trainingRepo, venueRepo, bookingRepo, notifier)
+	bookingHandler := handlers.NewBookingHandler(bookingRepo, athleteRepo,
+		time.Duration(cfg.BookingCutoffMinutes)*time.Minute, time.Duration(cfg.CancelCutoffMinutes)*time.Minute)
+	attendanceHandler := handlers.NewAttendanceHandler(trainingRepo, athleteRepo)
+	checkinTokens := services.NewCheckinTokens(cfg.CheckinSecret, time.Duration(cfg.CheckinTokenSeconds)*time.Second)
+	checkinHandler := handlers.NewCheckinHandler(trainingRepo, venueRepo, athleteRepo, checkinTokens,
+		time.Duration(cfg.CheckinOpenMinutes)*time.Minute)
+	venueHandler := handlers.NewVenueHandler(venueRepo)
+	documentHandler := handlers.NewDocumentHandler(documentRepo, athleteRepo, userRepo, cloudinaryService, notifier)
+	eventHandler := handlers.NewEventHandler(eventRepo, athleteRepo)
+	bracketRepo := repository.NewBracketRepository(db)
+	rankingRepo := repository.NewRankingRepository(db)
+	ratingService := services.NewRatingService(rankingRepo)
+	bracketHandler := handlers.NewBracketHandler(bracketRepo, eventRepo, ratingService)
+	rankingHandler := handlers.NewRankingHandler(rankingRepo, ratingService)
+	weighInRepo := repository.NewWeighInRepository(db)
+	weighInHandler := handlers.NewWeighInHandler(weighInRepo, athleteRepo)
+	gradingRepo := repository.NewGradingRepository(db)
+	gradingHandler := handlers.NewGradingHandler(gradingRepo, trainingRepo)
+	announcementHandler := handlers.NewAnnouncementHandler(announcementRepo, cloudinaryService)
 
 	// --- Payments ---
 	paymentRepo := repository.NewPaymentRepository(db)
-	paymentHandler := handlers.NewPaymentHandler(paymentRepo, athleteRepo)
+	receiptService := services.NewReceiptService(cfg.ReceiptFontPath)
+	paymentHandler := handlers.NewPaymentHandler(paymentRepo, athleteRepo, receiptService, notifier, cfg.PaymentGraceDays)
+
+	// --- Online Payments ---
+	paymentProvider, err := services.NewPaymentProvider(cfg.PaymentProvider, cfg.PaymentWebhookSecret, cfg.PublicURL)
+	if err != nil {
+		log.Fatal("Failed to initialize payment provider:", err)
+	}
+	if paymentProvider != nil {
+		log.Printf("💳 Online payments enabled (%s)", paymentProvider.Name())
+	}
+	checkoutRepo := repository.NewCheckoutRepository(db)
+	checkoutHandler := handlers.NewCheckoutHandler(checkoutRepo, athleteRepo, paymentProvider, cfg.MonthlyFee, cfg.PublicURL)
+
+	// --- Reports ---
+	reportRepo := repository.NewReportRepository(db)
+	reportHandler := handlers.NewReportHandler(reportRepo, cfg.MonthlyFee)
 
 	// --- Schedules ---
 	scheduleRepo := repository.NewScheduleRepository(db)
-	scheduleHandler := handlers.NewScheduleHandler(scheduleRepo)
+	sessionGenerator := services.NewSessionGenerator(scheduleRepo, notifier, cfg.SessionHorizonDays)
+	scheduleHandler := handlers.NewScheduleHandler(scheduleRepo, venueRepo, sessionGenerator)
+
+	// --- Calendar feeds ---
+	calendarRepo := repository.NewCalendarRepository(db)
+	calendarHandler := handlers.NewCalendarHandler(calendarRepo, scheduleRepo, athleteRepo, cfg.PublicURL, cfg.ClubTimezone, cfg.SessionHorizonDays)
+
+	// --- Background jobs ---
+	jobRepo := repository.NewJobRepository(db)
+	jobRunner := services.NewJobRunner(jobRepo, cfg.WorkerID, cfg.JobWorkers,
+		time.Duration(cfg.JobPollSeconds)*time.Second, clubLocation)
+
+	// Keep sessions generated for the rolling horizon
+	jobRunner.Register("sessions.generate", 3, 5*time.Minute, func(ctx context.Context, job *models.Job) error {
+		result, err := sessionGenerator.SyncAll(ctx)
+		if err != nil {
+			return err
+		}
+		log.Printf("📅 Sessions generated: %d created, %d updated, %d removed (%d with bookings)",
+			result.Created, result.Updated, result.Removed, len(result.Cancelled))
+		return nil
+	})
+	jobRunner.Register("notifications.reminders", 3, 10*time.Minute, func(ctx context.Context, job *models.Job) error {
+		result, err := notifier.QueueReminders(ctx, cfg.DocumentReminderDays, cfg.PaymentGraceDays)
+		if err != nil {
+			return err
+		}
+		log.Printf("⏰ Reminders queued: %d (%d expiring documents, %d overdue payments)",
+			result.Queued, result.DocumentsExpiring, result.PaymentsOverdue)
+		return nil
+	})
+	jobRunner.Register("documents.cleanup_shares", 3, time.Minute, func(ctx context.Context, job *models.Job) error {
+		removed, err := documentRepo.DeleteExpiredShares(ctx)
+		if err != nil {
+			return err
+		}
+		log.Printf("🧹 Expired document shares removed: %d", removed)
+		return nil
+	})
+	jobRunner.Register("memberships.expire", 3, 5*time.Minute, func(ctx context.Context, job *models.Job) error {
+		if cfg.MembershipExpiryDays <= 0 {
+			return nil
+		}
+		expired, renewed, err := athleteRepo.SyncMembershipExpiry(ctx, cfg.MembershipExpiryDays)
+		if err != nil {
+			return err
+		}
+		log.Printf("🪪 Memberships: %d expired, %d renewed", expired, renewed)
+		return nil
+	})
+	jobRunner.Register("jobs.cleanup", 1, time.Minute, func(ctx context.Context, job *models.Job) error {
+		removed, err := jobRepo.DeleteFinished(ctx, 30*24*time.Hour)
+		if err != nil {
+			return err
+		}
+		log.Printf("🧹 Finished jobs removed: %d", removed)
+		return nil
+	})
+
+	// Cron expressions are in the club's time zone
+	for _, s := range []struct{ name, cron, jobType string }{
+		{"sessions-daily", "0 3 * * *", "sessions.generate"},
+		{"reminders-daily", "0 9 * * *", "notifications.reminders"},
+		{"document-shares-nightly", "30 2 * * *", "documents.cleanup_shares"},
+		{"memberships-daily", "0 4 * * *", "memberships.expire"},
+		{"jobs-weekly", "0 5 * * 0", "jobs.cleanup"},
+	} {
+		if err := jobRunner.Schedule(s.name, s.cron, s.jobType); err != nil {
+			log.Fatal("Invalid job schedule:", err)
+		}
+	}
+	// Generate sessions at startup too, not only at the nightly run
+	if _, err := jobRunner.Enqueue("sessions.generate", nil, time.Now()); err != nil {
+		log.Printf("❌ Could not queue session generation: %v", err)
+	}
+	jobHandler := handlers.NewJobHandler(jobRepo, jobRunner)
+
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		if err := jobRunner.Run(ctx); err != nil {
+			log.Printf("❌ Job runner failed: %v", err)
+		}
+	}()
 
 	// Créer le routeur
 	router := mux.NewRouter()
@@ -100,7 +301,7 @@ func main() {
 		}
 		w.Header().Set("Access-Control-Allow-Origin", origin)
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Requested-With")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Requested-With, X-Athlete-ID")
 		w.Header().Set("Access-Control-Allow-Credentials", "true")
 		w.WriteHeader(http.StatusOK)
 	})
@@ -112,6 +313,17 @@ func main() {
 	router.HandleFunc("/api/auth/register", authHandler.Register).Methods("POST")
 	router.HandleFunc("/api/auth/login", authHandler.Login).Methods("POST")
 
+	// Payment provider webhooks (authenticated by signature)
+	router.HandleFunc("/api/payments/webhooks/{provider}", checkoutHandler.Webhook).Methods("POST")
+	if cfg.PaymentProvider == "fake" {
+		router.HandleFunc("/api/payments/providers/fake/checkout/{session}", checkoutHandler.FakeCheckoutPage).Methods("GET")
+		router.HandleFunc("/api/payments/providers/fake/checkout/{session}", checkoutHandler.FakeCheckoutSubmit).Methods("POST")
+	}
+
+	// Calendar subscriptions (authenticated by the per-user feed token)
+	router.HandleFunc("/api/calendar/club.ics", calendarHandler.ClubFeed).Methods("GET")
+	router.HandleFunc("/api/calendar/athlete.ics", calendarHandler.AthleteFeed).Methods("GET")
+
 	// Health check endpoint (public, for deployment)
 	router.HandleFunc("/api/health", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
@@ -133,6 +345,15 @@ func main() {
 	api.HandleFunc("/athletes/profile", athleteHandler.GetProfile).Methods("GET")
 	api.HandleFunc("/athletes/profile", athleteHandler.UpdateProfile).Methods("PUT")
 	api.HandleFunc("/athletes/profile/image", athleteHandler.UploadProfileImage).Methods("POST")
+	api.HandleFunc("/guardian/children", guardianHandler.GetChildren).Methods("GET")
+	api.HandleFunc("/announcements", announcementHandler.GetMine).Methods("GET")
+	api.HandleFunc("/announcements/{id}/read", announcementHandler.MarkRead).Methods("POST")
+	api.HandleFunc("/notifications/preferences", notificationHandler.GetPreferences).Methods("GET")
+	api.HandleFunc("/notifications/preferences", notificationHandler.UpdatePreferences).Methods("PUT")
+	api.HandleFunc("/notifications", notificationHandler.GetNotifications).Methods("GET")
+	api.HandleFunc("/notifications/read-all", notificationHandler.MarkAllRead).Methods("POST")
+	api.HandleFunc("/notifications/stream", notificationHandler.Stream).Methods("GET")
+	api.HandleFunc("/notifications/{id}/read", notificationHandler.MarkRead).Methods("POST")
 
 	// --- Routes Admin/Coach ---
 	admin := api.PathPrefix("/admin").Subrouter()
@@ -143,8 +364,16 @@ func main() {
 	admin.HandleFunc("/athletes/pending", athleteHandler.GetPending).Methods("GET")
 	admin.HandleFunc("/athletes/stats", athleteHandler.GetStats).Methods("GET")
 	admin.HandleFunc("/athletes/{id}", athleteHandler.GetByID).Methods("GET")
+	admin.HandleFunc("/athletes/{id}/attendance-stats", attendanceHandler.GetAthleteStats).Methods("GET")
+	admin.HandleFunc("/athletes/{id}/record", rankingHandler.GetAthleteRecord).Methods("GET")
+	admin.HandleFunc("/athletes/{id}/weigh-ins", weighInHandler.GetByAthlete).Methods("GET")
+	admin.HandleFunc("/athletes/{id}/weigh-ins", weighInHandler.Create).Methods("POST")
+	admin.HandleFunc("/athletes/{id}/promotions", gradingHandler.GetAthletePromotions).Methods("GET")
 	admin.HandleFunc("/athletes/{id}/approve", athleteHandler.Approve).Methods("POST")
 	admin.HandleFunc("/athletes/{id}/reject", athleteHandler.Reject).Methods("POST")
+	admin.HandleFunc("/athletes/{id}/guardians", guardianHandler.GetByAthlete).Methods("GET")
+	admin.HandleFunc("/athletes/{id}/guardians", guardianHandler.Add).Methods("POST")
+	admin.HandleFunc("/athletes/{id}/guardians/{userId}", guardianHandler.Remove).Methods("DELETE")
 	admin.HandleFunc("/athletes/{id}", athleteHandler.Update).Methods("PUT")
 	admin.HandleFunc("/athletes/{id}", athleteHandler.Delete).Methods("DELETE")
 
@@ -156,6 +385,10 @@ func main() {
 	admin.HandleFunc("/trainings/{id}", trainingHandler.Delete).Methods("DELETE")
 	admin.HandleFunc("/trainings/{id}/attendance", trainingHandler.MarkAttendance).Methods("POST")
 	admin.HandleFunc("/trainings/{id}/attendance", trainingHandler.GetAttendance).Methods("GET")
+	admin.HandleFunc("/trainings/{id}/attendance/batch", trainingHandler.MarkAttendanceBatch).Methods("POST")
+	admin.HandleFunc("/trainings/{id}/checkin-token", checkinHandler.GetToken).Methods("GET")
+	admin.HandleFunc("/attendance/low", attendanceHandler.GetLowAttendance).Methods("GET")
+	admin.HandleFunc("/trainings/{id}/bookings", bookingHandler.GetBySession).Methods("GET")
 
 	// Document Management
 	// More specific routes first to avoid conflicts
@@ -190,20 +423,130 @@ func main() {
 	admin.HandleFunc("/payments", paymentHandler.Create).Methods("POST")
 	admin.HandleFunc("/payments/recent", paymentHandler.GetRecent).Methods("GET")
 	admin.HandleFunc("/payments/athlete/{id}", paymentHandler.GetByAthlete).Methods("GET")
+	admin.HandleFunc("/payments/athlete/{id}/coverage", paymentHandler.GetCoverage).Methods("GET")
+	admin.HandleFunc("/payments/athlete/{id}/balance", paymentHandler.GetBalance).Methods("GET")
+	admin.HandleFunc("/payments/{id}/installments", paymentHandler.AddInstallment).Methods("POST")
+	admin.HandleFunc("/payments/{id}/refund", paymentHandler.Refund).Methods("POST")
 	admin.HandleFunc("/payments/{id}", paymentHandler.Update).Methods("PUT")
 	admin.HandleFunc("/payments/{id}", paymentHandler.Delete).Methods("DELETE")
 
+	// Finance Reports (admin only)
+	reports := admin.PathPrefix("/reports").Subrouter()
+	reports.Use(middleware.RequireAdmin)
+	reports.HandleFunc("/finance", reportHandler.GetFinance).Methods("GET")
+	reports.HandleFunc("/finance/{report}", reportHandler.GetFinanceReport).Methods("GET")
+
+	// Background jobs (Admin only)
+	jobs := admin.PathPrefix("/jobs").Subrouter()
+	jobs.Use(middleware.RequireAdmin)
+	jobs.HandleFunc("", jobHandler.GetAll).Methods("GET")
+	jobs.HandleFunc("", jobHandler.Enqueue).Methods("POST")
+	jobs.HandleFunc("/stats", jobHandler.GetStats).Methods("GET")
+	jobs.HandleFunc("/schedules", jobHandler.GetSchedules).Methods("GET")
+	jobs.HandleFunc("/{id}", jobHandler.GetByID).Methods("GET")
+	jobs.HandleFunc("/{id}/retry", jobHandler.Retry).Methods("POST")
+
+	// Account ↔ athlete links (admin only)
+	users := admin.PathPrefix("/users").Subrouter()
+	users.Use(middleware.RequireAdmin)
+	users.HandleFunc("/unlinked", userHandler.GetUnlinked).Methods("GET")
+	users.HandleFunc("/{id}/link", userHandler.LinkAthlete).Methods("POST")
+
 	// Schedule Management
 	admin.HandleFunc("/schedules", scheduleHandler.Create).Methods("POST")
 	admin.HandleFunc("/schedules", scheduleHandler.GetAll).Methods("GET")
+	admin.HandleFunc("/schedules/generate", scheduleHandler.GenerateSessions).Methods("POST")
+	admin.HandleFunc("/schedules/closures", scheduleHandler.GetClosures).Methods("GET")
+	admin.HandleFunc("/schedules/closures", scheduleHandler.CreateClosure).Methods("POST")
+	admin.HandleFunc("/schedules/closures/{id}", scheduleHandler.DeleteClosure).Methods("DELETE")
 	admin.HandleFunc("/schedules/{id}", scheduleHandler.Update).Methods("PUT")
 	admin.HandleFunc("/schedules/{id}", scheduleHandler.Delete).Methods("DELETE")
 
+	// Competitions & events
+	admin.HandleFunc("/events", eventHandler.GetAll).Methods("GET")
+	admin.HandleFunc("/events", eventHandler.Create).Methods("POST")
+	admin.HandleFunc("/events/{id}", eventHandler.GetByID).Methods("GET")
+	admin.HandleFunc("/events/{id}", eventHandler.Update).Methods("PUT")
+	admin.HandleFunc("/events/{id}", eventHandler.Delete).Methods("DELETE")
+	admin.HandleFunc("/events/{id}/registrations", eventHandler.GetRegistrations).Methods("GET")
+	admin.HandleFunc("/events/{id}/registrations", eventHandler.Nominate).Methods("POST")
+	admin.HandleFunc("/events/{id}/registrations/{athleteId}", eventHandler.RemoveRegistration).Methods("DELETE")
+	admin.HandleFunc("/events/{id}/brackets", bracketHandler.GetByEvent).Methods("GET")
+	admin.HandleFunc("/events/{id}/brackets", bracketHandler.Generate).Methods("POST")
+	admin.HandleFunc("/bouts/{id}/result", bracketHandler.RecordResult).Methods("PUT")
+	admin.HandleFunc("/rankings", rankingHandler.GetRankings).Methods("GET")
+	admin.HandleFunc("/rankings/recalculate", rankingHandler.Recalculate).Methods("POST")
+
+	// Weigh-ins & weight classes
+	admin.HandleFunc("/weigh-ins/alerts", weighInHandler.GetDrifts).Methods("GET")
+	admin.HandleFunc("/weigh-ins/{id}", weighInHandler.Delete).Methods("DELETE")
+	admin.HandleFunc("/weight-classes", weighInHandler.GetClasses).Methods("GET")
+	admin.HandleFunc("/weight-classes", weighInHandler.ReplaceClasses).Methods("PUT")
+
+	// Belt gradings
+	admin.HandleFunc("/belts", gradingHandler.GetBelts).Methods("GET")
+	admin.HandleFunc("/belts", gradingHandler.ReplaceBelts).Methods("PUT")
+	admin.HandleFunc("/gradings", gradingHandler.GetSessions).Methods("GET")
+	admin.HandleFunc("/gradings", gradingHandler.CreateSession).Methods("POST")
+	admin.HandleFunc("/gradings/{id}", gradingHandler.GetSession).Methods("GET")
+	admin.HandleFunc("/gradings/{id}", gradingHandler.UpdateSession).Methods("PUT")
+	admin.HandleFunc("/gradings/{id}", gradingHandler.DeleteSession).Methods("DELETE")
+	admin.HandleFunc("/gradings/{id}/eligible", gradingHandler.GetEligible).Methods("GET")
+	admin.HandleFunc("/gradings/{id}/candidates", gradingHandler.AddCandidates).Methods("POST")
+	admin.HandleFunc("/gradings/{id}/candidates/{athleteId}", gradingHandler.RemoveCandidate).Methods("DELETE")
+	admin.HandleFunc("/gradings/{id}/candidates/{athleteId}/result", gradingHandler.RecordResult).Methods("PUT")
+
+	// Announcements
+	admin.HandleFunc("/announcements", announcementHandler.GetAll).Methods("GET")
+	admin.HandleFunc("/announcements", announcementHandler.Create).Methods("POST")
+	admin.HandleFunc("/announcements/{id}", announcementHandler.GetByID).Methods("GET")
+	admin.HandleFunc("/announcements/{id}", announcementHandler.Update).Methods("PUT")
+	admin.HandleFunc("/announcements/{id}", announcementHandler.Delete).Methods("DELETE")
+	admin.HandleFunc("/announcements/{id}/readers", announcementHandler.GetReaders).Methods("GET")
+	admin.HandleFunc("/announcements/{id}/attachments", announcementHandler.UploadAttachment).Methods("POST")
+	admin.HandleFunc("/announcements/{id}/attachments/{attachmentId}", announcementHandler.DeleteAttachment).Methods("DELETE")
+
+	// Notification outbox
+	admin.HandleFunc("/notifications/outbox", notificationHandler.GetOutbox).Methods("GET")
+	admin.HandleFunc("/notifications/outbox/{id}/retry", notificationHandler.RetryOutbox).Methods("POST")
+	admin.HandleFunc("/notifications/reminders", notificationHandler.SendReminders).Methods("POST")
+
+	// Venues
+	admin.HandleFunc("/venues", venueHandler.GetAll).Methods("GET")
+	admin.HandleFunc("/venues", venueHandler.Create).Methods("POST")
+	admin.HandleFunc("/venues/{id}", venueHandler.Update).Methods("PUT")
+	admin.HandleFunc("/venues/{id}", venueHandler.Delete).Methods("DELETE")
+
 	// Athlete/Coach Shared Routes
 	api.HandleFunc("/trainings/upcoming", trainingHandler.GetUpcoming).Methods("GET")
-	api.HandleFunc("/trainings/history", trainingHandler.GetHistory).Methods("GET")
+	api.HandleFunc("/trainings/history", attendanceHandler.GetHistory).Methods("GET")
+	api.HandleFunc("/trainings/stats", attendanceHandler.GetMyStats).Methods("GET")
+	api.HandleFunc("/trainings/bookable", bookingHandler.GetBookable).Methods("GET")
+	api.HandleFunc("/trainings/bookings", bookingHandler.GetMine).Methods("GET")
+	api.HandleFunc("/trainings/{id}/book", bookingHandler.Book).Methods("POST")
+	api.HandleFunc("/trainings/{id}/book", bookingHandler.Cancel).Methods("DELETE")
+	api.HandleFunc("/trainings/{id}/checkin", checkinHandler.CheckIn).Methods("POST")
 	api.HandleFunc("/payments/my", paymentHandler.GetMyPayments).Methods("GET")
+	api.HandleFunc("/payments/my/balance", paymentHandler.GetMyBalance).Methods("GET")
+	api.HandleFunc("/payments/checkout", checkoutHandler.Create).Methods("POST")
+	api.HandleFunc("/payments/checkout/{id}", checkoutHandler.Get).Methods("GET")
+	api.HandleFunc("/payments/{id}/receipt.pdf", paymentHandler.GetReceipt).Methods("GET")
 	api.HandleFunc("/schedules", scheduleHandler.GetAll).Methods("GET")
+	api.HandleFunc("/events", eventHandler.GetForAthlete).Methods("GET")
+	api.HandleFunc("/events/registrations", eventHandler.GetMyRegistrations).Methods("GET")
+	api.HandleFunc("/events/{id}", eventHandler.GetByID).Methods("GET")
+	api.HandleFunc("/events/{id}/register", eventHandler.Register).Methods("POST")
+	api.HandleFunc("/events/{id}/register", eventHandler.Withdraw).Methods("DELETE")
+	api.HandleFunc("/events/{id}/brackets", bracketHandler.GetByEvent).Methods("GET")
+	api.HandleFunc("/rankings", rankingHandler.GetRankings).Methods("GET")
+	api.HandleFunc("/athletes/profile/record", rankingHandler.GetMyRecord).Methods("GET")
+	api.HandleFunc("/athletes/profile/weigh-ins", weighInHandler.GetMine).Methods("GET")
+	api.HandleFunc("/weight-classes", weighInHandler.GetClasses).Methods("GET")
+	api.HandleFunc("/athletes/profile/promotions", gradingHandler.GetMyPromotions).Methods("GET")
+	api.HandleFunc("/belts", gradingHandler.GetBelts).Methods("GET")
+	api.HandleFunc("/calendar/token", calendarHandler.GetToken).Methods("GET")
+	api.HandleFunc("/calendar/token", calendarHandler.RotateToken).Methods("POST")
+	api.HandleFunc("/calendar/token", calendarHandler.RevokeToken).Methods("DELETE")
 
 	// Document Upload (Athlete)
 	api.HandleFunc("/documents/upload", documentHandler.Upload).Methods("POST")
@@ -216,8 +559,37 @@ func main() {
 		port = "8080"
 	}
 
-	log.Printf("🚀 Serveur démarré sur le port %s", port)
-	if err := http.ListenAndServe(":"+port, router); err != nil {
-		log.Fatal(err)
+	// Requests see ctx, so live streams end when the server shuts down
+	server := &http.Server{
+		Addr:        ":" + port,
+		Handler:     router,
+		BaseContext: func(net.Listener) context.Context { return ctx },
+	}
+	go func() {
+		log.Printf("🚀 Serveur démarré sur le port %s", port)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatal(err)
+		}
+	}()
+
+	<-ctx.Done()
+	log.Println("🛑 Arrêt du serveur...")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("⚠️ Server shutdown: %v", err)
+	}
+	// Let running jobs finish; those cut short are run again by another
+	// replica. The outbox stops mid-batch and its unsent messages are
+	// claimed again once their lease runs out.
+	workersDone := make(chan struct{})
+	go func() {
+		workers.Wait()
+		close(workersDone)
+	}()
+	select {
+	case <-workersDone:
+	case <-shutdownCtx.Done():
+		log.Println("⚠️ Background workers still running at shutdown")
 	}
 }
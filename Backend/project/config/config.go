@@ -1,8 +1,10 @@
 package config
 
 import (
+	"fmt"
 	"net/url"
 	"os"
+	"strconv"
 	"strings"
 )
 
@@ -20,6 +22,53 @@ type Config struct {
 	CloudinaryCloudName string
 	CloudinaryAPIKey    string
 	CloudinaryAPISecret string
+
+	// Payments
+	PaymentGraceDays int     // Days after a period ends before an athlete counts as unpaid
+	ReceiptFontPath  string  // TrueType font embedded in PDF receipts (needs Arabic glyphs)
+	MonthlyFee       float64 // Expected monthly fee per athlete in finance reports (0 = derive from payments)
+
+	// Online payments
+	PaymentProvider      string // "" disables online checkout, "fake" for local testing
+	PaymentWebhookSecret string // Shared secret used to verify provider webhooks
+	PublicURL            string // Base URL of this API, used in checkout redirects
+
+	// Training
+	SessionHorizonDays int    // How many days ahead sessions are generated from schedules
+	ClubTimezone       string // IANA zone of stored session times, announced in calendar feeds
+
+	// Bookings
+	BookingCutoffMinutes int // Bookings close this long before a session starts
+	CancelCutoffMinutes  int // Cancellations close this long before a session starts
+
+	// QR check-in
+	CheckinSecret       string // Signs the rotating QR tokens (defaults to JWT_SECRET)
+	CheckinTokenSeconds int    // How long each QR token is shown before it rotates
+	CheckinOpenMinutes  int    // Self check-in opens this long before a session starts
+
+	// Notifications
+	NotifyEmailDriver    string // "smtp", "stub" (logs messages) or "" to disable email
+	SMTPHost             string
+	SMTPPort             string
+	SMTPUsername         string
+	SMTPPassword         string
+	SMTPFrom             string
+	NotifySMSDriver      string // "http", "stub" (logs messages) or "" to disable SMS
+	SMSHTTPURL           string // Gateway endpoint receiving {"from", "to", "text"}
+	SMSHTTPToken         string // Bearer token for the gateway
+	SMSSender            string
+	NotifyPollSeconds    int // How often the outbox worker looks for due messages
+	NotifyMaxAttempts    int // Deliveries tried before a message is marked failed
+	DocumentReminderDays int // Documents expiring within this many days get a reminder
+
+	// Background jobs
+	WorkerID             string // Identifies this replica in locked jobs (defaults to host name and pid)
+	JobWorkers           int    // Jobs this replica runs at once
+	JobPollSeconds       int    // How often idle workers look for due jobs
+	MembershipExpiryDays int    // Memberships expire this long after the last paid period ends (0 = never)
+
+	// Migrations
+	MigrateOnStart bool // Apply pending migrations before the server starts (also the -migrate flag)
 }
 
 func Load() *Config {
@@ -30,7 +79,47 @@ func Load() *Config {
 		CloudinaryCloudName: getEnv("CLOUDINARY_CLOUD_NAME", ""),
 		CloudinaryAPIKey:    getEnv("CLOUDINARY_API_KEY", ""),
 		CloudinaryAPISecret: getEnv("CLOUDINARY_API_SECRET", ""),
+
+		PaymentGraceDays: getEnvInt("PAYMENT_GRACE_DAYS", 7),
+		ReceiptFontPath:  getEnv("RECEIPT_FONT_PATH", "/usr/share/fonts/truetype/dejavu/DejaVuSans.ttf"),
+		MonthlyFee:       getEnvFloat("MONTHLY_FEE", 0),
+
+		PaymentProvider:      getEnv("PAYMENT_PROVIDER", ""),
+		PaymentWebhookSecret: getEnv("PAYMENT_WEBHOOK_SECRET", ""),
+		PublicURL:            getEnv("PUBLIC_URL", "http://localhost:8080"),
+
+		SessionHorizonDays: getEnvInt("SESSION_HORIZON_DAYS", 28),
+		ClubTimezone:       getEnv("CLUB_TIMEZONE", "Africa/Algiers"),
+
+		BookingCutoffMinutes: getEnvInt("BOOKING_CUTOFF_MINUTES", 60),
+		CancelCutoffMinutes:  getEnvInt("CANCEL_CUTOFF_MINUTES", 120),
+
+		CheckinTokenSeconds: getEnvInt("CHECKIN_TOKEN_SECONDS", 30),
+		CheckinOpenMinutes:  getEnvInt("CHECKIN_OPEN_MINUTES", 30),
+
+		NotifyEmailDriver:    getEnv("NOTIFY_EMAIL_DRIVER", ""),
+		SMTPHost:             getEnv("SMTP_HOST", ""),
+		SMTPPort:             getEnv("SMTP_PORT", "587"),
+		SMTPUsername:         getEnv("SMTP_USERNAME", ""),
+		SMTPPassword:         getEnv("SMTP_PASSWORD", ""),
+		SMTPFrom:             getEnv("SMTP_FROM", ""),
+		NotifySMSDriver:      getEnv("NOTIFY_SMS_DRIVER", ""),
+		SMSHTTPURL:           getEnv("SMS_HTTP_URL", ""),
+		SMSHTTPToken:         getEnv("SMS_HTTP_TOKEN", ""),
+		SMSSender:            getEnv("SMS_SENDER", "EastEagles"),
+		NotifyPollSeconds:    getEnvInt("NOTIFY_POLL_SECONDS", 15),
+		NotifyMaxAttempts:    getEnvInt("NOTIFY_MAX_ATTEMPTS", 5),
+		DocumentReminderDays: getEnvInt("DOCUMENT_REMINDER_DAYS", 30),
+
+		JobWorkers:           getEnvInt("JOB_WORKERS", 2),
+		JobPollSeconds:       getEnvInt("JOB_POLL_SECONDS", 5),
+		MembershipExpiryDays: getEnvInt("MEMBERSHIP_EXPIRY_DAYS", 0),
+
+		MigrateOnStart: getEnvBool("MIGRATE_ON_START", false),
 	}
+	cfg.CheckinSecret = getEnv("CHECKIN_SECRET", cfg.JWTSecret)
+	host, _ := os.Hostname()
+	cfg.WorkerID = getEnv("WORKER_ID", fmt.Sprintf("%s-%d", host, os.Getpid()))
 
 	// Check for DATABASE_URL first (Render, Railway, Heroku style)
 	if dbURL := os.Getenv("DATABASE_URL"); dbURL != "" {
@@ -74,3 +163,30 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+func getEnvInt(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if n, err := strconv.Atoi(value); err == nil {
+			return n
+		}
+	}
+	return defaultValue
+}
+
+func getEnvBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return defaultValue
+}
+
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	}
+	return defaultValue
+}
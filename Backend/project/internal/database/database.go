@@ -9,18 +9,21 @@ import (
 	_ "github.com/lib/pq"
 )
 
-func Connect(cfg *config.Config) (*sql.DB, error) {
+// DSN returns the connection string of the configured database
+func DSN(cfg *config.Config) string {
 	sslmode := cfg.DBSSLMode
 	if sslmode == "" {
 		sslmode = "disable"
 	}
 
-	connStr := fmt.Sprintf(
+	return fmt.Sprintf(
 		"host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
 		cfg.DBHost, cfg.DBPort, cfg.DBUser, cfg.DBPassword, cfg.DBName, sslmode,
 	)
+}
 
-	db, err := sql.Open("postgres", connStr)
+func Connect(cfg *config.Config) (*sql.DB, error) {
+	db, err := sql.Open("postgres", DSN(cfg))
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,538 @@
+package database
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// migrationLockKey is the advisory lock held while migrating, so replicas
+// starting together apply each migration once
+const migrationLockKey = 7283001
+
+// Migration states reported by Status
+const (
+	MigrationApplied  = "applied"
+	MigrationPending  = "pending"
+	MigrationModified = "modified" // Applied, but the file changed since
+	MigrationMissing  = "missing"  // Applied, but unknown to this build
+)
+
+var migrationName = regexp.MustCompile(`^(\d{3})_[a-z0-9_]+?(\.down|\.baseline)?\.sql$`)
+
+// legacyDuplicates are numbers shared by two migrations before versions were
+// tracked; both files are applied, in name order, unless supersededBy says
+// otherwise. New numbers must be unique.
+var legacyDuplicates = map[string]bool{"002": true, "003": true}
+
+// supersededBy maps a migration that is never run to the one that replaced
+// it. The old cmd/migrate applied 002_sanda_club_schema.sql, which turned the
+// members table into athletes and is where users.athlete_id comes from on
+// those databases; fresh ones get 002_sanda_schema.sql instead. Up records
+// the superseded file as applied without running it, and `migrate baseline`
+// records it like any other for databases built by the old runner.
+var supersededBy = map[string]string{"002_sanda_club_schema": "002_sanda_schema"}
+
+// rewrittenChecksums are earlier checksums of migrations edited after they
+// shipped, so that an empty database can replay them: 003_documents_schema
+// and 007 failed on a database built from 002_sanda_schema, and 017 added a
+// constraint that was already there when run twice. The edits do not change
+// the schema of a database that applied the earlier file, so verify accepts
+// both.
+var rewrittenChecksums = map[string][]string{
+	"003_documents_schema":        {"33e879de9e5eb088dcadbf98ffed2f9631f9b0950f7e16862564cf156c52fa5f"},
+	"007_add_document_tags":       {"ac5f1bbdb6d5a715611e64a3e53662a43d88e3d6f340e099d5d7da3bafc03e47"},
+	"017_payment_methods_refunds": {"de5cac0acaa044edf3c6446d19126002b93858529585c18232012dcb980bd2b8"},
+}
+
+// Migration is one SQL file; its version is the file name without .sql
+type Migration struct {
+	Version  string
+	Checksum string // sha256 of the up file, checked against the applied one
+	up       string
+	down     string // "" when the migration cannot be reverted
+}
+
+// matches reports whether checksum is the one of this file, or of a version
+// listed in rewrittenChecksums
+func (mig *Migration) matches(checksum string) bool {
+	if checksum == mig.Checksum {
+		return true
+	}
+	for _, previous := range rewrittenChecksums[mig.Version] {
+		if checksum == previous {
+			return true
+		}
+	}
+	return false
+}
+
+// MigrationStatus is the state of a migration in the database
+type MigrationStatus struct {
+	Version   string
+	State     string
+	AppliedAt *time.Time
+}
+
+type appliedMigration struct {
+	version   string
+	checksum  string
+	appliedAt time.Time
+}
+
+// Migrator applies the migrations of a directory and records them in the
+// schema_migrations table
+type Migrator struct {
+	db         *sql.DB
+	migrations []*Migration
+	baseline   *Migration // Latest consolidated schema, written by cmd/schema-check
+}
+
+// NewMigrator loads the migrations of files, ordered by name. NNN_name.down.sql
+// reverts NNN_name.sql; NNN_name.baseline.sql is the whole schema as of
+// NNN_name, used instead of replaying the migrations on an empty database.
+func NewMigrator(db *sql.DB, files fs.FS) (*Migrator, error) {
+	names, err := fs.Glob(files, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+
+	byVersion := map[string]*Migration{}
+	numbers := map[string]string{}
+	var migrations []*Migration
+	var extras []string
+	for _, name := range names {
+		match := migrationName.FindStringSubmatch(name)
+		if match == nil {
+			return nil, fmt.Errorf("migration %s: name must look like 001_name.sql, 001_name.down.sql or 001_name.baseline.sql", name)
+		}
+		if match[2] != "" {
+			extras = append(extras, name)
+			continue
+		}
+		content, err := fs.ReadFile(files, name)
+		if err != nil {
+			return nil, err
+		}
+
+		version := strings.TrimSuffix(name, ".sql")
+		if other, ok := numbers[match[1]]; ok && !legacyDuplicates[match[1]] {
+			return nil, fmt.Errorf("migrations %s and %s share number %s", other, version, match[1])
+		}
+		numbers[match[1]] = version
+		sum := sha256.Sum256(content)
+		m := &Migration{Version: version, Checksum: hex.EncodeToString(sum[:]), up: string(content)}
+		byVersion[version] = m
+		migrations = append(migrations, m)
+	}
+
+	migrator := &Migrator{db: db, migrations: migrations}
+	for _, name := range extras {
+		version := strings.TrimSuffix(strings.TrimSuffix(strings.TrimSuffix(name, ".sql"), ".down"), ".baseline")
+		m, ok := byVersion[version]
+		if !ok {
+			return nil, fmt.Errorf("migration %s: no %s.sql", name, version)
+		}
+		content, err := fs.ReadFile(files, name)
+		if err != nil {
+			return nil, err
+		}
+		if strings.HasSuffix(name, ".down.sql") {
+			m.down = string(content)
+		} else {
+			// Names are sorted, so the latest baseline wins
+			migrator.baseline = &Migration{Version: version, up: string(content)}
+		}
+	}
+	return migrator, nil
+}
+
+// Migrations returns the known migrations in the order they are applied
+func (m *Migrator) Migrations() []*Migration {
+	return m.migrations
+}
+
+// withLock runs fn on a single connection holding the migration lock, once
+// the schema_migrations table exists
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var locked bool
+	if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1)`, migrationLockKey).Scan(&locked); err != nil {
+		return err
+	}
+	if !locked {
+		log.Println("⏳ Waiting for another migration run to finish...")
+		if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, migrationLockKey); err != nil {
+			return err
+		}
+	}
+	defer conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, migrationLockKey)
+
+	if _, err := conn.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+		    version TEXT PRIMARY KEY,
+		    checksum TEXT NOT NULL,
+		    applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		)
+	`); err != nil {
+		return err
+	}
+	return fn(conn)
+}
+
+func loadApplied(ctx context.Context, conn *sql.Conn) ([]appliedMigration, error) {
+	rows, err := conn.QueryContext(ctx, `
+		SELECT version, checksum, applied_at FROM schema_migrations ORDER BY applied_at, version
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var applied []appliedMigration
+	for rows.Next() {
+		var a appliedMigration
+		if err := rows.Scan(&a.version, &a.checksum, &a.appliedAt); err != nil {
+			return nil, err
+		}
+		applied = append(applied, a)
+	}
+	return applied, rows.Err()
+}
+
+// verify fails when an applied migration was edited after it ran: the
+// database no longer matches the file, and a fresh database would differ
+func (m *Migrator) verify(applied []appliedMigration) error {
+	for _, a := range applied {
+		for _, mig := range m.migrations {
+			if mig.Version == a.version && !mig.matches(a.checksum) {
+				return fmt.Errorf("migration %s was modified after it was applied; restore the file and add a new migration instead", a.version)
+			}
+		}
+	}
+	return nil
+}
+
+func (m *Migrator) find(version string) *Migration {
+	for _, mig := range m.migrations {
+		if mig.Version == version {
+			return mig
+		}
+	}
+	return nil
+}
+
+// ownsTransaction reports whether a file runs its own BEGIN/COMMIT, in
+// which case it is not wrapped in another transaction
+func ownsTransaction(script string) bool {
+	for _, line := range strings.Split(script, "\n") {
+		if strings.EqualFold(strings.TrimSpace(line), "BEGIN;") {
+			return true
+		}
+	}
+	return false
+}
+
+// run executes a script and records the change to schema_migrations in the
+// same transaction, or right after a script that commits on its own
+func run(ctx context.Context, conn *sql.Conn, script, record string, args ...interface{}) error {
+	if ownsTransaction(script) {
+		if _, err := conn.ExecContext(ctx, script); err != nil {
+			return err
+		}
+		_, err := conn.ExecContext(ctx, record, args...)
+		return err
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, record, args...); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, mig *Migration) error {
+	started := time.Now()
+	if err := run(ctx, conn, mig.up, `INSERT INTO schema_migrations (version, checksum) VALUES ($1, $2)`,
+		mig.Version, mig.Checksum); err != nil {
+		return fmt.Errorf("migration %s: %w", mig.Version, err)
+	}
+	log.Printf("✅ Applied %s in %s", mig.Version, time.Since(started).Round(time.Millisecond))
+	return nil
+}
+
+// skip records a superseded migration as applied without running it
+func (m *Migrator) skip(ctx context.Context, conn *sql.Conn, mig *Migration) error {
+	if _, err := conn.ExecContext(ctx, `INSERT INTO schema_migrations (version, checksum) VALUES ($1, $2)`,
+		mig.Version, mig.Checksum); err != nil {
+		return fmt.Errorf("migration %s: %w", mig.Version, err)
+	}
+	log.Printf("⏭️ Recorded %s without running it (superseded by %s)", mig.Version, supersededBy[mig.Version])
+	return nil
+}
+
+// applyBaseline creates the baseline schema and records the migrations it
+// covers as applied, and returns their versions
+func (m *Migrator) applyBaseline(ctx context.Context, conn *sql.Conn) ([]string, error) {
+	started := time.Now()
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, m.baseline.up); err != nil {
+		return nil, fmt.Errorf("baseline %s: %w", m.baseline.Version, err)
+	}
+	var versions []string
+	for _, mig := range m.migrations {
+		if mig.Version > m.baseline.Version {
+			break
+		}
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO schema_migrations (version, checksum) VALUES ($1, $2)
+		`, mig.Version, mig.Checksum); err != nil {
+			return nil, err
+		}
+		versions = append(versions, mig.Version)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	log.Printf("✅ Applied baseline %s (%d migrations) in %s", m.baseline.Version, len(versions), time.Since(started).Round(time.Millisecond))
+	return versions, nil
+}
+
+func (m *Migrator) revert(ctx context.Context, conn *sql.Conn, version string) error {
+	mig := m.find(version)
+	if mig == nil {
+		return fmt.Errorf("migration %s is unknown to this build", version)
+	}
+	if mig.down == "" {
+		return fmt.Errorf("migration %s has no %s.down.sql", version, version)
+	}
+	if err := run(ctx, conn, mig.down, `DELETE FROM schema_migrations WHERE version = $1`, version); err != nil {
+		return fmt.Errorf("reverting %s: %w", version, err)
+	}
+	log.Printf("↩️ Reverted %s", version)
+	return nil
+}
+
+// Up applies the pending migrations in order, up to and including target
+// ("" for all), and returns their versions. On an empty database the latest
+// baseline schema replaces the migrations it covers. It refuses to run
+// against a database built before versions were tracked: see Baseline.
+func (m *Migrator) Up(ctx context.Context, target string) ([]string, error) {
+	if target != "" && m.find(target) == nil {
+		return nil, fmt.Errorf("unknown migration %s", target)
+	}
+
+	var done []string
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := loadApplied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if err := m.verify(applied); err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			var existing bool
+			if err := conn.QueryRowContext(ctx, `SELECT to_regclass('public.athletes') IS NOT NULL`).Scan(&existing); err != nil {
+				return err
+			}
+			if existing {
+				return fmt.Errorf("the database has tables but no migration history; record the migrations it already has with `migrate baseline <version>` first")
+			}
+		}
+
+		isApplied := map[string]bool{}
+		for _, a := range applied {
+			isApplied[a.version] = true
+		}
+		if len(applied) == 0 && m.baseline != nil && (target == "" || target >= m.baseline.Version) {
+			versions, err := m.applyBaseline(ctx, conn)
+			if err != nil {
+				return err
+			}
+			for _, v := range versions {
+				isApplied[v] = true
+			}
+			done = append(done, versions...)
+		}
+		for _, mig := range m.migrations {
+			if !isApplied[mig.Version] {
+				apply := m.apply
+				if supersededBy[mig.Version] != "" {
+					apply = m.skip
+				}
+				if err := apply(ctx, conn, mig); err != nil {
+					return err
+				}
+				done = append(done, mig.Version)
+			}
+			if mig.Version == target {
+				break
+			}
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down reverts the last steps applied migrations, latest first, and returns
+// their versions
+func (m *Migrator) Down(ctx context.Context, steps int) ([]string, error) {
+	var done []string
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := loadApplied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if err := m.verify(applied); err != nil {
+			return err
+		}
+
+		for i := len(applied) - 1; i >= 0 && len(done) < steps; i-- {
+			if err := m.revert(ctx, conn, applied[i].version); err != nil {
+				return err
+			}
+			done = append(done, applied[i].version)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Redo reverts the last applied migration and applies it again, and returns
+// its version
+func (m *Migrator) Redo(ctx context.Context) (string, error) {
+	var version string
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := loadApplied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			return fmt.Errorf("no migration applied")
+		}
+		version = applied[len(applied)-1].version
+		// The file is not verified against the applied checksum: editing the
+		// latest migration and redoing it is the point
+		if err := m.revert(ctx, conn, version); err != nil {
+			return err
+		}
+		return m.apply(ctx, conn, m.find(version))
+	})
+	return version, err
+}
+
+// Baseline records the migrations up to and including version as applied
+// without running them, for a database created before versions were
+// tracked, and returns their versions
+func (m *Migrator) Baseline(ctx context.Context, version string) ([]string, error) {
+	if m.find(version) == nil {
+		return nil, fmt.Errorf("unknown migration %s", version)
+	}
+
+	var done []string
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := loadApplied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if len(applied) > 0 {
+			return fmt.Errorf("the database already has a migration history")
+		}
+
+		tx, err := conn.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		for _, mig := range m.migrations {
+			if _, err := tx.ExecContext(ctx, `
+				INSERT INTO schema_migrations (version, checksum) VALUES ($1, $2)
+			`, mig.Version, mig.Checksum); err != nil {
+				return err
+			}
+			done = append(done, mig.Version)
+			if mig.Version == version {
+				break
+			}
+		}
+		return tx.Commit()
+	})
+	return done, err
+}
+
+// Status lists the known migrations in order, then the applied ones this
+// build does not know. It only reads: it neither waits for a running
+// migration nor creates schema_migrations, which is missing until the first
+// Up, so every migration is pending then.
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	var tracked bool
+	if err := conn.QueryRowContext(ctx, `SELECT to_regclass('public.schema_migrations') IS NOT NULL`).Scan(&tracked); err != nil {
+		return nil, err
+	}
+	var applied []appliedMigration
+	if tracked {
+		if applied, err = loadApplied(ctx, conn); err != nil {
+			return nil, err
+		}
+	}
+	byVersion := map[string]appliedMigration{}
+	for _, a := range applied {
+		byVersion[a.version] = a
+	}
+
+	var statuses []MigrationStatus
+	for _, mig := range m.migrations {
+		s := MigrationStatus{Version: mig.Version, State: MigrationPending}
+		if a, ok := byVersion[mig.Version]; ok {
+			appliedAt := a.appliedAt
+			s.AppliedAt = &appliedAt
+			s.State = MigrationApplied
+			if !mig.matches(a.checksum) {
+				s.State = MigrationModified
+			}
+		}
+		statuses = append(statuses, s)
+	}
+	for _, a := range applied {
+		if m.find(a.version) == nil {
+			appliedAt := a.appliedAt
+			statuses = append(statuses, MigrationStatus{Version: a.version, State: MigrationMissing, AppliedAt: &appliedAt})
+		}
+	}
+	return statuses, nil
+}
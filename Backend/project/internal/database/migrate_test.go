@@ -0,0 +1,73 @@
+package database
+
+import (
+	"testing"
+
+	"east-eagles/backend/migrations"
+)
+
+// firstRevertible is the first migration that must have a down file; the
+// earlier ones drop and rebuild whole tables and are never reverted
+const firstRevertible = "015"
+
+func TestMigrationsLoad(t *testing.T) {
+	m, err := NewMigrator(nil, migrations.FS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Migrations()) == 0 {
+		t.Fatal("no migrations embedded")
+	}
+}
+
+func TestMigrationsHaveDownFiles(t *testing.T) {
+	m, err := NewMigrator(nil, migrations.FS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, mig := range m.Migrations() {
+		if mig.Version[:3] >= firstRevertible && mig.down == "" {
+			t.Errorf("%s has no %s.down.sql", mig.Version, mig.Version)
+		}
+	}
+}
+
+func TestSupersededMigrationsAreKnown(t *testing.T) {
+	m, err := NewMigrator(nil, migrations.FS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for old, replacement := range supersededBy {
+		if m.find(old) == nil || m.find(replacement) == nil {
+			t.Errorf("%s superseded by %s: both files must be embedded", old, replacement)
+		}
+		if old[:3] != replacement[:3] || !legacyDuplicates[old[:3]] {
+			t.Errorf("%s and %s must share a legacy number", old, replacement)
+		}
+	}
+}
+
+func TestRewrittenMigrationsAcceptEarlierChecksums(t *testing.T) {
+	m, err := NewMigrator(nil, migrations.FS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for version, previous := range rewrittenChecksums {
+		mig := m.find(version)
+		if mig == nil {
+			t.Errorf("%s is not embedded", version)
+			continue
+		}
+		for _, sum := range previous {
+			if sum == mig.Checksum {
+				t.Errorf("%s: %s is the current checksum, not an earlier one", version, sum)
+			}
+			if !mig.matches(sum) {
+				t.Errorf("%s does not accept %s", version, sum)
+			}
+		}
+		if mig.matches("0000") {
+			t.Errorf("%s accepts any checksum", version)
+		}
+	}
+}
@@ -0,0 +1,252 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// Column is a column of the live database, as information_schema reports it
+type Column struct {
+	Table    string
+	Name     string
+	Type     string // Type family compared with repository.Schema, e.g. text for varchar(100)
+	SQLType  string // Declared type, e.g. character varying(100)
+	Nullable bool
+	Default  string
+}
+
+// TypeFamily groups the types the Go code scans alike: the integer sizes,
+// the decimal and floating point types, and the character types
+func TypeFamily(dataType, udtName string) string {
+	switch dataType {
+	case "smallint", "integer", "bigint":
+		return "integer"
+	case "numeric", "real", "double precision":
+		return "numeric"
+	case "character varying", "character", "text":
+		return "text"
+	case "timestamp without time zone":
+		return "timestamp"
+	case "timestamp with time zone":
+		return "timestamptz"
+	case "time without time zone":
+		return "time"
+	case "json", "jsonb":
+		return "jsonb"
+	case "ARRAY":
+		elem := strings.TrimPrefix(udtName, "_")
+		switch elem {
+		case "int2", "int4", "int8":
+			return "integer[]"
+		case "varchar", "bpchar", "text":
+			return "text[]"
+		default:
+			return elem + "[]"
+		}
+	}
+	return dataType
+}
+
+// Columns returns the columns of the tables of the public schema, by table
+// and in declaration order
+func Columns(db *sql.DB) (map[string][]Column, error) {
+	rows, err := db.Query(`
+		SELECT c.table_name, c.column_name, c.data_type, c.udt_name,
+		       c.character_maximum_length, c.numeric_precision, c.numeric_scale,
+		       c.is_nullable = 'YES', COALESCE(c.column_default, '')
+		FROM information_schema.columns c
+		JOIN information_schema.tables t ON t.table_schema = c.table_schema AND t.table_name = c.table_name
+		WHERE c.table_schema = 'public' AND t.table_type = 'BASE TABLE'
+		ORDER BY c.table_name, c.ordinal_position
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tables := map[string][]Column{}
+	for rows.Next() {
+		var c Column
+		var dataType, udtName string
+		var length, precision, scale sql.NullInt64
+		if err := rows.Scan(&c.Table, &c.Name, &dataType, &udtName, &length, &precision, &scale, &c.Nullable, &c.Default); err != nil {
+			return nil, err
+		}
+		c.Type = TypeFamily(dataType, udtName)
+		c.SQLType = sqlType(dataType, udtName, length, precision, scale)
+		tables[c.Table] = append(tables[c.Table], c)
+	}
+	return tables, rows.Err()
+}
+
+func sqlType(dataType, udtName string, length, precision, scale sql.NullInt64) string {
+	switch {
+	case dataType == "ARRAY":
+		return strings.TrimPrefix(udtName, "_") + "[]"
+	case dataType == "USER-DEFINED":
+		return udtName
+	case length.Valid:
+		return fmt.Sprintf("%s(%d)", dataType, length.Int64)
+	case dataType == "numeric" && precision.Valid:
+		return fmt.Sprintf("numeric(%d,%d)", precision.Int64, scale.Int64)
+	}
+	return dataType
+}
+
+var sequenceDefault = regexp.MustCompile(`^nextval\('[^']+'::regclass\)$`)
+
+// serialDefault reports whether an integer column takes its default from a
+// sequence, as SERIAL columns do. The sequence may be named after a former
+// table, like members_id_seq for athletes.
+func serialDefault(c Column) bool {
+	return sequenceDefault.MatchString(c.Default) && (c.SQLType == "integer" || c.SQLType == "bigint")
+}
+
+// WriteBaseline writes SQL recreating the public schema of db: functions,
+// tables, constraints, indexes and triggers, then the rows of seedTables.
+// schema_migrations is left out; the migrator records the history itself.
+func WriteBaseline(db *sql.DB, w io.Writer, seedTables []string) error {
+	tables, err := Columns(db)
+	if err != nil {
+		return err
+	}
+	delete(tables, "schema_migrations")
+	var names []string
+	for name := range tables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// Functions first, for the triggers and defaults using them
+	functions, err := queryStrings(db, `
+		SELECT pg_get_functiondef(p.oid) || ';'
+		FROM pg_proc p
+		WHERE p.pronamespace = 'public'::regnamespace AND p.prokind = 'f'
+		  AND NOT EXISTS (SELECT 1 FROM pg_depend d WHERE d.objid = p.oid AND d.deptype = 'e')
+		ORDER BY p.proname
+	`)
+	if err != nil {
+		return err
+	}
+	writeSection(w, "Functions", functions)
+
+	var creates []string
+	for _, name := range names {
+		var lines []string
+		for _, c := range tables[name] {
+			line := "    " + pq.QuoteIdentifier(c.Name) + " "
+			switch {
+			case serialDefault(c) && c.SQLType == "bigint":
+				line += "BIGSERIAL"
+			case serialDefault(c):
+				line += "SERIAL"
+			default:
+				line += c.SQLType
+				if c.Default != "" {
+					line += " DEFAULT " + c.Default
+				}
+			}
+			if !c.Nullable {
+				line += " NOT NULL"
+			}
+			lines = append(lines, line)
+		}
+		creates = append(creates, fmt.Sprintf("CREATE TABLE %s (\n%s\n);", pq.QuoteIdentifier(name), strings.Join(lines, ",\n")))
+	}
+	writeSection(w, "Tables", creates)
+
+	// Foreign keys last, once every table they reference exists
+	constraints, err := queryStrings(db, `
+		SELECT format('ALTER TABLE %s ADD CONSTRAINT %I %s;', conrelid::regclass, conname, pg_get_constraintdef(oid))
+		FROM pg_constraint
+		WHERE connamespace = 'public'::regnamespace AND contype IN ('p', 'u', 'c', 'x', 'f')
+		  AND conrelid::regclass::text <> 'schema_migrations'
+		ORDER BY contype = 'f', conrelid::regclass::text, conname
+	`)
+	if err != nil {
+		return err
+	}
+	writeSection(w, "Constraints", constraints)
+
+	indexes, err := queryStrings(db, `
+		SELECT indexdef || ';'
+		FROM pg_indexes i
+		WHERE schemaname = 'public' AND tablename <> 'schema_migrations'
+		  AND NOT EXISTS (SELECT 1 FROM pg_constraint c WHERE c.conname = i.indexname AND c.connamespace = 'public'::regnamespace)
+		ORDER BY tablename, indexname
+	`)
+	if err != nil {
+		return err
+	}
+	writeSection(w, "Indexes", indexes)
+
+	triggers, err := queryStrings(db, `
+		SELECT pg_get_triggerdef(t.oid) || ';'
+		FROM pg_trigger t
+		JOIN pg_class c ON c.oid = t.tgrelid
+		WHERE c.relnamespace = 'public'::regnamespace AND NOT t.tgisinternal
+		ORDER BY c.relname, t.tgname
+	`)
+	if err != nil {
+		return err
+	}
+	writeSection(w, "Triggers", triggers)
+
+	for _, table := range seedTables {
+		if _, ok := tables[table]; !ok {
+			return fmt.Errorf("seed table %s does not exist", table)
+		}
+		// Rows go through json_populate_record so every type round-trips
+		rows, err := queryStrings(db, fmt.Sprintf(`
+			SELECT format('INSERT INTO %%1$I SELECT * FROM json_populate_record(NULL::%%1$I, %%2$L);', $1::text, row_to_json(t))
+			FROM %s t
+			ORDER BY 1
+		`, pq.QuoteIdentifier(table)), table)
+		if err != nil {
+			return err
+		}
+		for _, c := range tables[table] {
+			if serialDefault(c) {
+				rows = append(rows, fmt.Sprintf(
+					"SELECT setval(pg_get_serial_sequence('%[1]s', '%[2]s'), (SELECT COALESCE(MAX(%[2]s), 0) + 1 FROM %[1]s), false);",
+					table, c.Name))
+			}
+		}
+		writeSection(w, "Seed data: "+table, rows)
+	}
+	return nil
+}
+
+func queryStrings(db *sql.DB, query string, args ...interface{}) ([]string, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var values []string
+	for rows.Next() {
+		var v string
+		if err := rows.Scan(&v); err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	return values, rows.Err()
+}
+
+func writeSection(w io.Writer, title string, statements []string) {
+	if len(statements) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "\n-- ============================================================================\n-- %s\n-- ============================================================================\n", title)
+	for _, s := range statements {
+		fmt.Fprintf(w, "%s\n\n", s)
+	}
+}
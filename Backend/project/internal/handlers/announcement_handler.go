@@ -1,24 +1,67 @@
 package handlers
 
 import (
+	"database/sql"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
+	"east-eagles/backend/internal/middleware"
 	"east-eagles/backend/internal/models"
 	"east-eagles/backend/internal/repository"
+	"east-eagles/backend/internal/services"
 
 	"github.com/gorilla/mux"
 )
 
 type AnnouncementHandler struct {
-	repo *repository.AnnouncementRepository
+	repo              *repository.AnnouncementRepository
+	cloudinaryService *services.CloudinaryService
 }
 
-func NewAnnouncementHandler(repo *repository.AnnouncementRepository) *AnnouncementHandler {
-	return &AnnouncementHandler{repo: repo}
+func NewAnnouncementHandler(repo *repository.AnnouncementRepository, cloudinaryService *services.CloudinaryService) *AnnouncementHandler {
+	return &AnnouncementHandler{repo: repo, cloudinaryService: cloudinaryService}
 }
 
+// validateAnnouncement checks a request and returns its publish and expiry
+// times; the announcement is published right away when no date is given
+func validateAnnouncement(req *models.CreateAnnouncementRequest) (time.Time, *time.Time, error) {
+	if req.Title == "" || req.Content == "" {
+		return time.Time{}, nil, fmt.Errorf("title and content are required")
+	}
+
+	published := models.WallClockNow().Truncate(time.Minute)
+	if req.PublishedDate != "" {
+		var err error
+		if published, err = time.Parse("2006-01-02 15:04", req.PublishedDate); err != nil {
+			return time.Time{}, nil, fmt.Errorf("published_date must be YYYY-MM-DD HH:MM")
+		}
+	}
+	var expires *time.Time
+	if req.ExpiresAt != "" {
+		t, err := time.Parse("2006-01-02 15:04", req.ExpiresAt)
+		if err != nil {
+			return time.Time{}, nil, fmt.Errorf("expires_at must be YYYY-MM-DD HH:MM")
+		}
+		if !t.After(published) {
+			return time.Time{}, nil, fmt.Errorf("expires_at must be after published_date")
+		}
+		expires = &t
+	}
+
+	for _, role := range req.TargetRoles {
+		switch models.UserRole(role) {
+		case models.RoleAdmin, models.RoleCoach, models.RoleAthlete, models.RoleGuardian:
+		default:
+			return time.Time{}, nil, fmt.Errorf("unknown target role: %s", role)
+		}
+	}
+	return published, expires, nil
+}
+
+// GetAll returns every announcement with its status and read count
 func (h *AnnouncementHandler) GetAll(w http.ResponseWriter, r *http.Request) {
 	announcements, err := h.repo.GetAll()
 	if err != nil {
@@ -54,8 +97,19 @@ func (h *AnnouncementHandler) Create(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Données invalides", http.StatusBadRequest)
 		return
 	}
+	published, expires, err := validateAnnouncement(&req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
 
-	announcement, err := h.repo.Create(&req)
+	announcement, err := h.repo.Create(&req, published, expires, userID)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -79,12 +133,21 @@ func (h *AnnouncementHandler) Update(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Données invalides", http.StatusBadRequest)
 		return
 	}
-
-	announcement, err := h.repo.Update(id, &req)
+	published, expires, err := validateAnnouncement(&req)
 	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	announcement, err := h.repo.Update(id, &req, published, expires)
+	if err == sql.ErrNoRows {
 		http.Error(w, "Annonce non trouvée", http.StatusNotFound)
 		return
 	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(announcement)
@@ -108,3 +171,159 @@ func (h *AnnouncementHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]string{"message": "Annonce supprimée"})
 }
+
+// UploadAttachment attaches a file to an announcement
+func (h *AnnouncementHandler) UploadAttachment(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "ID invalide", http.StatusBadRequest)
+		return
+	}
+
+	// Max upload size: 10MB
+	if err := r.ParseMultipartForm(10 << 20); err != nil {
+		http.Error(w, "Error parsing form", http.StatusBadRequest)
+		return
+	}
+	file, handler, err := r.FormFile("file")
+	if err != nil {
+		http.Error(w, "Error retrieving file", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	if _, err := h.repo.GetByID(id); err != nil {
+		http.Error(w, "Annonce non trouvée", http.StatusNotFound)
+		return
+	}
+
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	filename := fmt.Sprintf("%d_%s", time.Now().UnixNano(), handler.Filename)
+	folderPath := fmt.Sprintf("east-eagles/announcements/%d", id)
+	fileURL, err := h.cloudinaryService.UploadDocument(file, filename, folderPath, "auto")
+	if err != nil {
+		http.Error(w, "Error uploading to cloud: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	attachment := &models.AnnouncementAttachment{
+		AnnouncementID: id,
+		FileName:       handler.Filename,
+		FileURL:        fileURL,
+		MimeType:       handler.Header.Get("Content-Type"),
+		FileSizeBytes:  handler.Size,
+		UploadedBy:     &userID,
+	}
+	if err := h.repo.AddAttachment(attachment); err == sql.ErrNoRows {
+		http.Error(w, "Annonce non trouvée", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(attachment)
+}
+
+func (h *AnnouncementHandler) DeleteAttachment(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "ID invalide", http.StatusBadRequest)
+		return
+	}
+	attachmentID, err := strconv.Atoi(vars["attachmentId"])
+	if err != nil {
+		http.Error(w, "ID invalide", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.repo.DeleteAttachment(id, attachmentID); err == sql.ErrNoRows {
+		http.Error(w, "Pièce jointe non trouvée", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Pièce jointe supprimée"})
+}
+
+// GetReaders lists the audience of an announcement with when each user read
+// it; ?unread=true keeps those who have not
+func (h *AnnouncementHandler) GetReaders(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "ID invalide", http.StatusBadRequest)
+		return
+	}
+
+	if _, err := h.repo.GetByID(id); err != nil {
+		http.Error(w, "Annonce non trouvée", http.StatusNotFound)
+		return
+	}
+	readers, err := h.repo.GetReaders(id, r.URL.Query().Get("unread") == "true")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(readers)
+}
+
+// GetMine returns the published announcements addressed to the authenticated
+// user; ?unread=true keeps the unread ones
+func (h *AnnouncementHandler) GetMine(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	announcements, err := h.repo.GetForUser(userID, r.URL.Query().Get("unread") == "true")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(announcements)
+}
+
+// MarkRead marks an announcement as read by the authenticated user
+func (h *AnnouncementHandler) MarkRead(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "ID invalide", http.StatusBadRequest)
+		return
+	}
+
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.repo.MarkRead(id, userID); err == sql.ErrNoRows {
+		http.Error(w, "Annonce non trouvée", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Annonce lue"})
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"database/sql"
 	"east-eagles/backend/internal/middleware"
 	"east-eagles/backend/internal/models"
 	"east-eagles/backend/internal/repository"
@@ -17,12 +18,14 @@ import (
 type AthleteHandler struct {
 	repo              *repository.AthleteRepository
 	cloudinaryService *services.CloudinaryService
+	notifier          *services.Notifier
 }
 
-func NewAthleteHandler(repo *repository.AthleteRepository, cloudinaryService *services.CloudinaryService) *AthleteHandler {
+func NewAthleteHandler(repo *repository.AthleteRepository, cloudinaryService *services.CloudinaryService, notifier *services.Notifier) *AthleteHandler {
 	return &AthleteHandler{
 		repo:              repo,
 		cloudinaryService: cloudinaryService,
+		notifier:          notifier,
 	}
 }
 
@@ -166,9 +169,14 @@ func (h *AthleteHandler) Approve(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := h.repo.Approve(id, adminID); err != nil {
+		if err == repository.ErrGuardianRequired {
+			http.Error(w, "Un tuteur doit être lié avant d'approuver un athlète mineur", http.StatusBadRequest)
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	notifyAthlete(h.notifier, id, "athlete_approved", nil)
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]string{"message": "Athlète approuvé"})
@@ -200,6 +208,7 @@ func (h *AthleteHandler) Reject(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	notifyAthlete(h.notifier, id, "athlete_rejected", map[string]string{"reason": req.Reason})
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]string{"message": "Athlète rejeté"})
@@ -217,54 +226,37 @@ func (h *AthleteHandler) GetStats(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(stats)
 }
 
-// GetProfile returns the profile of the authenticated athlete
-func (h *AthleteHandler) GetProfile(w http.ResponseWriter, r *http.Request) {
-	email, ok := r.Context().Value(middleware.UserEmailKey).(string)
+// currentAthleteID returns the athlete linked to the authenticated account
+func currentAthleteID(r *http.Request) (int, bool) {
+	id, ok := r.Context().Value(middleware.AthleteIDKey).(int)
+	return id, ok && id > 0
+}
+
+// currentAthlete loads the athlete linked to the authenticated account,
+// answering 404 when the account has none
+func currentAthlete(w http.ResponseWriter, r *http.Request, repo *repository.AthleteRepository) (*models.Athlete, bool) {
+	id, ok := currentAthleteID(r)
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
-		return
+		http.Error(w, "Athlete profile not found", http.StatusNotFound)
+		return nil, false
+	}
+	athlete, err := repo.GetByID(id)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Athlete profile not found", http.StatusNotFound)
+		return nil, false
 	}
-
-	athlete, err := h.repo.GetByEmail(email)
 	if err != nil {
-		// If athlete doesn't exist, create a basic profile for them
-		// This handles the case where a user exists but no athlete profile has been created yet
-		fmt.Printf("Athlete with email %s not found, creating new profile\n", email)
-
-		// Create a basic athlete profile
-		createReq := &models.CreateAthleteRequest{
-			FirstName:                "", // Will be updated by the user
-			LastName:                 "", // Will be updated by the user
-			Email:                    email,
-			Phone:                    "",
-			DateOfBirth:              "",
-			WeightKG:                 0,
-			Gender:                   "",
-			Nationality:              "",
-			Address:                  "",
-			City:                     "",
-			PostalCode:               "",
-			BeltLevel:                "beginner",
-			SkillLevel:               "beginner",
-			YearsOfExperience:        0,
-			WeightCategory:           "",
-			EmergencyContactName:     "",
-			EmergencyContactPhone:    "",
-			EmergencyContactRelation: "",
-			MedicalConditions:        "",
-			Allergies:                "",
-			BloodType:                "",
-			PhotoURL:                 "",
-		}
-
-		athlete, err = h.repo.Create(createReq)
-		if err != nil {
-			fmt.Printf("Error creating athlete profile: %v\n", err)
-			http.Error(w, "Error creating athlete profile", http.StatusInternalServerError)
-			return
-		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return nil, false
+	}
+	return athlete, true
+}
 
-		fmt.Printf("Created new athlete profile with ID: %d\n", athlete.ID)
+// GetProfile returns the profile of the authenticated athlete
+func (h *AthleteHandler) GetProfile(w http.ResponseWriter, r *http.Request) {
+	athlete, ok := currentAthlete(w, r, h.repo)
+	if !ok {
+		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -273,17 +265,8 @@ func (h *AthleteHandler) GetProfile(w http.ResponseWriter, r *http.Request) {
 
 // UpdateProfile updates the profile of the authenticated athlete
 func (h *AthleteHandler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
-	email, ok := r.Context().Value(middleware.UserEmailKey).(string)
+	athlete, ok := currentAthlete(w, r, h.repo)
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
-		return
-	}
-
-	athlete, err := h.repo.GetByEmail(email)
-	if err != nil {
-		// If athlete doesn't exist, return an error
-		// The GetProfile function should have created the profile already
-		http.Error(w, "Athlete profile not found", http.StatusNotFound)
 		return
 	}
 
@@ -294,8 +277,16 @@ func (h *AthleteHandler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Ensure critical fields are not changed or are consistent
-	req.Email = email
+	// The email stays as the club recorded it; the login email lives on the account
+	req.Email = athlete.Email
+	// The weight and weight category follow official weigh-ins and the belt
+	// gradings, not the athlete
+	req.WeightKG = 0
+	if athlete.WeightKG != nil {
+		req.WeightKG = *athlete.WeightKG
+	}
+	req.WeightCategory = athlete.WeightCategory
+	req.BeltLevel = athlete.BeltLevel
 
 	// Log the request for debugging
 	fmt.Printf("📝 UpdateProfile Request Data: %+v\n", req)
@@ -327,17 +318,9 @@ func (h *AthleteHandler) UploadProfileImage(w http.ResponseWriter, r *http.Reque
 	}
 	defer file.Close()
 
-	// 3. Get user email from context
-	email, ok := r.Context().Value(middleware.UserEmailKey).(string)
+	// 3. Get the athlete linked to the account
+	athlete, ok := currentAthlete(w, r, h.repo)
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
-		return
-	}
-
-	// 4. Get athlete to get ID
-	athlete, err := h.repo.GetByEmail(email)
-	if err != nil {
-		http.Error(w, "Athlete not found", http.StatusNotFound)
 		return
 	}
 
@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"east-eagles/backend/internal/models"
+	"east-eagles/backend/internal/repository"
+	"east-eagles/backend/internal/services"
+
+	"github.com/gorilla/mux"
+)
+
+type AttendanceHandler struct {
+	repo        *repository.TrainingRepository
+	athleteRepo *repository.AthleteRepository
+}
+
+func NewAttendanceHandler(repo *repository.TrainingRepository, athleteRepo *repository.AthleteRepository) *AttendanceHandler {
+	return &AttendanceHandler{repo: repo, athleteRepo: athleteRepo}
+}
+
+// parseStatsRange reads ?from=&to= (YYYY-MM-DD, default the last 90 days)
+func parseStatsRange(r *http.Request) (time.Time, time.Time, error) {
+	q := r.URL.Query()
+	now := time.Now()
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	from := to.AddDate(0, 0, -90)
+
+	var err error
+	if v := q.Get("from"); v != "" {
+		if from, err = time.Parse("2006-01-02", v); err != nil {
+			return from, to, fmt.Errorf("invalid from date (YYYY-MM-DD)")
+		}
+	}
+	if v := q.Get("to"); v != "" {
+		if to, err = time.Parse("2006-01-02", v); err != nil {
+			return from, to, fmt.Errorf("invalid to date (YYYY-MM-DD)")
+		}
+	}
+	if to.Before(from) {
+		return from, to, fmt.Errorf("from must be before to")
+	}
+	return from, to, nil
+}
+
+// GetHistory returns attendance history for the authenticated athlete
+func (h *AttendanceHandler) GetHistory(w http.ResponseWriter, r *http.Request) {
+	athlete, ok := currentAthlete(w, r, h.athleteRepo)
+	if !ok {
+		return
+	}
+
+	history, err := h.repo.GetAttendanceByAthlete(athlete.ID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(history)
+}
+
+func (h *AttendanceHandler) writeStats(w http.ResponseWriter, r *http.Request, athleteID int) {
+	from, to, err := parseStatsRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	records, err := h.repo.GetAttendanceRecords(athleteID, from, to)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(services.AttendanceStats(athleteID, records, from, to))
+}
+
+// GetMyStats returns the authenticated athlete's attendance statistics
+func (h *AttendanceHandler) GetMyStats(w http.ResponseWriter, r *http.Request) {
+	athlete, ok := currentAthlete(w, r, h.athleteRepo)
+	if !ok {
+		return
+	}
+	h.writeStats(w, r, athlete.ID)
+}
+
+// GetAthleteStats returns an athlete's attendance statistics
+func (h *AttendanceHandler) GetAthleteStats(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+	h.writeStats(w, r, id)
+}
+
+// GetLowAttendance lists athletes whose attendance rate is below ?threshold=
+// (0-1, default 0.5), ignoring those with fewer than ?min_sessions= (default 3)
+func (h *AttendanceHandler) GetLowAttendance(w http.ResponseWriter, r *http.Request) {
+	from, to, err := parseStatsRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	q := r.URL.Query()
+	threshold := 0.5
+	if v := q.Get("threshold"); v != "" {
+		if threshold, err = strconv.ParseFloat(v, 64); err != nil || threshold < 0 || threshold > 1 {
+			http.Error(w, "threshold must be between 0 and 1", http.StatusBadRequest)
+			return
+		}
+	}
+	minSessions := 3
+	if v := q.Get("min_sessions"); v != "" {
+		if minSessions, err = strconv.Atoi(v); err != nil || minSessions < 1 {
+			http.Error(w, "min_sessions must be a positive number", http.StatusBadRequest)
+			return
+		}
+	}
+
+	records, err := h.repo.GetAttendanceRecords(0, from, to)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	low := []*models.AttendanceStats{}
+	for _, s := range services.AttendanceStatsByAthlete(records, from, to) {
+		if s.Sessions >= minSessions && s.Rate < threshold {
+			low = append(low, s)
+		}
+	}
+	sort.Slice(low, func(i, j int) bool { return low[i].Rate < low[j].Rate })
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(low)
+}
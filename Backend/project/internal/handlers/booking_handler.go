@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"east-eagles/backend/internal/repository"
+
+	"github.com/gorilla/mux"
+)
+
+type BookingHandler struct {
+	repo          *repository.BookingRepository
+	athleteRepo   *repository.AthleteRepository
+	bookingCutoff time.Duration
+	cancelCutoff  time.Duration
+}
+
+// NewBookingHandler creates the session booking handler. Bookings close
+// bookingCutoff before a session starts, cancellations cancelCutoff before.
+func NewBookingHandler(repo *repository.BookingRepository, athleteRepo *repository.AthleteRepository, bookingCutoff, cancelCutoff time.Duration) *BookingHandler {
+	return &BookingHandler{repo: repo, athleteRepo: athleteRepo, bookingCutoff: bookingCutoff, cancelCutoff: cancelCutoff}
+}
+
+// GetBookable lists upcoming sessions open to the athlete's level
+func (h *BookingHandler) GetBookable(w http.ResponseWriter, r *http.Request) {
+	athlete, ok := currentAthlete(w, r, h.athleteRepo)
+	if !ok {
+		return
+	}
+
+	sessions, err := h.repo.GetBookable(athlete, h.bookingCutoff)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sessions)
+}
+
+// GetMine lists the athlete's bookings and waitlist entries for upcoming sessions
+func (h *BookingHandler) GetMine(w http.ResponseWriter, r *http.Request) {
+	athlete, ok := currentAthlete(w, r, h.athleteRepo)
+	if !ok {
+		return
+	}
+
+	bookings, err := h.repo.GetByAthlete(athlete.ID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(bookings)
+}
+
+// Book books a session for the athlete, or puts them on its waitlist when full
+func (h *BookingHandler) Book(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	sessionID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	athlete, ok := currentAthlete(w, r, h.athleteRepo)
+	if !ok {
+		return
+	}
+	if athlete.MembershipStatus != "approved" {
+		http.Error(w, "Votre adhésion doit être approuvée pour réserver", http.StatusForbidden)
+		return
+	}
+
+	booking, err := h.repo.Book(sessionID, athlete, h.bookingCutoff)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Session not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		writeBookingError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(booking)
+}
+
+// Cancel cancels the athlete's booking; the next athlete on the waitlist gets the place
+func (h *BookingHandler) Cancel(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	sessionID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	athlete, ok := currentAthlete(w, r, h.athleteRepo)
+	if !ok {
+		return
+	}
+
+	promoted, err := h.repo.Cancel(sessionID, athlete.ID, h.cancelCutoff)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Booking not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		writeBookingError(w, err)
+		return
+	}
+	if len(promoted) > 0 {
+		log.Printf("🎟️ Session %d: athletes %v promoted from the waitlist", sessionID, promoted)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Réservation annulée"})
+}
+
+// GetBySession returns a session's bookings and waitlist for the coach's roll call
+func (h *BookingHandler) GetBySession(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	sessionID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	bookings, err := h.repo.GetBySession(sessionID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(bookings)
+}
@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"east-eagles/backend/internal/models"
+	"east-eagles/backend/internal/repository"
+	"east-eagles/backend/internal/services"
+
+	"github.com/gorilla/mux"
+)
+
+type BracketHandler struct {
+	repo      *repository.BracketRepository
+	eventRepo *repository.EventRepository
+	ratings   *services.RatingService
+}
+
+func NewBracketHandler(repo *repository.BracketRepository, eventRepo *repository.EventRepository, ratings *services.RatingService) *BracketHandler {
+	return &BracketHandler{repo: repo, eventRepo: eventRepo, ratings: ratings}
+}
+
+// Generate draws a bracket for each weight category and gender of the
+// event's registered athletes, replacing any previous draw
+func (h *BracketHandler) Generate(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	eventID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "ID invalide", http.StatusBadRequest)
+		return
+	}
+
+	var req models.GenerateBracketsRequest
+	if r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Données invalides", http.StatusBadRequest)
+			return
+		}
+	}
+	switch req.Format {
+	case "", models.FormatSingleElimination, models.FormatRoundRobin:
+	default:
+		http.Error(w, "format must be single_elimination or round_robin", http.StatusBadRequest)
+		return
+	}
+
+	if _, err := h.eventRepo.GetByID(eventID); err != nil {
+		http.Error(w, "Événement non trouvé", http.StatusNotFound)
+		return
+	}
+	pools, err := h.repo.GetDrawPools(eventID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if len(pools) == 0 {
+		http.Error(w, "Aucun athlète inscrit", http.StatusBadRequest)
+		return
+	}
+
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+	brackets := make([]*models.Bracket, 0, len(pools))
+	placings := make([]map[int]int, 0, len(pools))
+	for _, pool := range pools {
+		b := services.DrawBracket(pool, req.Format, req.Seeds, rng)
+		brackets = append(brackets, b)
+		// Brackets made only of byes are already decided
+		placings = append(placings, services.BracketPlacings(b))
+	}
+
+	if err := h.repo.ReplaceBrackets(eventID, brackets, placings); err == repository.ErrBracketStarted {
+		http.Error(w, "Des combats ont déjà eu lieu, le tirage ne peut plus être refait", http.StatusConflict)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	saved, err := h.repo.GetByEvent(eventID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(saved)
+}
+
+// GetByEvent returns an event's brackets with their bouts
+func (h *BracketHandler) GetByEvent(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	eventID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "ID invalide", http.StatusBadRequest)
+		return
+	}
+
+	brackets, err := h.repo.GetByEvent(eventID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(brackets)
+}
+
+// validateBoutResult checks the parts of a result that do not depend on the bout
+func validateBoutResult(req *models.BoutResultRequest) error {
+	switch req.Method {
+	case "points", "ko", "tko", "disqualification", "walkover":
+		if req.WinnerAthleteID == 0 {
+			return fmt.Errorf("winner_athlete_id is required")
+		}
+	case "draw":
+		if req.WinnerAthleteID != 0 {
+			return fmt.Errorf("a draw has no winner")
+		}
+	default:
+		return fmt.Errorf("method must be points, ko, tko, disqualification, walkover or draw")
+	}
+	if req.RedRoundsWon < 0 || req.BlueRoundsWon < 0 || req.RedPoints < 0 || req.BluePoints < 0 {
+		return fmt.Errorf("rounds and points cannot be negative")
+	}
+	return nil
+}
+
+// RecordResult records a bout's result, advances the winner, updates ratings
+// and, once the bracket is decided, stores the final placings
+func (h *BracketHandler) RecordResult(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	boutID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "ID invalide", http.StatusBadRequest)
+		return
+	}
+
+	var req models.BoutResultRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Données invalides", http.StatusBadRequest)
+		return
+	}
+	if err := validateBoutResult(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	bracketID, err := h.repo.RecordResult(boutID, &req)
+	if err != nil {
+		if resultErr, ok := err.(*repository.BoutResultError); ok {
+			http.Error(w, resultErr.Message, http.StatusBadRequest)
+			return
+		}
+		switch err {
+		case sql.ErrNoRows:
+			http.Error(w, "Combat non trouvé", http.StatusNotFound)
+		case repository.ErrBoutLocked:
+			http.Error(w, "Le combat suivant a déjà un résultat", http.StatusConflict)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	// The result is saved; a failed rating update is caught up by the next one
+	if err := h.ratings.Recalculate(); err != nil {
+		log.Printf("⚠️ Failed to update ratings after bout %d: %v", boutID, err)
+	}
+
+	bracket, err := h.repo.GetByID(bracketID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if placings := services.BracketPlacings(bracket); placings != nil {
+		if err := h.repo.SavePlacings(bracket.ID, placings); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		log.Printf("🏆 Bracket %d (%s %s) decided", bracket.ID, bracket.WeightCategory, bracket.Gender)
+		if bracket, err = h.repo.GetByID(bracketID); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(bracket)
+}
@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"east-eagles/backend/internal/middleware"
+	"east-eagles/backend/internal/models"
+	"east-eagles/backend/internal/repository"
+	"east-eagles/backend/internal/services"
+)
+
+type CalendarHandler struct {
+	repo         *repository.CalendarRepository
+	scheduleRepo *repository.ScheduleRepository
+	athleteRepo  *repository.AthleteRepository
+	publicURL    string
+	timezone     string
+	horizonDays  int
+}
+
+// NewCalendarHandler creates the iCalendar feed handler. Feeds are
+// authenticated by a per-user token in the URL so calendar apps can subscribe.
+func NewCalendarHandler(repo *repository.CalendarRepository, scheduleRepo *repository.ScheduleRepository, athleteRepo *repository.AthleteRepository, publicURL, timezone string, horizonDays int) *CalendarHandler {
+	return &CalendarHandler{
+		repo:         repo,
+		scheduleRepo: scheduleRepo,
+		athleteRepo:  athleteRepo,
+		publicURL:    strings.TrimRight(publicURL, "/"),
+		timezone:     timezone,
+		horizonDays:  horizonDays,
+	}
+}
+
+func (h *CalendarHandler) withURLs(t *models.CalendarToken) *models.CalendarToken {
+	t.ClubURL = fmt.Sprintf("%s/api/calendar/club.ics?token=%s", h.publicURL, t.Token)
+	t.PersonalURL = fmt.Sprintf("%s/api/calendar/athlete.ics?token=%s", h.publicURL, t.Token)
+	return t
+}
+
+// GetToken returns the user's feed URLs, creating a token on first use
+func (h *CalendarHandler) GetToken(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	token, err := h.repo.GetToken(userID)
+	created := false
+	if err == sql.ErrNoRows {
+		secret, genErr := services.NewCalendarToken()
+		if genErr != nil {
+			http.Error(w, genErr.Error(), http.StatusInternalServerError)
+			return
+		}
+		token, created, err = h.repo.CreateToken(userID, secret)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if created {
+		w.WriteHeader(http.StatusCreated)
+	}
+	json.NewEncoder(w).Encode(h.withURLs(token))
+}
+
+// RotateToken replaces the user's token; subscriptions using the old URL stop working
+func (h *CalendarHandler) RotateToken(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	secret, err := services.NewCalendarToken()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	token, err := h.repo.SaveToken(userID, secret)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(h.withURLs(token))
+}
+
+// RevokeToken disables the user's feed URLs
+func (h *CalendarHandler) RevokeToken(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.repo.DeleteToken(userID); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Calendar token revoked"})
+}
+
+// feedUser resolves ?token=; unknown or revoked tokens get a 404
+func (h *CalendarHandler) feedUser(w http.ResponseWriter, r *http.Request) (*models.User, bool) {
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		http.Error(w, "Calendar not found", http.StatusNotFound)
+		return nil, false
+	}
+	user, err := h.repo.GetUserByToken(token)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Calendar not found", http.StatusNotFound)
+		return nil, false
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return nil, false
+	}
+	return user, true
+}
+
+// ClubFeed serves the club calendar: every schedule as a recurring event, plus
+// one-off sessions and sessions moved away from their schedule
+func (h *CalendarHandler) ClubFeed(w http.ResponseWriter, r *http.Request) {
+	if _, ok := h.feedUser(w, r); !ok {
+		return
+	}
+
+	schedules, err := h.scheduleRepo.GetAll()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	closures, err := h.scheduleRepo.GetClosures(time.Time{})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	now := models.WallClockNow()
+	sessions, err := h.repo.GetSessionsBetween(now.AddDate(0, 0, -30), now.AddDate(0, 0, h.horizonDays+1))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	detached := map[int][]*models.TrainingSession{}
+	cal := services.NewCalendar("East Eagles - Entraînements", h.timezone)
+	for _, s := range sessions {
+		switch {
+		case s.ScheduleID == nil:
+			cal.AddSession(s)
+		case s.Detached:
+			detached[*s.ScheduleID] = append(detached[*s.ScheduleID], s)
+		}
+	}
+	for _, s := range schedules {
+		if err := cal.AddSchedule(s, closures, detached[s.ID]); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	writeCalendar(w, "east-eagles.ics", cal)
+}
+
+// AthleteFeed serves an athlete's own calendar: their sessions and the
+// events they registered for
+func (h *CalendarHandler) AthleteFeed(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.feedUser(w, r)
+	if !ok {
+		return
+	}
+	if user.AthleteID == nil {
+		http.Error(w, "Athlete profile not found", http.StatusNotFound)
+		return
+	}
+	athlete, err := h.athleteRepo.GetByID(*user.AthleteID)
+	if err != nil {
+		http.Error(w, "Athlete profile not found", http.StatusNotFound)
+		return
+	}
+
+	now := models.WallClockNow()
+	sessions, err := h.repo.GetAthleteSessions(athlete.ID, now.AddDate(0, 0, -90), now.AddDate(0, 0, h.horizonDays+1))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	events, err := h.repo.GetAthleteEvents(athlete.ID, now.AddDate(0, 0, -1))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	cal := services.NewCalendar(fmt.Sprintf("East Eagles - %s %s", athlete.FirstName, athlete.LastName), h.timezone)
+	for _, s := range sessions {
+		cal.AddSession(s)
+	}
+	for i := range events {
+		cal.AddEvent(&events[i])
+	}
+
+	writeCalendar(w, "east-eagles-athlete.ics", cal)
+}
+
+func writeCalendar(w http.ResponseWriter, filename string, cal *services.Calendar) {
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`inline; filename="%s"`, filename))
+	w.Header().Set("Cache-Control", "private, max-age=900")
+	w.Write(cal.Bytes())
+}
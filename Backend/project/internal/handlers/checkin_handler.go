@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"east-eagles/backend/internal/middleware"
+	"east-eagles/backend/internal/models"
+	"east-eagles/backend/internal/repository"
+	"east-eagles/backend/internal/services"
+
+	"github.com/gorilla/mux"
+)
+
+type CheckinHandler struct {
+	repo        *repository.TrainingRepository
+	venueRepo   *repository.VenueRepository
+	athleteRepo *repository.AthleteRepository
+	tokens      *services.CheckinTokens
+	openBefore  time.Duration
+}
+
+// NewCheckinHandler creates the QR self check-in handler. Check-in opens
+// openBefore the session starts and closes when it ends.
+func NewCheckinHandler(repo *repository.TrainingRepository, venueRepo *repository.VenueRepository, athleteRepo *repository.AthleteRepository, tokens *services.CheckinTokens, openBefore time.Duration) *CheckinHandler {
+	return &CheckinHandler{repo: repo, venueRepo: venueRepo, athleteRepo: athleteRepo, tokens: tokens, openBefore: openBefore}
+}
+
+// window returns when check-in opens and closes for a session, as wall-clock
+// times like session_date
+func (h *CheckinHandler) window(s *models.TrainingSession) (time.Time, time.Time) {
+	return s.SessionDate.Add(-h.openBefore), s.SessionDate.Add(time.Duration(s.DurationMinutes) * time.Minute)
+}
+
+func (h *CheckinHandler) session(w http.ResponseWriter, r *http.Request) (*models.TrainingSession, bool) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return nil, false
+	}
+	session, err := h.repo.GetByID(id)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Session not found", http.StatusNotFound)
+		return nil, false
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return nil, false
+	}
+	return session, true
+}
+
+// GetToken returns the session's current QR token; the screen showing it
+// fetches a new one at expires_at
+func (h *CheckinHandler) GetToken(w http.ResponseWriter, r *http.Request) {
+	session, ok := h.session(w, r)
+	if !ok {
+		return
+	}
+
+	opens, closes := h.window(session)
+	token, expires := h.tokens.Issue(session.ID, time.Now())
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.CheckinToken{
+		SessionID: session.ID,
+		Token:     token,
+		Payload:   fmt.Sprintf("east-eagles:checkin:%d:%s", session.ID, token),
+		ExpiresAt: expires,
+		OpensAt:   opens,
+		ClosesAt:  closes,
+	})
+}
+
+// CheckIn marks the authenticated athlete present after they scanned the
+// session's QR code on site and during the session
+func (h *CheckinHandler) CheckIn(w http.ResponseWriter, r *http.Request) {
+	session, ok := h.session(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.CheckinRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	athlete, ok := currentAthlete(w, r, h.athleteRepo)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	opens, closes := h.window(session)
+	if wall := models.WallClock(now); wall.Before(opens) || wall.After(closes) {
+		http.Error(w, "Le check-in n'est pas ouvert pour cette séance", http.StatusBadRequest)
+		return
+	}
+	if !h.tokens.Verify(session.ID, req.Token, now) {
+		http.Error(w, "QR code expiré ou invalide", http.StatusBadRequest)
+		return
+	}
+	if !repository.LevelAllowed(session.Level, athlete.SkillLevel) {
+		http.Error(w, "Cette séance n'est pas ouverte à votre niveau", http.StatusForbidden)
+		return
+	}
+
+	if session.VenueID != nil {
+		venue, err := h.venueRepo.GetByID(*session.VenueID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if venue.Latitude != nil && venue.Longitude != nil {
+			if req.Latitude == nil || req.Longitude == nil {
+				http.Error(w, "Position requise pour le check-in", http.StatusBadRequest)
+				return
+			}
+			distance := services.DistanceMeters(*venue.Latitude, *venue.Longitude, *req.Latitude, *req.Longitude)
+			if distance > float64(venue.Radius) {
+				http.Error(w, "Vous devez être sur place pour le check-in", http.StatusForbidden)
+				return
+			}
+		}
+	}
+
+	saved, err := h.repo.CheckIn(session.ID, athlete.ID, userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !saved {
+		http.Error(w, "Votre présence a déjà été fixée par l'entraîneur", http.StatusConflict)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Présence enregistrée"})
+}
@@ -0,0 +1,299 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"east-eagles/backend/internal/middleware"
+	"east-eagles/backend/internal/models"
+	"east-eagles/backend/internal/repository"
+	"east-eagles/backend/internal/services"
+
+	"github.com/gorilla/mux"
+)
+
+type CheckoutHandler struct {
+	repo        *repository.CheckoutRepository
+	athleteRepo *repository.AthleteRepository
+	provider    services.PaymentProvider
+	monthlyFee  float64
+	publicURL   string
+}
+
+// NewCheckoutHandler creates the online payment handler. provider may be nil
+// when online payments are disabled.
+func NewCheckoutHandler(repo *repository.CheckoutRepository, athleteRepo *repository.AthleteRepository, provider services.PaymentProvider, monthlyFee float64, publicURL string) *CheckoutHandler {
+	return &CheckoutHandler{
+		repo:        repo,
+		athleteRepo: athleteRepo,
+		provider:    provider,
+		monthlyFee:  monthlyFee,
+		publicURL:   publicURL,
+	}
+}
+
+// Create opens an online checkout for the authenticated athlete.
+// Clients should send an Idempotency-Key header so retries return the same checkout.
+func (h *CheckoutHandler) Create(w http.ResponseWriter, r *http.Request) {
+	if h.provider == nil {
+		http.Error(w, "Paiement en ligne non disponible", http.StatusServiceUnavailable)
+		return
+	}
+	if h.monthlyFee <= 0 {
+		http.Error(w, "Tarif mensuel non configuré (MONTHLY_FEE)", http.StatusServiceUnavailable)
+		return
+	}
+
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	athlete, ok := currentAthlete(w, r, h.athleteRepo)
+	if !ok {
+		return
+	}
+
+	var req models.CreateCheckoutRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.MonthsCovered < 1 || req.MonthsCovered > 12 {
+		http.Error(w, "months_covered must be between 1 and 12", http.StatusBadRequest)
+		return
+	}
+	if req.SuccessURL == "" {
+		req.SuccessURL = h.publicURL
+	}
+	if req.CancelURL == "" {
+		req.CancelURL = h.publicURL
+	}
+	// The fake provider redirects to these URLs, so they must stay on our origin
+	if !sameOrigin(req.SuccessURL, h.publicURL) || !sameOrigin(req.CancelURL, h.publicURL) {
+		http.Error(w, "success_url and cancel_url must be on "+h.publicURL, http.StatusBadRequest)
+		return
+	}
+
+	key := r.Header.Get("Idempotency-Key")
+	if key == "" {
+		http.Error(w, "Idempotency-Key header is required", http.StatusBadRequest)
+		return
+	}
+
+	checkout, existing, err := h.repo.CreatePending(&models.PaymentCheckout{
+		AthleteID:      athlete.ID,
+		UserID:         userID,
+		Provider:       h.provider.Name(),
+		IdempotencyKey: fmt.Sprintf("%d:%s", userID, key),
+		Amount:         h.monthlyFee * float64(req.MonthsCovered),
+		MonthsCovered:  req.MonthsCovered,
+		SuccessURL:     req.SuccessURL,
+		CancelURL:      req.CancelURL,
+	})
+	if errors.Is(err, repository.ErrIdempotencyConflict) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if checkout.SessionID == "" {
+		session, err := h.provider.CreateCheckout(&services.CheckoutRequest{
+			CheckoutID:  checkout.ID,
+			Amount:      checkout.Amount,
+			Currency:    "DZD",
+			Description: fmt.Sprintf("Cotisation East Eagles - %d mois", checkout.MonthsCovered),
+			SuccessURL:  checkout.SuccessURL,
+			CancelURL:   checkout.CancelURL,
+		})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		if err := h.repo.SetSession(checkout.ID, session.SessionID, session.URL); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		checkout.SessionID = session.SessionID
+		checkout.CheckoutURL = session.URL
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if !existing {
+		w.WriteHeader(http.StatusCreated)
+	}
+	json.NewEncoder(w).Encode(checkout)
+}
+
+// sameOrigin reports whether raw is an absolute URL with the scheme and host of base
+func sameOrigin(raw, base string) bool {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return false
+	}
+	b, err := url.Parse(base)
+	if err != nil {
+		return false
+	}
+	return u.Scheme == b.Scheme && strings.EqualFold(u.Host, b.Host) && u.User == nil
+}
+
+// Get returns a checkout of the authenticated user (coaches can see any)
+func (h *CheckoutHandler) Get(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	checkout, err := h.repo.GetByID(id)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Checkout not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	userID, _ := r.Context().Value(middleware.UserIDKey).(int)
+	role, _ := r.Context().Value(middleware.UserRoleKey).(models.UserRole)
+	if checkout.UserID != userID && role != models.RoleAdmin && role != models.RoleCoach {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(checkout)
+}
+
+// Webhook receives provider notifications. It is public: authenticity comes
+// from the provider signature, and replays are ignored by event id.
+func (h *CheckoutHandler) Webhook(w http.ResponseWriter, r *http.Request) {
+	if h.provider == nil || mux.Vars(r)["provider"] != h.provider.Name() {
+		http.Error(w, "Unknown payment provider", http.StatusNotFound)
+		return
+	}
+
+	payload, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	checkout, err := h.applyWebhook(payload, r.Header)
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"received": true, "status": checkout.Status})
+}
+
+func (h *CheckoutHandler) applyWebhook(payload []byte, header http.Header) (*models.PaymentCheckout, error) {
+	event, err := h.provider.VerifyWebhook(payload, header)
+	if err != nil {
+		return nil, err
+	}
+
+	checkout, err := h.repo.ApplyEvent(
+		h.provider.Name(), event.EventID, event.SessionID, event.Status, event.Amount, event.Reference,
+	)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("💳 Checkout #%d %s (%s event %s)", checkout.ID, checkout.Status, h.provider.Name(), event.EventID)
+	return checkout, nil
+}
+
+// writeWebhookError maps bad signatures to 401 and unknown sessions to 404;
+// other errors go through writePaymentError
+func writeWebhookError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidSignature):
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+	case err == sql.ErrNoRows:
+		http.Error(w, "Unknown checkout session", http.StatusNotFound)
+	default:
+		writePaymentError(w, err)
+	}
+}
+
+var fakeCheckoutPage = template.Must(template.New("fake").Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>Fake checkout</title></head>
+<body style="font-family: sans-serif; max-width: 28em; margin: 4em auto">
+<h2>Fake payment provider</h2>
+<p>Checkout #{{.ID}}: {{.MonthsCovered}} month(s), {{printf "%.2f" .Amount}} DZD</p>
+<p>Status: <strong>{{.Status}}</strong></p>
+{{if eq .Status "pending"}}
+<form method="post"><button name="status" value="paid">Pay</button>
+<button name="status" value="failed">Fail</button>
+<button name="status" value="canceled">Cancel</button></form>
+{{end}}
+</body></html>`))
+
+// FakeCheckoutPage shows the fake provider's payment page (local testing only)
+func (h *CheckoutHandler) FakeCheckoutPage(w http.ResponseWriter, r *http.Request) {
+	checkout, err := h.repo.GetBySession("fake", mux.Vars(r)["session"])
+	if err != nil {
+		http.Error(w, "Unknown checkout session", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fakeCheckoutPage.Execute(w, checkout)
+}
+
+// FakeCheckoutSubmit simulates the gateway: it signs a webhook event and
+// feeds it through the same verification as real deliveries
+func (h *CheckoutHandler) FakeCheckoutSubmit(w http.ResponseWriter, r *http.Request) {
+	fake, ok := h.provider.(*services.FakePaymentProvider)
+	if !ok {
+		http.Error(w, "Fake provider not enabled", http.StatusNotFound)
+		return
+	}
+
+	sessionID := mux.Vars(r)["session"]
+	checkout, err := h.repo.GetBySession(fake.Name(), sessionID)
+	if err != nil {
+		http.Error(w, "Unknown checkout session", http.StatusNotFound)
+		return
+	}
+
+	status := r.FormValue("status")
+	payload, header, err := fake.SignedEvent(&services.PaymentEvent{
+		SessionID: sessionID,
+		Status:    status,
+		Amount:    checkout.Amount,
+		Reference: "FAKE-" + sessionID,
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	checkout, err = h.applyWebhook(payload, header)
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+
+	target := checkout.CancelURL
+	if checkout.Status == models.CheckoutStatusPaid {
+		target = checkout.SuccessURL
+	}
+	http.Redirect(w, r, target, http.StatusSeeOther)
+}
@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"east-eagles/backend/internal/database"
+	"east-eagles/backend/internal/middleware"
+	"east-eagles/backend/internal/models"
+	"east-eagles/backend/internal/repository"
+	"east-eagles/backend/internal/services"
+	"east-eagles/backend/migrations"
+
+	"github.com/gorilla/mux"
+)
+
+const testWebhookSecret = "test-secret"
+
+// postWebhook delivers payload to the checkout webhook of provider
+func postWebhook(h *CheckoutHandler, provider string, payload []byte, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/api/payments/webhooks/"+provider, bytes.NewReader(payload))
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req = mux.SetURLVars(req, map[string]string{"provider": provider})
+	rec := httptest.NewRecorder()
+	h.Webhook(rec, req)
+	return rec
+}
+
+func TestWebhookRejectsInvalidSignatures(t *testing.T) {
+	fake := services.NewFakePaymentProvider(testWebhookSecret, "http://localhost:8080")
+	// No repository: a delivery that fails verification must never reach it
+	h := NewCheckoutHandler(nil, nil, fake, 3000, "http://localhost:8080")
+
+	payload, header, err := fake.SignedEvent(&services.PaymentEvent{
+		SessionID: "fake_1_abc", Status: models.CheckoutStatusPaid, Amount: 3000,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged, forgedHeader, err := services.NewFakePaymentProvider("other-secret", "").SignedEvent(&services.PaymentEvent{
+		SessionID: "fake_1_abc", Status: models.CheckoutStatusPaid, Amount: 3000,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		payload []byte
+		header  http.Header
+	}{
+		{"no signature", payload, http.Header{}},
+		{"not hex", payload, http.Header{services.FakeSignatureHeader: {"zz"}}},
+		{"tampered body", bytes.Replace(payload, []byte("3000"), []byte("1"), 1), header},
+		{"other secret", forged, forgedHeader},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := postWebhook(h, "fake", tt.payload, tt.header)
+			if rec.Code != http.StatusUnauthorized {
+				t.Errorf("status %d, want 401: %s", rec.Code, rec.Body.String())
+			}
+		})
+	}
+
+	if rec := postWebhook(h, "stripe", payload, header); rec.Code != http.StatusNotFound {
+		t.Errorf("unknown provider: status %d, want 404", rec.Code)
+	}
+}
+
+// checkoutTestDB migrates the database at TEST_DATABASE_URL and creates an
+// athlete account in it, skipping the test when the variable is not set
+func checkoutTestDB(t *testing.T) (db *sql.DB, userID, athleteID int) {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	migrator, err := database.NewMigrator(db, migrations.FS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(context.Background(), ""); err != nil {
+		t.Fatal(err)
+	}
+
+	email := fmt.Sprintf("checkout-handler-%d@test.local", time.Now().UnixNano())
+	err = db.QueryRow(`
+		INSERT INTO users (email, password_hash, role, first_name, last_name, is_active)
+		VALUES ($1, 'x', 'athlete', 'Test', 'Checkout', true) RETURNING id
+	`, email).Scan(&userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.QueryRow(`
+		INSERT INTO athletes (first_name, last_name, email, phone)
+		VALUES ('Test', 'Checkout', $1, '0000') RETURNING id
+	`, email).Scan(&athleteID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.Exec(`DELETE FROM payment_webhook_events WHERE session_id IN (SELECT session_id FROM payment_checkouts WHERE athlete_id = $1)`, athleteID)
+		db.Exec(`DELETE FROM payment_checkouts WHERE athlete_id = $1`, athleteID)
+		db.Exec(`DELETE FROM payments WHERE athlete_id = $1`, athleteID)
+		db.Exec(`DELETE FROM athletes WHERE id = $1`, athleteID)
+		db.Exec(`DELETE FROM users WHERE id = $1`, userID)
+	})
+	return db, userID, athleteID
+}
+
+// createCheckout opens a checkout through the handler as the test athlete
+func createCheckout(h *CheckoutHandler, userID, athleteID int, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/api/payments/checkout", strings.NewReader(body))
+	req.Header.Set("Idempotency-Key", key)
+	ctx := context.WithValue(req.Context(), middleware.UserIDKey, userID)
+	ctx = context.WithValue(ctx, middleware.AthleteIDKey, athleteID)
+	rec := httptest.NewRecorder()
+	h.Create(rec, req.WithContext(ctx))
+	return rec
+}
+
+func TestCheckoutWebhookFlow(t *testing.T) {
+	db, userID, athleteID := checkoutTestDB(t)
+	fake := services.NewFakePaymentProvider(testWebhookSecret, "http://localhost:8080")
+	repo := repository.NewCheckoutRepository(db)
+	h := NewCheckoutHandler(repo, repository.NewAthleteRepository(db), fake, 3000, "http://localhost:8080")
+
+	key := fmt.Sprintf("key-%d", time.Now().UnixNano())
+	rec := createCheckout(h, userID, athleteID, key, `{"months_covered": 2}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create: status %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := createCheckout(h, userID, athleteID, key, `{"months_covered": 2}`); rec.Code != http.StatusOK {
+		t.Errorf("retry: status %d, want 200", rec.Code)
+	}
+	if rec := createCheckout(h, userID, athleteID, key, `{"months_covered": 3}`); rec.Code != http.StatusConflict {
+		t.Errorf("same key, other checkout: status %d, want 409", rec.Code)
+	}
+
+	var sessionID string
+	if err := db.QueryRow(
+		`SELECT session_id FROM payment_checkouts WHERE idempotency_key = $1`, fmt.Sprintf("%d:%s", userID, key),
+	).Scan(&sessionID); err != nil {
+		t.Fatal(err)
+	}
+
+	short, shortHeader, err := fake.SignedEvent(&services.PaymentEvent{
+		SessionID: sessionID, Status: models.CheckoutStatusPaid, Amount: 3000,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec := postWebhook(h, "fake", short, shortHeader); rec.Code != http.StatusBadRequest {
+		t.Errorf("amount mismatch: status %d, want 400", rec.Code)
+	}
+
+	paid, paidHeader, err := fake.SignedEvent(&services.PaymentEvent{
+		SessionID: sessionID, Status: models.CheckoutStatusPaid, Amount: 6000,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		rec := postWebhook(h, "fake", paid, paidHeader)
+		if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"status":"paid"`) {
+			t.Errorf("delivery %d: status %d: %s", i+1, rec.Code, rec.Body.String())
+		}
+	}
+	var payments int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM payments WHERE athlete_id = $1`, athleteID).Scan(&payments); err != nil {
+		t.Fatal(err)
+	}
+	if payments != 1 {
+		t.Errorf("%d payments recorded for a replayed webhook, want 1", payments)
+	}
+}
@@ -25,14 +25,16 @@ type DocumentHandler struct {
 	athleteRepo       *repository.AthleteRepository
 	userRepo          *repository.UserRepository
 	cloudinaryService *services.CloudinaryService
+	notifier          *services.Notifier
 }
 
-func NewDocumentHandler(repo *repository.DocumentRepository, athleteRepo *repository.AthleteRepository, userRepo *repository.UserRepository, cloudinaryService *services.CloudinaryService) *DocumentHandler {
+func NewDocumentHandler(repo *repository.DocumentRepository, athleteRepo *repository.AthleteRepository, userRepo *repository.UserRepository, cloudinaryService *services.CloudinaryService, notifier *services.Notifier) *DocumentHandler {
 	return &DocumentHandler{
 		repo:              repo,
 		athleteRepo:       athleteRepo,
 		userRepo:          userRepo,
 		cloudinaryService: cloudinaryService,
+		notifier:          notifier,
 	}
 }
 
@@ -57,6 +59,24 @@ func (h *DocumentHandler) Upload(w http.ResponseWriter, r *http.Request) {
 	expiryDateStr := r.FormValue("expiry_date")
 	notes := r.FormValue("notes")
 
+	// Athletes and guardians upload for their own (selected) athlete only,
+	// e.g. a guardian's parental_consent
+	role, _ := r.Context().Value(middleware.UserRoleKey).(models.UserRole)
+	if role == models.RoleAthlete || role == models.RoleGuardian {
+		ownID, ok := currentAthleteID(r)
+		if !ok {
+			http.Error(w, "Athlete profile not found", http.StatusNotFound)
+			return
+		}
+		if athleteIDStr == "" {
+			athleteIDStr = strconv.Itoa(ownID)
+		}
+		if athleteIDStr != strconv.Itoa(ownID) {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+	}
+
 	athleteID, err := strconv.Atoi(athleteIDStr)
 	if err != nil {
 		http.Error(w, "Invalid athlete ID", http.StatusBadRequest)
@@ -348,25 +368,17 @@ func (h *DocumentHandler) GetMyDocuments(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// Get user to find their email
-	user, err := h.userRepo.GetByID(userID)
-	if err != nil {
-		log.Printf("Error getting user %d: %v", userID, err)
-		http.Error(w, "User not found", http.StatusNotFound)
-		return
-	}
-
-	// Get athlete by email
-	athlete, err := h.athleteRepo.GetByEmail(user.Email)
-	if err != nil {
-		log.Printf("Athlete with email %s not found: %v", user.Email, err)
+	// Get the athlete linked to the account
+	athleteID, ok := currentAthleteID(r)
+	if !ok {
+		log.Printf("User %d has no athlete profile", userID)
 		// Return empty array instead of error for new athletes
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode([]*models.Document{})
 		return
 	}
 
-	docs, err := h.repo.GetByAthlete(athlete.ID)
+	docs, err := h.repo.GetByAthlete(athleteID)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -446,23 +458,14 @@ func (h *DocumentHandler) DeleteMyDocument(w http.ResponseWriter, r *http.Reques
 	}
 	log.Printf("🔍 DeleteMyDocument: userID=%d, docID=%d", userID, id)
 
-	// Get user to find athlete by email
-	user, err := h.userRepo.GetByID(userID)
-	if err != nil {
-		log.Printf("❌ DeleteMyDocument: user not found for userID=%d: %v", userID, err)
-		http.Error(w, "User not found", http.StatusNotFound)
-		return
-	}
-	log.Printf("🔍 DeleteMyDocument: user email=%s", user.Email)
-
-	// Find athlete by email
-	athlete, err := h.athleteRepo.GetByEmail(user.Email)
-	if err != nil {
-		log.Printf("❌ DeleteMyDocument: athlete not found for email=%s: %v", user.Email, err)
+	// Get the athlete linked to the account
+	athleteID, ok := currentAthleteID(r)
+	if !ok {
+		log.Printf("❌ DeleteMyDocument: no athlete linked to userID=%d", userID)
 		http.Error(w, "Athlete not found", http.StatusNotFound)
 		return
 	}
-	log.Printf("🔍 DeleteMyDocument: athlete ID=%d", athlete.ID)
+	log.Printf("🔍 DeleteMyDocument: athlete ID=%d", athleteID)
 
 	// Get the document first to check ownership
 	doc, err := h.repo.GetByID(id)
@@ -471,11 +474,11 @@ func (h *DocumentHandler) DeleteMyDocument(w http.ResponseWriter, r *http.Reques
 		http.Error(w, "Document not found", http.StatusNotFound)
 		return
 	}
-	log.Printf("🔍 DeleteMyDocument: doc.AthleteID=%d, athlete.ID=%d", doc.AthleteID, athlete.ID)
+	log.Printf("🔍 DeleteMyDocument: doc.AthleteID=%d, athleteID=%d", doc.AthleteID, athleteID)
 
 	// Check if this document belongs to the athlete
-	if doc.AthleteID != athlete.ID {
-		log.Printf("❌ DeleteMyDocument: ownership mismatch doc.AthleteID=%d != athlete.ID=%d", doc.AthleteID, athlete.ID)
+	if doc.AthleteID != athleteID {
+		log.Printf("❌ DeleteMyDocument: ownership mismatch doc.AthleteID=%d != athleteID=%d", doc.AthleteID, athleteID)
 		http.Error(w, "You can only delete your own documents", http.StatusForbidden)
 		return
 	}
@@ -493,7 +496,7 @@ func (h *DocumentHandler) DeleteMyDocument(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	log.Printf("✅ Document ID=%d deleted by athlete ID=%d", id, athlete.ID)
+	log.Printf("✅ Document ID=%d deleted by athlete ID=%d", id, athleteID)
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]string{"message": "Document deleted successfully"})
@@ -627,6 +630,9 @@ func (h *DocumentHandler) Validate(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	if doc, err := h.repo.GetByID(id); err == nil {
+		notifyAthlete(h.notifier, doc.AthleteID, "document_validated", map[string]string{"document": doc.DocumentType})
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]string{"message": "Document validé"})
@@ -659,6 +665,12 @@ func (h *DocumentHandler) Reject(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	if doc, err := h.repo.GetByID(id); err == nil {
+		notifyAthlete(h.notifier, doc.AthleteID, "document_rejected", map[string]string{
+			"document": doc.DocumentType,
+			"reason":   req.Reason,
+		})
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]string{"message": "Document rejeté"})
@@ -1,10 +1,14 @@
 package handlers
 
 import (
+	"database/sql"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
+	"east-eagles/backend/internal/middleware"
 	"east-eagles/backend/internal/models"
 	"east-eagles/backend/internal/repository"
 
@@ -12,15 +16,83 @@ import (
 )
 
 type EventHandler struct {
-	repo *repository.EventRepository
+	repo        *repository.EventRepository
+	athleteRepo *repository.AthleteRepository
 }
 
-func NewEventHandler(repo *repository.EventRepository) *EventHandler {
-	return &EventHandler{repo: repo}
+func NewEventHandler(repo *repository.EventRepository, athleteRepo *repository.AthleteRepository) *EventHandler {
+	return &EventHandler{repo: repo, athleteRepo: athleteRepo}
 }
 
+// writeEventError answers registration errors: reasons the athlete is not
+// eligible as 400 with the list, a full event as 409
+func writeEventError(w http.ResponseWriter, err error) {
+	if regErr, ok := err.(*repository.EventRegistrationError); ok {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Inscription impossible",
+			"reasons": regErr.Reasons,
+		})
+		return
+	}
+	switch err {
+	case repository.ErrEventFull:
+		http.Error(w, "L'événement est complet", http.StatusConflict)
+	case sql.ErrNoRows:
+		http.Error(w, "Événement non trouvé", http.StatusNotFound)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// validateEvent checks an event request and fills in the defaults
+func validateEvent(req *models.CreateEventRequest) error {
+	if req.Title == "" {
+		return fmt.Errorf("title is required")
+	}
+	date, err := time.Parse("2006-01-02 15:04", req.Date)
+	if err != nil {
+		return fmt.Errorf("date must be YYYY-MM-DD HH:MM")
+	}
+	if req.RegistrationDeadline != nil && *req.RegistrationDeadline == "" {
+		req.RegistrationDeadline = nil
+	}
+	if req.RegistrationDeadline != nil {
+		deadline, err := time.Parse("2006-01-02 15:04", *req.RegistrationDeadline)
+		if err != nil {
+			return fmt.Errorf("registration_deadline must be YYYY-MM-DD HH:MM")
+		}
+		if deadline.After(date) {
+			return fmt.Errorf("registration_deadline must be before the event date")
+		}
+	}
+	if req.MaxParticipants < 0 {
+		return fmt.Errorf("max_participants cannot be negative")
+	}
+
+	if req.EventType == "" {
+		req.EventType = "competition"
+	}
+	switch req.EventType {
+	case "competition", "seminar", "other":
+	default:
+		return fmt.Errorf("event_type must be competition, seminar or other")
+	}
+	if req.Status == "" {
+		req.Status = models.EventOpen
+	}
+	switch req.Status {
+	case models.EventOpen, models.EventClosed, models.EventCancelled:
+	default:
+		return fmt.Errorf("status must be open, closed or cancelled")
+	}
+	return nil
+}
+
+// GetAll returns upcoming events; ?all=true includes past ones
 func (h *EventHandler) GetAll(w http.ResponseWriter, r *http.Request) {
-	events, err := h.repo.GetAll()
+	events, err := h.repo.GetAll(r.URL.Query().Get("all") == "true")
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -54,8 +126,18 @@ func (h *EventHandler) Create(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Données invalides", http.StatusBadRequest)
 		return
 	}
+	if err := validateEvent(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
-	event, err := h.repo.Create(&req)
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	event, err := h.repo.Create(&req, userID)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -66,32 +148,39 @@ func (h *EventHandler) Create(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(event)
 }
 
-func (h *EventHandler) RegisterMember(w http.ResponseWriter, r *http.Request) {
+func (h *EventHandler) Update(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
-	eventID, err := strconv.Atoi(vars["id"])
+	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
 		http.Error(w, "ID invalide", http.StatusBadRequest)
 		return
 	}
 
-	var req models.RegisterEventRequest
+	var req models.CreateEventRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "Données invalides", http.StatusBadRequest)
 		return
 	}
+	if err := validateEvent(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
-	if err := h.repo.RegisterMember(eventID, req.MemberID); err != nil {
+	event, err := h.repo.Update(id, &req)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Événement non trouvé", http.StatusNotFound)
+		return
+	}
+	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(map[string]string{
-		"message": "Inscription réussie",
-	})
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(event)
 }
 
-func (h *EventHandler) Update(w http.ResponseWriter, r *http.Request) {
+func (h *EventHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
@@ -99,37 +188,197 @@ func (h *EventHandler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var req models.CreateEventRequest
+	err = h.repo.Delete(id)
+	if err != nil {
+		http.Error(w, "Événement non trouvé", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Événement supprimé"})
+}
+
+// GetRegistrations lists an event's entries
+func (h *EventHandler) GetRegistrations(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "ID invalide", http.StatusBadRequest)
+		return
+	}
+
+	regs, err := h.repo.GetRegistrations(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(regs)
+}
+
+// Nominate enters an athlete in an event on behalf of their coach. The same
+// eligibility rules as self-registration apply.
+func (h *EventHandler) Nominate(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	eventID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "ID invalide", http.StatusBadRequest)
+		return
+	}
+
+	var req models.RegisterEventRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "Données invalides", http.StatusBadRequest)
 		return
 	}
+	athlete, err := h.athleteRepo.GetByID(req.AthleteID)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Athlete not found", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
 
-	event, err := h.repo.Update(id, &req)
+	coachID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	reg, err := h.repo.Register(eventID, athlete, req.WeightCategory, &coachID)
 	if err != nil {
-		http.Error(w, "Événement non trouvé", http.StatusNotFound)
+		writeEventError(w, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(event)
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(reg)
 }
 
-func (h *EventHandler) Delete(w http.ResponseWriter, r *http.Request) {
+// RemoveRegistration withdraws an athlete from an event, at any time
+func (h *EventHandler) RemoveRegistration(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
-	id, err := strconv.Atoi(vars["id"])
+	eventID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "ID invalide", http.StatusBadRequest)
+		return
+	}
+	athleteID, err := strconv.Atoi(vars["athleteId"])
 	if err != nil {
 		http.Error(w, "ID invalide", http.StatusBadRequest)
 		return
 	}
 
-	err = h.repo.Delete(id)
+	if err := h.repo.Withdraw(eventID, athleteID, false); err == sql.ErrNoRows {
+		http.Error(w, "Inscription non trouvée", http.StatusNotFound)
+		return
+	} else if err != nil {
+		writeEventError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Inscription annulée"})
+}
+
+// GetForAthlete lists upcoming events with the athlete's registration and
+// eligibility
+func (h *EventHandler) GetForAthlete(w http.ResponseWriter, r *http.Request) {
+	athlete, ok := currentAthlete(w, r, h.athleteRepo)
+	if !ok {
+		return
+	}
+
+	events, err := h.repo.GetForAthlete(athlete)
 	if err != nil {
-		http.Error(w, "Événement non trouvé", http.StatusNotFound)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]string{"message": "Événement supprimé"})
+	json.NewEncoder(w).Encode(events)
+}
+
+// GetMyRegistrations lists the athlete's event entries
+func (h *EventHandler) GetMyRegistrations(w http.ResponseWriter, r *http.Request) {
+	athleteID, ok := currentAthleteID(r)
+	if !ok {
+		http.Error(w, "Athlete profile not found", http.StatusNotFound)
+		return
+	}
+
+	regs, err := h.repo.GetByAthlete(athleteID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(regs)
+}
+
+// Register enters the authenticated athlete in an event
+func (h *EventHandler) Register(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	eventID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "ID invalide", http.StatusBadRequest)
+		return
+	}
+
+	// The body is optional: it only carries a weight category
+	var req models.RegisterEventRequest
+	if r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Données invalides", http.StatusBadRequest)
+			return
+		}
+	}
+
+	athlete, ok := currentAthlete(w, r, h.athleteRepo)
+	if !ok {
+		return
+	}
+
+	reg, err := h.repo.Register(eventID, athlete, req.WeightCategory, nil)
+	if err != nil {
+		writeEventError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(reg)
+}
+
+// Withdraw withdraws the authenticated athlete while registrations are open
+func (h *EventHandler) Withdraw(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	eventID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "ID invalide", http.StatusBadRequest)
+		return
+	}
+
+	athleteID, ok := currentAthleteID(r)
+	if !ok {
+		http.Error(w, "Athlete profile not found", http.StatusNotFound)
+		return
+	}
+
+	if err := h.repo.Withdraw(eventID, athleteID, true); err == sql.ErrNoRows {
+		http.Error(w, "Inscription non trouvée", http.StatusNotFound)
+		return
+	} else if err != nil {
+		writeEventError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Inscription annulée"})
 }
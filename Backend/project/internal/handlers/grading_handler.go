@@ -0,0 +1,519 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"east-eagles/backend/internal/middleware"
+	"east-eagles/backend/internal/models"
+	"east-eagles/backend/internal/repository"
+	"east-eagles/backend/internal/services"
+
+	"github.com/gorilla/mux"
+)
+
+type GradingHandler struct {
+	repo         *repository.GradingRepository
+	trainingRepo *repository.TrainingRepository
+}
+
+func NewGradingHandler(repo *repository.GradingRepository, trainingRepo *repository.TrainingRepository) *GradingHandler {
+	return &GradingHandler{repo: repo, trainingRepo: trainingRepo}
+}
+
+// writeGradingError answers grading errors
+func writeGradingError(w http.ResponseWriter, err error) {
+	switch err {
+	case sql.ErrNoRows:
+		http.Error(w, "Candidat non trouvé", http.StatusNotFound)
+	case repository.ErrGradingDecided:
+		http.Error(w, "Le résultat a déjà été enregistré", http.StatusConflict)
+	case repository.ErrGradingCancelled:
+		http.Error(w, "Le passage de grade a été annulé", http.StatusConflict)
+	case repository.ErrGradingBeltChanged:
+		http.Error(w, "La ceinture de l'athlète a changé depuis son inscription", http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// eligibility returns the grade status of athletes (all active approved ones
+// when athleteIDs is empty) checked against the belt requirements for an exam
+// on examDate
+func (h *GradingHandler) eligibility(athleteIDs []int, examDate time.Time) ([]*models.GradingCandidate, error) {
+	belts, err := h.repo.GetBelts()
+	if err != nil {
+		return nil, err
+	}
+	candidates, err := h.repo.GetGradeStatus(athleteIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	to := examDate.Truncate(24 * time.Hour)
+	from := to.AddDate(0, 0, -services.GradingAttendanceDays)
+	records, err := h.trainingRepo.GetAttendanceRecords(0, from, to)
+	if err != nil {
+		return nil, err
+	}
+	attendance := map[int]*models.AttendanceStats{}
+	for _, s := range services.AttendanceStatsByAthlete(records, from, to) {
+		attendance[s.AthleteID] = s
+	}
+
+	for _, c := range candidates {
+		services.CheckGradingEligibility(c, belts, attendance[c.AthleteID], examDate)
+	}
+	return candidates, nil
+}
+
+// GetBelts returns the belt list, lowest grade first
+func (h *GradingHandler) GetBelts(w http.ResponseWriter, r *http.Request) {
+	belts, err := h.repo.GetBelts()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(belts)
+}
+
+// ReplaceBelts replaces the belt list; belts are ranked in the order given
+func (h *GradingHandler) ReplaceBelts(w http.ResponseWriter, r *http.Request) {
+	var belts []models.Belt
+	if err := json.NewDecoder(r.Body).Decode(&belts); err != nil {
+		http.Error(w, "Données invalides", http.StatusBadRequest)
+		return
+	}
+	names := map[string]bool{}
+	for i := range belts {
+		b := &belts[i]
+		if b.Name == "" {
+			http.Error(w, "belt name is required", http.StatusBadRequest)
+			return
+		}
+		if names[b.Name] {
+			http.Error(w, fmt.Sprintf("belt %s is listed twice", b.Name), http.StatusBadRequest)
+			return
+		}
+		if b.MinMonths < 0 || b.MinAttendanceRate < 0 || b.MinAttendanceRate > 1 {
+			http.Error(w, fmt.Sprintf("belt %s: min_months must be positive and min_attendance_rate between 0 and 1", b.Name), http.StatusBadRequest)
+			return
+		}
+		names[b.Name] = true
+		b.Rank = i + 1
+	}
+
+	if err := h.repo.ReplaceBelts(belts); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	saved, err := h.repo.GetBelts()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(saved)
+}
+
+// validateGradingSession checks a session request and returns its exam date
+func validateGradingSession(req *models.CreateGradingSessionRequest) (time.Time, error) {
+	if req.Title == "" {
+		return time.Time{}, fmt.Errorf("title is required")
+	}
+	examDate, err := time.Parse("2006-01-02 15:04", req.ExamDate)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("exam_date must be YYYY-MM-DD HH:MM")
+	}
+	if req.Status == "" {
+		req.Status = models.GradingPlanned
+	}
+	switch req.Status {
+	case models.GradingPlanned, models.GradingCompleted, models.GradingCancelled:
+	default:
+		return time.Time{}, fmt.Errorf("status must be planned, completed or cancelled")
+	}
+	return examDate, nil
+}
+
+func (h *GradingHandler) GetSessions(w http.ResponseWriter, r *http.Request) {
+	sessions, err := h.repo.GetSessions()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sessions)
+}
+
+// GetSession returns a session with its candidates. Pending candidates come
+// with their current eligibility.
+func (h *GradingHandler) GetSession(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "ID invalide", http.StatusBadRequest)
+		return
+	}
+
+	session, err := h.repo.GetSession(id)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Passage de grade non trouvé", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	var pending []int
+	for _, c := range session.Candidates {
+		if c.Result == models.GradingPending {
+			pending = append(pending, c.AthleteID)
+		}
+	}
+	if len(pending) > 0 {
+		status, err := h.eligibility(pending, session.ExamDate)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		byAthlete := map[int]*models.GradingCandidate{}
+		for _, s := range status {
+			byAthlete[s.AthleteID] = s
+		}
+		for _, c := range session.Candidates {
+			if s := byAthlete[c.AthleteID]; s != nil && c.Result == models.GradingPending {
+				c.GradeSince, c.MonthsAtGrade, c.AttendanceRate = s.GradeSince, s.MonthsAtGrade, s.AttendanceRate
+				c.Eligible, c.Reasons = s.Eligible, s.Reasons
+			}
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(session)
+}
+
+func (h *GradingHandler) CreateSession(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateGradingSessionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Données invalides", http.StatusBadRequest)
+		return
+	}
+	examDate, err := validateGradingSession(&req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	session, err := h.repo.CreateSession(&req, examDate, userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(session)
+}
+
+func (h *GradingHandler) UpdateSession(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "ID invalide", http.StatusBadRequest)
+		return
+	}
+
+	var req models.CreateGradingSessionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Données invalides", http.StatusBadRequest)
+		return
+	}
+	examDate, err := validateGradingSession(&req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	session, err := h.repo.UpdateSession(id, &req, examDate)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Passage de grade non trouvé", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(session)
+}
+
+func (h *GradingHandler) DeleteSession(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "ID invalide", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.repo.DeleteSession(id); err == sql.ErrNoRows {
+		http.Error(w, "Passage de grade non trouvé", http.StatusNotFound)
+		return
+	} else if err == repository.ErrGradingDecided {
+		http.Error(w, "Des résultats ont déjà été enregistrés", http.StatusConflict)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Passage de grade supprimé"})
+}
+
+// GetEligible lists every active athlete with their eligibility for the
+// session; ?eligible=true keeps only those who meet the requirements
+func (h *GradingHandler) GetEligible(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "ID invalide", http.StatusBadRequest)
+		return
+	}
+
+	session, err := h.repo.GetSession(id)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Passage de grade non trouvé", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	candidates, err := h.eligibility(nil, session.ExamDate)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if r.URL.Query().Get("eligible") == "true" {
+		eligible := []*models.GradingCandidate{}
+		for _, c := range candidates {
+			if c.Eligible {
+				eligible = append(eligible, c)
+			}
+		}
+		candidates = eligible
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(candidates)
+}
+
+// AddCandidates presents athletes at a session. Athletes who do not meet the
+// requirements are refused with the reasons, unless force is set.
+func (h *GradingHandler) AddCandidates(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "ID invalide", http.StatusBadRequest)
+		return
+	}
+
+	var req models.AddCandidatesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Données invalides", http.StatusBadRequest)
+		return
+	}
+	if len(req.AthleteIDs) == 0 {
+		http.Error(w, "athlete_ids is required", http.StatusBadRequest)
+		return
+	}
+
+	session, err := h.repo.GetSession(id)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Passage de grade non trouvé", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if session.Status != models.GradingPlanned {
+		http.Error(w, "Le passage de grade n'est plus ouvert aux candidatures", http.StatusConflict)
+		return
+	}
+
+	candidates, err := h.eligibility(req.AthleteIDs, session.ExamDate)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	refused := []*models.GradingCandidate{}
+	found := map[int]bool{}
+	for _, c := range candidates {
+		found[c.AthleteID] = true
+		if c.TargetBelt == "" || (!c.Eligible && !req.Force) {
+			refused = append(refused, c)
+		}
+	}
+	for _, athleteID := range req.AthleteIDs {
+		if !found[athleteID] {
+			refused = append(refused, &models.GradingCandidate{
+				AthleteID: athleteID,
+				Reasons:   []string{"Athlète inactif ou adhésion non approuvée"},
+			})
+		}
+	}
+	if len(refused) > 0 {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Candidature impossible",
+			"refused": refused,
+		})
+		return
+	}
+
+	if err := h.repo.AddCandidates(id, candidates); err != nil {
+		writeGradingError(w, err)
+		return
+	}
+	saved, err := h.repo.GetSession(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(saved)
+}
+
+// RemoveCandidate withdraws an athlete from a session before their result
+func (h *GradingHandler) RemoveCandidate(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "ID invalide", http.StatusBadRequest)
+		return
+	}
+	athleteID, err := strconv.Atoi(vars["athleteId"])
+	if err != nil {
+		http.Error(w, "ID invalide", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.repo.RemoveCandidate(id, athleteID); err != nil {
+		writeGradingError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Candidature retirée"})
+}
+
+// RecordResult records the examiners' decision for a candidate; a pass
+// updates the athlete's belt
+func (h *GradingHandler) RecordResult(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "ID invalide", http.StatusBadRequest)
+		return
+	}
+	athleteID, err := strconv.Atoi(vars["athleteId"])
+	if err != nil {
+		http.Error(w, "ID invalide", http.StatusBadRequest)
+		return
+	}
+
+	var req models.GradingResultRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Données invalides", http.StatusBadRequest)
+		return
+	}
+	switch req.Result {
+	case models.GradingPassed, models.GradingFailed, models.GradingAbsent:
+	default:
+		http.Error(w, "result must be passed, failed or absent", http.StatusBadRequest)
+		return
+	}
+	if req.Score != nil && (*req.Score < 0 || *req.Score > 100) {
+		http.Error(w, "score must be between 0 and 100", http.StatusBadRequest)
+		return
+	}
+
+	examinerID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.repo.RecordResult(id, athleteID, &req, examinerID); err != nil {
+		writeGradingError(w, err)
+		return
+	}
+	session, err := h.repo.GetSession(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(session)
+}
+
+// GetAthletePromotions returns an athlete's belt history
+func (h *GradingHandler) GetAthletePromotions(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	athleteID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "ID invalide", http.StatusBadRequest)
+		return
+	}
+
+	promotions, err := h.repo.GetPromotions(athleteID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(promotions)
+}
+
+// GetMyPromotions returns the authenticated athlete's belt history
+func (h *GradingHandler) GetMyPromotions(w http.ResponseWriter, r *http.Request) {
+	athleteID, ok := currentAthleteID(r)
+	if !ok {
+		http.Error(w, "Athlete profile not found", http.StatusNotFound)
+		return
+	}
+
+	promotions, err := h.repo.GetPromotions(athleteID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(promotions)
+}
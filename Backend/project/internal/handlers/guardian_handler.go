@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"east-eagles/backend/internal/middleware"
+	"east-eagles/backend/internal/models"
+	"east-eagles/backend/internal/repository"
+
+	"github.com/gorilla/mux"
+)
+
+type GuardianHandler struct {
+	repo     *repository.GuardianRepository
+	userRepo *repository.UserRepository
+}
+
+func NewGuardianHandler(repo *repository.GuardianRepository, userRepo *repository.UserRepository) *GuardianHandler {
+	return &GuardianHandler{repo: repo, userRepo: userRepo}
+}
+
+// GetChildren lists the athletes the authenticated guardian manages. The
+// client switches child by sending the chosen athlete_id in X-Athlete-ID.
+func (h *GuardianHandler) GetChildren(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if role, _ := r.Context().Value(middleware.UserRoleKey).(models.UserRole); role != models.RoleGuardian {
+		http.Error(w, "Forbidden: Guardian access required", http.StatusForbidden)
+		return
+	}
+
+	wards, err := h.repo.GetWards(userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(wards)
+}
+
+// GetByAthlete lists an athlete's guardians
+func (h *GuardianHandler) GetByAthlete(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	guardians, err := h.repo.GetByAthlete(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(guardians)
+}
+
+// Add links a guardian account, given by user_id or email, to an athlete
+func (h *GuardianHandler) Add(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.AddGuardianRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.UserID == 0 && req.Email != "" {
+		user, err := h.userRepo.GetByEmail(req.Email)
+		if err != nil {
+			http.Error(w, "User not found", http.StatusBadRequest)
+			return
+		}
+		req.UserID = user.ID
+	}
+	if req.UserID == 0 {
+		http.Error(w, "user_id or email is required", http.StatusBadRequest)
+		return
+	}
+	if req.Relation == "" {
+		req.Relation = "parent"
+	}
+
+	switch err := h.repo.Add(id, req.UserID, req.Relation, req.IsPrimary); err {
+	case nil:
+	case sql.ErrNoRows:
+		http.Error(w, "User or athlete not found", http.StatusNotFound)
+		return
+	case repository.ErrNotGuardianAccount:
+		http.Error(w, "Ce compte n'est pas un compte tuteur", http.StatusBadRequest)
+		return
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Tuteur lié à l'athlète"})
+}
+
+// Remove unlinks a guardian from an athlete; an approved minor keeps at least one
+func (h *GuardianHandler) Remove(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+	userID, err := strconv.Atoi(vars["userId"])
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	switch err := h.repo.Remove(id, userID); err {
+	case nil:
+	case sql.ErrNoRows:
+		http.Error(w, "Guardian not found", http.StatusNotFound)
+		return
+	case repository.ErrGuardianRequired:
+		http.Error(w, "Un athlète mineur doit garder au moins un tuteur", http.StatusConflict)
+		return
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Tuteur retiré"})
+}
@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"east-eagles/backend/internal/models"
+	"east-eagles/backend/internal/repository"
+	"east-eagles/backend/internal/services"
+
+	"github.com/gorilla/mux"
+)
+
+type JobHandler struct {
+	repo   *repository.JobRepository
+	runner *services.JobRunner
+}
+
+func NewJobHandler(repo *repository.JobRepository, runner *services.JobRunner) *JobHandler {
+	return &JobHandler{repo: repo, runner: runner}
+}
+
+// GetAll lists the latest jobs; ?status= and ?type= filter them and ?limit=
+// caps how many are returned (100 by default)
+func (h *JobHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	status := query.Get("status")
+	switch status {
+	case "", models.JobQueued, models.JobRunning, models.JobSucceeded, models.JobDead:
+	default:
+		http.Error(w, "Statut invalide", http.StatusBadRequest)
+		return
+	}
+	limit := 100
+	if s := query.Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > 1000 {
+			http.Error(w, "limit must be between 1 and 1000", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	jobs, err := h.repo.GetAll(status, query.Get("type"), limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(jobs)
+}
+
+// GetStats counts jobs per status
+func (h *JobHandler) GetStats(w http.ResponseWriter, r *http.Request) {
+	stats, err := h.repo.GetStats()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
+}
+
+func (h *JobHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "ID invalide", http.StatusBadRequest)
+		return
+	}
+
+	job, err := h.repo.GetByID(id)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Tâche non trouvée", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(job)
+}
+
+// Retry queues a dead job again
+func (h *JobHandler) Retry(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "ID invalide", http.StatusBadRequest)
+		return
+	}
+
+	job, err := h.repo.Retry(id)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Tâche en échec non trouvée", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(job)
+}
+
+// GetSchedules lists the periodic jobs with their next run
+func (h *JobHandler) GetSchedules(w http.ResponseWriter, r *http.Request) {
+	schedules, err := h.repo.GetSchedules()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(schedules)
+}
+
+// Enqueue queues a job of a registered type to run now, e.g. to trigger a
+// periodic job early
+func (h *JobHandler) Enqueue(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Type    string          `json:"type"`
+		Payload json.RawMessage `json:"payload"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Données invalides", http.StatusBadRequest)
+		return
+	}
+
+	known := false
+	for _, t := range h.runner.Types() {
+		known = known || t == req.Type
+	}
+	if !known {
+		http.Error(w, "Type de tâche inconnu", http.StatusBadRequest)
+		return
+	}
+	var payload interface{}
+	if len(req.Payload) > 0 {
+		payload = req.Payload
+	}
+
+	job, err := h.runner.Enqueue(req.Type, payload, time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(job)
+}
@@ -0,0 +1,367 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"east-eagles/backend/internal/middleware"
+	"east-eagles/backend/internal/models"
+	"east-eagles/backend/internal/repository"
+	"east-eagles/backend/internal/services"
+
+	"github.com/gorilla/mux"
+)
+
+type NotificationHandler struct {
+	repo       *repository.NotificationRepository
+	notifier   *services.Notifier
+	hub        *services.NotificationHub
+	expiryDays int // Documents expiring within this many days get a reminder
+	graceDays  int // Payments overdue by more than this many days get a reminder
+}
+
+func NewNotificationHandler(repo *repository.NotificationRepository, notifier *services.Notifier, hub *services.NotificationHub, expiryDays, graceDays int) *NotificationHandler {
+	return &NotificationHandler{repo: repo, notifier: notifier, hub: hub, expiryDays: expiryDays, graceDays: graceDays}
+}
+
+// streamKeepAlive is how often an idle stream sends a comment, so proxies
+// do not close it
+const streamKeepAlive = 25 * time.Second
+
+// notifyAthlete queues a notification about an athlete. Failures are only
+// logged: the action that triggered the notification already succeeded.
+func notifyAthlete(notifier *services.Notifier, athleteID int, key string, params map[string]string) {
+	if _, err := notifier.NotifyAthlete(athleteID, key, params); err != nil {
+		log.Printf("⚠️ Could not queue %s notification for athlete %d: %v", key, athleteID, err)
+	}
+}
+
+// GetPreferences returns the authenticated user's notification settings
+func (h *NotificationHandler) GetPreferences(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	prefs, err := h.repo.GetPreferences(userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(prefs)
+}
+
+// UpdatePreferences replaces the authenticated user's notification settings
+func (h *NotificationHandler) UpdatePreferences(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var prefs models.NotificationPreferences
+	if err := json.NewDecoder(r.Body).Decode(&prefs); err != nil {
+		http.Error(w, "Données invalides", http.StatusBadRequest)
+		return
+	}
+	prefs.UserID = userID
+	prefs.Phone = strings.TrimSpace(prefs.Phone)
+
+	templates := h.notifier.Templates()
+	known := false
+	for _, lang := range templates.Languages() {
+		known = known || lang == prefs.Language
+	}
+	if !known {
+		http.Error(w, "Langue non prise en charge", http.StatusBadRequest)
+		return
+	}
+	for _, key := range prefs.Muted {
+		if !templates.Has(key) {
+			http.Error(w, "Notification inconnue: "+key, http.StatusBadRequest)
+			return
+		}
+	}
+	if prefs.SMSEnabled && prefs.Phone == "" {
+		// Athletes fall back to their profile phone
+		current, err := h.repo.GetPreferences(userID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if current.Phone == "" {
+			http.Error(w, "Un numéro de téléphone est requis pour les SMS", http.StatusBadRequest)
+			return
+		}
+	}
+
+	if err := h.repo.SavePreferences(&prefs); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	saved, err := h.repo.GetPreferences(userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(saved)
+}
+
+// GetOutbox lists the latest outbox messages; ?status= filters them and
+// ?limit= caps how many are returned (100 by default)
+func (h *NotificationHandler) GetOutbox(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "", models.OutboxPending, models.OutboxSending, models.OutboxSent, models.OutboxFailed:
+	default:
+		http.Error(w, "Statut invalide", http.StatusBadRequest)
+		return
+	}
+	limit := 100
+	if s := r.URL.Query().Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > 1000 {
+			http.Error(w, "limit must be between 1 and 1000", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	messages, err := h.repo.GetOutbox(status, limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(messages)
+}
+
+// RetryOutbox queues a failed message again
+func (h *NotificationHandler) RetryOutbox(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "ID invalide", http.StatusBadRequest)
+		return
+	}
+
+	message, err := h.repo.Retry(id)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Message échoué non trouvé", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(message)
+}
+
+// SendReminders queues the expiring document and overdue payment reminders
+// now; each reminder is only queued once
+func (h *NotificationHandler) SendReminders(w http.ResponseWriter, r *http.Request) {
+	result, err := h.notifier.QueueReminders(r.Context(), h.expiryDays, h.graceDays)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// GetNotifications returns the authenticated user's latest notifications
+// with their unread count; ?unread=true keeps the unread ones, ?before= pages
+// back from a notification id and ?limit= caps the page (50 by default)
+func (h *NotificationHandler) GetNotifications(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	query := r.URL.Query()
+	limit := 50
+	if s := query.Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > 200 {
+			http.Error(w, "limit must be between 1 and 200", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+	before := 0
+	if s := query.Get("before"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 {
+			http.Error(w, "ID invalide", http.StatusBadRequest)
+			return
+		}
+		before = n
+	}
+
+	notifications, err := h.repo.GetNotifications(userID, query.Get("unread") == "true", before, limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	unread, err := h.repo.CountUnread(userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.NotificationInbox{Notifications: notifications, UnreadCount: unread})
+}
+
+// MarkRead marks one of the authenticated user's notifications as read
+func (h *NotificationHandler) MarkRead(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "ID invalide", http.StatusBadRequest)
+		return
+	}
+
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	notification, err := h.repo.MarkNotificationRead(userID, id)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Notification non trouvée", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(notification)
+}
+
+// MarkAllRead marks all the authenticated user's notifications as read
+func (h *NotificationHandler) MarkAllRead(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	marked, err := h.repo.MarkAllNotificationsRead(userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]int{"marked": marked})
+}
+
+// writeEvent writes one Server-Sent Event
+func writeEvent(w http.ResponseWriter, id int, event string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if id > 0 {
+		if _, err := fmt.Fprintf(w, "id: %d\n", id); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, payload)
+	return err
+}
+
+// Stream pushes the authenticated user's new notifications as Server-Sent
+// Events. EventSource cannot send headers, so the token is usually passed as
+// ?token=. The stream starts with an "unread" event holding the unread
+// count; each new item is a "notification" event whose id lets a
+// reconnecting client receive what it missed through Last-Event-ID.
+func (h *NotificationHandler) Stream(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming non supporté", http.StatusInternalServerError)
+		return
+	}
+
+	// Subscribe before reading the backlog so nothing falls in between
+	live, unsubscribe := h.hub.Subscribe(userID)
+	defer unsubscribe()
+
+	lastID, _ := strconv.Atoi(r.Header.Get("Last-Event-ID"))
+	var missed []*models.Notification
+	if lastID > 0 {
+		var err error
+		if missed, err = h.repo.GetNotificationsSince(userID, lastID, 100); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	unread, err := h.repo.CountUnread(userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no") // Disable proxy buffering (nginx)
+	fmt.Fprint(w, "retry: 5000\n\n")
+	if err := writeEvent(w, 0, "unread", map[string]int{"unread_count": unread}); err != nil {
+		return
+	}
+	for _, n := range missed {
+		if err := writeEvent(w, n.ID, "notification", n); err != nil {
+			return
+		}
+		lastID = n.ID
+	}
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(streamKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case n := <-live:
+			if n.ID <= lastID {
+				continue // Already sent from the backlog
+			}
+			if err := writeEvent(w, n.ID, "notification", n); err != nil {
+				return
+			}
+			lastID = n.ID
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+	}
+}
@@ -1,13 +1,18 @@
 package handlers
 
 import (
+	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	"east-eagles/backend/internal/middleware"
 	"east-eagles/backend/internal/models"
 	"east-eagles/backend/internal/repository"
+	"east-eagles/backend/internal/services"
 
 	"github.com/gorilla/mux"
 )
@@ -15,10 +20,38 @@ import (
 type PaymentHandler struct {
 	repo        *repository.PaymentRepository
 	athleteRepo *repository.AthleteRepository
+	receipts    *services.ReceiptService
+	notifier    *services.Notifier
+	graceDays   int
 }
 
-func NewPaymentHandler(repo *repository.PaymentRepository, athleteRepo *repository.AthleteRepository) *PaymentHandler {
-	return &PaymentHandler{repo: repo, athleteRepo: athleteRepo}
+func NewPaymentHandler(repo *repository.PaymentRepository, athleteRepo *repository.AthleteRepository, receipts *services.ReceiptService, notifier *services.Notifier, graceDays int) *PaymentHandler {
+	return &PaymentHandler{repo: repo, athleteRepo: athleteRepo, receipts: receipts, notifier: notifier, graceDays: graceDays}
+}
+
+// writePaymentError maps coverage conflicts to 409, rule violations to 400,
+// unknown payments to 404 and everything else to 500
+func writePaymentError(w http.ResponseWriter, err error) {
+	var overlap *repository.PaymentOverlapError
+	var invalid *repository.PaymentValidationError
+	switch {
+	case errors.As(err, &invalid):
+		http.Error(w, invalid.Message, http.StatusBadRequest)
+		return
+	case err == sql.ErrNoRows:
+		http.Error(w, "Payment not found", http.StatusNotFound)
+		return
+	}
+	if errors.As(err, &overlap) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message":   "La période chevauche un paiement existant",
+			"conflicts": overlap.Conflicts,
+		})
+		return
+	}
+	http.Error(w, err.Error(), http.StatusInternalServerError)
 }
 
 // Create records a new payment
@@ -37,9 +70,10 @@ func (h *PaymentHandler) Create(w http.ResponseWriter, r *http.Request) {
 
 	payment, err := h.repo.Create(&req, recordedBy)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writePaymentError(w, err)
 		return
 	}
+	notifyAthlete(h.notifier, payment.AthleteID, "payment_recorded", map[string]string{"amount": fmt.Sprintf("%.2f", payment.Amount)})
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
@@ -65,33 +99,118 @@ func (h *PaymentHandler) GetByAthlete(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(payments)
 }
 
-// GetMyPayments returns payments for the authenticated athlete
-func (h *PaymentHandler) GetMyPayments(w http.ResponseWriter, r *http.Request) {
-	email, ok := r.Context().Value(middleware.UserEmailKey).(string)
-	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+// GetCoverage returns the paid/grace/unpaid timeline for an athlete
+func (h *PaymentHandler) GetCoverage(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	athleteID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
 		return
 	}
 
-	athlete, err := h.athleteRepo.GetByEmail(email)
+	var from, to time.Time
+	if v := r.URL.Query().Get("from"); v != "" {
+		if from, err = time.Parse("2006-01-02", v); err != nil {
+			http.Error(w, "Invalid from date (YYYY-MM-DD)", http.StatusBadRequest)
+			return
+		}
+	}
+	if v := r.URL.Query().Get("to"); v != "" {
+		if to, err = time.Parse("2006-01-02", v); err != nil {
+			http.Error(w, "Invalid to date (YYYY-MM-DD)", http.StatusBadRequest)
+			return
+		}
+	}
+
+	timeline, err := h.repo.GetCoverageTimeline(athleteID, from, to, h.graceDays)
 	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(timeline)
+}
+
+// GetMyPayments returns payments for the authenticated athlete
+func (h *PaymentHandler) GetMyPayments(w http.ResponseWriter, r *http.Request) {
+	athleteID, ok := currentAthleteID(r)
+	if !ok {
 		http.Error(w, "Athlete profile not found", http.StatusNotFound)
 		return
 	}
 
-	payments, err := h.repo.GetByAthlete(athlete.ID)
+	payments, err := h.repo.GetByAthlete(athleteID)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	for _, p := range payments {
+		p.ReceiptURL = fmt.Sprintf("/api/payments/%d/receipt.pdf", p.ID)
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(payments)
 }
 
-// GetRecent returns recent payments
+// GetReceipt renders the PDF receipt of a payment (?lang=fr|ar).
+// Coaches and admins can fetch any receipt, athletes only their own.
+func (h *PaymentHandler) GetReceipt(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	lang := r.URL.Query().Get("lang")
+	if lang == "" {
+		lang = "fr"
+	}
+	if !h.receipts.SupportsLanguage(lang) {
+		http.Error(w, "Unsupported receipt language", http.StatusBadRequest)
+		return
+	}
+
+	payment, err := h.repo.GetByID(id)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Payment not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	role, _ := r.Context().Value(middleware.UserRoleKey).(models.UserRole)
+	if role != models.RoleAdmin && role != models.RoleCoach {
+		athleteID, ok := currentAthleteID(r)
+		if !ok || athleteID != payment.AthleteID {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+	}
+
+	pdf, err := h.receipts.Render(payment, lang)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`inline; filename="recu-%s.pdf"`, payment.ReceiptNumber))
+	w.Write(pdf)
+}
+
+// GetRecent returns recent payments (?method= filters by payment method)
 func (h *PaymentHandler) GetRecent(w http.ResponseWriter, r *http.Request) {
-	payments, err := h.repo.GetRecent()
+	method := models.PaymentMethod(r.URL.Query().Get("method"))
+	if method != "" && !method.IsValid() {
+		http.Error(w, "Invalid payment method", http.StatusBadRequest)
+		return
+	}
+
+	payments, err := h.repo.GetRecent(method)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -124,7 +243,7 @@ func (h *PaymentHandler) Update(w http.ResponseWriter, r *http.Request) {
 
 	payment, err := h.repo.Update(id, &req, recordedBy)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writePaymentError(w, err)
 		return
 	}
 
@@ -132,7 +251,73 @@ func (h *PaymentHandler) Update(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(payment)
 }
 
-// Delete removes a payment record
+// AddInstallment records a partial payment against an existing period
+func (h *PaymentHandler) AddInstallment(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.InstallmentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	recordedBy, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	payment, err := h.repo.AddInstallment(id, &req, recordedBy)
+	if err != nil {
+		writePaymentError(w, err)
+		return
+	}
+	notifyAthlete(h.notifier, payment.AthleteID, "payment_recorded", map[string]string{"amount": fmt.Sprintf("%.2f", payment.Amount)})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(payment)
+}
+
+// Refund issues a credit note reversing all or part of a payment
+func (h *PaymentHandler) Refund(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.RefundRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	recordedBy, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	refund, err := h.repo.Refund(id, &req, recordedBy)
+	if err != nil {
+		writePaymentError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(refund)
+}
+
+// Delete cancels a payment by refunding it in full. Rows are never removed so
+// that receipt numbers stay gap-free; the credit note is returned.
 func (h *PaymentHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
@@ -141,11 +326,58 @@ func (h *PaymentHandler) Delete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.repo.Delete(id); err != nil {
+	recordedBy, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	refund, err := h.repo.Refund(id, &models.RefundRequest{Notes: "Annulation"}, recordedBy)
+	if err != nil {
+		writePaymentError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "Paiement annulé",
+		"refund":  refund,
+	})
+}
+
+// GetBalance returns the payment balance of an athlete
+func (h *PaymentHandler) GetBalance(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	athleteID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	balance, err := h.repo.GetBalance(athleteID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(balance)
+}
+
+// GetMyBalance returns the payment balance of the authenticated athlete
+func (h *PaymentHandler) GetMyBalance(w http.ResponseWriter, r *http.Request) {
+	athleteID, ok := currentAthleteID(r)
+	if !ok {
+		http.Error(w, "Athlete profile not found", http.StatusNotFound)
+		return
+	}
+
+	balance, err := h.repo.GetBalance(athleteID)
+	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{"message": "Payment deleted"})
+	json.NewEncoder(w).Encode(balance)
 }
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"east-eagles/backend/internal/repository"
+	"east-eagles/backend/internal/services"
+
+	"github.com/gorilla/mux"
+)
+
+type RankingHandler struct {
+	repo    *repository.RankingRepository
+	ratings *services.RatingService
+}
+
+func NewRankingHandler(repo *repository.RankingRepository, ratings *services.RatingService) *RankingHandler {
+	return &RankingHandler{repo: repo, ratings: ratings}
+}
+
+// GetRankings returns the club rankings by weight category, optionally
+// filtered with ?weight_category= and ?gender=
+func (h *RankingHandler) GetRankings(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	rankings, err := h.repo.GetRankings(q.Get("weight_category"), q.Get("gender"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rankings)
+}
+
+func (h *RankingHandler) writeRecord(w http.ResponseWriter, athleteID int) {
+	record, err := h.repo.GetRecord(athleteID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(record)
+}
+
+// GetAthleteRecord returns an athlete's fight record, medals and rating
+func (h *RankingHandler) GetAthleteRecord(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+	h.writeRecord(w, id)
+}
+
+// GetMyRecord returns the authenticated athlete's fight record, medals and rating
+func (h *RankingHandler) GetMyRecord(w http.ResponseWriter, r *http.Request) {
+	athleteID, ok := currentAthleteID(r)
+	if !ok {
+		http.Error(w, "Athlete profile not found", http.StatusNotFound)
+		return
+	}
+	h.writeRecord(w, athleteID)
+}
+
+// Recalculate rebuilds every rating from the recorded bouts
+func (h *RankingHandler) Recalculate(w http.ResponseWriter, r *http.Request) {
+	if err := h.ratings.Recalculate(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Classements recalculés"})
+}
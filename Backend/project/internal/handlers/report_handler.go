@@ -0,0 +1,221 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"east-eagles/backend/internal/models"
+	"east-eagles/backend/internal/repository"
+
+	"github.com/gorilla/mux"
+)
+
+type ReportHandler struct {
+	repo       *repository.ReportRepository
+	monthlyFee float64
+}
+
+// NewReportHandler creates a report handler. A zero monthlyFee makes reports
+// derive the fee from the period payments in the requested range.
+func NewReportHandler(repo *repository.ReportRepository, monthlyFee float64) *ReportHandler {
+	return &ReportHandler{repo: repo, monthlyFee: monthlyFee}
+}
+
+// financeParams are the query parameters shared by all finance reports
+type financeParams struct {
+	from, to   time.Time
+	monthlyFee float64
+	groupBy    string
+}
+
+// parseFinanceParams reads ?from=&to= (YYYY-MM-DD, default the last 12 months),
+// ?monthly_fee= and ?group_by=weight_category|skill_level
+func (h *ReportHandler) parseFinanceParams(r *http.Request) (*financeParams, error) {
+	q := r.URL.Query()
+	now := time.Now()
+	p := &financeParams{
+		from:    time.Date(now.Year(), now.Month()-11, 1, 0, 0, 0, 0, time.UTC),
+		to:      time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC),
+		groupBy: q.Get("group_by"),
+	}
+
+	var err error
+	if v := q.Get("from"); v != "" {
+		if p.from, err = time.Parse("2006-01-02", v); err != nil {
+			return nil, fmt.Errorf("invalid from date (YYYY-MM-DD)")
+		}
+	}
+	if v := q.Get("to"); v != "" {
+		if p.to, err = time.Parse("2006-01-02", v); err != nil {
+			return nil, fmt.Errorf("invalid to date (YYYY-MM-DD)")
+		}
+	}
+	if p.to.Before(p.from) {
+		return nil, fmt.Errorf("from must be before to")
+	}
+
+	if p.groupBy == "" {
+		p.groupBy = "weight_category"
+	}
+	if p.groupBy != "weight_category" && p.groupBy != "skill_level" {
+		return nil, fmt.Errorf("group_by must be weight_category or skill_level")
+	}
+
+	p.monthlyFee = h.monthlyFee
+	if v := q.Get("monthly_fee"); v != "" {
+		if p.monthlyFee, err = strconv.ParseFloat(v, 64); err != nil || p.monthlyFee < 0 {
+			return nil, fmt.Errorf("invalid monthly_fee")
+		}
+	}
+	if p.monthlyFee == 0 {
+		if p.monthlyFee, err = h.repo.AverageMonthlyFee(p.from, p.to); err != nil {
+			return nil, err
+		}
+	}
+
+	return p, nil
+}
+
+// GetFinance returns every finance report for the range as JSON
+func (h *ReportHandler) GetFinance(w http.ResponseWriter, r *http.Request) {
+	params, err := h.parseFinanceParams(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report := &models.FinanceReport{
+		From:       params.from.Format("2006-01-02"),
+		To:         params.to.Format("2006-01-02"),
+		MonthlyFee: params.monthlyFee,
+	}
+	if report.Monthly, err = h.repo.RevenueByMonth(params.from, params.to); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if report.Seasonal, err = h.repo.RevenueBySeason(params.from, params.to); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if report.Collection, err = h.repo.CollectionByMonth(params.from, params.to, params.monthlyFee); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if report.Aging, err = h.repo.ArrearsAging(params.to, params.monthlyFee); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if report.Breakdown, err = h.repo.FinanceBreakdown(params.groupBy, params.from, params.to); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// GetFinanceReport returns a single finance report (monthly, seasonal,
+// collection, aging or breakdown) as JSON, or as CSV with ?format=csv
+func (h *ReportHandler) GetFinanceReport(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["report"]
+	params, err := h.parseFinanceParams(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var data interface{}
+	var table [][]string
+	switch name {
+	case "monthly", "seasonal":
+		var periods []models.RevenuePeriod
+		if name == "monthly" {
+			periods, err = h.repo.RevenueByMonth(params.from, params.to)
+		} else {
+			periods, err = h.repo.RevenueBySeason(params.from, params.to)
+		}
+		data = periods
+		table = [][]string{{"period", "payments", "collected", "refunded", "net"}}
+		for _, p := range periods {
+			table = append(table, []string{p.Period, strconv.Itoa(p.Payments), money(p.Collected), money(p.Refunded), money(p.Net)})
+		}
+	case "collection":
+		var periods []models.CollectionPeriod
+		periods, err = h.repo.CollectionByMonth(params.from, params.to, params.monthlyFee)
+		data = periods
+		table = [][]string{{"period", "active_athletes", "expected", "collected", "collection_rate"}}
+		for _, p := range periods {
+			table = append(table, []string{
+				p.Period, strconv.Itoa(p.ActiveAthletes), money(p.Expected), money(p.Collected),
+				strconv.FormatFloat(p.CollectionRate, 'f', 3, 64),
+			})
+		}
+	case "aging":
+		var buckets []models.ArrearsBucket
+		buckets, err = h.repo.ArrearsAging(params.to, params.monthlyFee)
+		data = buckets
+		table = agingTable(buckets)
+	case "breakdown":
+		var rows []models.FinanceBreakdownRow
+		rows, err = h.repo.FinanceBreakdown(params.groupBy, params.from, params.to)
+		data = rows
+		table = [][]string{{params.groupBy, "active_athletes", "collected", "refunded", "net", "unpaid_balance", "in_arrears"}}
+		for _, row := range rows {
+			table = append(table, []string{
+				row.Group, strconv.Itoa(row.ActiveAthletes), money(row.Collected), money(row.Refunded),
+				money(row.Net), money(row.UnpaidBalance), strconv.Itoa(row.InArrears),
+			})
+		}
+	default:
+		http.Error(w, "Unknown report", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if r.URL.Query().Get("format") == "csv" {
+		filename := fmt.Sprintf("finance-%s-%s-%s.csv", name, params.from.Format("20060102"), params.to.Format("20060102"))
+		writeCSV(w, filename, table)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(data)
+}
+
+// agingTable flattens the arrears buckets to one CSV row per athlete
+func agingTable(buckets []models.ArrearsBucket) [][]string {
+	table := [][]string{{"bucket", "athlete_id", "athlete_name", "last_covered_date", "days_overdue", "unpaid_balance", "outstanding"}}
+	for _, b := range buckets {
+		for _, d := range b.Details {
+			last := ""
+			if d.LastCoveredDate != nil {
+				last = *d.LastCoveredDate
+			}
+			table = append(table, []string{
+				b.Bucket, strconv.Itoa(d.AthleteID), d.AthleteName, last,
+				strconv.Itoa(d.DaysOverdue), money(d.UnpaidBalance), money(d.Outstanding),
+			})
+		}
+	}
+	return table
+}
+
+// writeCSV sends table as a CSV attachment; encoding/csv quotes the fields
+// holding commas, quotes or line breaks
+func writeCSV(w http.ResponseWriter, filename string, table [][]string) {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	cw := csv.NewWriter(w)
+	cw.WriteAll(table)
+}
+
+func money(v float64) string {
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"east-eagles/backend/internal/models"
+)
+
+func TestAgingCSVEscapesNames(t *testing.T) {
+	last := "2026-01-31"
+	buckets := []models.ArrearsBucket{
+		{Bucket: "31-60", Details: []models.ArrearsAthlete{
+			{AthleteID: 7, AthleteName: `Haddad, Karim`, LastCoveredDate: &last, DaysOverdue: 45, Outstanding: 30},
+		}},
+		{Bucket: "never_paid", Details: []models.ArrearsAthlete{
+			{AthleteID: 9, AthleteName: `Ben "Ali", Sami`, DaysOverdue: 12, UnpaidBalance: 12.5, Outstanding: 42.5},
+		}},
+	}
+
+	rec := httptest.NewRecorder()
+	writeCSV(rec, "finance-aging.csv", agingTable(buckets))
+
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/csv") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	body := rec.Body.String()
+	for _, want := range []string{
+		`31-60,7,"Haddad, Karim",2026-01-31,45,0.00,30.00`,
+		`never_paid,9,"Ben ""Ali"", Sami",,12,12.50,42.50`,
+	} {
+		if !strings.Contains(body, want+"\n") {
+			t.Errorf("missing row %s in\n%s", want, body)
+		}
+	}
+
+	records, err := csv.NewReader(strings.NewReader(body)).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("%d records, want a header and 2 rows", len(records))
+	}
+	for i, want := range []string{`Haddad, Karim`, `Ben "Ali", Sami`} {
+		if got := records[i+1][2]; got != want {
+			t.Errorf("row %d athlete_name = %q, want %q", i+1, got, want)
+		}
+	}
+}
@@ -1,12 +1,18 @@
 package handlers
 
 import (
+	"context"
+	"database/sql"
 	"encoding/json"
 	"net/http"
+	"sort"
 	"strconv"
+	"time"
 
+	"east-eagles/backend/internal/middleware"
 	"east-eagles/backend/internal/models"
 	"east-eagles/backend/internal/repository"
+	"east-eagles/backend/internal/services"
 
 	"log"
 
@@ -14,11 +20,141 @@ import (
 )
 
 type ScheduleHandler struct {
-	repo *repository.ScheduleRepository
+	repo      *repository.ScheduleRepository
+	venueRepo *repository.VenueRepository
+	generator *services.SessionGenerator
 }
 
-func NewScheduleHandler(repo *repository.ScheduleRepository) *ScheduleHandler {
-	return &ScheduleHandler{repo: repo}
+func NewScheduleHandler(repo *repository.ScheduleRepository, venueRepo *repository.VenueRepository, generator *services.SessionGenerator) *ScheduleHandler {
+	return &ScheduleHandler{repo: repo, venueRepo: venueRepo, generator: generator}
+}
+
+// checkBooking resolves the slot's venue and compares its occurrences over
+// the coming year with other schedules and sessions in the same venue or with
+// the same coach. existing is the schedule being updated, nil on create.
+func (h *ScheduleHandler) checkBooking(r *http.Request, req *models.CreateScheduleRequest, existing *models.TrainingSchedule) error {
+	venue, err := h.venueRepo.Resolve(req.VenueID, req.Location)
+	if err != nil {
+		return err
+	}
+	if venue != nil {
+		req.VenueID = &venue.ID
+		req.Location = venue.Name
+		if req.MaxParticipants != nil {
+			if err := checkCapacity(venue, *req.MaxParticipants); err != nil {
+				return err
+			}
+		}
+	}
+
+	candidate := &models.TrainingSchedule{
+		DayOfWeek:       req.DayOfWeek,
+		StartTime:       req.StartTime,
+		DurationMinutes: req.DurationMinutes,
+		Title:           req.Title,
+		VenueID:         req.VenueID,
+		CoachID:         req.CoachID,
+		RRule:           req.RRule,
+		ValidFrom:       req.ValidFrom,
+		ValidUntil:      req.ValidUntil,
+		ExDates:         req.ExDates,
+		CreatedAt:       time.Now(),
+	}
+	if existing != nil {
+		candidate.ID = existing.ID
+		candidate.CreatedAt = existing.CreatedAt
+	}
+
+	today := time.Now()
+	conflicts, err := h.repo.FindConflicts(candidate, today, today.AddDate(1, 0, 0))
+	if err != nil {
+		return err
+	}
+	return checkConflicts(r, conflicts, req.Override)
+}
+
+// normalizeSchedule validates the request and fills in the recurrence
+// defaults: without rrule the slot repeats weekly on day_of_week, and
+// day_of_week is derived from the rule's BYDAY otherwise
+func normalizeSchedule(req *models.CreateScheduleRequest) string {
+	weekday, hasDay := models.ParseWeekday(req.DayOfWeek)
+	if req.DayOfWeek != "" && !hasDay {
+		return "day_of_week must be a day name (Monday ... Sunday)"
+	}
+
+	var rule *models.RecurrenceRule
+	if req.RRule == "" {
+		if !hasDay {
+			return "day_of_week or rrule is required"
+		}
+		rule = models.WeeklyRule(weekday)
+	} else {
+		var err error
+		if rule, err = models.ParseRecurrenceRule(req.RRule); err != nil {
+			return err.Error()
+		}
+		if first, ok := rule.FirstWeekday(); ok {
+			weekday, hasDay = first, true
+		} else if !hasDay {
+			return "day_of_week is required when rrule has no BYDAY"
+		}
+	}
+	req.RRule = rule.String()
+	req.DayOfWeek = weekday.String()
+
+	if _, err := time.Parse("15:04", req.StartTime); err != nil {
+		if _, err := time.Parse("15:04:05", req.StartTime); err != nil {
+			return "start_time must be HH:MM"
+		}
+	}
+	if req.DurationMinutes <= 0 {
+		return "duration_minutes must be positive"
+	}
+
+	var from, until time.Time
+	var err error
+	if req.ValidFrom != nil && *req.ValidFrom != "" {
+		if from, err = time.Parse("2006-01-02", *req.ValidFrom); err != nil {
+			return "Invalid valid_from (YYYY-MM-DD)"
+		}
+	} else {
+		req.ValidFrom = nil
+	}
+	if req.ValidUntil != nil && *req.ValidUntil != "" {
+		if until, err = time.Parse("2006-01-02", *req.ValidUntil); err != nil {
+			return "Invalid valid_until (YYYY-MM-DD)"
+		}
+		if req.ValidFrom != nil && until.Before(from) {
+			return "valid_until must not be before valid_from"
+		}
+	} else {
+		req.ValidUntil = nil
+	}
+
+	exdates := []string{}
+	for _, d := range req.ExDates {
+		date, err := time.Parse("2006-01-02", d)
+		if err != nil {
+			return "Invalid exdate (YYYY-MM-DD): " + d
+		}
+		exdates = append(exdates, date.Format("2006-01-02"))
+	}
+	sort.Strings(exdates)
+	req.ExDates = exdates
+
+	return ""
+}
+
+// syncSessions regenerates the schedule's future sessions. A failure is only
+// logged: the schedule is saved and the periodic run will catch up.
+func (h *ScheduleHandler) syncSessions(id int) {
+	result, err := h.generator.Sync(context.Background(), id)
+	if err != nil {
+		log.Printf("❌ Session generation for schedule %d failed: %v", id, err)
+		return
+	}
+	log.Printf("📅 Schedule %d: %d sessions created, %d updated, %d removed",
+		id, result.Created, result.Updated, result.Removed)
 }
 
 // Create creates a new schedule slot
@@ -28,12 +164,24 @@ func (h *ScheduleHandler) Create(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	if msg := normalizeSchedule(&req); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+	if !allowOverride(w, r, req.Override) {
+		return
+	}
+	if err := h.checkBooking(r, &req, nil); err != nil {
+		writeBookingError(w, err)
+		return
+	}
 
 	schedule, err := h.repo.Create(&req)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	h.syncSessions(schedule.ID)
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
@@ -68,7 +216,7 @@ func (h *ScheduleHandler) Delete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.repo.Delete(id); err != nil {
+	if err := h.generator.Remove(r.Context(), id); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -91,13 +239,125 @@ func (h *ScheduleHandler) Update(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	if msg := normalizeSchedule(&req); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+	if !allowOverride(w, r, req.Override) {
+		return
+	}
+
+	existing, err := h.repo.GetByID(id)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Schedule not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := h.checkBooking(r, &req, existing); err != nil {
+		writeBookingError(w, err)
+		return
+	}
 
 	schedule, err := h.repo.Update(id, &req)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	// Future sessions follow the new slot; past ones and their attendance stay as they were
+	h.syncSessions(schedule.ID)
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(schedule)
 }
+
+// GenerateSessions materialises every schedule for the rolling horizon now
+func (h *ScheduleHandler) GenerateSessions(w http.ResponseWriter, r *http.Request) {
+	result, err := h.generator.SyncAll(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// GetClosures returns current and upcoming holidays and exception dates
+func (h *ScheduleHandler) GetClosures(w http.ResponseWriter, r *http.Request) {
+	now := models.WallClockNow()
+	closures, err := h.repo.GetClosures(time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(closures)
+}
+
+// CreateClosure adds a holiday (no schedule_id) or an exception for one
+// schedule, and removes the future sessions it covers
+func (h *ScheduleHandler) CreateClosure(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateClosureRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.EndDate == "" {
+		req.EndDate = req.StartDate
+	}
+	start, err := time.Parse("2006-01-02", req.StartDate)
+	if err != nil {
+		http.Error(w, "Invalid start_date (YYYY-MM-DD)", http.StatusBadRequest)
+		return
+	}
+	end, err := time.Parse("2006-01-02", req.EndDate)
+	if err != nil {
+		http.Error(w, "Invalid end_date (YYYY-MM-DD)", http.StatusBadRequest)
+		return
+	}
+	if end.Before(start) {
+		http.Error(w, "end_date must not be before start_date", http.StatusBadRequest)
+		return
+	}
+
+	createdBy, _ := r.Context().Value(middleware.UserIDKey).(int)
+	closure, err := h.repo.CreateClosure(&req, createdBy)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	h.syncAll()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(closure)
+}
+
+// DeleteClosure reopens the closed days; their sessions are generated again
+func (h *ScheduleHandler) DeleteClosure(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.repo.DeleteClosure(id); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	h.syncAll()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Closure deleted"})
+}
+
+func (h *ScheduleHandler) syncAll() {
+	if _, err := h.generator.SyncAll(context.Background()); err != nil {
+		log.Printf("❌ Session generation failed: %v", err)
+	}
+}
@@ -1,23 +1,61 @@
 package handlers
 
 import (
+	"database/sql"
 	"encoding/json"
+	"log"
 	"net/http"
 	"strconv"
+	"time"
 
 	"east-eagles/backend/internal/middleware"
 	"east-eagles/backend/internal/models"
 	"east-eagles/backend/internal/repository"
+	"east-eagles/backend/internal/services"
 
 	"github.com/gorilla/mux"
 )
 
 type TrainingHandler struct {
-	repo *repository.TrainingRepository
+	repo        *repository.TrainingRepository
+	venueRepo   *repository.VenueRepository
+	bookingRepo *repository.BookingRepository
+	notifier    *services.Notifier
 }
 
-func NewTrainingHandler(repo *repository.TrainingRepository) *TrainingHandler {
-	return &TrainingHandler{repo: repo}
+func NewTrainingHandler(repo *repository.TrainingRepository, venueRepo *repository.VenueRepository, bookingRepo *repository.BookingRepository, notifier *services.Notifier) *TrainingHandler {
+	return &TrainingHandler{repo: repo, venueRepo: venueRepo, bookingRepo: bookingRepo, notifier: notifier}
+}
+
+// checkBooking resolves the session's venue and rejects it when it is over
+// capacity or overlaps another session in the same venue or with the same
+// coach. excludeID is the session being updated, 0 on create.
+func (h *TrainingHandler) checkBooking(r *http.Request, req *models.CreateTrainingSessionRequest, coachID *int, excludeID int) error {
+	venue, err := h.venueRepo.Resolve(req.VenueID, req.Location)
+	if err != nil {
+		return err
+	}
+	if venue != nil {
+		req.VenueID = &venue.ID
+		req.Location = venue.Name
+		if err := checkCapacity(venue, req.MaxParticipants); err != nil {
+			return err
+		}
+	}
+
+	start, err := time.Parse("2006-01-02 15:04", req.SessionDate)
+	if err != nil {
+		return &repository.BookingValidationError{Message: "session_date must be YYYY-MM-DD HH:MM"}
+	}
+	if req.DurationMinutes <= 0 {
+		return &repository.BookingValidationError{Message: "duration_minutes must be positive"}
+	}
+
+	conflicts, err := h.repo.FindConflicts(start, req.DurationMinutes, req.VenueID, coachID, excludeID)
+	if err != nil {
+		return err
+	}
+	return checkConflicts(r, conflicts, req.Override)
 }
 
 // Create creates a new training session
@@ -33,6 +71,18 @@ func (h *TrainingHandler) Create(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
+	if !allowOverride(w, r, req.Override) {
+		return
+	}
+
+	coach := &coachID
+	if req.CoachID != nil {
+		coach = req.CoachID
+	}
+	if err := h.checkBooking(r, &req, coach, 0); err != nil {
+		writeBookingError(w, err)
+		return
+	}
 
 	session, err := h.repo.Create(&req, coachID)
 	if err != nil {
@@ -103,6 +153,27 @@ func (h *TrainingHandler) Update(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	if !allowOverride(w, r, req.Override) {
+		return
+	}
+
+	existing, err := h.repo.GetByID(id)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Session not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	coach := existing.CoachID
+	if req.CoachID != nil {
+		coach = req.CoachID
+	}
+	if err := h.checkBooking(r, &req, coach, id); err != nil {
+		writeBookingError(w, err)
+		return
+	}
 
 	session, err := h.repo.Update(id, &req)
 	if err != nil {
@@ -110,6 +181,13 @@ func (h *TrainingHandler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// A larger capacity frees places for the waitlist
+	if promoted, err := h.bookingRepo.PromoteWaitlist(id); err != nil {
+		log.Printf("❌ Waitlist promotion for session %d failed: %v", id, err)
+	} else if len(promoted) > 0 {
+		log.Printf("🎟️ Session %d: athletes %v promoted from the waitlist", id, promoted)
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(session)
 }
@@ -123,10 +201,23 @@ func (h *TrainingHandler) Delete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Athletes booked on an upcoming session are told it is cancelled
+	session, _ := h.repo.GetByID(id)
+	var bookings []*models.SessionBooking
+	if session != nil && session.SessionDate.After(models.WallClockNow()) {
+		bookings, _ = h.bookingRepo.GetBySession(id)
+	}
+
 	if err := h.repo.Delete(id); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	for _, b := range bookings {
+		notifyAthlete(h.notifier, b.AthleteID, "session_cancelled", map[string]string{
+			"session": session.Title,
+			"date":    session.SessionDate.Format("02/01/2006 15:04"),
+		})
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]string{"message": "Session supprimée"})
@@ -163,8 +254,8 @@ func (h *TrainingHandler) MarkAttendance(w http.ResponseWriter, r *http.Request)
 	json.NewEncoder(w).Encode(map[string]string{"message": "Présence marquée"})
 }
 
-// GetAttendance returns attendance for a session
-func (h *TrainingHandler) GetAttendance(w http.ResponseWriter, r *http.Request) {
+// MarkAttendanceBatch marks a whole roster in one request and one transaction
+func (h *TrainingHandler) MarkAttendanceBatch(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	sessionID, err := strconv.Atoi(vars["id"])
 	if err != nil {
@@ -172,30 +263,57 @@ func (h *TrainingHandler) GetAttendance(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	attendance, err := h.repo.GetAttendance(sessionID)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	var req models.BatchAttendanceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(req.Records) == 0 {
+		http.Error(w, "records is required", http.StatusBadRequest)
+		return
+	}
+	seen := map[int]bool{}
+	for _, rec := range req.Records {
+		if rec.AthleteID <= 0 || seen[rec.AthleteID] {
+			http.Error(w, "Each record needs a distinct athlete_id", http.StatusBadRequest)
+			return
+		}
+		seen[rec.AthleteID] = true
+	}
+
+	markedBy, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.repo.MarkAttendanceBatch(sessionID, req.Records, markedBy); err == sql.ErrNoRows {
+		http.Error(w, "Session not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		writeBookingError(w, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(attendance)
+	json.NewEncoder(w).Encode(map[string]interface{}{"message": "Présences enregistrées", "count": len(req.Records)})
 }
 
-// GetHistory returns attendance history for the authenticated athlete
-func (h *TrainingHandler) GetHistory(w http.ResponseWriter, r *http.Request) {
-	athleteID, ok := r.Context().Value(middleware.UserIDKey).(int)
-	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+// GetAttendance returns attendance for a session
+func (h *TrainingHandler) GetAttendance(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	sessionID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
 		return
 	}
 
-	history, err := h.repo.GetAttendanceByAthlete(athleteID)
+	attendance, err := h.repo.GetAttendance(sessionID)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(history)
+	json.NewEncoder(w).Encode(attendance)
 }
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"east-eagles/backend/internal/models"
+	"east-eagles/backend/internal/repository"
+
+	"github.com/gorilla/mux"
+)
+
+type UserHandler struct {
+	repo        *repository.UserRepository
+	athleteRepo *repository.AthleteRepository
+}
+
+func NewUserHandler(repo *repository.UserRepository, athleteRepo *repository.AthleteRepository) *UserHandler {
+	return &UserHandler{repo: repo, athleteRepo: athleteRepo}
+}
+
+// GetUnlinked lists athlete accounts the email backfill could not link
+func (h *UserHandler) GetUnlinked(w http.ResponseWriter, r *http.Request) {
+	users, err := h.repo.GetUnlinked()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(users)
+}
+
+// LinkAthlete links an account to an athlete profile. The user's next
+// requests see the new link: the auth middleware reads it on every request
+// instead of trusting the athlete_id of an existing token.
+func (h *UserHandler) LinkAthlete(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.LinkAthleteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if _, err := h.athleteRepo.GetByID(req.AthleteID); err == sql.ErrNoRows {
+		http.Error(w, "Athlete not found", http.StatusBadRequest)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	switch err := h.repo.SetAthleteID(id, req.AthleteID); err {
+	case nil:
+	case sql.ErrNoRows:
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	case repository.ErrAthleteAlreadyLinked:
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Compte lié à l'athlète"})
+}
@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"east-eagles/backend/internal/middleware"
+	"east-eagles/backend/internal/models"
+	"east-eagles/backend/internal/repository"
+
+	"github.com/gorilla/mux"
+)
+
+type VenueHandler struct {
+	repo *repository.VenueRepository
+}
+
+func NewVenueHandler(repo *repository.VenueRepository) *VenueHandler {
+	return &VenueHandler{repo: repo}
+}
+
+// writeBookingError maps validation errors to 400 and venue/coach overlaps to
+// 409 with the conflicting sessions
+func writeBookingError(w http.ResponseWriter, err error) {
+	var conflict *repository.BookingConflictError
+	var invalid *repository.BookingValidationError
+	switch {
+	case errors.As(err, &invalid):
+		http.Error(w, invalid.Message, http.StatusBadRequest)
+	case errors.As(err, &conflict):
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message":   "Conflit de salle ou d'entraîneur (un admin peut forcer avec override)",
+			"conflicts": conflict.Conflicts,
+		})
+	case err == sql.ErrNoRows:
+		http.Error(w, "Not found", http.StatusNotFound)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// checkConflicts turns conflicts into a BookingConflictError unless an admin
+// asked to override them
+func checkConflicts(r *http.Request, conflicts []models.BookingConflict, override bool) error {
+	if len(conflicts) == 0 {
+		return nil
+	}
+	if override {
+		userID, _ := r.Context().Value(middleware.UserIDKey).(int)
+		log.Printf("⚠️ User %d overrode %d booking conflict(s) on %s %s", userID, len(conflicts), r.Method, r.URL.Path)
+		return nil
+	}
+	return &repository.BookingConflictError{Conflicts: conflicts}
+}
+
+// allowOverride rejects the override flag for non-admins
+func allowOverride(w http.ResponseWriter, r *http.Request, override bool) bool {
+	role, _ := r.Context().Value(middleware.UserRoleKey).(models.UserRole)
+	if override && role != models.RoleAdmin {
+		http.Error(w, "Only admins can override booking conflicts", http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+// checkCapacity rejects more participants than the venue holds
+func checkCapacity(venue *models.Venue, maxParticipants int) error {
+	if venue.Capacity != nil && maxParticipants > *venue.Capacity {
+		return &repository.BookingValidationError{Message: "max_participants exceeds the capacity of " + venue.Name}
+	}
+	return nil
+}
+
+// GetAll returns venues (?all=true includes inactive ones)
+func (h *VenueHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	venues, err := h.repo.GetAll(r.URL.Query().Get("all") == "true")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(venues)
+}
+
+func validateVenue(req *models.CreateVenueRequest) string {
+	if strings.TrimSpace(req.Name) == "" {
+		return "name is required"
+	}
+	if req.Capacity != nil && *req.Capacity <= 0 {
+		return "capacity must be positive"
+	}
+	if (req.Latitude == nil) != (req.Longitude == nil) {
+		return "latitude and longitude go together"
+	}
+	if req.Latitude != nil && (*req.Latitude < -90 || *req.Latitude > 90 || *req.Longitude < -180 || *req.Longitude > 180) {
+		return "Invalid coordinates"
+	}
+	if req.Radius != nil && *req.Radius <= 0 {
+		return "checkin_radius_m must be positive"
+	}
+	return ""
+}
+
+// Create adds a venue
+func (h *VenueHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateVenueRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if msg := validateVenue(&req); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	venue, err := h.repo.Create(&req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(venue)
+}
+
+// Update changes a venue
+func (h *VenueHandler) Update(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.CreateVenueRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if msg := validateVenue(&req); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	venue, err := h.repo.Update(id, &req)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Venue not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(venue)
+}
+
+// Delete deactivates a venue; past sessions keep referencing it
+func (h *VenueHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.repo.Deactivate(id); err == sql.ErrNoRows {
+		http.Error(w, "Venue not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Venue deactivated"})
+}
@@ -0,0 +1,272 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"east-eagles/backend/internal/middleware"
+	"east-eagles/backend/internal/models"
+	"east-eagles/backend/internal/repository"
+	"east-eagles/backend/internal/services"
+
+	"github.com/gorilla/mux"
+)
+
+// DefaultDriftDays is how far ahead weight alerts look when no ?days= is given
+const DefaultDriftDays = 30
+
+type WeighInHandler struct {
+	repo        *repository.WeighInRepository
+	athleteRepo *repository.AthleteRepository
+}
+
+func NewWeighInHandler(repo *repository.WeighInRepository, athleteRepo *repository.AthleteRepository) *WeighInHandler {
+	return &WeighInHandler{repo: repo, athleteRepo: athleteRepo}
+}
+
+// GetByAthlete lists an athlete's weigh-ins
+func (h *WeighInHandler) GetByAthlete(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	athleteID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "ID invalide", http.StatusBadRequest)
+		return
+	}
+
+	weighIns, err := h.repo.GetByAthlete(athleteID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(weighIns)
+}
+
+// GetMine lists the authenticated athlete's weigh-ins
+func (h *WeighInHandler) GetMine(w http.ResponseWriter, r *http.Request) {
+	athleteID, ok := currentAthleteID(r)
+	if !ok {
+		http.Error(w, "Athlete profile not found", http.StatusNotFound)
+		return
+	}
+
+	weighIns, err := h.repo.GetByAthlete(athleteID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(weighIns)
+}
+
+// Create records a weigh-in. The weight category is derived from the class
+// table for the athlete's gender and age on the day.
+func (h *WeighInHandler) Create(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	athleteID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "ID invalide", http.StatusBadRequest)
+		return
+	}
+
+	var req models.CreateWeighInRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Données invalides", http.StatusBadRequest)
+		return
+	}
+	if req.WeightKG <= 0 || req.WeightKG >= 1000 {
+		http.Error(w, "weight_kg must be between 0 and 1000", http.StatusBadRequest)
+		return
+	}
+	weighedAt := models.WallClockNow().Truncate(time.Minute)
+	if req.WeighedAt != "" {
+		if weighedAt, err = time.Parse("2006-01-02 15:04", req.WeighedAt); err != nil {
+			http.Error(w, "weighed_at must be YYYY-MM-DD HH:MM", http.StatusBadRequest)
+			return
+		}
+	}
+
+	athlete, err := h.athleteRepo.GetByID(athleteID)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Athlète non trouvé", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	table, err := h.repo.GetTable()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	coachID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	weighIn := &models.WeighIn{
+		AthleteID:      athleteID,
+		WeightKG:       req.WeightKG,
+		WeighedAt:      weighedAt,
+		Official:       req.Official == nil || *req.Official,
+		EventID:        req.EventID,
+		WeightCategory: services.WeightClassFor(table, athlete.Gender, athlete.DateOfBirth, req.WeightKG, weighedAt),
+		RecordedBy:     &coachID,
+		Notes:          req.Notes,
+	}
+	saved, err := h.repo.Create(weighIn)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(saved)
+}
+
+func (h *WeighInHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "ID invalide", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.repo.Delete(id); err == sql.ErrNoRows {
+		http.Error(w, "Pesée non trouvée", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Pesée supprimée"})
+}
+
+// GetClasses returns the weight class configuration
+func (h *WeighInHandler) GetClasses(w http.ResponseWriter, r *http.Request) {
+	table, err := h.repo.GetTable()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(table)
+}
+
+// validateWeightClasses checks a whole weight class configuration
+func validateWeightClasses(table *models.WeightClassTable) error {
+	groups := map[string]bool{}
+	for _, g := range table.AgeGroups {
+		if g.Name == "" {
+			return fmt.Errorf("age group name is required")
+		}
+		if groups[g.Name] {
+			return fmt.Errorf("age group %s is listed twice", g.Name)
+		}
+		if g.MinAge < 0 || (g.MaxAge != nil && *g.MaxAge < g.MinAge) {
+			return fmt.Errorf("age group %s has an invalid age range", g.Name)
+		}
+		groups[g.Name] = true
+	}
+
+	classes := map[string]bool{}
+	for _, c := range table.Classes {
+		if c.Gender != "male" && c.Gender != "female" {
+			return fmt.Errorf("class gender must be male or female")
+		}
+		if !groups[c.AgeGroup] {
+			return fmt.Errorf("class %s uses unknown age group %q", c.Name, c.AgeGroup)
+		}
+		if c.Name == "" {
+			return fmt.Errorf("class name is required")
+		}
+		if c.MaxKG != nil && *c.MaxKG <= 0 {
+			return fmt.Errorf("class %s must have a positive max_kg", c.Name)
+		}
+		key := c.Gender + "/" + c.AgeGroup + "/" + c.Name
+		if classes[key] {
+			return fmt.Errorf("class %s is listed twice for %s %s", c.Name, c.Gender, c.AgeGroup)
+		}
+		classes[key] = true
+	}
+	return nil
+}
+
+// ReplaceClasses replaces the weight class configuration. It applies to
+// weigh-ins recorded from now on.
+func (h *WeighInHandler) ReplaceClasses(w http.ResponseWriter, r *http.Request) {
+	var table models.WeightClassTable
+	if err := json.NewDecoder(r.Body).Decode(&table); err != nil {
+		http.Error(w, "Données invalides", http.StatusBadRequest)
+		return
+	}
+	if err := validateWeightClasses(&table); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.repo.ReplaceTable(&table); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	saved, err := h.repo.GetTable()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(saved)
+}
+
+// GetDrifts lists athletes registered in events within the next ?days= days
+// (30 by default) whose latest official weigh-in puts them in another class
+// than the one they are registered in
+func (h *WeighInHandler) GetDrifts(w http.ResponseWriter, r *http.Request) {
+	days := DefaultDriftDays
+	if v := r.URL.Query().Get("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			http.Error(w, "days must be a positive number", http.StatusBadRequest)
+			return
+		}
+		days = n
+	}
+
+	table, err := h.repo.GetTable()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	candidates, err := h.repo.GetDriftCandidates(days)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	drifts := []*models.WeightDrift{}
+	for _, d := range candidates {
+		// Age groups are those of the event day
+		d.CurrentCategory = services.WeightClassFor(table, d.Gender, d.DateOfBirth, d.WeightKG, d.EventDate)
+		if d.CurrentCategory != "" && d.CurrentCategory != d.RegisteredCategory {
+			drifts = append(drifts, d)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(drifts)
+}
@@ -2,9 +2,12 @@ package middleware
 
 import (
 	"context"
+	"database/sql"
 	"net/http"
+	"strconv"
 	"strings"
 
+	"east-eagles/backend/internal/models"
 	"east-eagles/backend/internal/services"
 )
 
@@ -14,8 +17,13 @@ const (
 	UserIDKey    contextKey = "userID"
 	UserRoleKey  contextKey = "userRole"
 	UserEmailKey contextKey = "userEmail"
+	AthleteIDKey contextKey = "athleteID" // Linked athlete profile (a guardian's selected child), absent for accounts without one
 )
 
+// WardHeader selects which child a guardian is acting for; downloads may use
+// the ward query parameter instead
+const WardHeader = "X-Athlete-ID"
+
 func AuthMiddleware(authService *services.AuthService) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -48,7 +56,65 @@ func AuthMiddleware(authService *services.AuthService) func(http.Handler) http.H
 			ctx = context.WithValue(ctx, UserRoleKey, claims.Role)
 			ctx = context.WithValue(ctx, UserEmailKey, claims.Email)
 
+			// The link is read from the database rather than trusted from the
+			// token, so relinking or unlinking an account applies to tokens
+			// already issued
+			athleteID := 0
+			if claims.AthleteID != 0 || claims.Role == models.RoleAthlete {
+				athleteID, err = authService.AthleteIDForUser(claims.UserID)
+				if err == sql.ErrNoRows {
+					http.Error(w, "Invalid or expired token", http.StatusUnauthorized)
+					return
+				}
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+					return
+				}
+			}
+			if claims.Role == models.RoleGuardian {
+				var ok bool
+				if athleteID, ok = selectedWard(r, authService, claims.UserID); !ok {
+					http.Error(w, "Athlete not managed by this account", http.StatusForbidden)
+					return
+				}
+			}
+			if athleteID != 0 {
+				ctx = context.WithValue(ctx, AthleteIDKey, athleteID)
+			}
+
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
+
+// selectedWard returns the child a guardian acts for: the one named by the
+// X-Athlete-ID header, or their only child. It is 0 when a guardian with
+// several children selected none, and not ok when the child is not theirs.
+func selectedWard(r *http.Request, authService *services.AuthService, userID int) (int, bool) {
+	wards, err := authService.WardIDs(userID)
+	if err != nil {
+		return 0, false
+	}
+
+	selected := r.Header.Get(WardHeader)
+	if selected == "" {
+		selected = r.URL.Query().Get("ward")
+	}
+	if selected == "" {
+		if len(wards) == 1 {
+			return wards[0], true
+		}
+		return 0, true
+	}
+
+	id, err := strconv.Atoi(selected)
+	if err != nil {
+		return 0, false
+	}
+	for _, ward := range wards {
+		if ward == id {
+			return id, true
+		}
+	}
+	return 0, false
+}
@@ -2,17 +2,69 @@ package models
 
 import "time"
 
+// Announcement statuses, derived from the publish and expiry times
+const (
+	AnnouncementScheduled = "scheduled"
+	AnnouncementPublished = "published"
+	AnnouncementExpired   = "expired"
+)
+
+// Announcement is a club message. Empty target lists do not restrict the
+// audience; an athlete list, when given, replaces the skill level and weight
+// category filters. Guardians receive what targets their wards.
 type Announcement struct {
-	ID            int       `json:"id"`
-	Title         string    `json:"title"`
-	Content       string    `json:"content"`
-	PublishedDate time.Time `json:"published_date"`
-	IsPinned      bool      `json:"is_pinned"`
-	CreatedAt     time.Time `json:"created_at"`
+	ID                     int                      `json:"id"`
+	Title                  string                   `json:"title"`
+	Content                string                   `json:"content"`
+	PublishedDate          time.Time                `json:"published_date"` // Visible from then on
+	ExpiresAt              *time.Time               `json:"expires_at"`
+	IsPinned               bool                     `json:"is_pinned"`
+	Status                 string                   `json:"status"` // 'scheduled', 'published', 'expired'
+	TargetRoles            []string                 `json:"target_roles"`
+	TargetSkillLevels      []string                 `json:"target_skill_levels"`
+	TargetWeightCategories []string                 `json:"target_weight_categories"`
+	TargetAthleteIDs       []int                    `json:"target_athlete_ids"`
+	CreatedBy              *int                     `json:"created_by"`
+	CreatedAt              time.Time                `json:"created_at"`
+	UpdatedAt              time.Time                `json:"updated_at"`
+	Attachments            []AnnouncementAttachment `json:"attachments"`
+
+	IsRead         bool `json:"is_read"`                   // For the current user
+	RecipientCount *int `json:"recipient_count,omitempty"` // Staff views only
+	ReadCount      *int `json:"read_count,omitempty"`
 }
 
 type CreateAnnouncementRequest struct {
-	Title    string `json:"title"`
-	Content  string `json:"content"`
-	IsPinned bool   `json:"is_pinned"`
-}
\ No newline at end of file
+	Title                  string   `json:"title"`
+	Content                string   `json:"content"`
+	IsPinned               bool     `json:"is_pinned"`
+	PublishedDate          string   `json:"published_date"` // Format: YYYY-MM-DD HH:MM, now when empty
+	ExpiresAt              string   `json:"expires_at"`     // Format: YYYY-MM-DD HH:MM, never when empty
+	TargetRoles            []string `json:"target_roles"`
+	TargetSkillLevels      []string `json:"target_skill_levels"`
+	TargetWeightCategories []string `json:"target_weight_categories"`
+	TargetAthleteIDs       []int    `json:"target_athlete_ids"`
+}
+
+// AnnouncementAttachment is a file attached to an announcement
+type AnnouncementAttachment struct {
+	ID             int       `json:"id"`
+	AnnouncementID int       `json:"announcement_id"`
+	FileName       string    `json:"file_name"`
+	FileURL        string    `json:"file_url"`
+	MimeType       string    `json:"mime_type"`
+	FileSizeBytes  int64     `json:"file_size_bytes"`
+	UploadedBy     *int      `json:"uploaded_by"`
+	UploadedAt     time.Time `json:"uploaded_at"`
+}
+
+// AnnouncementReader is a user in an announcement's audience; ReadAt is nil
+// until they read it
+type AnnouncementReader struct {
+	UserID    int        `json:"user_id"`
+	Email     string     `json:"email"`
+	FirstName string     `json:"first_name"`
+	LastName  string     `json:"last_name"`
+	Role      UserRole   `json:"role"`
+	ReadAt    *time.Time `json:"read_at"`
+}
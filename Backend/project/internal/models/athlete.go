@@ -46,8 +46,9 @@ type Athlete struct {
 	BloodType         string `json:"blood_type"`
 
 	// Payment Status (computed from payments table)
-	PaymentEndDate *string `json:"payment_end_date,omitempty"`
-	PaymentValid   *bool   `json:"payment_valid,omitempty"`
+	PaymentEndDate *string  `json:"payment_end_date,omitempty"`
+	PaymentValid   *bool    `json:"payment_valid,omitempty"`
+	PaymentBalance *float64 `json:"payment_balance,omitempty"` // Amount still owed on active periods
 }
 
 // CreateAthleteRequest for registration
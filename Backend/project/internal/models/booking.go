@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// Booking statuses
+const (
+	BookingBooked     = "booked"
+	BookingWaitlisted = "waitlisted"
+	BookingCancelled  = "cancelled"
+)
+
+// SessionBooking is an athlete's place (or waitlist entry) in a training session
+type SessionBooking struct {
+	ID                int        `json:"id"`
+	TrainingSessionID int        `json:"training_session_id"`
+	AthleteID         int        `json:"athlete_id"`
+	Status            string     `json:"status"`                      // 'booked', 'waitlisted', 'cancelled'
+	WaitlistPosition  int        `json:"waitlist_position,omitempty"` // 1 = next to be promoted
+	BookedAt          time.Time  `json:"booked_at"`
+	PromotedAt        *time.Time `json:"promoted_at"`
+	CancelledAt       *time.Time `json:"cancelled_at"`
+
+	// Joined for lists
+	AthleteName  string     `json:"athlete_name,omitempty"`
+	SessionTitle string     `json:"session_title,omitempty"`
+	SessionDate  *time.Time `json:"session_date,omitempty"`
+	Attended     *bool      `json:"attended,omitempty"` // Set once the coach has marked attendance
+}
+
+// BookableSession is an upcoming session as seen by an athlete
+type BookableSession struct {
+	TrainingSession
+	Capacity         *int   `json:"capacity"` // Lower of max_participants and the venue capacity, nil = unlimited
+	BookedCount      int    `json:"booked_count"`
+	WaitlistCount    int    `json:"waitlist_count"`
+	MyStatus         string `json:"my_status"` // '' when not booked
+	WaitlistPosition int    `json:"waitlist_position,omitempty"`
+	BookingOpen      bool   `json:"booking_open"` // False once the booking cut-off has passed
+}
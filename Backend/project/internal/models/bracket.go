@@ -0,0 +1,93 @@
+package models
+
+import "time"
+
+// Bracket formats
+const (
+	FormatSingleElimination = "single_elimination"
+	FormatRoundRobin        = "round_robin"
+)
+
+// Bracket statuses
+const (
+	BracketPending   = "pending"
+	BracketCompleted = "completed"
+)
+
+// Bout statuses
+const (
+	BoutPending   = "pending"
+	BoutCompleted = "completed"
+	BoutBye       = "bye" // Only one athlete, who advances without fighting
+)
+
+// Bracket is the draw of one weight category and gender of an event
+type Bracket struct {
+	ID             int            `json:"id"`
+	EventID        int            `json:"event_id"`
+	WeightCategory string         `json:"weight_category"`
+	Gender         string         `json:"gender"`
+	Format         string         `json:"format"` // 'single_elimination', 'round_robin'
+	Status         string         `json:"status"` // 'pending', 'completed'
+	Entries        []BracketEntry `json:"entries"`
+	Bouts          []Bout         `json:"bouts"`
+	CreatedAt      time.Time      `json:"created_at"`
+}
+
+// BracketEntry is an athlete in a bracket
+type BracketEntry struct {
+	AthleteID   int    `json:"athlete_id"`
+	AthleteName string `json:"athlete_name,omitempty"`
+	Seed        int    `json:"seed"`
+	Placing     *int   `json:"placing"`
+}
+
+// Bout is one fight of a bracket
+type Bout struct {
+	ID              int        `json:"id"`
+	BracketID       int        `json:"bracket_id"`
+	Round           int        `json:"round"`
+	Position        int        `json:"position"`
+	RedAthleteID    *int       `json:"red_athlete_id"`
+	BlueAthleteID   *int       `json:"blue_athlete_id"`
+	RedName         string     `json:"red_name,omitempty"`
+	BlueName        string     `json:"blue_name,omitempty"`
+	Status          string     `json:"status"` // 'pending', 'completed', 'bye'
+	WinnerAthleteID *int       `json:"winner_athlete_id"`
+	Method          *string    `json:"method"` // 'points', 'ko', 'tko', 'disqualification', 'walkover', 'draw'
+	RedRoundsWon    int        `json:"red_rounds_won"`
+	BlueRoundsWon   int        `json:"blue_rounds_won"`
+	RedPoints       int        `json:"red_points"`
+	BluePoints      int        `json:"blue_points"`
+	NextBoutID      *int       `json:"next_bout_id"`
+	NextSlot        *string    `json:"next_slot"` // 'red', 'blue'
+	CompletedAt     *time.Time `json:"completed_at"`
+
+	NextIndex int `json:"-"` // While drawing: index of the next bout in the draw, -1 for none
+}
+
+// GenerateBracketsRequest draws an event's brackets. Seeds lists athlete ids
+// from first seed down; the other athletes are drawn at random. Format is
+// chosen from the pool size when empty.
+type GenerateBracketsRequest struct {
+	Format string `json:"format"`
+	Seeds  []int  `json:"seeds"`
+}
+
+// BoutResultRequest records the outcome of a bout. A draw, round-robin only,
+// has no winner.
+type BoutResultRequest struct {
+	WinnerAthleteID int    `json:"winner_athlete_id"`
+	Method          string `json:"method"`
+	RedRoundsWon    int    `json:"red_rounds_won"`
+	BlueRoundsWon   int    `json:"blue_rounds_won"`
+	RedPoints       int    `json:"red_points"`
+	BluePoints      int    `json:"blue_points"`
+}
+
+// DrawPool is the registered athletes of one weight category and gender
+type DrawPool struct {
+	WeightCategory string
+	Gender         string
+	AthleteIDs     []int
+}
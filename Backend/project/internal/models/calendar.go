@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// CalendarToken is a user's secret feed token and the subscription URLs built from it
+type CalendarToken struct {
+	Token       string    `json:"token"`
+	ClubURL     string    `json:"club_url"`
+	PersonalURL string    `json:"personal_url"`
+	CreatedAt   time.Time `json:"created_at"`
+}
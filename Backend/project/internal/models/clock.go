@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// clubLocation is the zone of the wall-clock times stored without a time
+// zone (session_date, event dates, announcement schedules...)
+var clubLocation = time.Local
+
+// SetClubLocation sets the club timezone; called once at startup from CLUB_TIMEZONE
+func SetClubLocation(loc *time.Location) {
+	clubLocation = loc
+}
+
+// WallClock returns t as a club wall-clock time: the local date and time in
+// the club timezone, labelled UTC like the values read from the database
+func WallClock(t time.Time) time.Time {
+	t = t.In(clubLocation)
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.UTC)
+}
+
+// WallClockNow returns the current club wall-clock time
+func WallClockNow() time.Time {
+	return WallClock(time.Now())
+}
@@ -2,26 +2,83 @@ package models
 
 import "time"
 
+// Event statuses
+const (
+	EventOpen      = "open"
+	EventClosed    = "closed"
+	EventCancelled = "cancelled"
+)
+
+// Event registration statuses
+const (
+	RegistrationRegistered = "registered"
+	RegistrationWithdrawn  = "withdrawn"
+)
+
+// Event is a club event, usually a Sanda competition athletes register for
 type Event struct {
-	ID              int       `json:"id"`
-	Title           string    `json:"title"`
-	Description     string    `json:"description"`
-	Date            time.Time `json:"date"`
-	Location        string    `json:"location"`
-	ImageURL        *string   `json:"image_url"`
-	MaxParticipants int       `json:"max_participants"`
-	CreatedAt       time.Time `json:"created_at"`
+	ID                   int        `json:"id"`
+	Title                string     `json:"title"`
+	Description          string     `json:"description"`
+	EventType            string     `json:"event_type"` // 'competition', 'seminar', 'other'
+	Status               string     `json:"status"`     // 'open', 'closed', 'cancelled'
+	Date                 time.Time  `json:"date"`
+	Location             string     `json:"location"`
+	ImageURL             *string    `json:"image_url"`
+	MaxParticipants      int        `json:"max_participants"`      // 0 = unlimited
+	RegistrationDeadline *time.Time `json:"registration_deadline"` // nil = until the event starts
+	WeightCategories     []string   `json:"weight_categories"`     // Eligible categories, empty = all
+	BeltLevels           []string   `json:"belt_levels"`           // Eligible belts, empty = all
+	RequiredDocuments    []string   `json:"required_documents"`    // document_type values needing an approved, unexpired document
+	RegisteredCount      int        `json:"registered_count"`
+	RegistrationOpen     bool       `json:"registration_open"`
+	CreatedBy            *int       `json:"created_by"`
+	CreatedAt            time.Time  `json:"created_at"`
 }
 
 type CreateEventRequest struct {
-	Title           string  `json:"title"`
-	Description     string  `json:"description"`
-	Date            string  `json:"date"`
-	Location        string  `json:"location"`
-	ImageURL        *string `json:"image_url"`
-	MaxParticipants int     `json:"max_participants"`
+	Title                string   `json:"title"`
+	Description          string   `json:"description"`
+	EventType            string   `json:"event_type"`
+	Status               string   `json:"status"`
+	Date                 string   `json:"date"` // Format: YYYY-MM-DD HH:MM
+	Location             string   `json:"location"`
+	ImageURL             *string  `json:"image_url"`
+	MaxParticipants      int      `json:"max_participants"`
+	RegistrationDeadline *string  `json:"registration_deadline"` // Format: YYYY-MM-DD HH:MM
+	WeightCategories     []string `json:"weight_categories"`
+	BeltLevels           []string `json:"belt_levels"`
+	RequiredDocuments    []string `json:"required_documents"`
 }
 
+// RegisterEventRequest enters an athlete in an event. Coaches nominate an
+// athlete by id; athletes register themselves. WeightCategory defaults to
+// the athlete's own category.
 type RegisterEventRequest struct {
-	MemberID int `json:"member_id"`
+	AthleteID      int    `json:"athlete_id"`
+	WeightCategory string `json:"weight_category"`
+}
+
+// EventRegistration is an athlete's entry in an event
+type EventRegistration struct {
+	ID             int        `json:"id"`
+	EventID        int        `json:"event_id"`
+	AthleteID      int        `json:"athlete_id"`
+	AthleteName    string     `json:"athlete_name,omitempty"`
+	EventTitle     string     `json:"event_title,omitempty"`
+	WeightCategory string     `json:"weight_category"`
+	BeltLevel      string     `json:"belt_level"`
+	Status         string     `json:"status"`       // 'registered', 'withdrawn'
+	NominatedBy    *int       `json:"nominated_by"` // Coach who entered the athlete, nil for self-registration
+	Placing        *int       `json:"placing"`      // Final placing once the bracket is decided
+	RegisteredAt   time.Time  `json:"registered_at"`
+	WithdrawnAt    *time.Time `json:"withdrawn_at"`
+}
+
+// AthleteEvent is an upcoming event as seen by one athlete
+type AthleteEvent struct {
+	Event
+	MyStatus   string   `json:"my_status,omitempty"` // Registration status, empty when not registered
+	Eligible   bool     `json:"eligible"`
+	Ineligible []string `json:"ineligible_reasons"` // Why the athlete cannot register
 }
@@ -0,0 +1,101 @@
+package models
+
+import "time"
+
+// Grading session statuses
+const (
+	GradingPlanned   = "planned"
+	GradingCompleted = "completed"
+	GradingCancelled = "cancelled"
+)
+
+// Grading results
+const (
+	GradingPending = "pending"
+	GradingPassed  = "passed"
+	GradingFailed  = "failed"
+	GradingAbsent  = "absent"
+)
+
+// Belt is a grade of the club's belt list, ordered by Rank. Its requirements
+// apply to athletes grading towards it.
+type Belt struct {
+	ID                int     `json:"id"`
+	Name              string  `json:"name"`
+	Rank              int     `json:"rank"`
+	Color             string  `json:"color"`
+	MinMonths         int     `json:"min_months"`          // Months at the previous grade
+	MinAttendanceRate float64 `json:"min_attendance_rate"` // 0-1, over the months before the exam
+}
+
+// GradingSession is a belt exam
+type GradingSession struct {
+	ID             int                 `json:"id"`
+	Title          string              `json:"title"`
+	ExamDate       time.Time           `json:"exam_date"`
+	Location       string              `json:"location"`
+	Status         string              `json:"status"` // 'planned', 'completed', 'cancelled'
+	Notes          string              `json:"notes"`
+	CreatedBy      *int                `json:"created_by"`
+	CreatedAt      time.Time           `json:"created_at"`
+	CandidateCount int                 `json:"candidate_count"`
+	Candidates     []*GradingCandidate `json:"candidates,omitempty"`
+}
+
+// CreateGradingSessionRequest creates or updates a grading session
+type CreateGradingSessionRequest struct {
+	Title    string `json:"title"`
+	ExamDate string `json:"exam_date"` // Format: YYYY-MM-DD HH:MM
+	Location string `json:"location"`
+	Status   string `json:"status"`
+	Notes    string `json:"notes"`
+}
+
+// GradingCandidate is an athlete presented at a grading session, or who could
+// be. The eligibility fields are computed for the session's exam date.
+type GradingCandidate struct {
+	SessionID     int        `json:"session_id"`
+	AthleteID     int        `json:"athlete_id"`
+	AthleteName   string     `json:"athlete_name"`
+	CurrentBelt   string     `json:"current_belt"`
+	TargetBelt    string     `json:"target_belt"`
+	Result        string     `json:"result"` // 'pending', 'passed', 'failed', 'absent'
+	Score         *float64   `json:"score"`
+	ExaminerID    *int       `json:"examiner_id"`
+	ExaminerName  string     `json:"examiner_name"`
+	ExaminerNotes string     `json:"examiner_notes"`
+	GradedAt      *time.Time `json:"graded_at"`
+
+	GradeSince     *time.Time `json:"grade_since"` // Last promotion, or joining the club
+	MonthsAtGrade  int        `json:"months_at_grade"`
+	AttendanceRate float64    `json:"attendance_rate"`
+	Eligible       bool       `json:"eligible"`
+	Reasons        []string   `json:"reasons"` // Why the athlete is not eligible
+}
+
+// AddCandidatesRequest presents athletes at a grading session. Force
+// presents them even when they do not meet the requirements.
+type AddCandidatesRequest struct {
+	AthleteIDs []int `json:"athlete_ids"`
+	Force      bool  `json:"force"`
+}
+
+// GradingResultRequest records an examiner's decision
+type GradingResultRequest struct {
+	Result string   `json:"result"` // 'passed', 'failed', 'absent'
+	Score  *float64 `json:"score"`
+	Notes  string   `json:"notes"`
+}
+
+// BeltPromotion is a belt obtained by an athlete
+type BeltPromotion struct {
+	ID           int       `json:"id"`
+	AthleteID    int       `json:"athlete_id"`
+	FromBelt     string    `json:"from_belt"`
+	ToBelt       string    `json:"to_belt"`
+	PromotedAt   time.Time `json:"promoted_at"`
+	SessionID    *int      `json:"session_id"`
+	SessionTitle string    `json:"session_title"`
+	PromotedBy   *int      `json:"promoted_by"`
+	Notes        string    `json:"notes"`
+}
@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+// MinorAge is the age below which an athlete needs a guardian
+const MinorAge = 18
+
+// Guardian is an account responsible for an athlete
+type Guardian struct {
+	UserID    int       `json:"user_id"`
+	AthleteID int       `json:"athlete_id"`
+	Email     string    `json:"email"`
+	FirstName string    `json:"first_name"`
+	LastName  string    `json:"last_name"`
+	Relation  string    `json:"relation"` // 'parent', 'legal_guardian', 'other'
+	IsPrimary bool      `json:"is_primary"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Ward is an athlete as seen by one of their guardians
+type Ward struct {
+	AthleteID        int        `json:"athlete_id"`
+	FirstName        string     `json:"first_name"`
+	LastName         string     `json:"last_name"`
+	DateOfBirth      *time.Time `json:"date_of_birth"`
+	PhotoURL         string     `json:"photo_url"`
+	MembershipStatus string     `json:"membership_status"`
+	Relation         string     `json:"relation"`
+	IsPrimary        bool       `json:"is_primary"`
+}
+
+// AddGuardianRequest links a guardian account to an athlete, by user id or email
+type AddGuardianRequest struct {
+	UserID    int    `json:"user_id"`
+	Email     string `json:"email"`
+	Relation  string `json:"relation"`
+	IsPrimary bool   `json:"is_primary"`
+}
+
+// IsMinor reports whether someone born on dob is under MinorAge at t. An
+// unknown date of birth is not treated as a minor.
+func IsMinor(dob *time.Time, t time.Time) bool {
+	if dob == nil {
+		return false
+	}
+	return t.Before(dob.AddDate(MinorAge, 0, 0))
+}
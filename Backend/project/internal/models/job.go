@@ -0,0 +1,44 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Job statuses
+const (
+	JobQueued    = "queued"
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobDead      = "dead" // Failed its last attempt, waits for a manual retry
+)
+
+// Job is a unit of background work
+type Job struct {
+	ID           int64           `json:"id"`
+	Type         string          `json:"type"`
+	Payload      json.RawMessage `json:"payload"`
+	Status       string          `json:"status"`
+	Attempts     int             `json:"attempts"`
+	MaxAttempts  int             `json:"max_attempts"`
+	RunAt        time.Time       `json:"run_at"`
+	LockedBy     *string         `json:"locked_by"`
+	LockedUntil  *time.Time      `json:"locked_until"`
+	LastError    string          `json:"last_error"`
+	ScheduleName *string         `json:"schedule_name"`
+	CreatedAt    time.Time       `json:"created_at"`
+	StartedAt    *time.Time      `json:"started_at"`
+	FinishedAt   *time.Time      `json:"finished_at"`
+}
+
+// JobSchedule queues a job of JobType each time its cron expression matches
+type JobSchedule struct {
+	Name      string     `json:"name"`
+	JobType   string     `json:"job_type"`
+	Cron      string     `json:"cron"`
+	NextRunAt time.Time  `json:"next_run_at"`
+	LastRunAt *time.Time `json:"last_run_at"`
+}
+
+// JobStats counts jobs per status
+type JobStats map[string]int
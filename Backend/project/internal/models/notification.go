@@ -0,0 +1,103 @@
+package models
+
+import "time"
+
+// Notification channels
+const (
+	ChannelEmail = "email"
+	ChannelSMS   = "sms"
+)
+
+// Outbox message statuses
+const (
+	OutboxPending = "pending"
+	OutboxSending = "sending"
+	OutboxSent    = "sent"
+	OutboxFailed  = "failed" // Gave up after the last attempt
+)
+
+// NotificationPreferences are a user's notification settings
+type NotificationPreferences struct {
+	UserID       int       `json:"user_id"`
+	Language     string    `json:"language"` // 'fr', 'ar'
+	EmailEnabled bool      `json:"email_enabled"`
+	SMSEnabled   bool      `json:"sms_enabled"`
+	Phone        string    `json:"phone"`
+	Muted        []string  `json:"muted"` // Template keys not sent by email or SMS
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// NotificationRecipient is someone a notification is addressed to, with
+// their contact details and preferences. UserID is nil for athletes without
+// an account, who get emails in French.
+type NotificationRecipient struct {
+	UserID       *int
+	AthleteID    *int
+	Name         string
+	Email        string
+	Phone        string
+	Language     string
+	EmailEnabled bool
+	SMSEnabled   bool
+	Muted        []string
+}
+
+// OutboxMessage is a rendered message waiting to be sent, or sent
+type OutboxMessage struct {
+	ID            int        `json:"id"`
+	UserID        *int       `json:"user_id"`
+	AthleteID     *int       `json:"athlete_id"`
+	Channel       string     `json:"channel"`
+	Recipient     string     `json:"recipient"`
+	Template      string     `json:"template"`
+	Language      string     `json:"language"`
+	Subject       string     `json:"subject"`
+	Body          string     `json:"body"`
+	Status        string     `json:"status"`
+	Attempts      int        `json:"attempts"`
+	NextAttemptAt time.Time  `json:"next_attempt_at"`
+	LastError     string     `json:"last_error"`
+	DedupeKey     *string    `json:"dedupe_key"`
+	CreatedAt     time.Time  `json:"created_at"`
+	SentAt        *time.Time `json:"sent_at"`
+}
+
+// ExpiringDocument is an approved document close to its expiry date
+type ExpiringDocument struct {
+	DocumentID   int
+	AthleteID    int
+	DocumentType string
+	ExpiryDate   time.Time
+}
+
+// OverdueAthlete is an active athlete whose paid periods ended
+type OverdueAthlete struct {
+	AthleteID    int
+	CoveredUntil time.Time
+}
+
+// ReminderResult counts the reminders queued by a reminder run
+type ReminderResult struct {
+	DocumentsExpiring int `json:"documents_expiring"`
+	PaymentsOverdue   int `json:"payments_overdue"`
+	Queued            int `json:"queued"` // Messages added to the outbox
+}
+
+// Notification is an item of a user's in-app inbox
+type Notification struct {
+	ID        int        `json:"id"`
+	UserID    int        `json:"user_id"`
+	AthleteID *int       `json:"athlete_id"` // Athlete it is about, for guardians with several children
+	Type      string     `json:"type"`       // Template key, e.g. 'document_validated'
+	Title     string     `json:"title"`
+	Message   string     `json:"message"`
+	ReadAt    *time.Time `json:"read_at"`
+	CreatedAt time.Time  `json:"created_at"`
+	DedupeKey *string    `json:"-"`
+}
+
+// NotificationInbox is a page of a user's notifications
+type NotificationInbox struct {
+	Notifications []*Notification `json:"notifications"`
+	UnreadCount   int             `json:"unread_count"`
+}
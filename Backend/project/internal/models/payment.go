@@ -2,19 +2,70 @@ package models
 
 import "time"
 
+// PaymentMethod is how a payment was settled
+type PaymentMethod string
+
+const (
+	PaymentMethodCash         PaymentMethod = "cash"
+	PaymentMethodBankTransfer PaymentMethod = "bank_transfer"
+	PaymentMethodCCP          PaymentMethod = "ccp"
+	PaymentMethodCard         PaymentMethod = "card"
+)
+
+// IsValid reports whether m is a known payment method
+func (m PaymentMethod) IsValid() bool {
+	switch m {
+	case PaymentMethodCash, PaymentMethodBankTransfer, PaymentMethodCCP, PaymentMethodCard:
+		return true
+	}
+	return false
+}
+
+// Payment kinds: a period payment opens a coverage period, installments and
+// refunds are attached to it through ParentPaymentID.
+const (
+	PaymentKindPeriod      = "period"
+	PaymentKindInstallment = "installment"
+	PaymentKindRefund      = "refund"
+)
+
+// Payment statuses: a refunded period no longer counts as coverage
+const (
+	PaymentStatusActive   = "active"
+	PaymentStatusRefunded = "refunded"
+)
+
 type Payment struct {
 	ID            int       `json:"id"`
 	AthleteID     int       `json:"athlete_id"`
 	Amount        float64   `json:"amount"`
 	MonthsCovered int       `json:"months_covered"`
 	StartDate     string    `json:"start_date"` // YYYY-MM-DD
-	EndDate       string    `json:"end_date"`   // YYYY-MM-DD
+	EndDate       string    `json:"end_date"`   // YYYY-MM-DD (inclusive)
 	PaymentDate   time.Time `json:"payment_date"`
 	Notes         string    `json:"notes"`
 	RecordedBy    *int      `json:"recorded_by"`
+	ReceiptNumber string    `json:"receipt_number"` // YYYY-NNNNN, sequential per year
+
+	Method            PaymentMethod `json:"method"`
+	ExternalReference string        `json:"external_reference"`  // Transfer, CCP or card reference
+	Kind              string        `json:"kind"`                // 'period', 'installment', 'refund'
+	ParentPaymentID   *int          `json:"parent_payment_id"`   // Period an installment or refund belongs to
+	Status            string        `json:"status"`              // 'active', 'refunded'
+	AmountDue         float64       `json:"amount_due"`          // Price of the period (period payments only)
+	RefundedPaymentID *int          `json:"refunded_payment_id"` // Period or installment a refund returns money from
+
+	// Computed for period payments: net collected (installments minus refunds) and what is left to pay
+	AmountPaid float64 `json:"amount_paid"`
+	Balance    float64 `json:"balance"`
 
 	// Joined fields
-	AthleteName string `json:"athlete_name,omitempty"`
+	AthleteName    string `json:"athlete_name,omitempty"`
+	RecordedByName string `json:"recorded_by_name,omitempty"`
+	ReceiptURL     string `json:"receipt_url,omitempty"`
+
+	// Coverage warnings (gaps, accepted overlaps) raised while recording
+	Warnings []string `json:"warnings,omitempty"`
 }
 
 type CreatePaymentRequest struct {
@@ -23,4 +74,95 @@ type CreatePaymentRequest struct {
 	MonthsCovered int     `json:"months_covered"`
 	StartDate     string  `json:"start_date"` // YYYY-MM-DD
 	Notes         string  `json:"notes"`
+
+	Method            PaymentMethod `json:"method"`
+	ExternalReference string        `json:"external_reference"`
+	// AmountDue is the full price of the period; defaults to Amount. A lower
+	// Amount records a first partial payment.
+	AmountDue float64 `json:"amount_due"`
+
+	// AutoStart starts the period the day after the athlete's last covered date
+	AutoStart bool `json:"auto_start"`
+	// AllowOverlap records the payment even if it overlaps existing coverage
+	AllowOverlap bool `json:"allow_overlap"`
+}
+
+// InstallmentRequest records a further partial payment against a period
+type InstallmentRequest struct {
+	Amount            float64       `json:"amount"`
+	Method            PaymentMethod `json:"method"`
+	ExternalReference string        `json:"external_reference"`
+	Notes             string        `json:"notes"`
+}
+
+// RefundRequest issues a credit note reversing all or part of a payment
+type RefundRequest struct {
+	Amount            float64       `json:"amount"` // 0 refunds the whole payment
+	Method            PaymentMethod `json:"method"`
+	ExternalReference string        `json:"external_reference"`
+	Notes             string        `json:"notes"`
+	// CancelPeriod releases the coverage period even after a partial refund
+	CancelPeriod bool `json:"cancel_period"`
+}
+
+// PaymentBalance sums an athlete's periods, collections and refunds
+type PaymentBalance struct {
+	AthleteID     int     `json:"athlete_id"`
+	TotalDue      float64 `json:"total_due"`
+	TotalPaid     float64 `json:"total_paid"`
+	TotalRefunded float64 `json:"total_refunded"`
+	Outstanding   float64 `json:"outstanding"`
+}
+
+// CoverageSegment is a continuous run of days with the same payment status
+type CoverageSegment struct {
+	Status     string `json:"status"`     // 'paid', 'grace', 'unpaid'
+	StartDate  string `json:"start_date"` // YYYY-MM-DD
+	EndDate    string `json:"end_date"`   // YYYY-MM-DD (inclusive)
+	Days       int    `json:"days"`
+	PaymentIDs []int  `json:"payment_ids,omitempty"`
+}
+
+// CoverageTimeline describes an athlete's paid, grace and unpaid periods
+type CoverageTimeline struct {
+	AthleteID       int               `json:"athlete_id"`
+	From            string            `json:"from"`
+	To              string            `json:"to"`
+	GraceDays       int               `json:"grace_days"`
+	LastCoveredDate *string           `json:"last_covered_date"`
+	Segments        []CoverageSegment `json:"segments"`
+}
+
+// Online checkout statuses
+const (
+	CheckoutStatusPending  = "pending"
+	CheckoutStatusPaid     = "paid"
+	CheckoutStatusFailed   = "failed"
+	CheckoutStatusCanceled = "canceled"
+)
+
+// PaymentCheckout is an online payment started by an athlete
+type PaymentCheckout struct {
+	ID             int        `json:"id"`
+	AthleteID      int        `json:"athlete_id"`
+	UserID         int        `json:"user_id"`
+	Provider       string     `json:"provider"`
+	SessionID      string     `json:"session_id,omitempty"`
+	CheckoutURL    string     `json:"checkout_url,omitempty"`
+	IdempotencyKey string     `json:"idempotency_key"`
+	Amount         float64    `json:"amount"`
+	MonthsCovered  int        `json:"months_covered"`
+	Status         string     `json:"status"` // 'pending', 'paid', 'failed', 'canceled'
+	SuccessURL     string     `json:"success_url,omitempty"`
+	CancelURL      string     `json:"cancel_url,omitempty"`
+	PaymentID      *int       `json:"payment_id"` // Period payment recorded once paid
+	CreatedAt      time.Time  `json:"created_at"`
+	CompletedAt    *time.Time `json:"completed_at"`
+}
+
+// CreateCheckoutRequest starts an online payment for the next months
+type CreateCheckoutRequest struct {
+	MonthsCovered int    `json:"months_covered"`
+	SuccessURL    string `json:"success_url"`
+	CancelURL     string `json:"cancel_url"`
 }
@@ -0,0 +1,79 @@
+package models
+
+import "time"
+
+// InitialRating is the rating of an athlete before their first rated bout
+const InitialRating = 1500
+
+// RatedBout is a fought bout as used to rebuild ratings
+type RatedBout struct {
+	ID              int
+	RedAthleteID    int
+	BlueAthleteID   int
+	WinnerAthleteID *int // nil for a draw
+	CompletedAt     time.Time
+}
+
+// RatingChange is one athlete's rating before and after a bout
+type RatingChange struct {
+	AthleteID int       `json:"athlete_id"`
+	BoutID    int       `json:"bout_id"`
+	Before    int       `json:"rating_before"`
+	After     int       `json:"rating_after"`
+	RatedAt   time.Time `json:"rated_at"`
+}
+
+// MethodRecord counts bouts decided by one method
+type MethodRecord struct {
+	Wins   int `json:"wins"`
+	Losses int `json:"losses"`
+	Draws  int `json:"draws"`
+}
+
+// Medal is a podium finish in an event
+type Medal struct {
+	EventID        int       `json:"event_id"`
+	EventTitle     string    `json:"event_title"`
+	EventDate      time.Time `json:"event_date"`
+	WeightCategory string    `json:"weight_category"`
+	Placing        int       `json:"placing"`
+	Medal          string    `json:"medal"` // 'gold', 'silver', 'bronze'
+}
+
+// AthleteRecord is an athlete's competition history
+type AthleteRecord struct {
+	AthleteID int                      `json:"athlete_id"`
+	Bouts     int                      `json:"bouts"`
+	Wins      int                      `json:"wins"`
+	Losses    int                      `json:"losses"`
+	Draws     int                      `json:"draws"`
+	ByMethod  map[string]*MethodRecord `json:"by_method"`
+	Gold      int                      `json:"gold"`
+	Silver    int                      `json:"silver"`
+	Bronze    int                      `json:"bronze"`
+	Medals    []Medal                  `json:"medals"`
+	Rating    int                      `json:"rating"`
+	History   []RatingChange           `json:"rating_history"`
+}
+
+// RankingEntry is an athlete's line in a club ranking
+type RankingEntry struct {
+	Rank        int    `json:"rank"`
+	AthleteID   int    `json:"athlete_id"`
+	AthleteName string `json:"athlete_name"`
+	Gender      string `json:"gender"`
+	Rating      int    `json:"rating"`
+	Bouts       int    `json:"bouts"`
+	Wins        int    `json:"wins"`
+	Losses      int    `json:"losses"`
+	Draws       int    `json:"draws"`
+	Gold        int    `json:"gold"`
+	Silver      int    `json:"silver"`
+	Bronze      int    `json:"bronze"`
+}
+
+// Ranking is the club ranking of one weight category
+type Ranking struct {
+	WeightCategory string          `json:"weight_category"`
+	Athletes       []*RankingEntry `json:"athletes"`
+}
@@ -0,0 +1,288 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RecurrenceRule is the subset of RFC 5545 RRULE supported for training
+// schedules: FREQ=DAILY|WEEKLY|MONTHLY with INTERVAL, BYDAY (with an ordinal
+// for monthly rules, e.g. 1SA or -1FR), BYMONTHDAY, UNTIL and COUNT.
+//
+//	FREQ=WEEKLY;INTERVAL=2;BYDAY=TU,TH        every other Tuesday and Thursday
+//	FREQ=MONTHLY;BYDAY=1SA                    first Saturday of the month
+//	FREQ=WEEKLY;BYDAY=MO;UNTIL=20260630       every Monday until June 30th
+type RecurrenceRule struct {
+	Freq       string // DAILY, WEEKLY or MONTHLY
+	Interval   int
+	ByDay      []RecurrenceDay
+	ByMonthDay []int
+	Until      *time.Time // Inclusive date
+	Count      int        // 0 = unlimited
+}
+
+// RecurrenceDay is a BYDAY entry. Ordinal is only used by monthly rules:
+// 1 = first, -1 = last, 0 = every such weekday of the month.
+type RecurrenceDay struct {
+	Ordinal int
+	Weekday time.Weekday
+}
+
+var rruleDays = map[string]time.Weekday{
+	"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+	"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+}
+
+var rruleDayCodes = [...]string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+// WeeklyRule returns the rule of a plain weekly slot on the given day
+func WeeklyRule(day time.Weekday) *RecurrenceRule {
+	return &RecurrenceRule{Freq: "WEEKLY", Interval: 1, ByDay: []RecurrenceDay{{Weekday: day}}}
+}
+
+// ParseRecurrenceRule parses an RRULE value, with or without the "RRULE:" prefix
+func ParseRecurrenceRule(s string) (*RecurrenceRule, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "RRULE:")
+	rule := &RecurrenceRule{Interval: 1}
+
+	for _, part := range strings.Split(s, ";") {
+		if part == "" {
+			continue
+		}
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid rrule part: %s", part)
+		}
+		key, value := strings.ToUpper(kv[0]), strings.ToUpper(kv[1])
+
+		switch key {
+		case "FREQ":
+			if value != "DAILY" && value != "WEEKLY" && value != "MONTHLY" {
+				return nil, fmt.Errorf("unsupported FREQ: %s (DAILY, WEEKLY or MONTHLY)", value)
+			}
+			rule.Freq = value
+		case "INTERVAL":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("invalid INTERVAL: %s", value)
+			}
+			rule.Interval = n
+		case "BYDAY":
+			for _, d := range strings.Split(value, ",") {
+				day, err := parseRecurrenceDay(d)
+				if err != nil {
+					return nil, err
+				}
+				rule.ByDay = append(rule.ByDay, day)
+			}
+		case "BYMONTHDAY":
+			for _, d := range strings.Split(value, ",") {
+				n, err := strconv.Atoi(d)
+				if err != nil || n == 0 || n < -31 || n > 31 {
+					return nil, fmt.Errorf("invalid BYMONTHDAY: %s", d)
+				}
+				rule.ByMonthDay = append(rule.ByMonthDay, n)
+			}
+		case "UNTIL":
+			if len(value) < 8 {
+				return nil, fmt.Errorf("invalid UNTIL: %s", value)
+			}
+			until, err := time.Parse("20060102", value[:8])
+			if err != nil {
+				return nil, fmt.Errorf("invalid UNTIL: %s", value)
+			}
+			rule.Until = &until
+		case "COUNT":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("invalid COUNT: %s", value)
+			}
+			rule.Count = n
+		case "WKST":
+			// Weeks always start on Monday
+		default:
+			return nil, fmt.Errorf("unsupported rrule part: %s", key)
+		}
+	}
+
+	if rule.Freq == "" {
+		return nil, fmt.Errorf("rrule needs a FREQ")
+	}
+	if rule.Until != nil && rule.Count > 0 {
+		return nil, fmt.Errorf("UNTIL and COUNT cannot be combined")
+	}
+	for _, d := range rule.ByDay {
+		if d.Ordinal != 0 && rule.Freq != "MONTHLY" {
+			return nil, fmt.Errorf("BYDAY ordinals are only supported with FREQ=MONTHLY")
+		}
+	}
+	if len(rule.ByMonthDay) > 0 && rule.Freq != "MONTHLY" {
+		return nil, fmt.Errorf("BYMONTHDAY is only supported with FREQ=MONTHLY")
+	}
+	return rule, nil
+}
+
+func parseRecurrenceDay(s string) (RecurrenceDay, error) {
+	s = strings.TrimSpace(s)
+	if len(s) < 2 {
+		return RecurrenceDay{}, fmt.Errorf("invalid BYDAY: %s", s)
+	}
+	weekday, ok := rruleDays[s[len(s)-2:]]
+	if !ok {
+		return RecurrenceDay{}, fmt.Errorf("invalid BYDAY: %s", s)
+	}
+	day := RecurrenceDay{Weekday: weekday}
+	if prefix := s[:len(s)-2]; prefix != "" {
+		n, err := strconv.Atoi(prefix)
+		if err != nil || n == 0 || n < -5 || n > 5 {
+			return RecurrenceDay{}, fmt.Errorf("invalid BYDAY: %s", s)
+		}
+		day.Ordinal = n
+	}
+	return day, nil
+}
+
+// String formats the rule back to its RRULE value
+func (r *RecurrenceRule) String() string {
+	parts := []string{"FREQ=" + r.Freq}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, len(r.ByDay))
+		for i, d := range r.ByDay {
+			days[i] = rruleDayCodes[d.Weekday]
+			if d.Ordinal != 0 {
+				days[i] = strconv.Itoa(d.Ordinal) + days[i]
+			}
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if len(r.ByMonthDay) > 0 {
+		days := make([]string, len(r.ByMonthDay))
+		for i, d := range r.ByMonthDay {
+			days[i] = strconv.Itoa(d)
+		}
+		parts = append(parts, "BYMONTHDAY="+strings.Join(days, ","))
+	}
+	if r.Until != nil {
+		parts = append(parts, "UNTIL="+r.Until.Format("20060102"))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	return strings.Join(parts, ";")
+}
+
+// FirstWeekday returns the first weekday the rule falls on, in Monday-first
+// order, or false when it only uses BYMONTHDAY
+func (r *RecurrenceRule) FirstWeekday() (time.Weekday, bool) {
+	if len(r.ByDay) == 0 {
+		return 0, false
+	}
+	first := r.ByDay[0].Weekday
+	for _, d := range r.ByDay[1:] {
+		if WeekdayIndex(d.Weekday) < WeekdayIndex(first) {
+			first = d.Weekday
+		}
+	}
+	return first, true
+}
+
+// Occurrences returns the dates (at midnight UTC) the rule produces between
+// from and to inclusive. start is the DTSTART date anchoring intervals,
+// COUNT and the defaults of rules without BYDAY/BYMONTHDAY.
+func (r *RecurrenceRule) Occurrences(start, from, to time.Time) []time.Time {
+	start = dateOnly(start)
+	from, to = dateOnly(from), dateOnly(to)
+	if r.Until != nil && r.Until.Before(to) {
+		to = dateOnly(*r.Until)
+	}
+
+	var dates []time.Time
+	count := 0
+	for d := start; !d.After(to); d = d.AddDate(0, 0, 1) {
+		if !r.matches(start, d) {
+			continue
+		}
+		count++
+		if r.Count > 0 && count > r.Count {
+			break
+		}
+		if !d.Before(from) {
+			dates = append(dates, d)
+		}
+	}
+	return dates
+}
+
+func (r *RecurrenceRule) matches(start, d time.Time) bool {
+	switch r.Freq {
+	case "DAILY":
+		return int(d.Sub(start).Hours()/24)%r.Interval == 0
+
+	case "WEEKLY":
+		weeks := int(weekStart(d).Sub(weekStart(start)).Hours() / (24 * 7))
+		if weeks%r.Interval != 0 {
+			return false
+		}
+		if len(r.ByDay) == 0 {
+			return d.Weekday() == start.Weekday()
+		}
+		for _, day := range r.ByDay {
+			if day.Weekday == d.Weekday() {
+				return true
+			}
+		}
+		return false
+
+	case "MONTHLY":
+		months := (d.Year()-start.Year())*12 + int(d.Month()) - int(start.Month())
+		if months%r.Interval != 0 {
+			return false
+		}
+		if len(r.ByDay) == 0 && len(r.ByMonthDay) == 0 {
+			return d.Day() == start.Day()
+		}
+		lastDay := time.Date(d.Year(), d.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+		for _, n := range r.ByMonthDay {
+			if n == d.Day() || (n < 0 && lastDay+n+1 == d.Day()) {
+				return true
+			}
+		}
+		for _, day := range r.ByDay {
+			if day.Weekday != d.Weekday() {
+				continue
+			}
+			switch {
+			case day.Ordinal == 0:
+				return true
+			case day.Ordinal > 0 && (d.Day()-1)/7+1 == day.Ordinal:
+				return true
+			case day.Ordinal < 0 && (lastDay-d.Day())/7+1 == -day.Ordinal:
+				return true
+			}
+		}
+		return false
+	}
+	return false
+}
+
+// WeekdayIndex orders weekdays Monday first (Monday = 1 ... Sunday = 7)
+func WeekdayIndex(day time.Weekday) int {
+	if day == time.Sunday {
+		return 7
+	}
+	return int(day)
+}
+
+func dateOnly(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// weekStart returns the Monday of the date's week
+func weekStart(d time.Time) time.Time {
+	return d.AddDate(0, 0, 1-WeekdayIndex(d.Weekday()))
+}
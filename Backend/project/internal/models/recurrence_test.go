@@ -0,0 +1,142 @@
+package models
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func date(s string) time.Time {
+	d, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func formatDates(dates []time.Time, layout string) string {
+	out := make([]string, len(dates))
+	for i, d := range dates {
+		out[i] = d.Format(layout)
+	}
+	return strings.Join(out, " ")
+}
+
+func TestRecurrenceOccurrences(t *testing.T) {
+	tests := []struct {
+		name     string
+		rrule    string
+		start    string
+		from, to string
+		want     string
+	}{
+		{"weekly defaults to the start weekday", "FREQ=WEEKLY", "2026-01-07", "2026-01-01", "2026-01-31",
+			"2026-01-07 2026-01-14 2026-01-21 2026-01-28"},
+		{"every other week on two days", "FREQ=WEEKLY;INTERVAL=2;BYDAY=TU,TH", "2026-01-05", "2026-01-05", "2026-02-08",
+			"2026-01-06 2026-01-08 2026-01-20 2026-01-22 2026-02-03 2026-02-05"},
+		{"interval counted from the start, not from", "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO", "2026-01-05", "2026-01-10", "2026-02-08",
+			"2026-01-19 2026-02-02"},
+		{"first saturday", "FREQ=MONTHLY;BYDAY=1SA", "2026-01-01", "2026-01-01", "2026-03-31",
+			"2026-01-03 2026-02-07 2026-03-07"},
+		{"last friday", "FREQ=MONTHLY;BYDAY=-1FR", "2026-01-01", "2026-01-01", "2026-03-31",
+			"2026-01-30 2026-02-27 2026-03-27"},
+		{"last day of the month", "FREQ=MONTHLY;BYMONTHDAY=-1", "2026-01-01", "2026-01-01", "2026-03-31",
+			"2026-01-31 2026-02-28 2026-03-31"},
+		{"monthly skips months without the start day", "FREQ=MONTHLY", "2026-01-31", "2026-01-01", "2026-04-30",
+			"2026-01-31 2026-03-31"},
+		{"until is inclusive", "FREQ=WEEKLY;BYDAY=MO;UNTIL=20260119", "2026-01-01", "2026-01-01", "2026-02-28",
+			"2026-01-05 2026-01-12 2026-01-19"},
+		{"count includes occurrences before from", "FREQ=DAILY;INTERVAL=3;COUNT=4", "2026-01-01", "2026-01-05", "2026-01-31",
+			"2026-01-07 2026-01-10"},
+		{"nothing before the start", "FREQ=DAILY", "2026-01-30", "2026-01-01", "2026-02-01",
+			"2026-01-30 2026-01-31 2026-02-01"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := ParseRecurrenceRule(tt.rrule)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := formatDates(rule.Occurrences(date(tt.start), date(tt.from), date(tt.to)), "2006-01-02")
+			if got != tt.want {
+				t.Errorf("got  %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRecurrenceRuleString(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"FREQ=WEEKLY;INTERVAL=2;BYDAY=TU,TH", "FREQ=WEEKLY;INTERVAL=2;BYDAY=TU,TH"},
+		{"RRULE:freq=monthly;byday=1sa,-1fr", "FREQ=MONTHLY;BYDAY=1SA,-1FR"},
+		{"FREQ=WEEKLY;BYDAY=MO;UNTIL=20260630T235959Z;WKST=MO", "FREQ=WEEKLY;BYDAY=MO;UNTIL=20260630"},
+		{"FREQ=MONTHLY;BYMONTHDAY=1,15,-1;INTERVAL=1", "FREQ=MONTHLY;BYMONTHDAY=1,15,-1"},
+		{"FREQ=DAILY;COUNT=3", "FREQ=DAILY;COUNT=3"},
+	}
+	for _, tt := range tests {
+		rule, err := ParseRecurrenceRule(tt.in)
+		if err != nil {
+			t.Errorf("ParseRecurrenceRule(%q): %v", tt.in, err)
+			continue
+		}
+		if got := rule.String(); got != tt.want {
+			t.Errorf("ParseRecurrenceRule(%q).String() = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseRecurrenceRuleErrors(t *testing.T) {
+	for _, in := range []string{
+		"",
+		"INTERVAL=2",
+		"FREQ=YEARLY",
+		"FREQ=WEEKLY;INTERVAL=0",
+		"FREQ=WEEKLY;BYDAY=XX",
+		"FREQ=WEEKLY;BYDAY=1MO",
+		"FREQ=MONTHLY;BYDAY=6MO",
+		"FREQ=WEEKLY;BYMONTHDAY=1",
+		"FREQ=MONTHLY;BYMONTHDAY=32",
+		"FREQ=DAILY;UNTIL=20260101;COUNT=2",
+		"FREQ=DAILY;UNTIL=2026",
+		"FREQ=DAILY;BYHOUR=8",
+		"FREQ",
+	} {
+		if _, err := ParseRecurrenceRule(in); err == nil {
+			t.Errorf("ParseRecurrenceRule(%q) accepted an invalid rule", in)
+		}
+	}
+}
+
+func TestScheduleOccurrences(t *testing.T) {
+	from, until := "2026-01-05", "2026-01-26"
+	tests := []struct {
+		name     string
+		schedule TrainingSchedule
+		want     string
+	}{
+		{"exdates and validity window",
+			TrainingSchedule{StartTime: "18:30", RRule: "FREQ=WEEKLY;BYDAY=MO", ValidFrom: &from, ValidUntil: &until,
+				ExDates: []string{"2026-01-12"}},
+			"2026-01-05 18:30 2026-01-19 18:30 2026-01-26 18:30"},
+		{"valid_from anchors the interval",
+			TrainingSchedule{StartTime: "10:00", RRule: "FREQ=WEEKLY;INTERVAL=2;BYDAY=SA", ValidFrom: &from},
+			"2026-01-10 10:00 2026-01-24 10:00"},
+		{"day_of_week without rrule",
+			TrainingSchedule{StartTime: "09:00", DayOfWeek: "Thursday", CreatedAt: date("2025-12-01"),
+				ExDates: []string{"2026-01-15"}},
+			"2026-01-01 09:00 2026-01-08 09:00 2026-01-22 09:00 2026-01-29 09:00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			times, err := tt.schedule.Occurrences(date("2026-01-01"), date("2026-01-31"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := formatDates(times, "2006-01-02 15:04"); got != tt.want {
+				t.Errorf("got  %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
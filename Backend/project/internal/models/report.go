@@ -0,0 +1,60 @@
+package models
+
+// FinanceReport bundles every finance report for a date range
+type FinanceReport struct {
+	From       string                `json:"from"` // YYYY-MM-DD
+	To         string                `json:"to"`   // YYYY-MM-DD
+	MonthlyFee float64               `json:"monthly_fee"`
+	Monthly    []RevenuePeriod       `json:"monthly"`
+	Seasonal   []RevenuePeriod       `json:"seasonal"`
+	Collection []CollectionPeriod    `json:"collection"`
+	Aging      []ArrearsBucket       `json:"aging"`
+	Breakdown  []FinanceBreakdownRow `json:"breakdown"`
+}
+
+// RevenuePeriod is the money received during a month ("2026-01") or a season ("2025/2026")
+type RevenuePeriod struct {
+	Period    string  `json:"period"`
+	Payments  int     `json:"payments"`
+	Collected float64 `json:"collected"`
+	Refunded  float64 `json:"refunded"`
+	Net       float64 `json:"net"`
+}
+
+// CollectionPeriod compares what active athletes should have paid in a month with what came in
+type CollectionPeriod struct {
+	Period         string  `json:"period"` // YYYY-MM
+	ActiveAthletes int     `json:"active_athletes"`
+	Expected       float64 `json:"expected"`
+	Collected      float64 `json:"collected"`
+	CollectionRate float64 `json:"collection_rate"` // Collected / Expected, 0 when nothing is expected
+}
+
+// ArrearsBucket groups active athletes by how long their coverage has been over
+type ArrearsBucket struct {
+	Bucket      string           `json:"bucket"` // 'current', '0-30', '31-60', '61-90', '90+', 'never_paid'
+	Athletes    int              `json:"athletes"`
+	Outstanding float64          `json:"outstanding"` // Estimated: months overdue x monthly fee + unpaid balances
+	Details     []ArrearsAthlete `json:"details"`
+}
+
+// ArrearsAthlete is one athlete in an arrears bucket
+type ArrearsAthlete struct {
+	AthleteID       int     `json:"athlete_id"`
+	AthleteName     string  `json:"athlete_name"`
+	LastCoveredDate *string `json:"last_covered_date"`
+	DaysOverdue     int     `json:"days_overdue"`
+	UnpaidBalance   float64 `json:"unpaid_balance"`
+	Outstanding     float64 `json:"outstanding"`
+}
+
+// FinanceBreakdownRow aggregates payments for one weight category or skill level
+type FinanceBreakdownRow struct {
+	Group          string  `json:"group"`
+	ActiveAthletes int     `json:"active_athletes"`
+	Collected      float64 `json:"collected"`
+	Refunded       float64 `json:"refunded"`
+	Net            float64 `json:"net"`
+	UnpaidBalance  float64 `json:"unpaid_balance"`
+	InArrears      int     `json:"in_arrears"`
+}
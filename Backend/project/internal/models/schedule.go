@@ -1,6 +1,10 @@
 package models
 
-import "time"
+import (
+	"fmt"
+	"strings"
+	"time"
+)
 
 type TrainingSchedule struct {
 	ID              int       `json:"id"`
@@ -8,16 +12,145 @@ type TrainingSchedule struct {
 	StartTime       string    `json:"start_time"` // HH:MM
 	DurationMinutes int       `json:"duration_minutes"`
 	Title           string    `json:"title"`
-	Location        string    `json:"location"`
+	Location        string    `json:"location"` // Venue name
+	VenueID         *int      `json:"venue_id"`
+	CoachID         *int      `json:"coach_id"`
 	Description     string    `json:"description"`
+	Level           string    `json:"level"` // Copied onto generated sessions
+	MaxParticipants *int      `json:"max_participants"`
+	RRule           string    `json:"rrule"`       // RFC 5545 RRULE value, e.g. FREQ=WEEKLY;INTERVAL=2;BYDAY=SA
+	ValidFrom       *string   `json:"valid_from"`  // YYYY-MM-DD, also anchors INTERVAL and COUNT
+	ValidUntil      *string   `json:"valid_until"` // YYYY-MM-DD, inclusive
+	ExDates         []string  `json:"exdates"`     // YYYY-MM-DD dates without training
 	CreatedAt       time.Time `json:"created_at"`
 }
 
+var weekdays = map[string]time.Weekday{
+	"sunday": time.Sunday, "monday": time.Monday, "tuesday": time.Tuesday, "wednesday": time.Wednesday,
+	"thursday": time.Thursday, "friday": time.Friday, "saturday": time.Saturday,
+}
+
+// ParseWeekday reads a day name ("Monday", "monday") or RRULE code ("MO");
+// Weekday.String() gives the canonical name stored in day_of_week
+func ParseWeekday(day string) (time.Weekday, bool) {
+	day = strings.TrimSpace(day)
+	if wd, ok := rruleDays[strings.ToUpper(day)]; ok {
+		return wd, true
+	}
+	wd, ok := weekdays[strings.ToLower(day)]
+	return wd, ok
+}
+
+// Rule returns the schedule's recurrence rule, falling back to a weekly rule
+// on DayOfWeek for rows saved without one
+func (s *TrainingSchedule) Rule() (*RecurrenceRule, error) {
+	if s.RRule != "" {
+		return ParseRecurrenceRule(s.RRule)
+	}
+	weekday, ok := ParseWeekday(s.DayOfWeek)
+	if !ok {
+		return nil, fmt.Errorf("schedule %d has an unknown day_of_week: %s", s.ID, s.DayOfWeek)
+	}
+	return WeeklyRule(weekday), nil
+}
+
+// Occurrences returns the start times of the schedule between from and to
+// (inclusive dates), within its validity window and skipping its exdates.
+// Closures are not applied.
+func (s *TrainingSchedule) Occurrences(from, to time.Time) ([]time.Time, error) {
+	rule, err := s.Rule()
+	if err != nil {
+		return nil, err
+	}
+	start, err := time.Parse("15:04", s.StartTime)
+	if err != nil {
+		return nil, err
+	}
+
+	// valid_from (or the creation date) anchors INTERVAL and COUNT
+	anchor := s.CreatedAt
+	if s.ValidFrom != nil {
+		if anchor, err = time.Parse("2006-01-02", *s.ValidFrom); err != nil {
+			return nil, err
+		}
+		if anchor.After(from) {
+			from = anchor
+		}
+	}
+	if s.ValidUntil != nil {
+		until, err := time.Parse("2006-01-02", *s.ValidUntil)
+		if err != nil {
+			return nil, err
+		}
+		if until.Before(to) {
+			to = until
+		}
+	}
+
+	skip := map[string]bool{}
+	for _, d := range s.ExDates {
+		skip[d] = true
+	}
+
+	var times []time.Time
+	for _, d := range rule.Occurrences(anchor, from, to) {
+		if skip[d.Format("2006-01-02")] {
+			continue
+		}
+		times = append(times, time.Date(d.Year(), d.Month(), d.Day(), start.Hour(), start.Minute(), 0, 0, time.UTC))
+	}
+	return times, nil
+}
+
 type CreateScheduleRequest struct {
-	DayOfWeek       string `json:"day_of_week"`
-	StartTime       string `json:"start_time"`
-	DurationMinutes int    `json:"duration_minutes"`
-	Title           string `json:"title"`
-	Location        string `json:"location"`
-	Description     string `json:"description"`
+	DayOfWeek       string   `json:"day_of_week"`
+	StartTime       string   `json:"start_time"`
+	DurationMinutes int      `json:"duration_minutes"`
+	Title           string   `json:"title"`
+	Location        string   `json:"location"` // Venue name, used when venue_id is not set
+	VenueID         *int     `json:"venue_id"`
+	CoachID         *int     `json:"coach_id"`
+	Description     string   `json:"description"`
+	Level           string   `json:"level"` // Defaults to 'all'
+	MaxParticipants *int     `json:"max_participants"`
+	RRule           string   `json:"rrule"` // Defaults to weekly on day_of_week
+	ValidFrom       *string  `json:"valid_from"`
+	ValidUntil      *string  `json:"valid_until"`
+	ExDates         []string `json:"exdates"`
+	Override        bool     `json:"override"` // Admins only: save despite venue/coach conflicts
+}
+
+// TrainingClosure is a period without training: a club-wide holiday, or an
+// exception date range for one schedule when ScheduleID is set
+type TrainingClosure struct {
+	ID         int       `json:"id"`
+	ScheduleID *int      `json:"schedule_id"`
+	StartDate  string    `json:"start_date"` // YYYY-MM-DD
+	EndDate    string    `json:"end_date"`   // YYYY-MM-DD, inclusive
+	Reason     string    `json:"reason"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type CreateClosureRequest struct {
+	ScheduleID *int   `json:"schedule_id"`
+	StartDate  string `json:"start_date"`
+	EndDate    string `json:"end_date"` // Defaults to start_date
+	Reason     string `json:"reason"`
+}
+
+// SessionSyncResult counts what a materialisation run changed
+type SessionSyncResult struct {
+	Created   int                `json:"created"`
+	Updated   int                `json:"updated"`
+	Removed   int                `json:"removed"`
+	Cancelled []CancelledSession `json:"cancelled,omitempty"` // Removed sessions that had bookings
+}
+
+// CancelledSession is an upcoming session removed while athletes were still
+// booked or waitlisted on it; they are told it is cancelled
+type CancelledSession struct {
+	SessionID   int       `json:"session_id"`
+	Title       string    `json:"title"`
+	SessionDate time.Time `json:"session_date"`
+	AthleteIDs  []int     `json:"athlete_ids"`
 }
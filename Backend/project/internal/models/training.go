@@ -4,16 +4,20 @@ import "time"
 
 // TrainingSession represents a training session
 type TrainingSession struct {
-	ID              int       `json:"id"`
-	Title           string    `json:"title"`
-	Description     string    `json:"description"`
-	SessionDate     time.Time `json:"session_date"`
-	DurationMinutes int       `json:"duration_minutes"`
-	Location        string    `json:"location"`
-	CoachID         *int      `json:"coach_id"`
-	MaxParticipants int       `json:"max_participants"`
-	Level           string    `json:"level"` // 'beginner', 'intermediate', 'advanced', 'all'
-	CreatedAt       time.Time `json:"created_at"`
+	ID              int        `json:"id"`
+	Title           string     `json:"title"`
+	Description     string     `json:"description"`
+	SessionDate     time.Time  `json:"session_date"`
+	DurationMinutes int        `json:"duration_minutes"`
+	Location        string     `json:"location"` // Venue name
+	VenueID         *int       `json:"venue_id"`
+	CoachID         *int       `json:"coach_id"`
+	MaxParticipants int        `json:"max_participants"`
+	Level           string     `json:"level"`           // 'beginner', 'intermediate', 'advanced', 'all'
+	ScheduleID      *int       `json:"schedule_id"`     // Set when generated from a TrainingSchedule
+	OccurrenceDate  *time.Time `json:"occurrence_date"` // Schedule slot the session materialises
+	Detached        bool       `json:"detached"`        // Edited by hand, no longer follows its schedule
+	CreatedAt       time.Time  `json:"created_at"`
 }
 
 // CreateTrainingSessionRequest for creating training sessions
@@ -22,9 +26,12 @@ type CreateTrainingSessionRequest struct {
 	Description     string `json:"description"`
 	SessionDate     string `json:"session_date"` // Format: YYYY-MM-DD HH:MM
 	DurationMinutes int    `json:"duration_minutes"`
-	Location        string `json:"location"`
+	Location        string `json:"location"` // Venue name, used when venue_id is not set
+	VenueID         *int   `json:"venue_id"`
+	CoachID         *int   `json:"coach_id"` // Defaults to the user creating the session
 	MaxParticipants int    `json:"max_participants"`
 	Level           string `json:"level"`
+	Override        bool   `json:"override"` // Admins only: save despite venue/coach conflicts
 }
 
 // Attendance represents athlete attendance at training
@@ -34,6 +41,7 @@ type Attendance struct {
 	AthleteID         int       `json:"athlete_id"`
 	Attended          bool      `json:"attended"`
 	Notes             string    `json:"notes"`
+	CheckInMethod     string    `json:"check_in_method"` // 'coach' or 'qr'
 	CreatedAt         time.Time `json:"created_at"`
 	SessionTitle      string    `json:"session_title,omitempty"` // Added for history
 	SessionDate       time.Time `json:"session_date,omitempty"`  // Added for history
@@ -46,3 +54,60 @@ type MarkAttendanceRequest struct {
 	Attended          bool   `json:"attended"`
 	Notes             string `json:"notes"`
 }
+
+// BatchAttendanceRequest marks a whole roster at once
+type BatchAttendanceRequest struct {
+	Records []MarkAttendanceRequest `json:"records"`
+}
+
+// CheckinToken is the rotating code a session shows as a QR code
+type CheckinToken struct {
+	SessionID int       `json:"session_id"`
+	Token     string    `json:"token"`
+	Payload   string    `json:"payload"` // Encoded in the QR code
+	ExpiresAt time.Time `json:"expires_at"`
+	OpensAt   time.Time `json:"opens_at"`
+	ClosesAt  time.Time `json:"closes_at"`
+}
+
+// CheckinRequest is sent by an athlete's app after scanning the QR code
+type CheckinRequest struct {
+	Token     string   `json:"token"`
+	Latitude  *float64 `json:"latitude"`
+	Longitude *float64 `json:"longitude"`
+}
+
+// AttendanceRecord is one past session an athlete was expected at: booked
+// or with attendance marked
+type AttendanceRecord struct {
+	AthleteID   int
+	AthleteName string
+	SessionID   int
+	SessionDate time.Time
+	Level       string
+	Attended    bool
+}
+
+// AttendanceStats summarises an athlete's attendance over a date range
+type AttendanceStats struct {
+	AthleteID     int                `json:"athlete_id"`
+	AthleteName   string             `json:"athlete_name,omitempty"`
+	From          string             `json:"from"` // YYYY-MM-DD
+	To            string             `json:"to"`   // YYYY-MM-DD
+	Sessions      int                `json:"sessions"`
+	Attended      int                `json:"attended"`
+	Rate          float64            `json:"rate"`           // Attended / Sessions, 0 without sessions
+	CurrentStreak int                `json:"current_streak"` // Sessions attended in a row up to the latest one
+	MissedInARow  int                `json:"missed_in_a_row"`
+	LastAttended  *time.Time         `json:"last_attended"`
+	ByLevel       []AttendancePeriod `json:"by_level"`
+	ByMonth       []AttendancePeriod `json:"by_month"` // Every month of the range, for charts
+}
+
+// AttendancePeriod is attendance for one session level or one month ("2026-01")
+type AttendancePeriod struct {
+	Group    string  `json:"group"`
+	Sessions int     `json:"sessions"`
+	Attended int     `json:"attended"`
+	Rate     float64 `json:"rate"`
+}
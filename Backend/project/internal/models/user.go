@@ -10,12 +10,13 @@ import (
 type UserRole string
 
 const (
-	RoleAdmin   UserRole = "admin"
-	RoleCoach   UserRole = "coach"
-	RoleAthlete UserRole = "athlete"
+	RoleAdmin    UserRole = "admin"
+	RoleCoach    UserRole = "coach"
+	RoleAthlete  UserRole = "athlete"
+	RoleGuardian UserRole = "guardian" // Parent or legal guardian of minor athletes
 )
 
-// User represents a system user (admin, coach, or athlete)
+// User represents a system user (admin, coach, athlete or guardian)
 type User struct {
 	ID           int       `json:"id"`
 	Email        string    `json:"email"`
@@ -23,6 +24,7 @@ type User struct {
 	Role         UserRole  `json:"role"`
 	FirstName    string    `json:"first_name"`
 	LastName     string    `json:"last_name"`
+	AthleteID    *int      `json:"athlete_id"` // Athlete profile of this account, if any
 	IsActive     bool      `json:"is_active"`
 	CreatedAt    time.Time `json:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at"`
@@ -37,6 +39,20 @@ type CreateUserRequest struct {
 	LastName  string   `json:"last_name"`
 }
 
+// UnlinkedUser is an athlete account without a linked athlete profile
+type UnlinkedUser struct {
+	UserID     int    `json:"user_id"`
+	Email      string `json:"email"`
+	FirstName  string `json:"first_name"`
+	LastName   string `json:"last_name"`
+	Candidates []int  `json:"candidates"` // Athletes with the same email
+}
+
+// LinkAthleteRequest links an account to an athlete profile
+type LinkAthleteRequest struct {
+	AthleteID int `json:"athlete_id"`
+}
+
 // LoginRequest represents the payload for user login
 type LoginRequest struct {
 	Email    string `json:"email"`
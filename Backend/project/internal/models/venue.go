@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// Venue is a room or hall where trainings take place
+type Venue struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	Capacity  *int      `json:"capacity"` // Maximum athletes at once, nil = unlimited
+	Address   string    `json:"address"`
+	Latitude  *float64  `json:"latitude"`  // Used to check athletes are on site at QR check-in
+	Longitude *float64  `json:"longitude"` // idem
+	Radius    int       `json:"checkin_radius_m"`
+	IsActive  bool      `json:"is_active"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type CreateVenueRequest struct {
+	Name      string   `json:"name"`
+	Capacity  *int     `json:"capacity"`
+	Address   string   `json:"address"`
+	Latitude  *float64 `json:"latitude"`
+	Longitude *float64 `json:"longitude"`
+	Radius    *int     `json:"checkin_radius_m"` // Defaults to 150
+	IsActive  *bool    `json:"is_active"`        // Defaults to true
+}
+
+// BookingConflict is an existing session or schedule that overlaps the one
+// being saved in the same venue or with the same coach
+type BookingConflict struct {
+	Kind       string    `json:"kind"` // 'venue' or 'coach'
+	SessionID  *int      `json:"session_id,omitempty"`
+	ScheduleID *int      `json:"schedule_id,omitempty"`
+	Title      string    `json:"title"`
+	Start      time.Time `json:"start"`
+	End        time.Time `json:"end"`
+}
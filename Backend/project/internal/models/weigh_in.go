@@ -0,0 +1,67 @@
+package models
+
+import "time"
+
+// AgeGroup is a competition age bracket; MaxAge is nil for no upper limit
+type AgeGroup struct {
+	Name   string `json:"name"`
+	MinAge int    `json:"min_age"`
+	MaxAge *int   `json:"max_age"`
+}
+
+// WeightClass is a Sanda weight class for a gender and age group. MaxKG is the
+// inclusive upper limit, nil for the open class.
+type WeightClass struct {
+	ID       int      `json:"id"`
+	Gender   string   `json:"gender"` // 'male', 'female'
+	AgeGroup string   `json:"age_group"`
+	Name     string   `json:"name"`
+	MaxKG    *float64 `json:"max_kg"`
+}
+
+// WeightClassTable is the club's whole weight class configuration
+type WeightClassTable struct {
+	AgeGroups []AgeGroup    `json:"age_groups"`
+	Classes   []WeightClass `json:"classes"`
+}
+
+// WeighIn is a recorded weight. Only official weigh-ins set the athlete's
+// weight category.
+type WeighIn struct {
+	ID             int       `json:"id"`
+	AthleteID      int       `json:"athlete_id"`
+	WeightKG       float64   `json:"weight_kg"`
+	WeighedAt      time.Time `json:"weighed_at"`
+	Official       bool      `json:"official"`
+	EventID        *int      `json:"event_id"`
+	WeightCategory string    `json:"weight_category"`
+	RecordedBy     *int      `json:"recorded_by"`
+	RecordedByName string    `json:"recorded_by_name"`
+	Notes          string    `json:"notes"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// CreateWeighInRequest records a weigh-in; WeighedAt defaults to now
+type CreateWeighInRequest struct {
+	WeightKG  float64 `json:"weight_kg"`
+	WeighedAt string  `json:"weighed_at"` // Format: YYYY-MM-DD HH:MM
+	Official  *bool   `json:"official"`   // Defaults to true
+	EventID   *int    `json:"event_id"`
+	Notes     string  `json:"notes"`
+}
+
+// WeightDrift is an athlete registered in an upcoming event whose latest
+// official weigh-in no longer fits the registered class
+type WeightDrift struct {
+	EventID            int        `json:"event_id"`
+	EventTitle         string     `json:"event_title"`
+	EventDate          time.Time  `json:"event_date"`
+	AthleteID          int        `json:"athlete_id"`
+	AthleteName        string     `json:"athlete_name"`
+	Gender             string     `json:"gender"`
+	DateOfBirth        *time.Time `json:"-"`
+	RegisteredCategory string     `json:"registered_category"`
+	CurrentCategory    string     `json:"current_category"`
+	WeightKG           float64    `json:"weight_kg"`
+	WeighedAt          time.Time  `json:"weighed_at"`
+}
@@ -1,8 +1,12 @@
 package repository
 
 import (
-	"east-eagles/backend/internal/models"
 	"database/sql"
+	"time"
+
+	"east-eagles/backend/internal/models"
+
+	"github.com/lib/pq"
 )
 
 type AnnouncementRepository struct {
@@ -13,93 +17,209 @@ func NewAnnouncementRepository(db *sql.DB) *AnnouncementRepository {
 	return &AnnouncementRepository{db: db}
 }
 
-func (r *AnnouncementRepository) GetAll() ([]models.Announcement, error) {
-	query := `
-		SELECT id, title, content, published_date, is_pinned, created_at
-		FROM announcements
-		ORDER BY is_pinned DESC, published_date DESC
-	`
+const announcementColumns = `an.id, an.title, an.content, an.published_date, an.expires_at, an.is_pinned,
+	an.target_roles, an.target_skill_levels, an.target_weight_categories, an.target_athlete_ids,
+	an.created_by, an.created_at, an.updated_at`
+
+// announcementAudience is true when the user u is in the audience of the
+// announcement an. Athlete filters match the user's own athlete profile or,
+// for guardians, one of their wards.
+const announcementAudience = `(
+	(cardinality(an.target_roles) = 0 OR u.role = ANY(an.target_roles))
+	AND (
+		(cardinality(an.target_athlete_ids) = 0 AND cardinality(an.target_skill_levels) = 0
+		 AND cardinality(an.target_weight_categories) = 0)
+		OR EXISTS (
+			SELECT 1 FROM athletes ta
+			WHERE (ta.id = u.athlete_id OR ta.id IN (SELECT g.athlete_id FROM athlete_guardians g WHERE g.user_id = u.id))
+			  AND CASE WHEN cardinality(an.target_athlete_ids) > 0 THEN ta.id = ANY(an.target_athlete_ids)
+			      ELSE (cardinality(an.target_skill_levels) = 0 OR ta.skill_level = ANY(an.target_skill_levels))
+			       AND (cardinality(an.target_weight_categories) = 0 OR ta.weight_category = ANY(an.target_weight_categories))
+			      END
+		)
+	)
+)`
+
+// scanAnnouncement scans announcementColumns and sets the status against now
+func scanAnnouncement(row rowScanner, now time.Time) (*models.Announcement, error) {
+	a := &models.Announcement{Attachments: []models.AnnouncementAttachment{}}
+	var athleteIDs []int64
+	if err := row.Scan(
+		&a.ID, &a.Title, &a.Content, &a.PublishedDate, &a.ExpiresAt, &a.IsPinned,
+		pq.Array(&a.TargetRoles), pq.Array(&a.TargetSkillLevels), pq.Array(&a.TargetWeightCategories), pq.Array(&athleteIDs),
+		&a.CreatedBy, &a.CreatedAt, &a.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	a.TargetAthleteIDs = []int{}
+	for _, id := range athleteIDs {
+		a.TargetAthleteIDs = append(a.TargetAthleteIDs, int(id))
+	}
 
-	rows, err := r.db.Query(query)
+	switch {
+	case now.Before(a.PublishedDate):
+		a.Status = models.AnnouncementScheduled
+	case a.ExpiresAt != nil && !now.Before(*a.ExpiresAt):
+		a.Status = models.AnnouncementExpired
+	default:
+		a.Status = models.AnnouncementPublished
+	}
+	return a, nil
+}
+
+// loadAttachments fills in the attachments of announcements
+func (r *AnnouncementRepository) loadAttachments(announcements []*models.Announcement) error {
+	if len(announcements) == 0 {
+		return nil
+	}
+	byID := map[int]*models.Announcement{}
+	ids := make([]int64, 0, len(announcements))
+	for _, a := range announcements {
+		byID[a.ID] = a
+		ids = append(ids, int64(a.ID))
+	}
+
+	rows, err := r.db.Query(`
+		SELECT id, announcement_id, file_name, file_url, COALESCE(mime_type, ''), file_size_bytes, uploaded_by, uploaded_at
+		FROM announcement_attachments
+		WHERE announcement_id = ANY($1)
+		ORDER BY uploaded_at, id
+	`, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var f models.AnnouncementAttachment
+		if err := rows.Scan(&f.ID, &f.AnnouncementID, &f.FileName, &f.FileURL, &f.MimeType,
+			&f.FileSizeBytes, &f.UploadedBy, &f.UploadedAt); err != nil {
+			return err
+		}
+		byID[f.AnnouncementID].Attachments = append(byID[f.AnnouncementID].Attachments, f)
+	}
+	return rows.Err()
+}
+
+// GetAll returns every announcement, scheduled and expired included, with
+// how many users of its audience read it
+func (r *AnnouncementRepository) GetAll() ([]*models.Announcement, error) {
+	rows, err := r.db.Query(`
+		SELECT ` + announcementColumns + `,
+		       (SELECT COUNT(*) FROM users u WHERE u.is_active AND ` + announcementAudience + `),
+		       (SELECT COUNT(*) FROM announcement_reads ar JOIN users u ON u.id = ar.user_id
+		        WHERE ar.announcement_id = an.id AND ` + announcementAudience + `)
+		FROM announcements an
+		ORDER BY an.is_pinned DESC, an.published_date DESC
+	`)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var announcements []models.Announcement
+	now := models.WallClockNow()
+	announcements := []*models.Announcement{}
 	for rows.Next() {
-		var a models.Announcement
-		err := rows.Scan(
-			&a.ID, &a.Title, &a.Content, &a.PublishedDate,
-			&a.IsPinned, &a.CreatedAt,
-		)
+		var recipients, reads int
+		a, err := scanAnnouncement(withExtra{rows, []interface{}{&recipients, &reads}}, now)
 		if err != nil {
 			return nil, err
 		}
+		a.RecipientCount, a.ReadCount = &recipients, &reads
 		announcements = append(announcements, a)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
 
-	return announcements, nil
+	return announcements, r.loadAttachments(announcements)
 }
 
+// GetByID returns an announcement whatever its status or audience
 func (r *AnnouncementRepository) GetByID(id int) (*models.Announcement, error) {
-	query := `
-		SELECT id, title, content, published_date, is_pinned, created_at
-		FROM announcements WHERE id = $1
-	`
-
-	var a models.Announcement
-	err := r.db.QueryRow(query, id).Scan(
-		&a.ID, &a.Title, &a.Content, &a.PublishedDate,
-		&a.IsPinned, &a.CreatedAt,
-	)
+	a, err := scanAnnouncement(r.db.QueryRow(`
+		SELECT `+announcementColumns+` FROM announcements an WHERE an.id = $1
+	`, id), models.WallClockNow())
+	if err != nil {
+		return nil, err
+	}
+	return a, r.loadAttachments([]*models.Announcement{a})
+}
 
+// GetForUser returns the published announcements addressed to a user, pinned
+// first; unreadOnly leaves out those they read
+func (r *AnnouncementRepository) GetForUser(userID int, unreadOnly bool) ([]*models.Announcement, error) {
+	now := models.WallClockNow()
+	rows, err := r.db.Query(`
+		SELECT `+announcementColumns+`,
+		       EXISTS (SELECT 1 FROM announcement_reads ar WHERE ar.announcement_id = an.id AND ar.user_id = u.id)
+		FROM announcements an
+		JOIN users u ON u.id = $1
+		WHERE an.published_date <= $2 AND (an.expires_at IS NULL OR an.expires_at > $2)
+		  AND `+announcementAudience+`
+		ORDER BY an.is_pinned DESC, an.published_date DESC
+	`, userID, now)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
-	return &a, nil
-}
+	announcements := []*models.Announcement{}
+	for rows.Next() {
+		var read bool
+		a, err := scanAnnouncement(withExtra{rows, []interface{}{&read}}, now)
+		if err != nil {
+			return nil, err
+		}
+		a.IsRead = read
+		if !unreadOnly || !read {
+			announcements = append(announcements, a)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
 
-func (r *AnnouncementRepository) Create(req *models.CreateAnnouncementRequest) (*models.Announcement, error) {
-	query := `
-		INSERT INTO announcements (title, content, is_pinned)
-		VALUES ($1, $2, $3)
-		RETURNING id, title, content, published_date, is_pinned, created_at
-	`
-
-	var a models.Announcement
-	err := r.db.QueryRow(query, req.Title, req.Content, req.IsPinned).Scan(
-		&a.ID, &a.Title, &a.Content, &a.PublishedDate,
-		&a.IsPinned, &a.CreatedAt,
-	)
+	return announcements, r.loadAttachments(announcements)
+}
 
+func (r *AnnouncementRepository) Create(req *models.CreateAnnouncementRequest, publishedDate time.Time, expiresAt *time.Time, createdBy int) (*models.Announcement, error) {
+	var id int
+	err := r.db.QueryRow(`
+		INSERT INTO announcements (title, content, is_pinned, published_date, expires_at,
+		                           target_roles, target_skill_levels, target_weight_categories, target_athlete_ids, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id
+	`, req.Title, req.Content, req.IsPinned, publishedDate, expiresAt,
+		pq.Array(nonNil(req.TargetRoles)), pq.Array(nonNil(req.TargetSkillLevels)),
+		pq.Array(nonNil(req.TargetWeightCategories)), pq.Array(int64s(req.TargetAthleteIDs)), createdBy,
+	).Scan(&id)
 	if err != nil {
 		return nil, err
 	}
-
-	return &a, nil
+	return r.GetByID(id)
 }
 
-func (r *AnnouncementRepository) Update(id int, req *models.CreateAnnouncementRequest) (*models.Announcement, error) {
-	query := `
+func (r *AnnouncementRepository) Update(id int, req *models.CreateAnnouncementRequest, publishedDate time.Time, expiresAt *time.Time) (*models.Announcement, error) {
+	res, err := r.db.Exec(`
 		UPDATE announcements
-		SET title = $1, content = $2, is_pinned = $3
-		WHERE id = $4
-		RETURNING id, title, content, published_date, is_pinned, created_at
-	`
-
-	var a models.Announcement
-	err := r.db.QueryRow(query, req.Title, req.Content, req.IsPinned, id).Scan(
-		&a.ID, &a.Title, &a.Content, &a.PublishedDate,
-		&a.IsPinned, &a.CreatedAt,
+		SET title = $1, content = $2, is_pinned = $3, published_date = $4, expires_at = $5,
+		    target_roles = $6, target_skill_levels = $7, target_weight_categories = $8, target_athlete_ids = $9,
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE id = $10
+	`, req.Title, req.Content, req.IsPinned, publishedDate, expiresAt,
+		pq.Array(nonNil(req.TargetRoles)), pq.Array(nonNil(req.TargetSkillLevels)),
+		pq.Array(nonNil(req.TargetWeightCategories)), pq.Array(int64s(req.TargetAthleteIDs)), id,
 	)
-
 	if err != nil {
 		return nil, err
 	}
-
-	return &a, nil
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return r.GetByID(id)
 }
 
 func (r *AnnouncementRepository) Delete(id int) error {
@@ -120,3 +240,80 @@ func (r *AnnouncementRepository) Delete(id int) error {
 
 	return nil
 }
+
+// AddAttachment records a file uploaded for an announcement
+func (r *AnnouncementRepository) AddAttachment(f *models.AnnouncementAttachment) error {
+	err := r.db.QueryRow(`
+		INSERT INTO announcement_attachments (announcement_id, file_name, file_url, mime_type, file_size_bytes, uploaded_by)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6)
+		RETURNING id, uploaded_at
+	`, f.AnnouncementID, f.FileName, f.FileURL, f.MimeType, f.FileSizeBytes, f.UploadedBy).Scan(&f.ID, &f.UploadedAt)
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23503" {
+		return sql.ErrNoRows
+	}
+	return err
+}
+
+func (r *AnnouncementRepository) DeleteAttachment(announcementID, attachmentID int) error {
+	res, err := r.db.Exec(`
+		DELETE FROM announcement_attachments WHERE id = $1 AND announcement_id = $2
+	`, attachmentID, announcementID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// MarkRead records that a user read a published announcement addressed to
+// them; sql.ErrNoRows when it is not
+func (r *AnnouncementRepository) MarkRead(announcementID, userID int) error {
+	var ok bool
+	err := r.db.QueryRow(`
+		SELECT `+announcementAudience+`
+		FROM announcements an
+		JOIN users u ON u.id = $2
+		WHERE an.id = $1 AND an.published_date <= $3 AND (an.expires_at IS NULL OR an.expires_at > $3)
+	`, announcementID, userID, models.WallClockNow()).Scan(&ok)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return sql.ErrNoRows
+	}
+
+	_, err = r.db.Exec(`
+		INSERT INTO announcement_reads (announcement_id, user_id) VALUES ($1, $2)
+		ON CONFLICT (announcement_id, user_id) DO NOTHING
+	`, announcementID, userID)
+	return err
+}
+
+// GetReaders lists the active users an announcement is addressed to with when
+// they read it, those who have not first; unreadOnly keeps only them
+func (r *AnnouncementRepository) GetReaders(announcementID int, unreadOnly bool) ([]*models.AnnouncementReader, error) {
+	rows, err := r.db.Query(`
+		SELECT u.id, u.email, COALESCE(u.first_name, ''), COALESCE(u.last_name, ''), u.role, ar.read_at
+		FROM announcements an
+		JOIN users u ON u.is_active AND `+announcementAudience+`
+		LEFT JOIN announcement_reads ar ON ar.announcement_id = an.id AND ar.user_id = u.id
+		WHERE an.id = $1 AND (NOT $2 OR ar.read_at IS NULL)
+		ORDER BY ar.read_at NULLS FIRST, u.last_name, u.first_name
+	`, announcementID, unreadOnly)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	readers := []*models.AnnouncementReader{}
+	for rows.Next() {
+		u := &models.AnnouncementReader{}
+		if err := rows.Scan(&u.UserID, &u.Email, &u.FirstName, &u.LastName, &u.Role, &u.ReadAt); err != nil {
+			return nil, err
+		}
+		readers = append(readers, u)
+	}
+	return readers, rows.Err()
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"east-eagles/backend/internal/models"
+	"context"
 	"database/sql"
 	"fmt"
 )
@@ -26,19 +27,17 @@ func (r *AthleteRepository) GetAll() ([]models.Athlete, error) {
 		       a.membership_status, a.approved_by, a.approved_at, COALESCE(a.rejection_reason, ''),
 		       COALESCE(a.medical_conditions, ''), COALESCE(a.allergies, ''), COALESCE(a.blood_type, ''), COALESCE(a.photo_url, ''),
 		       p.end_date AS payment_end_date,
-		       CASE 
-		           WHEN p.end_date IS NULL THEN false
-		           WHEN p.end_date >= CURRENT_DATE THEN true
-		           ELSE false
-		       END AS payment_valid
+		       COALESCE(p.covers_today, false) AS payment_valid,
+		       b.balance AS payment_balance
 		FROM athletes a
 		LEFT JOIN LATERAL (
-		    SELECT end_date
+		    -- A later period does not make the athlete valid if today falls in a gap
+		    SELECT MAX(end_date) AS end_date,
+		           BOOL_OR(CURRENT_DATE BETWEEN start_date AND end_date) AS covers_today
 		    FROM payments
-		    WHERE athlete_id = a.id
-		    ORDER BY end_date DESC
-		    LIMIT 1
+		    WHERE athlete_id = a.id AND kind = 'period' AND status = 'active'
 		) p ON true
+		LEFT JOIN (` + athleteBalances + `) b ON b.athlete_id = a.id
 		ORDER BY a.created_at DESC
 	`
 
@@ -53,6 +52,7 @@ func (r *AthleteRepository) GetAll() ([]models.Athlete, error) {
 		var a models.Athlete
 		var paymentEndDate sql.NullString
 		var paymentValid sql.NullBool
+		var paymentBalance sql.NullFloat64
 
 		err := rows.Scan(
 			&a.ID, &a.FirstName, &a.LastName, &a.Email, &a.Phone,
@@ -63,7 +63,7 @@ func (r *AthleteRepository) GetAll() ([]models.Athlete, error) {
 			&a.EmergencyContactName, &a.EmergencyContactPhone, &a.EmergencyContactRelation,
 			&a.MembershipStatus, &a.ApprovedBy, &a.ApprovedAt, &a.RejectionReason,
 			&a.MedicalConditions, &a.Allergies, &a.BloodType, &a.PhotoURL,
-			&paymentEndDate, &paymentValid,
+			&paymentEndDate, &paymentValid, &paymentBalance,
 		)
 		if err != nil {
 			return nil, err
@@ -76,6 +76,9 @@ func (r *AthleteRepository) GetAll() ([]models.Athlete, error) {
 		if paymentValid.Valid {
 			a.PaymentValid = &paymentValid.Bool
 		}
+		if paymentBalance.Valid {
+			a.PaymentBalance = &paymentBalance.Float64
+		}
 
 		athletes = append(athletes, a)
 	}
@@ -238,6 +241,18 @@ func (r *AthleteRepository) Create(req *models.CreateAthleteRequest) (*models.At
 
 // Approve approves an athlete
 func (r *AthleteRepository) Approve(athleteID int, adminID int) error {
+	// Minors are only approved once a guardian is linked
+	missing, err := needsGuardian(r.db, athleteID)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("athlete not found")
+	}
+	if err != nil {
+		return err
+	}
+	if missing {
+		return ErrGuardianRequired
+	}
+
 	query := `
 		UPDATE athletes
 		SET membership_status = 'approved',
@@ -260,7 +275,14 @@ func (r *AthleteRepository) Approve(athleteID int, adminID int) error {
 		return fmt.Errorf("athlete not found")
 	}
 
-	return nil
+	// Link the account that registered this athlete, if it is not linked yet
+	_, err = r.db.Exec(`
+		UPDATE users SET athlete_id = $1
+		WHERE athlete_id IS NULL AND role = 'athlete'
+		  AND lower(email) = (SELECT lower(email) FROM athletes WHERE id = $1)
+		  AND NOT EXISTS (SELECT 1 FROM users WHERE athlete_id = $1)
+	`, athleteID)
+	return err
 }
 
 // Reject rejects an athlete
@@ -428,3 +450,44 @@ func (r *AthleteRepository) GetStats() (*models.AthleteStats, error) {
 
 	return &stats, nil
 }
+
+// SyncMembershipExpiry marks approved athletes whose last paid period ended
+// more than days ago as expired, and approves expired athletes again once a
+// payment covers them. It returns how many expired and renewed.
+func (r *AthleteRepository) SyncMembershipExpiry(ctx context.Context, days int) (int, int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer tx.Rollback()
+
+	lastEnd := `(SELECT MAX(p.end_date) FROM payments p
+		WHERE p.athlete_id = athletes.id AND p.kind = 'period' AND p.status = 'active')`
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE athletes SET membership_status = 'expired'
+		WHERE membership_status = 'approved' AND is_active = true
+		  AND `+lastEnd+` + $1::int < CURRENT_DATE
+	`, days)
+	if err != nil {
+		return 0, 0, err
+	}
+	expired, err := res.RowsAffected()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	res, err = tx.ExecContext(ctx, `
+		UPDATE athletes SET membership_status = 'approved'
+		WHERE membership_status = 'expired'
+		  AND `+lastEnd+` + $1::int >= CURRENT_DATE
+	`, days)
+	if err != nil {
+		return 0, 0, err
+	}
+	renewed, err := res.RowsAffected()
+	if err != nil {
+		return 0, 0, err
+	}
+	return int(expired), int(renewed), tx.Commit()
+}
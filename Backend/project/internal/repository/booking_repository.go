@@ -0,0 +1,378 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"east-eagles/backend/internal/models"
+)
+
+type BookingRepository struct {
+	db *sql.DB
+}
+
+func NewBookingRepository(db *sql.DB) *BookingRepository {
+	return &BookingRepository{db: db}
+}
+
+const bookingColumns = `id, training_session_id, athlete_id, status, booked_at, promoted_at, cancelled_at`
+
+func scanBooking(row rowScanner) (*models.SessionBooking, error) {
+	b := &models.SessionBooking{}
+	err := row.Scan(&b.ID, &b.TrainingSessionID, &b.AthleteID, &b.Status, &b.BookedAt, &b.PromotedAt, &b.CancelledAt)
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// withExtra scans the columns selected after a shared column list into extra
+type withExtra struct {
+	row   rowScanner
+	extra []interface{}
+}
+
+func (w withExtra) Scan(dest ...interface{}) error {
+	return w.row.Scan(append(dest, w.extra...)...)
+}
+
+// sessionCapacity is the lower of max_participants (0 = unset) and the venue
+// capacity; NULL when neither is set
+const sessionCapacity = `LEAST(NULLIF(t.max_participants, 0), (SELECT v.capacity FROM venues v WHERE v.id = t.venue_id))`
+
+// LevelAllowed reports whether an athlete of skillLevel may book a session of level
+func LevelAllowed(level, skillLevel string) bool {
+	return level == "" || level == "all" || level == skillLevel
+}
+
+// lockSession locks a session row so bookings on it are serialised, and
+// returns its start, level and capacity
+func lockSession(tx *sql.Tx, sessionID int) (time.Time, string, sql.NullInt64, error) {
+	var start time.Time
+	var level string
+	var capacity sql.NullInt64
+	err := tx.QueryRow(`
+		SELECT t.session_date, COALESCE(t.level, 'all'), `+sessionCapacity+`
+		FROM training_sessions t
+		WHERE t.id = $1
+		FOR UPDATE
+	`, sessionID).Scan(&start, &level, &capacity)
+	return start, level, capacity, err
+}
+
+// bookingOpen reports whether start is still after the cut-off
+func bookingOpen(start time.Time, cutoff time.Duration) bool {
+	return models.WallClockNow().Add(cutoff).Before(start)
+}
+
+// Book gives the athlete a place in the session, or a waitlist entry when it
+// is full. Booking again returns the existing booking.
+func (r *BookingRepository) Book(sessionID int, athlete *models.Athlete, cutoff time.Duration) (*models.SessionBooking, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	start, level, capacity, err := lockSession(tx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if !bookingOpen(start, cutoff) {
+		return nil, &BookingValidationError{Message: "Les réservations sont closes pour cette séance"}
+	}
+	if !LevelAllowed(level, athlete.SkillLevel) {
+		return nil, &BookingValidationError{Message: "Cette séance n'est pas ouverte à votre niveau"}
+	}
+
+	b, err := scanBooking(tx.QueryRow(`
+		SELECT `+bookingColumns+` FROM session_bookings
+		WHERE training_session_id = $1 AND athlete_id = $2
+	`, sessionID, athlete.ID))
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	if b == nil || b.Status == models.BookingCancelled {
+		var booked int
+		if err := tx.QueryRow(`
+			SELECT COUNT(*) FROM session_bookings WHERE training_session_id = $1 AND status = 'booked'
+		`, sessionID).Scan(&booked); err != nil {
+			return nil, err
+		}
+		status := models.BookingBooked
+		if capacity.Valid && int64(booked) >= capacity.Int64 {
+			status = models.BookingWaitlisted
+		}
+
+		b, err = scanBooking(tx.QueryRow(`
+			INSERT INTO session_bookings (training_session_id, athlete_id, status)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (training_session_id, athlete_id) DO UPDATE
+			SET status = EXCLUDED.status, booked_at = CURRENT_TIMESTAMP, promoted_at = NULL, cancelled_at = NULL
+			RETURNING `+bookingColumns,
+			sessionID, athlete.ID, status,
+		))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := waitlistPosition(tx, b); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// waitlistPosition sets the booking's place in the waitlist
+func waitlistPosition(tx *sql.Tx, b *models.SessionBooking) error {
+	if b.Status != models.BookingWaitlisted {
+		return nil
+	}
+	return tx.QueryRow(`
+		SELECT COUNT(*) FROM session_bookings
+		WHERE training_session_id = $1 AND status = 'waitlisted' AND (booked_at, id) <= ($2, $3)
+	`, b.TrainingSessionID, b.BookedAt, b.ID).Scan(&b.WaitlistPosition)
+}
+
+// Cancel cancels the athlete's booking or waitlist entry. A freed place goes
+// to the first athletes on the waitlist, whose ids are returned.
+func (r *BookingRepository) Cancel(sessionID, athleteID int, cutoff time.Duration) ([]int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	start, _, _, err := lockSession(tx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if !bookingOpen(start, cutoff) {
+		return nil, &BookingValidationError{Message: "Il est trop tard pour annuler cette réservation"}
+	}
+
+	var id int
+	var status string
+	err = tx.QueryRow(`
+		SELECT id, status FROM session_bookings
+		WHERE training_session_id = $1 AND athlete_id = $2 AND status <> 'cancelled'
+	`, sessionID, athleteID).Scan(&id, &status)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(`
+		UPDATE session_bookings SET status = 'cancelled', cancelled_at = CURRENT_TIMESTAMP WHERE id = $1
+	`, id); err != nil {
+		return nil, err
+	}
+
+	var promoted []int
+	if status == models.BookingBooked {
+		if promoted, err = promoteWaitlist(tx, sessionID); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return promoted, nil
+}
+
+// PromoteWaitlist fills free places of an upcoming session from its waitlist,
+// e.g. after its capacity was raised
+func (r *BookingRepository) PromoteWaitlist(sessionID int) ([]int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	start, _, _, err := lockSession(tx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if !start.After(models.WallClockNow()) {
+		return nil, nil
+	}
+	promoted, err := promoteWaitlist(tx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return promoted, nil
+}
+
+// promoteWaitlist books waitlisted athletes, first come first served, while
+// places are free. The session row must be locked.
+func promoteWaitlist(tx *sql.Tx, sessionID int) ([]int, error) {
+	var capacity sql.NullInt64
+	var booked int64
+	err := tx.QueryRow(`
+		SELECT `+sessionCapacity+`,
+		       (SELECT COUNT(*) FROM session_bookings b WHERE b.training_session_id = t.id AND b.status = 'booked')
+		FROM training_sessions t
+		WHERE t.id = $1
+	`, sessionID).Scan(&capacity, &booked)
+	if err != nil {
+		return nil, err
+	}
+
+	// A NULL limit promotes everyone
+	var limit interface{}
+	if capacity.Valid {
+		if booked >= capacity.Int64 {
+			return nil, nil
+		}
+		limit = capacity.Int64 - booked
+	}
+
+	rows, err := tx.Query(`
+		UPDATE session_bookings SET status = 'booked', promoted_at = CURRENT_TIMESTAMP
+		WHERE id IN (
+			SELECT id FROM session_bookings
+			WHERE training_session_id = $1 AND status = 'waitlisted'
+			ORDER BY booked_at, id
+			LIMIT $2
+		)
+		RETURNING athlete_id
+	`, sessionID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var promoted []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		promoted = append(promoted, id)
+	}
+	return promoted, rows.Err()
+}
+
+// GetBookable returns upcoming sessions open to the athlete's level, with
+// their fill and the athlete's own booking status
+func (r *BookingRepository) GetBookable(athlete *models.Athlete, cutoff time.Duration) ([]*models.BookableSession, error) {
+	rows, err := r.db.Query(`
+		SELECT `+sessionColumns+`,
+		       `+sessionCapacity+`,
+		       (SELECT COUNT(*) FROM session_bookings b WHERE b.training_session_id = t.id AND b.status = 'booked'),
+		       (SELECT COUNT(*) FROM session_bookings b WHERE b.training_session_id = t.id AND b.status = 'waitlisted'),
+		       COALESCE((SELECT b.status FROM session_bookings b
+		                 WHERE b.training_session_id = t.id AND b.athlete_id = $1 AND b.status <> 'cancelled'), ''),
+		       (SELECT COUNT(*) FROM session_bookings b, session_bookings me
+		        WHERE me.training_session_id = t.id AND me.athlete_id = $1 AND me.status = 'waitlisted'
+		          AND b.training_session_id = t.id AND b.status = 'waitlisted'
+		          AND (b.booked_at, b.id) <= (me.booked_at, me.id))
+		FROM training_sessions t
+		WHERE t.session_date >= $2
+		  AND (COALESCE(t.level, '') IN ('', 'all') OR t.level = $3)
+		ORDER BY t.session_date
+	`, athlete.ID, models.WallClockNow(), athlete.SkillLevel)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []*models.BookableSession{}
+	for rows.Next() {
+		b := &models.BookableSession{}
+		var capacity sql.NullInt64
+		s, err := scanSession(withExtra{rows, []interface{}{
+			&capacity, &b.BookedCount, &b.WaitlistCount, &b.MyStatus, &b.WaitlistPosition,
+		}})
+		if err != nil {
+			return nil, err
+		}
+		b.TrainingSession = *s
+		if capacity.Valid {
+			c := int(capacity.Int64)
+			b.Capacity = &c
+		}
+		b.BookingOpen = bookingOpen(s.SessionDate, cutoff)
+		sessions = append(sessions, b)
+	}
+	return sessions, rows.Err()
+}
+
+// GetByAthlete returns the athlete's current bookings for upcoming sessions
+func (r *BookingRepository) GetByAthlete(athleteID int) ([]*models.SessionBooking, error) {
+	rows, err := r.db.Query(`
+		SELECT b.id, b.training_session_id, b.athlete_id, b.status, b.booked_at, b.promoted_at, b.cancelled_at,
+		       t.title, t.session_date,
+		       CASE WHEN b.status = 'waitlisted' THEN (
+		           SELECT COUNT(*) FROM session_bookings w
+		           WHERE w.training_session_id = b.training_session_id AND w.status = 'waitlisted'
+		             AND (w.booked_at, w.id) <= (b.booked_at, b.id)
+		       ) ELSE 0 END
+		FROM session_bookings b
+		JOIN training_sessions t ON t.id = b.training_session_id
+		WHERE b.athlete_id = $1 AND b.status <> 'cancelled' AND t.session_date >= $2
+		ORDER BY t.session_date
+	`, athleteID, models.WallClockNow())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	bookings := []*models.SessionBooking{}
+	for rows.Next() {
+		var title string
+		var date time.Time
+		var position int
+		b, err := scanBooking(withExtra{rows, []interface{}{&title, &date, &position}})
+		if err != nil {
+			return nil, err
+		}
+		b.SessionTitle, b.SessionDate, b.WaitlistPosition = title, &date, position
+		bookings = append(bookings, b)
+	}
+	return bookings, rows.Err()
+}
+
+// GetBySession returns a session's bookings and waitlist with athlete names
+// and any attendance already marked, for the coach's roll call
+func (r *BookingRepository) GetBySession(sessionID int) ([]*models.SessionBooking, error) {
+	rows, err := r.db.Query(`
+		SELECT b.id, b.training_session_id, b.athlete_id, b.status, b.booked_at, b.promoted_at, b.cancelled_at,
+		       a.first_name || ' ' || a.last_name, att.attended
+		FROM session_bookings b
+		JOIN athletes a ON a.id = b.athlete_id
+		LEFT JOIN attendance att ON att.training_session_id = b.training_session_id AND att.athlete_id = b.athlete_id
+		WHERE b.training_session_id = $1 AND b.status <> 'cancelled'
+		ORDER BY b.status, b.booked_at, b.id
+	`, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	bookings := []*models.SessionBooking{}
+	position := 0
+	for rows.Next() {
+		var name string
+		var attended sql.NullBool
+		b, err := scanBooking(withExtra{rows, []interface{}{&name, &attended}})
+		if err != nil {
+			return nil, err
+		}
+		b.AthleteName = name
+		if attended.Valid {
+			b.Attended = &attended.Bool
+		}
+		if b.Status == models.BookingWaitlisted {
+			position++
+			b.WaitlistPosition = position
+		}
+		bookings = append(bookings, b)
+	}
+	return bookings, rows.Err()
+}
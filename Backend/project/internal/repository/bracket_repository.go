@@ -0,0 +1,366 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+
+	"east-eagles/backend/internal/models"
+
+	"github.com/lib/pq"
+)
+
+var (
+	// ErrBracketStarted is returned when redrawing an event whose bouts have begun
+	ErrBracketStarted = errors.New("brackets already have results")
+	// ErrBoutLocked is returned when changing a bout whose winner already fought again
+	ErrBoutLocked = errors.New("the next bout already has a result")
+)
+
+// BoutResultError is returned when a bout result is not valid for the bout
+type BoutResultError struct {
+	Message string
+}
+
+func (e *BoutResultError) Error() string {
+	return e.Message
+}
+
+type BracketRepository struct {
+	db *sql.DB
+}
+
+func NewBracketRepository(db *sql.DB) *BracketRepository {
+	return &BracketRepository{db: db}
+}
+
+const bracketColumns = `b.id, b.event_id, b.weight_category, b.gender, b.format, b.status, b.created_at`
+
+func scanBracket(row rowScanner) (*models.Bracket, error) {
+	b := &models.Bracket{Entries: []models.BracketEntry{}, Bouts: []models.Bout{}}
+	if err := row.Scan(&b.ID, &b.EventID, &b.WeightCategory, &b.Gender, &b.Format, &b.Status, &b.CreatedAt); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+const boutColumns = `o.id, o.bracket_id, o.round, o.position, o.red_athlete_id, o.blue_athlete_id,
+	COALESCE(red.first_name || ' ' || red.last_name, ''), COALESCE(blue.first_name || ' ' || blue.last_name, ''),
+	o.status, o.winner_athlete_id, o.method, o.red_rounds_won, o.blue_rounds_won, o.red_points, o.blue_points,
+	o.next_bout_id, o.next_slot, o.completed_at`
+
+const boutJoins = `
+	LEFT JOIN athletes red ON red.id = o.red_athlete_id
+	LEFT JOIN athletes blue ON blue.id = o.blue_athlete_id`
+
+func scanBout(row rowScanner) (*models.Bout, error) {
+	o := &models.Bout{}
+	if err := row.Scan(&o.ID, &o.BracketID, &o.Round, &o.Position, &o.RedAthleteID, &o.BlueAthleteID,
+		&o.RedName, &o.BlueName, &o.Status, &o.WinnerAthleteID, &o.Method, &o.RedRoundsWon, &o.BlueRoundsWon,
+		&o.RedPoints, &o.BluePoints, &o.NextBoutID, &o.NextSlot, &o.CompletedAt); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// GetDrawPools groups an event's registered athletes by weight category and gender
+func (r *BracketRepository) GetDrawPools(eventID int) ([]models.DrawPool, error) {
+	rows, err := r.db.Query(`
+		SELECT reg.weight_category, COALESCE(a.gender, ''), array_agg(reg.athlete_id ORDER BY reg.registered_at, reg.id)
+		FROM event_registrations reg
+		JOIN athletes a ON a.id = reg.athlete_id
+		WHERE reg.event_id = $1 AND reg.status = 'registered'
+		GROUP BY reg.weight_category, COALESCE(a.gender, '')
+		ORDER BY 1, 2
+	`, eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var pools []models.DrawPool
+	for rows.Next() {
+		var pool models.DrawPool
+		var ids []int64
+		if err := rows.Scan(&pool.WeightCategory, &pool.Gender, pq.Array(&ids)); err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			pool.AthleteIDs = append(pool.AthleteIDs, int(id))
+		}
+		pools = append(pools, pool)
+	}
+	return pools, rows.Err()
+}
+
+// ReplaceBrackets stores a new draw for the event in place of the previous
+// one, as long as no bout has been fought. placings holds the final placings
+// of brackets already decided by byes (nil for the others), keyed like brackets.
+func (r *BracketRepository) ReplaceBrackets(eventID int, brackets []*models.Bracket, placings []map[int]int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var fought int
+	err = tx.QueryRow(`
+		SELECT COUNT(*) FROM bouts o JOIN brackets b ON b.id = o.bracket_id
+		WHERE b.event_id = $1 AND o.status = 'completed'
+	`, eventID).Scan(&fought)
+	if err != nil {
+		return err
+	}
+	if fought > 0 {
+		return ErrBracketStarted
+	}
+
+	if _, err := tx.Exec(`DELETE FROM brackets WHERE event_id = $1`, eventID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`UPDATE event_registrations SET placing = NULL WHERE event_id = $1`, eventID); err != nil {
+		return err
+	}
+
+	for i, b := range brackets {
+		b.EventID = eventID
+		err := tx.QueryRow(`
+			INSERT INTO brackets (event_id, weight_category, gender, format, status)
+			VALUES ($1, $2, $3, $4, $5)
+			RETURNING id, created_at
+		`, eventID, b.WeightCategory, b.Gender, b.Format, b.Status).Scan(&b.ID, &b.CreatedAt)
+		if err != nil {
+			return err
+		}
+
+		for _, e := range b.Entries {
+			if _, err := tx.Exec(`
+				INSERT INTO bracket_entries (bracket_id, athlete_id, seed) VALUES ($1, $2, $3)
+			`, b.ID, e.AthleteID, e.Seed); err != nil {
+				return err
+			}
+		}
+
+		// Next bouts come later in the draw: insert from the end so their ids are known
+		for i := len(b.Bouts) - 1; i >= 0; i-- {
+			o := &b.Bouts[i]
+			o.BracketID = b.ID
+			if o.NextIndex >= 0 {
+				o.NextBoutID = &b.Bouts[o.NextIndex].ID
+			}
+			err := tx.QueryRow(`
+				INSERT INTO bouts (bracket_id, round, position, red_athlete_id, blue_athlete_id, status,
+				                   winner_athlete_id, next_bout_id, next_slot)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+				RETURNING id
+			`, b.ID, o.Round, o.Position, o.RedAthleteID, o.BlueAthleteID, o.Status,
+				o.WinnerAthleteID, o.NextBoutID, o.NextSlot).Scan(&o.ID)
+			if err != nil {
+				return err
+			}
+		}
+
+		if placings[i] != nil {
+			if err := savePlacings(tx, b.ID, placings[i]); err != nil {
+				return err
+			}
+		}
+	}
+	return tx.Commit()
+}
+
+// GetByEvent returns an event's brackets with their entries and bouts
+func (r *BracketRepository) GetByEvent(eventID int) ([]*models.Bracket, error) {
+	return r.load(`b.event_id = $1`, eventID)
+}
+
+// GetByID returns a bracket with its entries and bouts
+func (r *BracketRepository) GetByID(id int) (*models.Bracket, error) {
+	brackets, err := r.load(`b.id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(brackets) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return brackets[0], nil
+}
+
+// load returns the brackets matching where ($1 as its only argument) with
+// their entries and bouts
+func (r *BracketRepository) load(where string, arg int) ([]*models.Bracket, error) {
+	rows, err := r.db.Query(`
+		SELECT `+bracketColumns+` FROM brackets b WHERE `+where+`
+		ORDER BY b.weight_category, b.gender
+	`, arg)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	brackets := []*models.Bracket{}
+	byID := map[int]*models.Bracket{}
+	for rows.Next() {
+		b, err := scanBracket(rows)
+		if err != nil {
+			return nil, err
+		}
+		brackets = append(brackets, b)
+		byID[b.ID] = b
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	entries, err := r.db.Query(`
+		SELECT e.bracket_id, e.athlete_id, a.first_name || ' ' || a.last_name, e.seed, e.placing
+		FROM bracket_entries e
+		JOIN brackets b ON b.id = e.bracket_id
+		JOIN athletes a ON a.id = e.athlete_id
+		WHERE `+where+`
+		ORDER BY e.bracket_id, e.seed
+	`, arg)
+	if err != nil {
+		return nil, err
+	}
+	defer entries.Close()
+	for entries.Next() {
+		var bracketID int
+		var e models.BracketEntry
+		if err := entries.Scan(&bracketID, &e.AthleteID, &e.AthleteName, &e.Seed, &e.Placing); err != nil {
+			return nil, err
+		}
+		if b := byID[bracketID]; b != nil {
+			b.Entries = append(b.Entries, e)
+		}
+	}
+	if err := entries.Err(); err != nil {
+		return nil, err
+	}
+
+	bouts, err := r.db.Query(`
+		SELECT `+boutColumns+`
+		FROM bouts o
+		JOIN brackets b ON b.id = o.bracket_id`+boutJoins+`
+		WHERE `+where+`
+		ORDER BY o.bracket_id, o.round, o.position
+	`, arg)
+	if err != nil {
+		return nil, err
+	}
+	defer bouts.Close()
+	for bouts.Next() {
+		o, err := scanBout(bouts)
+		if err != nil {
+			return nil, err
+		}
+		if b := byID[o.BracketID]; b != nil {
+			b.Bouts = append(b.Bouts, *o)
+		}
+	}
+	return brackets, bouts.Err()
+}
+
+// RecordResult stores a bout's outcome and moves the winner into the next
+// bout. A result can be corrected until the next bout has one. Returns the
+// bout's bracket.
+func (r *BracketRepository) RecordResult(boutID int, req *models.BoutResultRequest) (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	bout, err := scanBout(tx.QueryRow(`
+		SELECT `+boutColumns+` FROM bouts o`+boutJoins+` WHERE o.id = $1 FOR UPDATE OF o
+	`, boutID))
+	if err != nil {
+		return 0, err
+	}
+
+	if bout.Status == models.BoutBye {
+		return 0, &BoutResultError{Message: "a bye has no result"}
+	}
+	if bout.RedAthleteID == nil || bout.BlueAthleteID == nil {
+		return 0, &BoutResultError{Message: "both athletes must be known before the bout"}
+	}
+	var winner *int
+	if req.Method == "draw" {
+		var format string
+		if err := tx.QueryRow(`SELECT format FROM brackets WHERE id = $1`, bout.BracketID).Scan(&format); err != nil {
+			return 0, err
+		}
+		if format != models.FormatRoundRobin {
+			return 0, &BoutResultError{Message: "only round-robin bouts can end in a draw"}
+		}
+	} else {
+		if req.WinnerAthleteID != *bout.RedAthleteID && req.WinnerAthleteID != *bout.BlueAthleteID {
+			return 0, &BoutResultError{Message: "winner must be one of the bout's athletes"}
+		}
+		winner = &req.WinnerAthleteID
+	}
+
+	if bout.NextBoutID != nil {
+		var nextStatus string
+		if err := tx.QueryRow(`SELECT status FROM bouts WHERE id = $1 FOR UPDATE`, *bout.NextBoutID).Scan(&nextStatus); err != nil {
+			return 0, err
+		}
+		if nextStatus == models.BoutCompleted {
+			return 0, ErrBoutLocked
+		}
+	}
+
+	_, err = tx.Exec(`
+		UPDATE bouts
+		SET status = 'completed', winner_athlete_id = $1, method = $2, red_rounds_won = $3, blue_rounds_won = $4,
+		    red_points = $5, blue_points = $6, completed_at = CURRENT_TIMESTAMP
+		WHERE id = $7
+	`, winner, req.Method, req.RedRoundsWon, req.BlueRoundsWon, req.RedPoints, req.BluePoints, boutID)
+	if err != nil {
+		return 0, err
+	}
+
+	if winner != nil && bout.NextBoutID != nil && bout.NextSlot != nil {
+		query := `UPDATE bouts SET red_athlete_id = $1 WHERE id = $2`
+		if *bout.NextSlot == "blue" {
+			query = `UPDATE bouts SET blue_athlete_id = $1 WHERE id = $2`
+		}
+		if _, err := tx.Exec(query, req.WinnerAthleteID, *bout.NextBoutID); err != nil {
+			return 0, err
+		}
+	}
+	return bout.BracketID, tx.Commit()
+}
+
+// SavePlacings stores the bracket's final placings on its entries and on the
+// athletes' event registrations, and marks the bracket completed
+func (r *BracketRepository) SavePlacings(bracketID int, placings map[int]int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := savePlacings(tx, bracketID, placings); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func savePlacings(tx *sql.Tx, bracketID int, placings map[int]int) error {
+	for athleteID, placing := range placings {
+		if _, err := tx.Exec(`
+			UPDATE bracket_entries SET placing = $1 WHERE bracket_id = $2 AND athlete_id = $3
+		`, placing, bracketID, athleteID); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`
+			UPDATE event_registrations SET placing = $1
+			WHERE athlete_id = $2 AND event_id = (SELECT event_id FROM brackets WHERE id = $3)
+		`, placing, athleteID, bracketID); err != nil {
+			return err
+		}
+	}
+	_, err := tx.Exec(`UPDATE brackets SET status = 'completed' WHERE id = $1`, bracketID)
+	return err
+}
@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"east-eagles/backend/internal/models"
+)
+
+type CalendarRepository struct {
+	db *sql.DB
+}
+
+func NewCalendarRepository(db *sql.DB) *CalendarRepository {
+	return &CalendarRepository{db: db}
+}
+
+// GetToken returns the user's feed token
+func (r *CalendarRepository) GetToken(userID int) (*models.CalendarToken, error) {
+	t := &models.CalendarToken{}
+	err := r.db.QueryRow(
+		`SELECT token, created_at FROM calendar_tokens WHERE user_id = $1`, userID,
+	).Scan(&t.Token, &t.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// CreateToken stores token for a user without one. created is false when the
+// user already had a token (e.g. a concurrent first request), which is returned instead.
+func (r *CalendarRepository) CreateToken(userID int, token string) (t *models.CalendarToken, created bool, err error) {
+	t = &models.CalendarToken{}
+	err = r.db.QueryRow(`
+		INSERT INTO calendar_tokens (user_id, token)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO NOTHING
+		RETURNING token, created_at
+	`, userID, token).Scan(&t.Token, &t.CreatedAt)
+	if err == sql.ErrNoRows {
+		t, err = r.GetToken(userID)
+		return t, false, err
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return t, true, nil
+}
+
+// SaveToken sets the user's feed token, replacing (and so revoking) any previous one
+func (r *CalendarRepository) SaveToken(userID int, token string) (*models.CalendarToken, error) {
+	t := &models.CalendarToken{}
+	err := r.db.QueryRow(`
+		INSERT INTO calendar_tokens (user_id, token)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE SET token = EXCLUDED.token, created_at = CURRENT_TIMESTAMP
+		RETURNING token, created_at
+	`, userID, token).Scan(&t.Token, &t.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// DeleteToken revokes the user's feed token
+func (r *CalendarRepository) DeleteToken(userID int) error {
+	_, err := r.db.Exec(`DELETE FROM calendar_tokens WHERE user_id = $1`, userID)
+	return err
+}
+
+// GetUserByToken returns the active user a feed token belongs to
+func (r *CalendarRepository) GetUserByToken(token string) (*models.User, error) {
+	u := &models.User{}
+	err := r.db.QueryRow(`
+		SELECT u.id, u.email, u.role, u.first_name, u.last_name, u.athlete_id
+		FROM calendar_tokens t
+		JOIN users u ON u.id = t.user_id
+		WHERE t.token = $1 AND u.is_active = true
+	`, token).Scan(&u.ID, &u.Email, &u.Role, &u.FirstName, &u.LastName, &u.AthleteID)
+	if err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// GetSessionsBetween returns the sessions dated between from and to
+func (r *CalendarRepository) GetSessionsBetween(from, to time.Time) ([]*models.TrainingSession, error) {
+	rows, err := r.db.Query(`
+		SELECT `+sessionColumns+`
+		FROM training_sessions
+		WHERE session_date >= $1 AND session_date < $2
+		ORDER BY session_date
+	`, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []*models.TrainingSession{}
+	for rows.Next() {
+		s, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, rows.Err()
+}
+
+// GetAthleteSessions returns the athlete's sessions between from and to:
+// those they booked, plus any they have attendance for
+func (r *CalendarRepository) GetAthleteSessions(athleteID int, from, to time.Time) ([]*models.TrainingSession, error) {
+	rows, err := r.db.Query(`
+		SELECT `+sessionColumns+`
+		FROM training_sessions t
+		WHERE t.session_date >= $2 AND t.session_date < $3
+		  AND (
+		      EXISTS (SELECT 1 FROM session_bookings b
+		              WHERE b.training_session_id = t.id AND b.athlete_id = $1 AND b.status = 'booked')
+		      OR EXISTS (SELECT 1 FROM attendance a WHERE a.training_session_id = t.id AND a.athlete_id = $1)
+		  )
+		ORDER BY t.session_date
+	`, athleteID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []*models.TrainingSession{}
+	for rows.Next() {
+		s, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, rows.Err()
+}
+
+// GetAthleteEvents returns the events the athlete is registered for, from the given date
+func (r *CalendarRepository) GetAthleteEvents(athleteID int, from time.Time) ([]models.Event, error) {
+	rows, err := r.db.Query(`
+		SELECT e.id, e.title, COALESCE(e.description, ''), e.date, COALESCE(e.location, ''),
+		       e.image_url, COALESCE(e.max_participants, 0), e.created_at
+		FROM event_registrations er
+		JOIN events e ON e.id = er.event_id
+		WHERE er.athlete_id = $1 AND er.status = 'registered' AND e.date >= $2
+		ORDER BY e.date
+	`, athleteID, from)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []models.Event{}
+	for rows.Next() {
+		var e models.Event
+		if err := rows.Scan(
+			&e.ID, &e.Title, &e.Description, &e.Date, &e.Location, &e.ImageURL, &e.MaxParticipants, &e.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
@@ -0,0 +1,188 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"east-eagles/backend/internal/models"
+)
+
+// ErrIdempotencyConflict is returned when an idempotency key was already used for another checkout
+var ErrIdempotencyConflict = errors.New("idempotency key already used for a different checkout")
+
+type CheckoutRepository struct {
+	db *sql.DB
+}
+
+func NewCheckoutRepository(db *sql.DB) *CheckoutRepository {
+	return &CheckoutRepository{db: db}
+}
+
+const checkoutColumns = `
+	id, athlete_id, user_id, provider, COALESCE(session_id, ''), COALESCE(checkout_url, ''),
+	idempotency_key, amount, months_covered, status, COALESCE(success_url, ''), COALESCE(cancel_url, ''),
+	payment_id, created_at, completed_at`
+
+func scanCheckout(row rowScanner) (*models.PaymentCheckout, error) {
+	c := &models.PaymentCheckout{}
+	err := row.Scan(
+		&c.ID, &c.AthleteID, &c.UserID, &c.Provider, &c.SessionID, &c.CheckoutURL,
+		&c.IdempotencyKey, &c.Amount, &c.MonthsCovered, &c.Status, &c.SuccessURL, &c.CancelURL,
+		&c.PaymentID, &c.CreatedAt, &c.CompletedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// CreatePending stores a new checkout, or returns the one already created with
+// the same idempotency key (existing is then true).
+func (r *CheckoutRepository) CreatePending(c *models.PaymentCheckout) (checkout *models.PaymentCheckout, existing bool, err error) {
+	row := r.db.QueryRow(`
+		INSERT INTO payment_checkouts (
+			athlete_id, user_id, provider, idempotency_key, amount, months_covered, success_url, cancel_url
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (idempotency_key) DO NOTHING
+		RETURNING `+checkoutColumns,
+		c.AthleteID, c.UserID, c.Provider, c.IdempotencyKey, c.Amount, c.MonthsCovered, c.SuccessURL, c.CancelURL,
+	)
+	checkout, err = scanCheckout(row)
+	if err == nil {
+		return checkout, false, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, false, err
+	}
+
+	// The key was used before: replay the original checkout if it matches
+	checkout, err = scanCheckout(r.db.QueryRow(
+		`SELECT `+checkoutColumns+` FROM payment_checkouts WHERE idempotency_key = $1`, c.IdempotencyKey,
+	))
+	if err != nil {
+		return nil, false, err
+	}
+	if checkout.UserID != c.UserID || checkout.MonthsCovered != c.MonthsCovered {
+		return nil, false, ErrIdempotencyConflict
+	}
+	return checkout, true, nil
+}
+
+// SetSession records the provider session once the checkout was opened
+func (r *CheckoutRepository) SetSession(id int, sessionID, url string) error {
+	_, err := r.db.Exec(
+		`UPDATE payment_checkouts SET session_id = $1, checkout_url = $2 WHERE id = $3`,
+		sessionID, url, id,
+	)
+	return err
+}
+
+// GetByID returns a checkout
+func (r *CheckoutRepository) GetByID(id int) (*models.PaymentCheckout, error) {
+	return scanCheckout(r.db.QueryRow(`SELECT `+checkoutColumns+` FROM payment_checkouts WHERE id = $1`, id))
+}
+
+// GetBySession returns the checkout a provider session belongs to
+func (r *CheckoutRepository) GetBySession(provider, sessionID string) (*models.PaymentCheckout, error) {
+	return scanCheckout(r.db.QueryRow(
+		`SELECT `+checkoutColumns+` FROM payment_checkouts WHERE provider = $1 AND session_id = $2`,
+		provider, sessionID,
+	))
+}
+
+// ApplyEvent processes a verified provider event exactly once. A paid event
+// records the period through the same path as PaymentRepository.Create,
+// starting the day after the athlete's last covered date.
+func (r *CheckoutRepository) ApplyEvent(provider, eventID, sessionID, status string, amount float64, reference string) (*models.PaymentCheckout, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	checkout, err := scanCheckout(tx.QueryRow(
+		`SELECT `+checkoutColumns+` FROM payment_checkouts WHERE provider = $1 AND session_id = $2 FOR UPDATE`,
+		provider, sessionID,
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := tx.Exec(`
+		INSERT INTO payment_webhook_events (provider, event_id, session_id, status)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (provider, event_id) DO NOTHING
+	`, provider, eventID, sessionID, status)
+	if err != nil {
+		return nil, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		// Replayed delivery: already applied
+		return checkout, nil
+	}
+
+	switch status {
+	case models.CheckoutStatusPaid:
+		if checkout.Status == models.CheckoutStatusPaid {
+			break
+		}
+		if math.Abs(amount-checkout.Amount) > 0.005 {
+			return nil, &PaymentValidationError{Message: fmt.Sprintf(
+				"paid amount %.2f does not match checkout amount %.2f", amount, checkout.Amount,
+			)}
+		}
+
+		payment, err := createPeriod(tx, &models.CreatePaymentRequest{
+			AthleteID:         checkout.AthleteID,
+			Amount:            checkout.Amount,
+			MonthsCovered:     checkout.MonthsCovered,
+			Notes:             fmt.Sprintf("Paiement en ligne (%s)", provider),
+			Method:            models.PaymentMethodCard,
+			ExternalReference: reference,
+			AutoStart:         true,
+		}, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		now := time.Now()
+		_, err = tx.Exec(
+			`UPDATE payment_checkouts SET status = $1, payment_id = $2, completed_at = $3 WHERE id = $4`,
+			models.CheckoutStatusPaid, payment.ID, now, checkout.ID,
+		)
+		if err != nil {
+			return nil, err
+		}
+		checkout.Status = models.CheckoutStatusPaid
+		checkout.PaymentID = &payment.ID
+		checkout.CompletedAt = &now
+
+	case models.CheckoutStatusFailed, models.CheckoutStatusCanceled:
+		if checkout.Status != models.CheckoutStatusPending {
+			break
+		}
+		now := time.Now()
+		_, err = tx.Exec(
+			`UPDATE payment_checkouts SET status = $1, completed_at = $2 WHERE id = $3`,
+			status, now, checkout.ID,
+		)
+		if err != nil {
+			return nil, err
+		}
+		checkout.Status = status
+		checkout.CompletedAt = &now
+
+	default:
+		return nil, &PaymentValidationError{Message: fmt.Sprintf("unknown checkout status: %s", status)}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return checkout, nil
+}
@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"east-eagles/backend/internal/models"
+)
+
+// checkoutFixture creates an athlete account and a pending checkout for one
+// month at 3000 DZD, with a provider session of its own; everything is
+// removed when the test ends
+func checkoutFixture(t *testing.T, db *sql.DB) (*CheckoutRepository, *models.PaymentCheckout) {
+	t.Helper()
+	tag := fmt.Sprintf("checkout-%d", time.Now().UnixNano())
+	sessionID := "sess_" + tag
+
+	var userID, athleteID int
+	err := db.QueryRow(`
+		INSERT INTO users (email, password_hash, role, first_name, last_name, is_active)
+		VALUES ($1, 'x', 'athlete', 'Test', 'Checkout', true) RETURNING id
+	`, tag+"@test.local").Scan(&userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.QueryRow(`
+		INSERT INTO athletes (first_name, last_name, email, phone)
+		VALUES ('Test', 'Checkout', $1, '0000') RETURNING id
+	`, tag+"@test.local").Scan(&athleteID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.Exec(`DELETE FROM payment_webhook_events WHERE session_id = $1`, sessionID)
+		db.Exec(`DELETE FROM payment_checkouts WHERE athlete_id = $1`, athleteID)
+		db.Exec(`DELETE FROM payments WHERE athlete_id = $1`, athleteID)
+		db.Exec(`DELETE FROM athletes WHERE id = $1`, athleteID)
+		db.Exec(`DELETE FROM users WHERE id = $1`, userID)
+	})
+
+	repo := NewCheckoutRepository(db)
+	checkout, existing, err := repo.CreatePending(&models.PaymentCheckout{
+		AthleteID:      athleteID,
+		UserID:         userID,
+		Provider:       "fake",
+		IdempotencyKey: tag,
+		Amount:         3000,
+		MonthsCovered:  1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if existing {
+		t.Fatal("new idempotency key reported as existing")
+	}
+	if err := repo.SetSession(checkout.ID, sessionID, "http://localhost/checkout"); err != nil {
+		t.Fatal(err)
+	}
+	checkout.SessionID = sessionID
+	return repo, checkout
+}
+
+func countPayments(t *testing.T, db *sql.DB, athleteID int) int {
+	t.Helper()
+	var n int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM payments WHERE athlete_id = $1`, athleteID).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestApplyEventIgnoresReplays(t *testing.T) {
+	db := openTestDB(t)
+	repo, checkout := checkoutFixture(t, db)
+
+	for i := 0; i < 2; i++ {
+		applied, err := repo.ApplyEvent("fake", "evt_"+checkout.SessionID, checkout.SessionID, models.CheckoutStatusPaid, 3000, "REF-1")
+		if err != nil {
+			t.Fatalf("delivery %d: %v", i+1, err)
+		}
+		if applied.Status != models.CheckoutStatusPaid {
+			t.Fatalf("delivery %d: status %s", i+1, applied.Status)
+		}
+	}
+	if n := countPayments(t, db, checkout.AthleteID); n != 1 {
+		t.Errorf("%d payments recorded for a replayed event, want 1", n)
+	}
+
+	// A later event may not turn a paid checkout back into a failed one
+	applied, err := repo.ApplyEvent("fake", "evt_late_"+checkout.SessionID, checkout.SessionID, models.CheckoutStatusFailed, 3000, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if applied.Status != models.CheckoutStatusPaid {
+		t.Errorf("status %s after a late failure, want paid", applied.Status)
+	}
+}
+
+func TestApplyEventRejectsAmountMismatch(t *testing.T) {
+	db := openTestDB(t)
+	repo, checkout := checkoutFixture(t, db)
+
+	_, err := repo.ApplyEvent("fake", "evt_"+checkout.SessionID, checkout.SessionID, models.CheckoutStatusPaid, 2999, "REF-2")
+	var invalid *PaymentValidationError
+	if !errors.As(err, &invalid) {
+		t.Fatalf("err = %v, want a PaymentValidationError", err)
+	}
+	if n := countPayments(t, db, checkout.AthleteID); n != 0 {
+		t.Errorf("%d payments recorded for a mismatched amount", n)
+	}
+
+	// The rejected event was rolled back, so a corrected delivery still applies
+	applied, err := repo.ApplyEvent("fake", "evt_"+checkout.SessionID, checkout.SessionID, models.CheckoutStatusPaid, 3000, "REF-2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if applied.Status != models.CheckoutStatusPaid || applied.PaymentID == nil {
+		t.Errorf("checkout %s with payment %v, want paid with a payment", applied.Status, applied.PaymentID)
+	}
+}
+
+func TestApplyEventUnknownSession(t *testing.T) {
+	db := openTestDB(t)
+	repo := NewCheckoutRepository(db)
+
+	_, err := repo.ApplyEvent("fake", "evt_unknown", "sess_does_not_exist", models.CheckoutStatusPaid, 3000, "")
+	if err != sql.ErrNoRows {
+		t.Errorf("err = %v, want sql.ErrNoRows", err)
+	}
+}
+
+func TestCreatePendingIdempotency(t *testing.T) {
+	db := openTestDB(t)
+	repo, checkout := checkoutFixture(t, db)
+
+	again, existing, err := repo.CreatePending(&models.PaymentCheckout{
+		AthleteID:      checkout.AthleteID,
+		UserID:         checkout.UserID,
+		Provider:       "fake",
+		IdempotencyKey: checkout.IdempotencyKey,
+		Amount:         checkout.Amount,
+		MonthsCovered:  checkout.MonthsCovered,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !existing || again.ID != checkout.ID {
+		t.Errorf("retry returned checkout #%d (existing %v), want #%d", again.ID, existing, checkout.ID)
+	}
+
+	_, _, err = repo.CreatePending(&models.PaymentCheckout{
+		AthleteID:      checkout.AthleteID,
+		UserID:         checkout.UserID,
+		Provider:       "fake",
+		IdempotencyKey: checkout.IdempotencyKey,
+		Amount:         checkout.Amount * 3,
+		MonthsCovered:  3,
+	})
+	if !errors.Is(err, ErrIdempotencyConflict) {
+		t.Errorf("err = %v, want ErrIdempotencyConflict", err)
+	}
+}
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"strings"
@@ -1012,3 +1013,14 @@ func (r *DocumentRepository) SearchDocuments(filters map[string]interface{}) ([]
 
 	return docs, nil
 }
+
+// DeleteExpiredShares removes the shares past their expiry date and returns
+// how many were removed
+func (r *DocumentRepository) DeleteExpiredShares(ctx context.Context) (int, error) {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM document_shares WHERE expires_at IS NOT NULL AND expires_at < CURRENT_TIMESTAMP`)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
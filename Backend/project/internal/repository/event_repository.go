@@ -1,10 +1,30 @@
 package repository
 
 import (
-	"east-eagles/backend/internal/models"
 	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"east-eagles/backend/internal/models"
+
+	"github.com/lib/pq"
 )
 
+// ErrEventFull is returned when an event has no place left
+var ErrEventFull = errors.New("event is full")
+
+// EventRegistrationError is returned when an athlete cannot be entered in an
+// event (registration closed, category or belt not admitted, documents missing)
+type EventRegistrationError struct {
+	Reasons []string
+}
+
+func (e *EventRegistrationError) Error() string {
+	return fmt.Sprintf("athlete cannot register: %v", e.Reasons)
+}
+
 type EventRepository struct {
 	db *sql.DB
 }
@@ -13,115 +33,213 @@ func NewEventRepository(db *sql.DB) *EventRepository {
 	return &EventRepository{db: db}
 }
 
-func (r *EventRepository) GetAll() ([]models.Event, error) {
-	query := `
-		SELECT id, title, description, date, location, image_url, 
-		       max_participants, created_at
-		FROM events
-		WHERE date >= NOW()
-		ORDER BY date ASC
-	`
+const eventColumns = `e.id, e.title, COALESCE(e.description, ''), e.event_type, e.status, e.date,
+	COALESCE(e.location, ''), e.image_url, COALESCE(e.max_participants, 0), e.registration_deadline,
+	e.weight_categories, e.belt_levels, e.required_documents,
+	(SELECT COUNT(*) FROM event_registrations r WHERE r.event_id = e.id AND r.status = 'registered'),
+	e.created_by, e.created_at`
+
+func scanEvent(row rowScanner) (*models.Event, error) {
+	e := &models.Event{}
+	if err := row.Scan(
+		&e.ID, &e.Title, &e.Description, &e.EventType, &e.Status, &e.Date,
+		&e.Location, &e.ImageURL, &e.MaxParticipants, &e.RegistrationDeadline,
+		pq.Array(&e.WeightCategories), pq.Array(&e.BeltLevels), pq.Array(&e.RequiredDocuments),
+		&e.RegisteredCount, &e.CreatedBy, &e.CreatedAt,
+	); err != nil {
+		return nil, err
+	}
+	e.RegistrationOpen = registrationOpen(e)
+	return e, nil
+}
+
+// registrationOpen reports whether the event still takes registrations. Like
+// session_date, event times are wall-clock times.
+func registrationOpen(e *models.Event) bool {
+	closes := e.Date
+	if e.RegistrationDeadline != nil {
+		closes = *e.RegistrationDeadline
+	}
+	return e.Status == models.EventOpen && models.WallClockNow().Before(closes)
+}
+
+// eventIneligibility lists why the athlete cannot enter the event in the given
+// weight category. docs holds the athlete's approved document types and their
+// latest expiry (nil when they do not expire).
+func eventIneligibility(e *models.Event, athlete *models.Athlete, category string, docs map[string]*time.Time) []string {
+	reasons := []string{}
+	if !e.RegistrationOpen {
+		reasons = append(reasons, "Les inscriptions sont closes")
+	}
+	if e.MaxParticipants > 0 && e.RegisteredCount >= e.MaxParticipants {
+		reasons = append(reasons, "L'événement est complet")
+	}
+	if athlete.MembershipStatus != "approved" {
+		reasons = append(reasons, "L'adhésion doit être approuvée")
+	}
+	if len(e.WeightCategories) > 0 && !slices.Contains(e.WeightCategories, category) {
+		reasons = append(reasons, fmt.Sprintf("Catégorie de poids non admise : %s", category))
+	}
+	if len(e.BeltLevels) > 0 && !slices.Contains(e.BeltLevels, athlete.BeltLevel) {
+		reasons = append(reasons, fmt.Sprintf("Ceinture non admise : %s", athlete.BeltLevel))
+	}
+	for _, docType := range e.RequiredDocuments {
+		expiry, ok := docs[docType]
+		if !ok || (expiry != nil && expiry.Before(e.Date.Truncate(24*time.Hour))) {
+			reasons = append(reasons, fmt.Sprintf("Document manquant ou expiré : %s", docType))
+		}
+	}
+	return reasons
+}
 
-	rows, err := r.db.Query(query)
+// athleteDocuments returns the athlete's approved document types with their
+// latest expiry date, nil for documents that do not expire. query is the
+// Query method of the database or of a transaction.
+func athleteDocuments(query func(string, ...interface{}) (*sql.Rows, error), athleteID int) (map[string]*time.Time, error) {
+	rows, err := query(`
+		SELECT document_type, CASE WHEN bool_or(expiry_date IS NULL) THEN NULL ELSE MAX(expiry_date) END
+		FROM documents
+		WHERE athlete_id = $1 AND validation_status = 'approved'
+		GROUP BY document_type
+	`, athleteID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var events []models.Event
+	docs := map[string]*time.Time{}
 	for rows.Next() {
-		var e models.Event
-		err := rows.Scan(
-			&e.ID, &e.Title, &e.Description, &e.Date, &e.Location,
-			&e.ImageURL, &e.MaxParticipants, &e.CreatedAt,
-		)
-		if err != nil {
+		var docType string
+		var expiry *time.Time
+		if err := rows.Scan(&docType, &expiry); err != nil {
 			return nil, err
 		}
-		events = append(events, e)
+		docs[docType] = expiry
 	}
-
-	return events, nil
+	return docs, rows.Err()
 }
 
-func (r *EventRepository) GetByID(id int) (*models.Event, error) {
-	query := `
-		SELECT id, title, description, date, location, image_url,
-		       max_participants, created_at
-		FROM events WHERE id = $1
-	`
-
-	var e models.Event
-	err := r.db.QueryRow(query, id).Scan(
-		&e.ID, &e.Title, &e.Description, &e.Date, &e.Location,
-		&e.ImageURL, &e.MaxParticipants, &e.CreatedAt,
-	)
-
+// GetAll returns events by date, upcoming ones only unless includePast is set
+func (r *EventRepository) GetAll(includePast bool) ([]*models.Event, error) {
+	rows, err := r.db.Query(`
+		SELECT `+eventColumns+`
+		FROM events e
+		WHERE e.date >= $1 OR $2
+		ORDER BY e.date ASC
+	`, models.WallClockNow(), includePast)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
-	return &e, nil
+	events := []*models.Event{}
+	for rows.Next() {
+		e, err := scanEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
 }
 
-func (r *EventRepository) Create(req *models.CreateEventRequest) (*models.Event, error) {
-	query := `
-		INSERT INTO events (title, description, date, location, image_url, max_participants)
-		VALUES ($1, $2, $3, $4, $5, $6)
-		RETURNING id, title, description, date, location, image_url, 
-		          max_participants, created_at
-	`
+func (r *EventRepository) GetByID(id int) (*models.Event, error) {
+	return scanEvent(r.db.QueryRow(`SELECT `+eventColumns+` FROM events e WHERE e.id = $1`, id))
+}
 
-	var e models.Event
-	err := r.db.QueryRow(
-		query, req.Title, req.Description, req.Date,
-		req.Location, req.ImageURL, req.MaxParticipants,
-	).Scan(
-		&e.ID, &e.Title, &e.Description, &e.Date, &e.Location,
-		&e.ImageURL, &e.MaxParticipants, &e.CreatedAt,
-	)
+// GetForAthlete returns upcoming events that are not cancelled, with the
+// athlete's registration and whether they may register
+func (r *EventRepository) GetForAthlete(athlete *models.Athlete) ([]*models.AthleteEvent, error) {
+	docs, err := athleteDocuments(r.db.Query, athlete.ID)
+	if err != nil {
+		return nil, err
+	}
 
+	rows, err := r.db.Query(`
+		SELECT `+eventColumns+`, COALESCE(mine.status, ''), COALESCE(mine.weight_category, '')
+		FROM events e
+		LEFT JOIN event_registrations mine ON mine.event_id = e.id AND mine.athlete_id = $1
+		WHERE e.date >= $2 AND e.status <> 'cancelled'
+		ORDER BY e.date ASC
+	`, athlete.ID, models.WallClockNow())
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
-	return &e, nil
+	events := []*models.AthleteEvent{}
+	for rows.Next() {
+		ae := &models.AthleteEvent{}
+		var category string
+		e, err := scanEvent(withExtra{rows, []interface{}{&ae.MyStatus, &category}})
+		if err != nil {
+			return nil, err
+		}
+		ae.Event = *e
+		if ae.MyStatus != models.RegistrationRegistered {
+			category = athlete.WeightCategory
+		}
+		ae.Ineligible = eventIneligibility(e, athlete, category, docs)
+		ae.Eligible = len(ae.Ineligible) == 0
+		events = append(events, ae)
+	}
+	return events, rows.Err()
 }
 
-func (r *EventRepository) RegisterMember(eventID, memberID int) error {
-	query := `
-		INSERT INTO event_registrations (event_id, member_id)
-		VALUES ($1, $2)
-	`
-
-	_, err := r.db.Exec(query, eventID, memberID)
-	return err
+func (r *EventRepository) Create(req *models.CreateEventRequest, createdBy int) (*models.Event, error) {
+	var id int
+	err := r.db.QueryRow(`
+		INSERT INTO events (title, description, event_type, status, date, location, image_url, max_participants,
+		                    registration_deadline, weight_categories, belt_levels, required_documents, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		RETURNING id
+	`,
+		req.Title, req.Description, req.EventType, req.Status, req.Date, req.Location, req.ImageURL, req.MaxParticipants,
+		req.RegistrationDeadline, pq.Array(nonNil(req.WeightCategories)), pq.Array(nonNil(req.BeltLevels)),
+		pq.Array(nonNil(req.RequiredDocuments)), createdBy,
+	).Scan(&id)
+	if err != nil {
+		return nil, err
+	}
+	return r.GetByID(id)
 }
 
 func (r *EventRepository) Update(id int, req *models.CreateEventRequest) (*models.Event, error) {
-	query := `
+	result, err := r.db.Exec(`
 		UPDATE events
-		SET title = $1, description = $2, date = $3, location = $4, 
-		    image_url = $5, max_participants = $6
-		WHERE id = $7
-		RETURNING id, title, description, date, location, image_url, 
-		          max_participants, created_at
-	`
-
-	var e models.Event
-	err := r.db.QueryRow(
-		query, req.Title, req.Description, req.Date, req.Location,
-		req.ImageURL, req.MaxParticipants, id,
-	).Scan(
-		&e.ID, &e.Title, &e.Description, &e.Date, &e.Location,
-		&e.ImageURL, &e.MaxParticipants, &e.CreatedAt,
+		SET title = $1, description = $2, event_type = $3, status = $4, date = $5, location = $6,
+		    image_url = $7, max_participants = $8, registration_deadline = $9,
+		    weight_categories = $10, belt_levels = $11, required_documents = $12
+		WHERE id = $13
+	`,
+		req.Title, req.Description, req.EventType, req.Status, req.Date, req.Location,
+		req.ImageURL, req.MaxParticipants, req.RegistrationDeadline,
+		pq.Array(nonNil(req.WeightCategories)), pq.Array(nonNil(req.BeltLevels)), pq.Array(nonNil(req.RequiredDocuments)), id,
 	)
-
 	if err != nil {
 		return nil, err
 	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return r.GetByID(id)
+}
+
+// nonNil keeps empty lists from being stored as NULL
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
 
-	return &e, nil
+// int64s converts ids for pq.Array, which has no []int support; never nil
+func int64s(ids []int) []int64 {
+	out := make([]int64, len(ids))
+	for i, id := range ids {
+		out[i] = int64(id)
+	}
+	return out
 }
 
 func (r *EventRepository) Delete(id int) error {
@@ -142,3 +260,149 @@ func (r *EventRepository) Delete(id int) error {
 
 	return nil
 }
+
+const registrationColumns = `r.id, r.event_id, r.athlete_id, r.weight_category, r.belt_level, r.status,
+	r.nominated_by, r.placing, r.registered_at, r.withdrawn_at`
+
+func scanRegistration(row rowScanner) (*models.EventRegistration, error) {
+	reg := &models.EventRegistration{}
+	if err := row.Scan(&reg.ID, &reg.EventID, &reg.AthleteID, &reg.WeightCategory, &reg.BeltLevel, &reg.Status,
+		&reg.NominatedBy, &reg.Placing, &reg.RegisteredAt, &reg.WithdrawnAt); err != nil {
+		return nil, err
+	}
+	return reg, nil
+}
+
+// Register enters the athlete in the event, in category (their own weight
+// category when empty). nominatedBy is the coach entering them, nil when the
+// athlete registers themselves. Registering again returns the existing entry.
+func (r *EventRepository) Register(eventID int, athlete *models.Athlete, category string, nominatedBy *int) (*models.EventRegistration, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Serialise registrations so capacity holds
+	event, err := scanEvent(tx.QueryRow(`SELECT `+eventColumns+` FROM events e WHERE e.id = $1 FOR UPDATE`, eventID))
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := scanRegistration(tx.QueryRow(`
+		SELECT `+registrationColumns+` FROM event_registrations r WHERE r.event_id = $1 AND r.athlete_id = $2
+	`, eventID, athlete.ID))
+	if err == nil && existing.Status == models.RegistrationRegistered {
+		return existing, nil
+	}
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	if event.MaxParticipants > 0 && event.RegisteredCount >= event.MaxParticipants {
+		return nil, ErrEventFull
+	}
+	docs, err := athleteDocuments(tx.Query, athlete.ID)
+	if err != nil {
+		return nil, err
+	}
+	if category == "" {
+		category = athlete.WeightCategory
+	}
+	if reasons := eventIneligibility(event, athlete, category, docs); len(reasons) > 0 {
+		return nil, &EventRegistrationError{Reasons: reasons}
+	}
+
+	reg, err := scanRegistration(tx.QueryRow(`
+		INSERT INTO event_registrations AS r (event_id, athlete_id, weight_category, belt_level, status, nominated_by)
+		VALUES ($1, $2, $3, $4, 'registered', $5)
+		ON CONFLICT (event_id, athlete_id) DO UPDATE
+		SET weight_category = EXCLUDED.weight_category, belt_level = EXCLUDED.belt_level, status = 'registered',
+		    nominated_by = EXCLUDED.nominated_by, registered_at = CURRENT_TIMESTAMP, withdrawn_at = NULL
+		RETURNING `+registrationColumns,
+		eventID, athlete.ID, category, athlete.BeltLevel, nominatedBy))
+	if err != nil {
+		return nil, err
+	}
+	return reg, tx.Commit()
+}
+
+// Withdraw withdraws the athlete from the event. With enforceDeadline the
+// withdrawal must happen while registrations are open.
+func (r *EventRepository) Withdraw(eventID, athleteID int, enforceDeadline bool) error {
+	if enforceDeadline {
+		event, err := r.GetByID(eventID)
+		if err != nil {
+			return err
+		}
+		if !event.RegistrationOpen {
+			return &EventRegistrationError{Reasons: []string{"Les inscriptions sont closes"}}
+		}
+	}
+
+	result, err := r.db.Exec(`
+		UPDATE event_registrations SET status = 'withdrawn', withdrawn_at = CURRENT_TIMESTAMP
+		WHERE event_id = $1 AND athlete_id = $2 AND status = 'registered'
+	`, eventID, athleteID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// GetRegistrations returns an event's entries, registered athletes first
+func (r *EventRepository) GetRegistrations(eventID int) ([]*models.EventRegistration, error) {
+	rows, err := r.db.Query(`
+		SELECT `+registrationColumns+`, a.first_name || ' ' || a.last_name
+		FROM event_registrations r
+		JOIN athletes a ON a.id = r.athlete_id
+		WHERE r.event_id = $1
+		ORDER BY r.status, r.weight_category, a.last_name, a.first_name
+	`, eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	regs := []*models.EventRegistration{}
+	for rows.Next() {
+		var name string
+		reg, err := scanRegistration(withExtra{rows, []interface{}{&name}})
+		if err != nil {
+			return nil, err
+		}
+		reg.AthleteName = name
+		regs = append(regs, reg)
+	}
+	return regs, rows.Err()
+}
+
+// GetByAthlete returns the athlete's event entries, most recent event first
+func (r *EventRepository) GetByAthlete(athleteID int) ([]*models.EventRegistration, error) {
+	rows, err := r.db.Query(`
+		SELECT `+registrationColumns+`, e.title
+		FROM event_registrations r
+		JOIN events e ON e.id = r.event_id
+		WHERE r.athlete_id = $1
+		ORDER BY e.date DESC
+	`, athleteID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	regs := []*models.EventRegistration{}
+	for rows.Next() {
+		var title string
+		reg, err := scanRegistration(withExtra{rows, []interface{}{&title}})
+		if err != nil {
+			return nil, err
+		}
+		reg.EventTitle = title
+		regs = append(regs, reg)
+	}
+	return regs, rows.Err()
+}
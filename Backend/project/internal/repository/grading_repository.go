@@ -0,0 +1,367 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"east-eagles/backend/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// ErrGradingDecided is returned when changing a candidate whose result is
+// already recorded
+var ErrGradingDecided = errors.New("grading result already recorded")
+
+// ErrGradingCancelled is returned when grading at a cancelled session
+var ErrGradingCancelled = errors.New("grading session cancelled")
+
+// ErrGradingBeltChanged is returned when the athlete's belt changed since they
+// were registered, e.g. promoted at another session
+var ErrGradingBeltChanged = errors.New("athlete belt changed since registration")
+
+type GradingRepository struct {
+	db *sql.DB
+}
+
+func NewGradingRepository(db *sql.DB) *GradingRepository {
+	return &GradingRepository{db: db}
+}
+
+// GetBelts returns the belt list, lowest grade first
+func (r *GradingRepository) GetBelts() ([]models.Belt, error) {
+	rows, err := r.db.Query(`
+		SELECT id, name, rank, COALESCE(color, ''), min_months, min_attendance_rate
+		FROM belts
+		ORDER BY rank
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	belts := []models.Belt{}
+	for rows.Next() {
+		var b models.Belt
+		if err := rows.Scan(&b.ID, &b.Name, &b.Rank, &b.Color, &b.MinMonths, &b.MinAttendanceRate); err != nil {
+			return nil, err
+		}
+		belts = append(belts, b)
+	}
+	return belts, rows.Err()
+}
+
+// ReplaceBelts replaces the belt list. Candidates and promotions keep the belt
+// names they were recorded with.
+func (r *GradingRepository) ReplaceBelts(belts []models.Belt) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM belts`); err != nil {
+		return err
+	}
+	for _, b := range belts {
+		if _, err := tx.Exec(`
+			INSERT INTO belts (name, rank, color, min_months, min_attendance_rate)
+			VALUES ($1, $2, NULLIF($3, ''), $4, $5)
+		`, b.Name, b.Rank, b.Color, b.MinMonths, b.MinAttendanceRate); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+const gradingSessionColumns = `
+	s.id, s.title, s.exam_date, COALESCE(s.location, ''), s.status, COALESCE(s.notes, ''),
+	s.created_by, s.created_at,
+	(SELECT COUNT(*) FROM grading_candidates c WHERE c.session_id = s.id)
+`
+
+func scanGradingSession(row rowScanner) (*models.GradingSession, error) {
+	var s models.GradingSession
+	err := row.Scan(&s.ID, &s.Title, &s.ExamDate, &s.Location, &s.Status, &s.Notes,
+		&s.CreatedBy, &s.CreatedAt, &s.CandidateCount)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// GetSessions returns the grading sessions, latest first
+func (r *GradingRepository) GetSessions() ([]*models.GradingSession, error) {
+	rows, err := r.db.Query(`
+		SELECT ` + gradingSessionColumns + `
+		FROM grading_sessions s
+		ORDER BY s.exam_date DESC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []*models.GradingSession{}
+	for rows.Next() {
+		s, err := scanGradingSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, rows.Err()
+}
+
+// GetSession returns a grading session with its candidates
+func (r *GradingRepository) GetSession(id int) (*models.GradingSession, error) {
+	s, err := scanGradingSession(r.db.QueryRow(`
+		SELECT `+gradingSessionColumns+`
+		FROM grading_sessions s
+		WHERE s.id = $1
+	`, id))
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.Query(`
+		SELECT c.session_id, c.athlete_id, a.first_name || ' ' || a.last_name, c.current_belt, c.target_belt,
+		       c.result, c.score, c.examiner_id,
+		       COALESCE(TRIM(COALESCE(u.first_name, '') || ' ' || COALESCE(u.last_name, '')), ''),
+		       COALESCE(c.examiner_notes, ''), c.graded_at
+		FROM grading_candidates c
+		JOIN athletes a ON a.id = c.athlete_id
+		LEFT JOIN users u ON u.id = c.examiner_id
+		WHERE c.session_id = $1
+		ORDER BY a.last_name, a.first_name
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	s.Candidates = []*models.GradingCandidate{}
+	for rows.Next() {
+		c := &models.GradingCandidate{Reasons: []string{}}
+		if err := rows.Scan(&c.SessionID, &c.AthleteID, &c.AthleteName, &c.CurrentBelt, &c.TargetBelt,
+			&c.Result, &c.Score, &c.ExaminerID, &c.ExaminerName, &c.ExaminerNotes, &c.GradedAt); err != nil {
+			return nil, err
+		}
+		s.Candidates = append(s.Candidates, c)
+	}
+	return s, rows.Err()
+}
+
+func (r *GradingRepository) CreateSession(req *models.CreateGradingSessionRequest, examDate time.Time, createdBy int) (*models.GradingSession, error) {
+	var id int
+	err := r.db.QueryRow(`
+		INSERT INTO grading_sessions (title, exam_date, location, status, notes, created_by)
+		VALUES ($1, $2, NULLIF($3, ''), $4, NULLIF($5, ''), $6)
+		RETURNING id
+	`, req.Title, examDate, req.Location, req.Status, req.Notes, createdBy).Scan(&id)
+	if err != nil {
+		return nil, err
+	}
+	return r.GetSession(id)
+}
+
+func (r *GradingRepository) UpdateSession(id int, req *models.CreateGradingSessionRequest, examDate time.Time) (*models.GradingSession, error) {
+	res, err := r.db.Exec(`
+		UPDATE grading_sessions
+		SET title = $1, exam_date = $2, location = NULLIF($3, ''), status = $4, notes = NULLIF($5, '')
+		WHERE id = $6
+	`, req.Title, examDate, req.Location, req.Status, req.Notes, id)
+	if err != nil {
+		return nil, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return r.GetSession(id)
+}
+
+// DeleteSession deletes a grading session that has no recorded result
+func (r *GradingRepository) DeleteSession(id int) error {
+	var decided bool
+	err := r.db.QueryRow(`
+		SELECT EXISTS (SELECT 1 FROM grading_candidates WHERE session_id = $1 AND result <> 'pending')
+	`, id).Scan(&decided)
+	if err != nil {
+		return err
+	}
+	if decided {
+		return ErrGradingDecided
+	}
+
+	res, err := r.db.Exec(`DELETE FROM grading_sessions WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// GetGradeStatus returns the current belt of active approved athletes and
+// since when they hold it: their last promotion, or the day they joined.
+// athleteIDs restricts the list when not empty.
+func (r *GradingRepository) GetGradeStatus(athleteIDs []int) ([]*models.GradingCandidate, error) {
+	rows, err := r.db.Query(`
+		SELECT a.id, a.first_name || ' ' || a.last_name, COALESCE(a.belt_level, ''),
+		       COALESCE((SELECT MAX(p.promoted_at) FROM belt_promotions p WHERE p.athlete_id = a.id), a.registration_date)
+		FROM athletes a
+		WHERE a.is_active AND a.membership_status = 'approved'
+		  AND (cardinality($1::int[]) = 0 OR a.id = ANY($1))
+		ORDER BY a.last_name, a.first_name
+	`, pq.Array(int64s(athleteIDs)))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	candidates := []*models.GradingCandidate{}
+	for rows.Next() {
+		c := &models.GradingCandidate{Result: models.GradingPending}
+		if err := rows.Scan(&c.AthleteID, &c.AthleteName, &c.CurrentBelt, &c.GradeSince); err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, c)
+	}
+	return candidates, rows.Err()
+}
+
+// AddCandidates presents athletes at a session. Candidates already presented
+// get their belts refreshed while their result is pending.
+func (r *GradingRepository) AddCandidates(sessionID int, candidates []*models.GradingCandidate) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, c := range candidates {
+		_, err := tx.Exec(`
+			INSERT INTO grading_candidates (session_id, athlete_id, current_belt, target_belt)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (session_id, athlete_id) DO UPDATE
+			SET current_belt = EXCLUDED.current_belt, target_belt = EXCLUDED.target_belt
+			WHERE grading_candidates.result = 'pending'
+		`, sessionID, c.AthleteID, c.CurrentBelt, c.TargetBelt)
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23503" {
+			return sql.ErrNoRows
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// RemoveCandidate withdraws an athlete from a session before their result
+func (r *GradingRepository) RemoveCandidate(sessionID, athleteID int) error {
+	var result string
+	err := r.db.QueryRow(`
+		SELECT result FROM grading_candidates WHERE session_id = $1 AND athlete_id = $2
+	`, sessionID, athleteID).Scan(&result)
+	if err != nil {
+		return err
+	}
+	if result != models.GradingPending {
+		return ErrGradingDecided
+	}
+
+	_, err = r.db.Exec(`
+		DELETE FROM grading_candidates WHERE session_id = $1 AND athlete_id = $2 AND result = 'pending'
+	`, sessionID, athleteID)
+	return err
+}
+
+// RecordResult records an examiner's decision. A pass promotes the athlete:
+// the promotion is added to their history and their belt is updated. The
+// result is refused when the athlete no longer holds the candidate's current belt.
+func (r *GradingRepository) RecordResult(sessionID, athleteID int, req *models.GradingResultRequest, examinerID int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var result, currentBelt, targetBelt, status string
+	var examDate time.Time
+	err = tx.QueryRow(`
+		SELECT c.result, c.current_belt, c.target_belt, s.status, s.exam_date
+		FROM grading_candidates c
+		JOIN grading_sessions s ON s.id = c.session_id
+		WHERE c.session_id = $1 AND c.athlete_id = $2
+		FOR UPDATE OF c
+	`, sessionID, athleteID).Scan(&result, &currentBelt, &targetBelt, &status, &examDate)
+	if err != nil {
+		return err
+	}
+	if status == models.GradingCancelled {
+		return ErrGradingCancelled
+	}
+	if result != models.GradingPending {
+		return ErrGradingDecided
+	}
+
+	// Lock the athlete so two sessions cannot promote from the same belt
+	var belt string
+	err = tx.QueryRow(`SELECT COALESCE(belt_level, '') FROM athletes WHERE id = $1 FOR UPDATE`, athleteID).Scan(&belt)
+	if err != nil {
+		return err
+	}
+	if belt != currentBelt {
+		return ErrGradingBeltChanged
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE grading_candidates
+		SET result = $1, score = $2, examiner_id = $3, examiner_notes = NULLIF($4, ''), graded_at = $5
+		WHERE session_id = $6 AND athlete_id = $7
+	`, req.Result, req.Score, examinerID, req.Notes, models.WallClockNow(), sessionID, athleteID); err != nil {
+		return err
+	}
+
+	if req.Result == models.GradingPassed {
+		if _, err := tx.Exec(`
+			INSERT INTO belt_promotions (athlete_id, from_belt, to_belt, promoted_at, session_id, promoted_by, notes)
+			VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''))
+		`, athleteID, currentBelt, targetBelt, examDate, sessionID, examinerID, req.Notes); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`UPDATE athletes SET belt_level = $1 WHERE id = $2`, targetBelt, athleteID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetPromotions returns an athlete's belt history, latest first
+func (r *GradingRepository) GetPromotions(athleteID int) ([]*models.BeltPromotion, error) {
+	rows, err := r.db.Query(`
+		SELECT p.id, p.athlete_id, p.from_belt, p.to_belt, p.promoted_at, p.session_id,
+		       COALESCE(s.title, ''), p.promoted_by, COALESCE(p.notes, '')
+		FROM belt_promotions p
+		LEFT JOIN grading_sessions s ON s.id = p.session_id
+		WHERE p.athlete_id = $1
+		ORDER BY p.promoted_at DESC, p.id DESC
+	`, athleteID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	promotions := []*models.BeltPromotion{}
+	for rows.Next() {
+		p := &models.BeltPromotion{}
+		if err := rows.Scan(&p.ID, &p.AthleteID, &p.FromBelt, &p.ToBelt, &p.PromotedAt, &p.SessionID,
+			&p.SessionTitle, &p.PromotedBy, &p.Notes); err != nil {
+			return nil, err
+		}
+		promotions = append(promotions, p)
+	}
+	return promotions, rows.Err()
+}
@@ -0,0 +1,188 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"east-eagles/backend/internal/models"
+
+	"github.com/lib/pq"
+)
+
+var (
+	// ErrGuardianRequired is returned when a minor athlete would be left without a guardian
+	ErrGuardianRequired = errors.New("a minor athlete needs at least one guardian")
+	// ErrNotGuardianAccount is returned when linking an account that does not have the guardian role
+	ErrNotGuardianAccount = errors.New("account is not a guardian account")
+)
+
+type GuardianRepository struct {
+	db *sql.DB
+}
+
+func NewGuardianRepository(db *sql.DB) *GuardianRepository {
+	return &GuardianRepository{db: db}
+}
+
+// GetByAthlete returns an athlete's guardians, primary guardian first
+func (r *GuardianRepository) GetByAthlete(athleteID int) ([]*models.Guardian, error) {
+	rows, err := r.db.Query(`
+		SELECT g.user_id, g.athlete_id, u.email, COALESCE(u.first_name, ''), COALESCE(u.last_name, ''),
+		       g.relation, g.is_primary, g.created_at
+		FROM athlete_guardians g
+		JOIN users u ON u.id = g.user_id
+		WHERE g.athlete_id = $1
+		ORDER BY g.is_primary DESC, g.created_at
+	`, athleteID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	guardians := []*models.Guardian{}
+	for rows.Next() {
+		g := &models.Guardian{}
+		if err := rows.Scan(&g.UserID, &g.AthleteID, &g.Email, &g.FirstName, &g.LastName,
+			&g.Relation, &g.IsPrimary, &g.CreatedAt); err != nil {
+			return nil, err
+		}
+		guardians = append(guardians, g)
+	}
+	return guardians, rows.Err()
+}
+
+// GetWards returns the athletes a guardian account manages
+func (r *GuardianRepository) GetWards(userID int) ([]*models.Ward, error) {
+	rows, err := r.db.Query(`
+		SELECT a.id, a.first_name, a.last_name, a.birth_date, COALESCE(a.photo_url, ''),
+		       COALESCE(a.membership_status, 'pending'), g.relation, g.is_primary
+		FROM athlete_guardians g
+		JOIN athletes a ON a.id = g.athlete_id
+		WHERE g.user_id = $1
+		ORDER BY a.first_name, a.last_name
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	wards := []*models.Ward{}
+	for rows.Next() {
+		w := &models.Ward{}
+		if err := rows.Scan(&w.AthleteID, &w.FirstName, &w.LastName, &w.DateOfBirth, &w.PhotoURL,
+			&w.MembershipStatus, &w.Relation, &w.IsPrimary); err != nil {
+			return nil, err
+		}
+		wards = append(wards, w)
+	}
+	return wards, rows.Err()
+}
+
+// WardIDs returns the ids of the athletes a guardian account manages
+func (r *GuardianRepository) WardIDs(userID int) ([]int, error) {
+	rows, err := r.db.Query(`SELECT athlete_id FROM athlete_guardians WHERE user_id = $1 ORDER BY athlete_id`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// Add links a guardian account to an athlete, or updates the existing link.
+// Making a guardian primary demotes the athlete's other guardians.
+func (r *GuardianRepository) Add(athleteID, userID int, relation string, primary bool) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var role models.UserRole
+	err = tx.QueryRow(`SELECT role FROM users WHERE id = $1`, userID).Scan(&role)
+	if err != nil {
+		return err
+	}
+	if role != models.RoleGuardian {
+		return ErrNotGuardianAccount
+	}
+
+	if primary {
+		if _, err := tx.Exec(`UPDATE athlete_guardians SET is_primary = FALSE WHERE athlete_id = $1`, athleteID); err != nil {
+			return err
+		}
+	}
+	_, err = tx.Exec(`
+		INSERT INTO athlete_guardians (athlete_id, user_id, relation, is_primary)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (athlete_id, user_id) DO UPDATE SET relation = EXCLUDED.relation, is_primary = EXCLUDED.is_primary
+	`, athleteID, userID, relation, primary)
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23503" {
+		return sql.ErrNoRows // Unknown athlete
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Remove unlinks a guardian from an athlete. The last guardian of an approved
+// minor cannot be removed.
+func (r *GuardianRepository) Remove(athleteID, userID int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var dob *time.Time
+	var status string
+	err = tx.QueryRow(`
+		SELECT birth_date, COALESCE(membership_status, 'pending') FROM athletes WHERE id = $1 FOR UPDATE
+	`, athleteID).Scan(&dob, &status)
+	if err != nil {
+		return err
+	}
+
+	res, err := tx.Exec(`DELETE FROM athlete_guardians WHERE athlete_id = $1 AND user_id = $2`, athleteID, userID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+
+	if status == "approved" && models.IsMinor(dob, time.Now()) {
+		var remaining int
+		if err := tx.QueryRow(`SELECT COUNT(*) FROM athlete_guardians WHERE athlete_id = $1`, athleteID).Scan(&remaining); err != nil {
+			return err
+		}
+		if remaining == 0 {
+			return ErrGuardianRequired
+		}
+	}
+	return tx.Commit()
+}
+
+// needsGuardian reports whether the athlete is a minor without any guardian
+func needsGuardian(db *sql.DB, athleteID int) (bool, error) {
+	var dob *time.Time
+	var guardians int
+	err := db.QueryRow(`
+		SELECT a.birth_date, (SELECT COUNT(*) FROM athlete_guardians g WHERE g.athlete_id = a.id)
+		FROM athletes a WHERE a.id = $1
+	`, athleteID).Scan(&dob, &guardians)
+	if err != nil {
+		return false, err
+	}
+	return guardians == 0 && models.IsMinor(dob, time.Now()), nil
+}
@@ -0,0 +1,262 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"east-eagles/backend/internal/models"
+
+	"github.com/lib/pq"
+)
+
+type JobRepository struct {
+	db *sql.DB
+}
+
+func NewJobRepository(db *sql.DB) *JobRepository {
+	return &JobRepository{db: db}
+}
+
+const jobColumns = `id, type, payload, status, attempts, max_attempts, run_at, locked_by, locked_until,
+	COALESCE(last_error, ''), schedule_name, created_at, started_at, finished_at`
+
+func scanJob(row rowScanner) (*models.Job, error) {
+	j := &models.Job{}
+	var payload []byte
+	if err := row.Scan(
+		&j.ID, &j.Type, &payload, &j.Status, &j.Attempts, &j.MaxAttempts, &j.RunAt, &j.LockedBy, &j.LockedUntil,
+		&j.LastError, &j.ScheduleName, &j.CreatedAt, &j.StartedAt, &j.FinishedAt,
+	); err != nil {
+		return nil, err
+	}
+	j.Payload = payload
+	return j, nil
+}
+
+// Enqueue queues a job to run from runAt
+func (r *JobRepository) Enqueue(jobType string, payload []byte, runAt time.Time, maxAttempts int) (*models.Job, error) {
+	return scanJob(r.db.QueryRow(`
+		INSERT INTO jobs (type, payload, run_at, max_attempts)
+		VALUES ($1, $2, $3, $4)
+		RETURNING `+jobColumns, jobType, payload, runAt, maxAttempts))
+}
+
+// Claim locks the next due job of one of types for worker until lease runs
+// out and counts the attempt; it returns sql.ErrNoRows when none is due.
+// Workers on several replicas never claim the same job.
+func (r *JobRepository) Claim(worker string, types []string, lease time.Duration) (*models.Job, error) {
+	return scanJob(r.db.QueryRow(`
+		UPDATE jobs
+		SET status = 'running', attempts = attempts + 1, locked_by = $1,
+		    locked_until = NOW() + make_interval(secs => $2), started_at = NOW()
+		WHERE id = (
+		    SELECT id FROM jobs
+		    WHERE status = 'queued' AND run_at <= NOW() AND type = ANY($3)
+		    ORDER BY run_at, id
+		    LIMIT 1
+		    FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+jobColumns, worker, lease.Seconds(), pq.Array(types)))
+}
+
+// Complete records that worker finished a job
+func (r *JobRepository) Complete(id int64, worker string) error {
+	_, err := r.db.Exec(`
+		UPDATE jobs
+		SET status = 'succeeded', finished_at = NOW(), locked_by = NULL, locked_until = NULL, last_error = NULL
+		WHERE id = $1 AND status = 'running' AND locked_by = $2
+	`, id, worker)
+	return err
+}
+
+// Fail records a failed attempt: the job runs again after retryIn, or goes
+// to the dead letters once its attempts are used up
+func (r *JobRepository) Fail(id int64, worker, lastError string, retryIn time.Duration) error {
+	_, err := r.db.Exec(`
+		UPDATE jobs
+		SET status = CASE WHEN attempts >= max_attempts THEN 'dead' ELSE 'queued' END,
+		    finished_at = CASE WHEN attempts >= max_attempts THEN NOW() END,
+		    run_at = NOW() + make_interval(secs => $4),
+		    locked_by = NULL, locked_until = NULL, last_error = $3
+		WHERE id = $1 AND status = 'running' AND locked_by = $2
+	`, id, worker, lastError, retryIn.Seconds())
+	return err
+}
+
+// RequeueAbandoned releases the running jobs whose lease ran out, because
+// their worker stopped without finishing them, and returns how many
+func (r *JobRepository) RequeueAbandoned() (int, error) {
+	res, err := r.db.Exec(`
+		UPDATE jobs
+		SET status = CASE WHEN attempts >= max_attempts THEN 'dead' ELSE 'queued' END,
+		    finished_at = CASE WHEN attempts >= max_attempts THEN NOW() END,
+		    last_error = 'abandoned by ' || COALESCE(locked_by, 'unknown worker'),
+		    locked_by = NULL, locked_until = NULL, run_at = NOW()
+		WHERE status = 'running' AND locked_until < NOW()
+	`)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+// GetByID returns a job
+func (r *JobRepository) GetByID(id int64) (*models.Job, error) {
+	return scanJob(r.db.QueryRow(`SELECT `+jobColumns+` FROM jobs WHERE id = $1`, id))
+}
+
+// GetAll returns the latest jobs, optionally of one status and type
+func (r *JobRepository) GetAll(status, jobType string, limit int) ([]*models.Job, error) {
+	rows, err := r.db.Query(`
+		SELECT `+jobColumns+`
+		FROM jobs
+		WHERE ($1 = '' OR status = $1) AND ($2 = '' OR type = $2)
+		ORDER BY created_at DESC, id DESC
+		LIMIT $3
+	`, status, jobType, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	jobs := []*models.Job{}
+	for rows.Next() {
+		j, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, j)
+	}
+	return jobs, rows.Err()
+}
+
+// GetStats counts jobs per status
+func (r *JobRepository) GetStats() (models.JobStats, error) {
+	stats := models.JobStats{models.JobQueued: 0, models.JobRunning: 0, models.JobSucceeded: 0, models.JobDead: 0}
+	rows, err := r.db.Query(`SELECT status, COUNT(*) FROM jobs GROUP BY status`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var status string
+		var count int
+		if err := rows.Scan(&status, &count); err != nil {
+			return nil, err
+		}
+		stats[status] = count
+	}
+	return stats, rows.Err()
+}
+
+// Retry queues a dead job again with fresh attempts
+func (r *JobRepository) Retry(id int64) (*models.Job, error) {
+	return scanJob(r.db.QueryRow(`
+		UPDATE jobs
+		SET status = 'queued', attempts = 0, run_at = NOW(), finished_at = NULL
+		WHERE id = $1 AND status = 'dead'
+		RETURNING `+jobColumns, id))
+}
+
+// DeleteFinished removes the succeeded jobs finished before olderThan ago
+func (r *JobRepository) DeleteFinished(ctx context.Context, olderThan time.Duration) (int, error) {
+	res, err := r.db.ExecContext(ctx, `
+		DELETE FROM jobs WHERE status = 'succeeded' AND finished_at < NOW() - make_interval(secs => $1)
+	`, olderThan.Seconds())
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+// SaveSchedule creates or updates a periodic schedule; next is its first
+// run, kept when the cron expression did not change
+func (r *JobRepository) SaveSchedule(name, jobType, cron string, next time.Time) error {
+	_, err := r.db.Exec(`
+		INSERT INTO job_schedules (name, job_type, cron, next_run_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (name) DO UPDATE
+		SET job_type = EXCLUDED.job_type, cron = EXCLUDED.cron,
+		    next_run_at = CASE WHEN job_schedules.cron = EXCLUDED.cron THEN job_schedules.next_run_at
+		                  ELSE EXCLUDED.next_run_at END,
+		    updated_at = NOW()
+	`, name, jobType, cron, next)
+	return err
+}
+
+// GetSchedules returns the periodic schedules
+func (r *JobRepository) GetSchedules() ([]*models.JobSchedule, error) {
+	rows, err := r.db.Query(`
+		SELECT name, job_type, cron, next_run_at, last_run_at FROM job_schedules ORDER BY name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	schedules := []*models.JobSchedule{}
+	for rows.Next() {
+		s := &models.JobSchedule{}
+		if err := rows.Scan(&s.Name, &s.JobType, &s.Cron, &s.NextRunAt, &s.LastRunAt); err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, s)
+	}
+	return schedules, rows.Err()
+}
+
+// FireDueSchedules queues a job for each due schedule of names and moves it
+// to its next run. Runs missed while no server was up are fired once. A
+// schedule is fired by one replica only.
+func (r *JobRepository) FireDueSchedules(names []string, next func(name string, now time.Time) time.Time,
+	maxAttempts func(jobType string) int) (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`
+		SELECT name, job_type, NOW() FROM job_schedules
+		WHERE next_run_at <= NOW() AND name = ANY($1)
+		FOR UPDATE SKIP LOCKED
+	`, pq.Array(names))
+	if err != nil {
+		return 0, err
+	}
+	type due struct {
+		name, jobType string
+		now           time.Time
+	}
+	var schedules []due
+	for rows.Next() {
+		var d due
+		if err := rows.Scan(&d.name, &d.jobType, &d.now); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		schedules = append(schedules, d)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, d := range schedules {
+		if _, err := tx.Exec(`
+			INSERT INTO jobs (type, schedule_name, max_attempts) VALUES ($1, $2, $3)
+		`, d.jobType, d.name, maxAttempts(d.jobType)); err != nil {
+			return 0, err
+		}
+		if _, err := tx.Exec(`
+			UPDATE job_schedules SET last_run_at = NOW(), next_run_at = $2 WHERE name = $1
+		`, d.name, next(d.name, d.now)); err != nil {
+			return 0, err
+		}
+	}
+	return len(schedules), tx.Commit()
+}
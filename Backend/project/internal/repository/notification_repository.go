@@ -0,0 +1,435 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"east-eagles/backend/internal/models"
+
+	"github.com/lib/pq"
+)
+
+type NotificationRepository struct {
+	db *sql.DB
+}
+
+func NewNotificationRepository(db *sql.DB) *NotificationRepository {
+	return &NotificationRepository{db: db}
+}
+
+// GetPreferences returns a user's notification settings, with the defaults
+// when they never saved any
+func (r *NotificationRepository) GetPreferences(userID int) (*models.NotificationPreferences, error) {
+	p := &models.NotificationPreferences{}
+	err := r.db.QueryRow(`
+		SELECT u.id, COALESCE(np.language, 'fr'), COALESCE(np.email_enabled, true), COALESCE(np.sms_enabled, false),
+		       COALESCE(np.phone, a.phone, ''), COALESCE(np.muted, '{}'), COALESCE(np.updated_at, u.created_at, CURRENT_TIMESTAMP)
+		FROM users u
+		LEFT JOIN athletes a ON a.id = u.athlete_id
+		LEFT JOIN notification_preferences np ON np.user_id = u.id
+		WHERE u.id = $1
+	`, userID).Scan(&p.UserID, &p.Language, &p.EmailEnabled, &p.SMSEnabled, &p.Phone, pq.Array(&p.Muted), &p.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// SavePreferences creates or replaces a user's notification settings
+func (r *NotificationRepository) SavePreferences(p *models.NotificationPreferences) error {
+	return r.db.QueryRow(`
+		INSERT INTO notification_preferences (user_id, language, email_enabled, sms_enabled, phone, muted)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6)
+		ON CONFLICT (user_id) DO UPDATE
+		SET language = EXCLUDED.language, email_enabled = EXCLUDED.email_enabled,
+		    sms_enabled = EXCLUDED.sms_enabled, phone = EXCLUDED.phone, muted = EXCLUDED.muted,
+		    updated_at = CURRENT_TIMESTAMP
+		RETURNING updated_at
+	`, p.UserID, p.Language, p.EmailEnabled, p.SMSEnabled, p.Phone, pq.Array(nonNil(p.Muted))).Scan(&p.UpdatedAt)
+}
+
+// recipientColumns reads a user u with their preferences np; their phone
+// falls back to the one on their athlete profile a
+const recipientColumns = `u.id, u.athlete_id, TRIM(COALESCE(u.first_name, '') || ' ' || COALESCE(u.last_name, '')), u.email,
+	COALESCE(np.phone, a.phone, ''), COALESCE(np.language, 'fr'),
+	COALESCE(np.email_enabled, true), COALESCE(np.sms_enabled, false), COALESCE(np.muted, '{}')`
+
+func scanRecipient(row rowScanner) (*models.NotificationRecipient, error) {
+	rc := &models.NotificationRecipient{}
+	var userID int
+	if err := row.Scan(
+		&userID, &rc.AthleteID, &rc.Name, &rc.Email,
+		&rc.Phone, &rc.Language, &rc.EmailEnabled, &rc.SMSEnabled, pq.Array(&rc.Muted),
+	); err != nil {
+		return nil, err
+	}
+	rc.UserID = &userID
+	return rc, nil
+}
+
+// GetUserRecipient returns an active user as a notification recipient
+func (r *NotificationRepository) GetUserRecipient(userID int) (*models.NotificationRecipient, error) {
+	return scanRecipient(r.db.QueryRow(`
+		SELECT `+recipientColumns+`
+		FROM users u
+		LEFT JOIN athletes a ON a.id = u.athlete_id
+		LEFT JOIN notification_preferences np ON np.user_id = u.id
+		WHERE u.id = $1 AND u.is_active = true
+	`, userID))
+}
+
+// GetAthleteRecipients returns who hears about an athlete: their own account
+// and their guardians' accounts, or the athlete's profile contacts when
+// nobody has an account
+func (r *NotificationRepository) GetAthleteRecipients(ctx context.Context, athleteID int) ([]*models.NotificationRecipient, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+recipientColumns+`
+		FROM users u
+		LEFT JOIN athletes a ON a.id = u.athlete_id
+		LEFT JOIN notification_preferences np ON np.user_id = u.id
+		WHERE u.is_active = true
+		  AND (u.athlete_id = $1 OR u.id IN (SELECT g.user_id FROM athlete_guardians g WHERE g.athlete_id = $1))
+		ORDER BY u.id
+	`, athleteID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	recipients := []*models.NotificationRecipient{}
+	for rows.Next() {
+		rc, err := scanRecipient(rows)
+		if err != nil {
+			return nil, err
+		}
+		rc.AthleteID = &athleteID // Guardians hear about their ward
+		recipients = append(recipients, rc)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(recipients) > 0 {
+		return recipients, nil
+	}
+
+	rc := &models.NotificationRecipient{AthleteID: &athleteID, Language: "fr", EmailEnabled: true, Muted: []string{}}
+	err = r.db.QueryRowContext(ctx, `
+		SELECT first_name || ' ' || last_name, COALESCE(email, ''), COALESCE(phone, '')
+		FROM athletes WHERE id = $1
+	`, athleteID).Scan(&rc.Name, &rc.Email, &rc.Phone)
+	if err == sql.ErrNoRows {
+		return recipients, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return append(recipients, rc), nil
+}
+
+// Enqueue adds messages to the outbox and returns how many were added;
+// messages whose dedupe key was already queued for the recipient are skipped
+func (r *NotificationRepository) Enqueue(ctx context.Context, messages []*models.OutboxMessage) (int, error) {
+	if len(messages) == 0 {
+		return 0, nil
+	}
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	added := 0
+	for _, m := range messages {
+		res, err := tx.ExecContext(ctx, `
+			INSERT INTO notification_outbox (user_id, athlete_id, channel, recipient, template, language, subject, body, dedupe_key)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			ON CONFLICT (dedupe_key, channel, recipient) WHERE dedupe_key IS NOT NULL DO NOTHING
+		`, m.UserID, m.AthleteID, m.Channel, m.Recipient, m.Template, m.Language, m.Subject, m.Body, m.DedupeKey)
+		if err != nil {
+			return 0, err
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		added += int(n)
+	}
+	return added, tx.Commit()
+}
+
+const outboxColumns = `id, user_id, athlete_id, channel, recipient, template, language, subject, body,
+	status, attempts, next_attempt_at, COALESCE(last_error, ''), dedupe_key, created_at, sent_at`
+
+func scanOutboxMessage(row rowScanner) (*models.OutboxMessage, error) {
+	m := &models.OutboxMessage{}
+	if err := row.Scan(
+		&m.ID, &m.UserID, &m.AthleteID, &m.Channel, &m.Recipient, &m.Template, &m.Language, &m.Subject, &m.Body,
+		&m.Status, &m.Attempts, &m.NextAttemptAt, &m.LastError, &m.DedupeKey, &m.CreatedAt, &m.SentAt,
+	); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (r *NotificationRepository) queryOutbox(query string, args ...interface{}) ([]*models.OutboxMessage, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	messages := []*models.OutboxMessage{}
+	for rows.Next() {
+		m, err := scanOutboxMessage(rows)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, m)
+	}
+	return messages, rows.Err()
+}
+
+// ClaimDue marks up to limit due messages as being sent and counts the
+// attempt. A claimed message becomes due again after lease, so messages of a
+// worker that died mid-send are picked up by another one.
+func (r *NotificationRepository) ClaimDue(limit int, lease time.Duration) ([]*models.OutboxMessage, error) {
+	return r.queryOutbox(`
+		UPDATE notification_outbox
+		SET status = 'sending', attempts = attempts + 1,
+		    next_attempt_at = CURRENT_TIMESTAMP + make_interval(secs => $2)
+		WHERE id IN (
+		    SELECT id FROM notification_outbox
+		    WHERE status IN ('pending', 'sending') AND next_attempt_at <= CURRENT_TIMESTAMP
+		    ORDER BY next_attempt_at
+		    LIMIT $1
+		    FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+outboxColumns, limit, lease.Seconds())
+}
+
+// MarkSent records a successful delivery
+func (r *NotificationRepository) MarkSent(id int) error {
+	_, err := r.db.Exec(`
+		UPDATE notification_outbox
+		SET status = 'sent', sent_at = CURRENT_TIMESTAMP, last_error = NULL
+		WHERE id = $1
+	`, id)
+	return err
+}
+
+// MarkFailed records a failed delivery; the message is tried again after
+// retryIn, or given up on when retryIn is zero
+func (r *NotificationRepository) MarkFailed(id int, lastError string, retryIn time.Duration) error {
+	status := models.OutboxPending
+	if retryIn <= 0 {
+		status = models.OutboxFailed
+	}
+	_, err := r.db.Exec(`
+		UPDATE notification_outbox
+		SET status = $2, last_error = $3, next_attempt_at = CURRENT_TIMESTAMP + make_interval(secs => $4)
+		WHERE id = $1
+	`, id, status, lastError, retryIn.Seconds())
+	return err
+}
+
+// GetOutbox returns the latest outbox messages, optionally of one status
+func (r *NotificationRepository) GetOutbox(status string, limit int) ([]*models.OutboxMessage, error) {
+	return r.queryOutbox(`
+		SELECT `+outboxColumns+`
+		FROM notification_outbox
+		WHERE ($1 = '' OR status = $1)
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2
+	`, status, limit)
+}
+
+// Retry puts a failed message back in the queue with fresh attempts
+func (r *NotificationRepository) Retry(id int) (*models.OutboxMessage, error) {
+	return scanOutboxMessage(r.db.QueryRow(`
+		UPDATE notification_outbox
+		SET status = 'pending', attempts = 0, next_attempt_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND status = 'failed'
+		RETURNING `+outboxColumns, id))
+}
+
+// GetExpiringDocuments returns the approved documents of active athletes
+// that expire within the next days
+func (r *NotificationRepository) GetExpiringDocuments(ctx context.Context, days int) ([]models.ExpiringDocument, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT d.id, d.athlete_id, d.document_type, d.expiry_date
+		FROM documents d
+		JOIN athletes a ON a.id = d.athlete_id
+		WHERE d.validation_status = 'approved' AND `+activeAthlete+`
+		  AND d.expiry_date >= CURRENT_DATE AND d.expiry_date <= CURRENT_DATE + $1::int
+		ORDER BY d.expiry_date, d.id
+	`, days)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	docs := []models.ExpiringDocument{}
+	for rows.Next() {
+		var d models.ExpiringDocument
+		if err := rows.Scan(&d.DocumentID, &d.AthleteID, &d.DocumentType, &d.ExpiryDate); err != nil {
+			return nil, err
+		}
+		docs = append(docs, d)
+	}
+	return docs, rows.Err()
+}
+
+// GetOverdueAthletes returns active athletes whose last paid period ended
+// more than graceDays ago. Athletes who never paid are left to the arrears
+// report.
+func (r *NotificationRepository) GetOverdueAthletes(ctx context.Context, graceDays int) ([]models.OverdueAthlete, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT a.id, c.last_end
+		FROM athletes a
+		JOIN LATERAL (
+		    SELECT MAX(end_date) AS last_end
+		    FROM payments
+		    WHERE athlete_id = a.id AND kind = 'period' AND status = 'active'
+		) c ON c.last_end IS NOT NULL
+		WHERE `+activeAthlete+` AND c.last_end + $1::int < CURRENT_DATE
+		ORDER BY a.id
+	`, graceDays)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	athletes := []models.OverdueAthlete{}
+	for rows.Next() {
+		var o models.OverdueAthlete
+		if err := rows.Scan(&o.AthleteID, &o.CoveredUntil); err != nil {
+			return nil, err
+		}
+		athletes = append(athletes, o)
+	}
+	return athletes, rows.Err()
+}
+
+// NotificationChannel is the NOTIFY channel announcing new in-app
+// notifications, with "user_id:id" payloads
+const NotificationChannel = "notifications"
+
+const notificationColumns = `id, user_id, athlete_id, type, title, message, read_at, created_at`
+
+func scanNotification(row rowScanner) (*models.Notification, error) {
+	n := &models.Notification{}
+	if err := row.Scan(&n.ID, &n.UserID, &n.AthleteID, &n.Type, &n.Title, &n.Message, &n.ReadAt, &n.CreatedAt); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+func (r *NotificationRepository) queryNotifications(query string, args ...interface{}) ([]*models.Notification, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	notifications := []*models.Notification{}
+	for rows.Next() {
+		n, err := scanNotification(rows)
+		if err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, n)
+	}
+	return notifications, rows.Err()
+}
+
+// CreateNotifications stores in-app notifications, skipping those whose
+// dedupe key the user already has; each one is announced on
+// NotificationChannel when the transaction commits
+func (r *NotificationRepository) CreateNotifications(ctx context.Context, notifications []*models.Notification) error {
+	if len(notifications) == 0 {
+		return nil
+	}
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, n := range notifications {
+		err := tx.QueryRowContext(ctx, `
+			INSERT INTO notifications (user_id, athlete_id, type, title, message, dedupe_key)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			ON CONFLICT (user_id, dedupe_key) WHERE dedupe_key IS NOT NULL DO NOTHING
+			RETURNING id, created_at
+		`, n.UserID, n.AthleteID, n.Type, n.Title, n.Message, n.DedupeKey).Scan(&n.ID, &n.CreatedAt)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `SELECT pg_notify($1, $2)`, NotificationChannel, fmt.Sprintf("%d:%d", n.UserID, n.ID)); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetNotification returns one of a user's notifications
+func (r *NotificationRepository) GetNotification(userID, id int) (*models.Notification, error) {
+	return scanNotification(r.db.QueryRow(`
+		SELECT `+notificationColumns+` FROM notifications WHERE id = $1 AND user_id = $2
+	`, id, userID))
+}
+
+// GetNotifications returns a user's latest notifications, older than
+// beforeID when it is set
+func (r *NotificationRepository) GetNotifications(userID int, unreadOnly bool, beforeID, limit int) ([]*models.Notification, error) {
+	return r.queryNotifications(`
+		SELECT `+notificationColumns+`
+		FROM notifications
+		WHERE user_id = $1 AND (NOT $2 OR read_at IS NULL) AND ($3 = 0 OR id < $3)
+		ORDER BY id DESC
+		LIMIT $4
+	`, userID, unreadOnly, beforeID, limit)
+}
+
+// GetNotificationsSince returns a user's notifications newer than afterID,
+// oldest first, for streams resuming after a disconnect
+func (r *NotificationRepository) GetNotificationsSince(userID, afterID, limit int) ([]*models.Notification, error) {
+	return r.queryNotifications(`
+		SELECT `+notificationColumns+`
+		FROM notifications
+		WHERE user_id = $1 AND id > $2
+		ORDER BY id
+		LIMIT $3
+	`, userID, afterID, limit)
+}
+
+// CountUnread returns how many notifications a user has not read
+func (r *NotificationRepository) CountUnread(userID int) (int, error) {
+	var count int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM notifications WHERE user_id = $1 AND read_at IS NULL`, userID).Scan(&count)
+	return count, err
+}
+
+// MarkNotificationRead marks one of a user's notifications as read
+func (r *NotificationRepository) MarkNotificationRead(userID, id int) (*models.Notification, error) {
+	return scanNotification(r.db.QueryRow(`
+		UPDATE notifications SET read_at = COALESCE(read_at, CURRENT_TIMESTAMP)
+		WHERE id = $1 AND user_id = $2
+		RETURNING `+notificationColumns, id, userID))
+}
+
+// MarkAllNotificationsRead marks all of a user's notifications as read and
+// returns how many were unread
+func (r *NotificationRepository) MarkAllNotificationsRead(userID int) (int, error) {
+	res, err := r.db.Exec(`UPDATE notifications SET read_at = CURRENT_TIMESTAMP WHERE user_id = $1 AND read_at IS NULL`, userID)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
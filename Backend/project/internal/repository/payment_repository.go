@@ -2,6 +2,8 @@ package repository
 
 import (
 	"database/sql"
+	"fmt"
+	"math"
 	"time"
 
 	"east-eagles/backend/internal/models"
@@ -15,56 +17,394 @@ func NewPaymentRepository(db *sql.DB) *PaymentRepository {
 	return &PaymentRepository{db: db}
 }
 
-func (r *PaymentRepository) Create(req *models.CreatePaymentRequest, recordedBy int) (*models.Payment, error) {
-	// Calculate EndDate based on StartDate and MonthsCovered
-	startDate, err := time.Parse("2006-01-02", req.StartDate)
-	if err != nil {
+// PaymentOverlapError is returned when a payment period overlaps existing coverage
+type PaymentOverlapError struct {
+	Conflicts []*models.Payment
+}
+
+func (e *PaymentOverlapError) Error() string {
+	return fmt.Sprintf("payment period overlaps %d existing payment(s)", len(e.Conflicts))
+}
+
+// PaymentValidationError is returned when a request breaks a payment rule
+// (unknown method, overpayment, refund larger than what was collected...)
+type PaymentValidationError struct {
+	Message string
+}
+
+func (e *PaymentValidationError) Error() string {
+	return e.Message
+}
+
+// coveringPayment restricts a query on payments to rows that grant coverage
+const coveringPayment = `kind = 'period' AND status = 'active'`
+
+// paymentColumns is the column list read by scanPayment (payments aliased as p).
+// The lateral join s must provide the net amount collected on a period.
+const paymentColumns = `
+	p.id, p.athlete_id, p.amount, p.months_covered, p.start_date, p.end_date, p.payment_date,
+	COALESCE(p.notes, ''), p.recorded_by, COALESCE(p.receipt_number, ''),
+	p.method, COALESCE(p.external_reference, ''), p.kind, p.parent_payment_id, p.status,
+	COALESCE(p.amount_due, 0), COALESCE(s.paid, 0), p.refunded_payment_id`
+
+// paymentTotals is the lateral join used with paymentColumns
+const paymentTotals = `
+	LEFT JOIN LATERAL (
+	    SELECT SUM(c.amount) AS paid
+	    FROM payments c
+	    WHERE p.kind = 'period' AND (c.id = p.id OR c.parent_payment_id = p.id)
+	) s ON true`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanPayment reads paymentColumns followed by any extra destinations
+func scanPayment(row rowScanner, extra ...interface{}) (*models.Payment, error) {
+	p := &models.Payment{}
+	var startDate, endDate time.Time
+	dest := []interface{}{
+		&p.ID, &p.AthleteID, &p.Amount, &p.MonthsCovered, &startDate, &endDate, &p.PaymentDate,
+		&p.Notes, &p.RecordedBy, &p.ReceiptNumber,
+		&p.Method, &p.ExternalReference, &p.Kind, &p.ParentPaymentID, &p.Status,
+		&p.AmountDue, &p.AmountPaid, &p.RefundedPaymentID,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
 		return nil, err
 	}
-	endDate := startDate.AddDate(0, req.MonthsCovered, 0)
+	p.StartDate = startDate.Format("2006-01-02")
+	p.EndDate = endDate.Format("2006-01-02")
+	if p.Kind == models.PaymentKindPeriod && p.Status == models.PaymentStatusActive {
+		p.Balance = roundAmount(p.AmountDue - p.AmountPaid)
+	}
+	return p, nil
+}
+
+func roundAmount(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// coverageEndDate returns the last covered day (inclusive) of a period
+func coverageEndDate(start time.Time, months int) time.Time {
+	return start.AddDate(0, months, -1)
+}
+
+// nextReceiptNumber reserves the next receipt number for year.
+// The counter row is updated inside the caller's transaction, so a rolled back
+// payment releases its number and the sequence stays gap-free.
+func nextReceiptNumber(tx *sql.Tx, year int) (int, error) {
+	var seq int
+	err := tx.QueryRow(`
+		INSERT INTO receipt_sequences (year, last_number)
+		VALUES ($1, 1)
+		ON CONFLICT (year) DO UPDATE SET last_number = receipt_sequences.last_number + 1
+		RETURNING last_number
+	`, year).Scan(&seq)
+	return seq, err
+}
+
+// formatReceiptNumber renders a receipt number as YYYY-NNNNN
+func formatReceiptNumber(year, seq int) string {
+	return fmt.Sprintf("%d-%05d", year, seq)
+}
+
+// resolveMethod defaults an empty method to fallback and validates it
+func resolveMethod(method, fallback models.PaymentMethod) (models.PaymentMethod, error) {
+	if method == "" {
+		method = fallback
+	}
+	if !method.IsValid() {
+		return "", &PaymentValidationError{Message: fmt.Sprintf("unknown payment method: %s", method)}
+	}
+	return method, nil
+}
+
+// insertPayment writes a payment row with a fresh receipt number and fills in
+// the generated fields
+func insertPayment(tx *sql.Tx, p *models.Payment) error {
+	receiptYear := time.Now().Year()
+	receiptSeq, err := nextReceiptNumber(tx, receiptYear)
+	if err != nil {
+		return err
+	}
+	p.ReceiptNumber = formatReceiptNumber(receiptYear, receiptSeq)
+	if p.Status == "" {
+		p.Status = models.PaymentStatusActive
+	}
+
+	var amountDue interface{}
+	if p.Kind == models.PaymentKindPeriod {
+		amountDue = p.AmountDue
+	}
 
 	query := `
 		INSERT INTO payments (
-			athlete_id, amount, months_covered, start_date, end_date, notes, recorded_by
+			athlete_id, amount, months_covered, start_date, end_date, notes, recorded_by,
+			receipt_year, receipt_seq, receipt_number,
+			method, external_reference, kind, parent_payment_id, status, amount_due,
+			refunded_payment_id
 		)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
 		RETURNING id, payment_date
 	`
+	return tx.QueryRow(
+		query,
+		p.AthleteID,
+		p.Amount,
+		p.MonthsCovered,
+		p.StartDate,
+		p.EndDate,
+		p.Notes,
+		p.RecordedBy,
+		receiptYear,
+		receiptSeq,
+		p.ReceiptNumber,
+		p.Method,
+		p.ExternalReference,
+		p.Kind,
+		p.ParentPaymentID,
+		p.Status,
+		amountDue,
+		p.RefundedPaymentID,
+	).Scan(&p.ID, &p.PaymentDate)
+}
 
-	payment := &models.Payment{
-		AthleteID:     req.AthleteID,
-		Amount:        req.Amount,
-		MonthsCovered: req.MonthsCovered,
-		StartDate:     req.StartDate,
-		EndDate:       endDate.Format("2006-01-02"),
-		Notes:         req.Notes,
-		RecordedBy:    &recordedBy,
+func (r *PaymentRepository) Create(req *models.CreatePaymentRequest, recordedBy int) (*models.Payment, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
 	}
+	defer tx.Rollback()
 
-	err = r.db.QueryRow(
-		query,
-		payment.AthleteID,
-		payment.Amount,
-		payment.MonthsCovered,
-		payment.StartDate,
-		payment.EndDate,
-		payment.Notes,
-		recordedBy,
-	).Scan(&payment.ID, &payment.PaymentDate)
+	payment, err := createPeriod(tx, req, &recordedBy)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return payment, nil
+}
+
+// createPeriod validates and records a period payment inside tx. It is shared
+// by coach-entered payments and online checkouts (recordedBy is nil for those).
+func createPeriod(tx *sql.Tx, req *models.CreatePaymentRequest, recordedBy *int) (*models.Payment, error) {
+	method, err := resolveMethod(req.Method, models.PaymentMethodCash)
+	if err != nil {
+		return nil, err
+	}
+	amountDue := req.AmountDue
+	if amountDue == 0 {
+		amountDue = req.Amount
+	}
+	if req.Amount <= 0 || req.Amount > amountDue {
+		return nil, &PaymentValidationError{Message: "amount must be positive and not exceed amount_due"}
+	}
+
+	// Lock the athlete row so concurrent payments are checked one at a time
+	if _, err := tx.Exec(`SELECT id FROM athletes WHERE id = $1 FOR UPDATE`, req.AthleteID); err != nil {
+		return nil, err
+	}
+
+	startDate, err := resolveStartDate(tx, req, 0)
+	if err != nil {
+		return nil, err
+	}
+	endDate := coverageEndDate(startDate, req.MonthsCovered)
 
+	warnings, err := checkCoverage(tx, req.AthleteID, startDate, endDate, 0, req.AllowOverlap)
 	if err != nil {
 		return nil, err
 	}
 
+	payment := &models.Payment{
+		AthleteID:         req.AthleteID,
+		Amount:            req.Amount,
+		MonthsCovered:     req.MonthsCovered,
+		StartDate:         startDate.Format("2006-01-02"),
+		EndDate:           endDate.Format("2006-01-02"),
+		Notes:             req.Notes,
+		RecordedBy:        recordedBy,
+		Method:            method,
+		ExternalReference: req.ExternalReference,
+		Kind:              models.PaymentKindPeriod,
+		AmountDue:         amountDue,
+		AmountPaid:        req.Amount,
+		Balance:           roundAmount(amountDue - req.Amount),
+		Warnings:          warnings,
+	}
+
+	if err := insertPayment(tx, payment); err != nil {
+		return nil, err
+	}
+
 	return payment, nil
 }
 
+// lockPeriod loads a payment for update together with the net amount collected on it
+func lockPeriod(tx *sql.Tx, id int) (*models.Payment, error) {
+	if _, err := tx.Exec(`SELECT id FROM payments WHERE id = $1 FOR UPDATE`, id); err != nil {
+		return nil, err
+	}
+	row := tx.QueryRow(`
+		SELECT `+paymentColumns+`
+		FROM payments p`+paymentTotals+`
+		WHERE p.id = $1
+	`, id)
+	return scanPayment(row)
+}
+
+// AddInstallment records a further partial payment against a period payment
+func (r *PaymentRepository) AddInstallment(periodID int, req *models.InstallmentRequest, recordedBy int) (*models.Payment, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	period, err := lockPeriod(tx, periodID)
+	if err != nil {
+		return nil, err
+	}
+	if period.Kind != models.PaymentKindPeriod || period.Status != models.PaymentStatusActive {
+		return nil, &PaymentValidationError{Message: "installments can only be added to an active period payment"}
+	}
+
+	method, err := resolveMethod(req.Method, period.Method)
+	if err != nil {
+		return nil, err
+	}
+	if req.Amount <= 0 {
+		return nil, &PaymentValidationError{Message: "amount must be positive"}
+	}
+	if req.Amount > period.Balance+0.005 {
+		return nil, &PaymentValidationError{Message: fmt.Sprintf("amount exceeds the remaining balance (%.2f)", period.Balance)}
+	}
+
+	installment := &models.Payment{
+		AthleteID:         period.AthleteID,
+		Amount:            req.Amount,
+		StartDate:         period.StartDate,
+		EndDate:           period.EndDate,
+		Notes:             req.Notes,
+		RecordedBy:        &recordedBy,
+		Method:            method,
+		ExternalReference: req.ExternalReference,
+		Kind:              models.PaymentKindInstallment,
+		ParentPaymentID:   &period.ID,
+	}
+	if err := insertPayment(tx, installment); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return installment, nil
+}
+
+// Refund issues a credit note reversing all or part of a payment. The original
+// rows are kept; a period whose net collected amount drops to zero (or with
+// CancelPeriod set) is marked refunded and stops granting coverage.
+func (r *PaymentRepository) Refund(paymentID int, req *models.RefundRequest, recordedBy int) (*models.Payment, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	target, err := lockPeriod(tx, paymentID)
+	if err != nil {
+		return nil, err
+	}
+
+	period := target
+	switch target.Kind {
+	case models.PaymentKindRefund:
+		return nil, &PaymentValidationError{Message: "a refund cannot be refunded"}
+	case models.PaymentKindInstallment:
+		if period, err = lockPeriod(tx, *target.ParentPaymentID); err != nil {
+			return nil, err
+		}
+	}
+
+	// What is left to refund on an installment: the row lock taken by
+	// lockPeriod keeps concurrent refunds of it from both passing this check
+	refundable := target.Amount
+	if target.Kind == models.PaymentKindInstallment {
+		var refunded float64
+		if err := tx.QueryRow(`
+			SELECT COALESCE(-SUM(amount), 0) FROM payments
+			WHERE kind = 'refund' AND refunded_payment_id = $1
+		`, target.ID).Scan(&refunded); err != nil {
+			return nil, err
+		}
+		refundable = roundAmount(target.Amount - refunded)
+	}
+
+	amount := req.Amount
+	if amount == 0 {
+		amount = refundable
+		if target.Kind == models.PaymentKindPeriod {
+			amount = period.AmountPaid
+		}
+	}
+	if amount <= 0 {
+		return nil, &PaymentValidationError{Message: "nothing left to refund"}
+	}
+	if amount > period.AmountPaid+0.005 {
+		return nil, &PaymentValidationError{Message: fmt.Sprintf("refund exceeds the amount collected (%.2f)", period.AmountPaid)}
+	}
+	// Refunds of an installment cannot return more than the installment itself
+	if target.Kind == models.PaymentKindInstallment && amount > refundable+0.005 {
+		return nil, &PaymentValidationError{Message: fmt.Sprintf("refund exceeds what is left of the installment (%.2f)", refundable)}
+	}
+
+	method, err := resolveMethod(req.Method, target.Method)
+	if err != nil {
+		return nil, err
+	}
+
+	refund := &models.Payment{
+		AthleteID:         period.AthleteID,
+		Amount:            -amount,
+		StartDate:         period.StartDate,
+		EndDate:           period.EndDate,
+		Notes:             req.Notes,
+		RecordedBy:        &recordedBy,
+		Method:            method,
+		ExternalReference: req.ExternalReference,
+		Kind:              models.PaymentKindRefund,
+		ParentPaymentID:   &period.ID,
+		RefundedPaymentID: &target.ID,
+	}
+	if err := insertPayment(tx, refund); err != nil {
+		return nil, err
+	}
+
+	if req.CancelPeriod || period.AmountPaid-amount < 0.005 {
+		_, err := tx.Exec(`UPDATE payments SET status = $1 WHERE id = $2`, models.PaymentStatusRefunded, period.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return refund, nil
+}
+
 func (r *PaymentRepository) GetByAthlete(athleteID int) ([]*models.Payment, error) {
 	query := `
-		SELECT id, athlete_id, amount, months_covered, start_date, end_date, payment_date, notes, recorded_by
-		FROM payments
-		WHERE athlete_id = $1
-		ORDER BY payment_date DESC
+		SELECT ` + paymentColumns + `
+		FROM payments p` + paymentTotals + `
+		WHERE p.athlete_id = $1
+		ORDER BY p.payment_date DESC
 	`
 	rows, err := r.db.Query(query, athleteID)
 	if err != nil {
@@ -74,31 +414,27 @@ func (r *PaymentRepository) GetByAthlete(athleteID int) ([]*models.Payment, erro
 
 	var payments []*models.Payment
 	for rows.Next() {
-		p := &models.Payment{}
-		var startDate, endDate time.Time
-		if err := rows.Scan(
-			&p.ID, &p.AthleteID, &p.Amount, &p.MonthsCovered, &startDate, &endDate,
-			&p.PaymentDate, &p.Notes, &p.RecordedBy,
-		); err != nil {
+		p, err := scanPayment(rows)
+		if err != nil {
 			return nil, err
 		}
-		p.StartDate = startDate.Format("2006-01-02")
-		p.EndDate = endDate.Format("2006-01-02")
 		payments = append(payments, p)
 	}
 	return payments, nil
 }
 
-func (r *PaymentRepository) GetRecent() ([]*models.Payment, error) {
+// GetRecent returns the latest payments, optionally filtered by method
+func (r *PaymentRepository) GetRecent(method models.PaymentMethod) ([]*models.Payment, error) {
 	query := `
-		SELECT p.id, p.athlete_id, p.amount, p.months_covered, p.start_date, p.end_date, p.payment_date, p.notes, p.recorded_by,
+		SELECT ` + paymentColumns + `,
 		       a.first_name || ' ' || a.last_name as athlete_name
 		FROM payments p
-		JOIN athletes a ON p.athlete_id = a.id
+		JOIN athletes a ON p.athlete_id = a.id` + paymentTotals + `
+		WHERE ($1 = '' OR p.method = $1)
 		ORDER BY p.payment_date DESC
 		LIMIT 50
 	`
-	rows, err := r.db.Query(query)
+	rows, err := r.db.Query(query, string(method))
 	if err != nil {
 		return nil, err
 	}
@@ -106,48 +442,133 @@ func (r *PaymentRepository) GetRecent() ([]*models.Payment, error) {
 
 	var payments []*models.Payment
 	for rows.Next() {
-		p := &models.Payment{}
-		var startDate, endDate time.Time
-		if err := rows.Scan(
-			&p.ID, &p.AthleteID, &p.Amount, &p.MonthsCovered, &startDate, &endDate,
-			&p.PaymentDate, &p.Notes, &p.RecordedBy, &p.AthleteName,
-		); err != nil {
+		var athleteName string
+		p, err := scanPayment(rows, &athleteName)
+		if err != nil {
 			return nil, err
 		}
-		p.StartDate = startDate.Format("2006-01-02")
-		p.EndDate = endDate.Format("2006-01-02")
+		p.AthleteName = athleteName
 		payments = append(payments, p)
 	}
 	return payments, nil
 }
 
+// GetByID returns a payment with the athlete and recording coach names
+func (r *PaymentRepository) GetByID(id int) (*models.Payment, error) {
+	query := `
+		SELECT ` + paymentColumns + `,
+		       a.first_name || ' ' || a.last_name as athlete_name,
+		       COALESCE(TRIM(COALESCE(u.first_name, '') || ' ' || COALESCE(u.last_name, '')), '')
+		FROM payments p
+		JOIN athletes a ON p.athlete_id = a.id
+		LEFT JOIN users u ON p.recorded_by = u.id` + paymentTotals + `
+		WHERE p.id = $1
+	`
+	var athleteName, recordedByName string
+	p, err := scanPayment(r.db.QueryRow(query, id), &athleteName, &recordedByName)
+	if err != nil {
+		return nil, err
+	}
+	p.AthleteName = athleteName
+	p.RecordedByName = recordedByName
+	return p, nil
+}
+
+// GetBalance sums what an athlete owes on active periods, what was collected and refunded
+func (r *PaymentRepository) GetBalance(athleteID int) (*models.PaymentBalance, error) {
+	query := `
+		SELECT COALESCE(SUM(p.amount_due) FILTER (WHERE p.kind = 'period' AND p.status = 'active'), 0),
+		       COALESCE(SUM(p.amount) FILTER (WHERE p.kind <> 'refund'), 0),
+		       COALESCE(-SUM(p.amount) FILTER (WHERE p.kind = 'refund'), 0),
+		       COALESCE(SUM(p.amount) FILTER (WHERE parent.status = 'active'), 0)
+		FROM payments p
+		JOIN payments parent ON parent.id = COALESCE(p.parent_payment_id, p.id)
+		WHERE p.athlete_id = $1
+	`
+	b := &models.PaymentBalance{AthleteID: athleteID}
+	var collectedOnActive float64
+	err := r.db.QueryRow(query, athleteID).Scan(&b.TotalDue, &b.TotalPaid, &b.TotalRefunded, &collectedOnActive)
+	if err != nil {
+		return nil, err
+	}
+	b.Outstanding = roundAmount(b.TotalDue - collectedOnActive)
+	return b, nil
+}
+
+// Update modifies the period, price or method of a period payment.
+// Installments and refunds are immutable; refund them instead.
 func (r *PaymentRepository) Update(id int, req *models.CreatePaymentRequest, recordedBy int) (*models.Payment, error) {
-	// Calculate EndDate based on StartDate and MonthsCovered
-	startDate, err := time.Parse("2006-01-02", req.StartDate)
+	amountDue := req.AmountDue
+	if amountDue == 0 {
+		amountDue = req.Amount
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`SELECT id FROM athletes WHERE id = $1 FOR UPDATE`, req.AthleteID); err != nil {
+		return nil, err
+	}
+
+	current, err := lockPeriod(tx, id)
+	if err != nil {
+		return nil, err
+	}
+	if current.Kind != models.PaymentKindPeriod {
+		return nil, &PaymentValidationError{Message: "only period payments can be edited"}
+	}
+
+	method, err := resolveMethod(req.Method, current.Method)
+	if err != nil {
+		return nil, err
+	}
+
+	// Net collected once this row's own amount is replaced by the new one
+	paid := current.AmountPaid - current.Amount + req.Amount
+	if req.Amount <= 0 || paid > amountDue+0.005 {
+		return nil, &PaymentValidationError{Message: "amount must be positive and not exceed amount_due"}
+	}
+
+	startDate, err := resolveStartDate(tx, req, id)
+	if err != nil {
+		return nil, err
+	}
+	endDate := coverageEndDate(startDate, req.MonthsCovered)
+
+	warnings, err := checkCoverage(tx, req.AthleteID, startDate, endDate, id, req.AllowOverlap)
 	if err != nil {
 		return nil, err
 	}
-	endDate := startDate.AddDate(0, req.MonthsCovered, 0)
 
 	query := `
 		UPDATE payments
-		SET athlete_id = $1, amount = $2, months_covered = $3, start_date = $4, end_date = $5, notes = $6, recorded_by = $7
-		WHERE id = $8
-		RETURNING id, payment_date
+		SET athlete_id = $1, amount = $2, months_covered = $3, start_date = $4, end_date = $5, notes = $6, recorded_by = $7,
+		    method = $8, external_reference = $9, amount_due = $10
+		WHERE id = $11
+		RETURNING id, payment_date, COALESCE(receipt_number, ''), status, parent_payment_id
 	`
 
 	payment := &models.Payment{
-		ID:            id,
-		AthleteID:     req.AthleteID,
-		Amount:        req.Amount,
-		MonthsCovered: req.MonthsCovered,
-		StartDate:     req.StartDate,
-		EndDate:       endDate.Format("2006-01-02"),
-		Notes:         req.Notes,
-		RecordedBy:    &recordedBy,
+		ID:                id,
+		AthleteID:         req.AthleteID,
+		Amount:            req.Amount,
+		MonthsCovered:     req.MonthsCovered,
+		StartDate:         startDate.Format("2006-01-02"),
+		EndDate:           endDate.Format("2006-01-02"),
+		Notes:             req.Notes,
+		RecordedBy:        &recordedBy,
+		Method:            method,
+		ExternalReference: req.ExternalReference,
+		Kind:              models.PaymentKindPeriod,
+		AmountDue:         amountDue,
+		AmountPaid:        roundAmount(paid),
+		Warnings:          warnings,
 	}
 
-	err = r.db.QueryRow(
+	err = tx.QueryRow(
 		query,
 		payment.AthleteID,
 		payment.Amount,
@@ -156,18 +577,276 @@ func (r *PaymentRepository) Update(id int, req *models.CreatePaymentRequest, rec
 		payment.EndDate,
 		payment.Notes,
 		recordedBy,
+		payment.Method,
+		payment.ExternalReference,
+		payment.AmountDue,
 		id,
-	).Scan(&payment.ID, &payment.PaymentDate)
+	).Scan(&payment.ID, &payment.PaymentDate, &payment.ReceiptNumber, &payment.Status, &payment.ParentPaymentID)
 
 	if err != nil {
 		return nil, err
 	}
 
+	// Installments and refunds follow their period
+	_, err = tx.Exec(
+		`UPDATE payments SET athlete_id = $1, start_date = $2, end_date = $3 WHERE parent_payment_id = $4`,
+		payment.AthleteID, payment.StartDate, payment.EndDate, id,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if payment.Status == models.PaymentStatusActive {
+		payment.Balance = roundAmount(amountDue - paid)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
 	return payment, nil
 }
 
-func (r *PaymentRepository) Delete(id int) error {
-	query := `DELETE FROM payments WHERE id = $1`
-	_, err := r.db.Exec(query, id)
-	return err
+// GetCoverageTimeline splits [from, to] into paid, grace and unpaid segments.
+// A zero from defaults to the first covered day, a zero to defaults to today
+// or the last covered day, whichever is later.
+func (r *PaymentRepository) GetCoverageTimeline(athleteID int, from, to time.Time, graceDays int) (*models.CoverageTimeline, error) {
+	query := `
+		SELECT id, start_date, end_date
+		FROM payments
+		WHERE athlete_id = $1 AND ` + coveringPayment + `
+		ORDER BY start_date ASC, id ASC
+	`
+	rows, err := r.db.Query(query, athleteID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var periods []coveragePeriod
+	for rows.Next() {
+		var p coveragePeriod
+		if err := rows.Scan(&p.paymentID, &p.start, &p.end); err != nil {
+			return nil, err
+		}
+		periods = append(periods, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	today := truncateDate(time.Now())
+	if from.IsZero() {
+		from = today
+		if len(periods) > 0 {
+			from = periods[0].start
+		}
+	}
+	if to.IsZero() {
+		to = today
+		for _, p := range periods {
+			if p.end.After(to) {
+				to = p.end
+			}
+		}
+	}
+	from, to = truncateDate(from), truncateDate(to)
+	if to.Before(from) {
+		return nil, fmt.Errorf("invalid range: %s is after %s", from.Format("2006-01-02"), to.Format("2006-01-02"))
+	}
+
+	timeline := &models.CoverageTimeline{
+		AthleteID: athleteID,
+		From:      from.Format("2006-01-02"),
+		To:        to.Format("2006-01-02"),
+		GraceDays: graceDays,
+		Segments:  buildCoverageSegments(periods, from, to, graceDays),
+	}
+
+	var lastCovered time.Time
+	for _, p := range periods {
+		if p.end.After(lastCovered) {
+			lastCovered = p.end
+		}
+	}
+	if !lastCovered.IsZero() {
+		last := lastCovered.Format("2006-01-02")
+		timeline.LastCoveredDate = &last
+	}
+
+	return timeline, nil
+}
+
+// coveragePeriod is a paid interval with the payments that make it up
+type coveragePeriod struct {
+	paymentID int
+	start     time.Time
+	end       time.Time
+	ids       []int
+}
+
+func truncateDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func daysBetween(start, end time.Time) int {
+	return int(end.Sub(start).Hours()/24) + 1
+}
+
+// buildCoverageSegments merges payment periods and fills the holes with grace
+// and unpaid segments. periods must be sorted by start date.
+func buildCoverageSegments(periods []coveragePeriod, from, to time.Time, graceDays int) []models.CoverageSegment {
+	// Merge overlapping or adjacent periods
+	var merged []coveragePeriod
+	for _, p := range periods {
+		p.start, p.end = truncateDate(p.start), truncateDate(p.end)
+		n := len(merged)
+		if n > 0 && !p.start.After(merged[n-1].end.AddDate(0, 0, 1)) {
+			if p.end.After(merged[n-1].end) {
+				merged[n-1].end = p.end
+			}
+			merged[n-1].ids = append(merged[n-1].ids, p.paymentID)
+			continue
+		}
+		p.ids = []int{p.paymentID}
+		merged = append(merged, p)
+	}
+
+	segments := []models.CoverageSegment{}
+	add := func(status string, start, end time.Time, ids []int) {
+		if end.Before(start) {
+			return
+		}
+		segments = append(segments, models.CoverageSegment{
+			Status:     status,
+			StartDate:  start.Format("2006-01-02"),
+			EndDate:    end.Format("2006-01-02"),
+			Days:       daysBetween(start, end),
+			PaymentIDs: ids,
+		})
+	}
+	addGap := func(start, end time.Time, lastPaidEnd *time.Time) {
+		if lastPaidEnd != nil && graceDays > 0 {
+			graceEnd := lastPaidEnd.AddDate(0, 0, graceDays)
+			if !start.After(graceEnd) {
+				if graceEnd.After(end) {
+					graceEnd = end
+				}
+				add("grace", start, graceEnd, nil)
+				start = graceEnd.AddDate(0, 0, 1)
+			}
+		}
+		add("unpaid", start, end, nil)
+	}
+
+	cursor := from
+	var lastPaidEnd *time.Time
+	for i := range merged {
+		p := merged[i]
+		if p.end.Before(from) {
+			lastPaidEnd = &merged[i].end
+			continue
+		}
+		if p.start.After(to) {
+			break
+		}
+		if p.start.After(cursor) {
+			addGap(cursor, p.start.AddDate(0, 0, -1), lastPaidEnd)
+		}
+		start, end := p.start, p.end
+		if start.Before(from) {
+			start = from
+		}
+		if end.After(to) {
+			end = to
+		}
+		add("paid", start, end, p.ids)
+		lastPaidEnd = &merged[i].end
+		cursor = p.end.AddDate(0, 0, 1)
+	}
+	if !cursor.After(to) {
+		addGap(cursor, to, lastPaidEnd)
+	}
+
+	return segments
+}
+
+// resolveStartDate picks the period start, honouring AutoStart
+func resolveStartDate(tx *sql.Tx, req *models.CreatePaymentRequest, excludeID int) (time.Time, error) {
+	if req.AutoStart {
+		var last sql.NullTime
+		err := tx.QueryRow(
+			`SELECT MAX(end_date) FROM payments WHERE athlete_id = $1 AND id <> $2 AND `+coveringPayment,
+			req.AthleteID, excludeID,
+		).Scan(&last)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if last.Valid {
+			return truncateDate(last.Time).AddDate(0, 0, 1), nil
+		}
+		if req.StartDate == "" {
+			return truncateDate(time.Now()), nil
+		}
+	}
+	return time.Parse("2006-01-02", req.StartDate)
+}
+
+// checkCoverage rejects overlapping periods (unless allowed) and reports gaps
+func checkCoverage(tx *sql.Tx, athleteID int, start, end time.Time, excludeID int, allowOverlap bool) ([]string, error) {
+	query := `
+		SELECT ` + paymentColumns + `
+		FROM payments p` + paymentTotals + `
+		WHERE p.athlete_id = $1 AND p.id <> $2 AND p.start_date <= $4 AND p.end_date >= $3
+		  AND p.kind = 'period' AND p.status = 'active'
+		ORDER BY p.start_date
+	`
+	rows, err := tx.Query(query, athleteID, excludeID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var conflicts []*models.Payment
+	for rows.Next() {
+		p, err := scanPayment(rows)
+		if err != nil {
+			return nil, err
+		}
+		conflicts = append(conflicts, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var warnings []string
+	if len(conflicts) > 0 {
+		if !allowOverlap {
+			return nil, &PaymentOverlapError{Conflicts: conflicts}
+		}
+		for _, c := range conflicts {
+			warnings = append(warnings, fmt.Sprintf("overlaps payment #%d (%s to %s)", c.ID, c.StartDate, c.EndDate))
+		}
+	}
+
+	// Report a gap between the previous covered day and the new start
+	var prevEnd sql.NullTime
+	err = tx.QueryRow(
+		`SELECT MAX(end_date) FROM payments WHERE athlete_id = $1 AND id <> $2 AND end_date < $3 AND `+coveringPayment,
+		athleteID, excludeID, start,
+	).Scan(&prevEnd)
+	if err != nil {
+		return nil, err
+	}
+	if prevEnd.Valid {
+		gapStart := truncateDate(prevEnd.Time).AddDate(0, 0, 1)
+		if gapStart.Before(start) {
+			warnings = append(warnings, fmt.Sprintf(
+				"gap of %d day(s) after last covered date %s",
+				daysBetween(gapStart, start.AddDate(0, 0, -1)), prevEnd.Time.Format("2006-01-02"),
+			))
+		}
+	}
+
+	return warnings, nil
 }
@@ -0,0 +1,260 @@
+package repository
+
+import (
+	"database/sql"
+
+	"east-eagles/backend/internal/models"
+)
+
+type RankingRepository struct {
+	db *sql.DB
+}
+
+func NewRankingRepository(db *sql.DB) *RankingRepository {
+	return &RankingRepository{db: db}
+}
+
+// ratingsLockKey is the advisory lock held while ratings are rebuilt, so
+// concurrent results do not interleave their deletes and inserts
+const ratingsLockKey = 7283002
+
+// ratedBouts is the query of the bouts that count for ratings, in the order
+// they were decided. Walkovers and byes were not fought and do not count.
+const ratedBouts = `
+	SELECT id, red_athlete_id, blue_athlete_id, winner_athlete_id, completed_at
+	FROM bouts
+	WHERE status = 'completed' AND method <> 'walkover'
+	  AND red_athlete_id IS NOT NULL AND blue_athlete_id IS NOT NULL
+	ORDER BY completed_at, id
+`
+
+// RebuildRatings replaces the rating history with replay's changes for every
+// rated bout, and each athlete's rating with their latest change. Rebuilds
+// run one at a time and each sees the bouts recorded before it started.
+func (r *RankingRepository) RebuildRatings(replay func([]models.RatedBout) []models.RatingChange) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`SELECT pg_advisory_xact_lock($1)`, ratingsLockKey); err != nil {
+		return err
+	}
+
+	rows, err := tx.Query(ratedBouts)
+	if err != nil {
+		return err
+	}
+	var bouts []models.RatedBout
+	for rows.Next() {
+		var b models.RatedBout
+		if err := rows.Scan(&b.ID, &b.RedAthleteID, &b.BlueAthleteID, &b.WinnerAthleteID, &b.CompletedAt); err != nil {
+			rows.Close()
+			return err
+		}
+		bouts = append(bouts, b)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	changes := replay(bouts)
+
+	if _, err := tx.Exec(`DELETE FROM rating_history`); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM athlete_ratings`); err != nil {
+		return err
+	}
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO rating_history (athlete_id, bout_id, rating_before, rating_after, rated_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	latest := map[int]int{}
+	counts := map[int]int{}
+	for _, c := range changes {
+		if _, err := stmt.Exec(c.AthleteID, c.BoutID, c.Before, c.After, c.RatedAt); err != nil {
+			return err
+		}
+		latest[c.AthleteID] = c.After
+		counts[c.AthleteID]++
+	}
+	for athleteID, rating := range latest {
+		if _, err := tx.Exec(`
+			INSERT INTO athlete_ratings (athlete_id, rating, bouts) VALUES ($1, $2, $3)
+		`, athleteID, rating, counts[athleteID]); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// medalName names a podium placing
+func medalName(placing int) string {
+	switch placing {
+	case 1:
+		return "gold"
+	case 2:
+		return "silver"
+	default:
+		return "bronze"
+	}
+}
+
+// GetRecord returns an athlete's fight record, medals and rating history
+func (r *RankingRepository) GetRecord(athleteID int) (*models.AthleteRecord, error) {
+	record := &models.AthleteRecord{
+		AthleteID: athleteID,
+		ByMethod:  map[string]*models.MethodRecord{},
+		Medals:    []models.Medal{},
+		History:   []models.RatingChange{},
+		Rating:    models.InitialRating,
+	}
+
+	rows, err := r.db.Query(`
+		SELECT method, winner_athlete_id
+		FROM bouts
+		WHERE status = 'completed' AND $1 IN (red_athlete_id, blue_athlete_id)
+	`, athleteID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var method string
+		var winner *int
+		if err := rows.Scan(&method, &winner); err != nil {
+			return nil, err
+		}
+		if record.ByMethod[method] == nil {
+			record.ByMethod[method] = &models.MethodRecord{}
+		}
+		m := record.ByMethod[method]
+		record.Bouts++
+		switch {
+		case winner == nil:
+			record.Draws++
+			m.Draws++
+		case *winner == athleteID:
+			record.Wins++
+			m.Wins++
+		default:
+			record.Losses++
+			m.Losses++
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	medals, err := r.db.Query(`
+		SELECT e.id, e.title, e.date, reg.weight_category, reg.placing
+		FROM event_registrations reg
+		JOIN events e ON e.id = reg.event_id
+		WHERE reg.athlete_id = $1 AND reg.status = 'registered' AND reg.placing BETWEEN 1 AND 3
+		ORDER BY e.date DESC
+	`, athleteID)
+	if err != nil {
+		return nil, err
+	}
+	defer medals.Close()
+	for medals.Next() {
+		var m models.Medal
+		if err := medals.Scan(&m.EventID, &m.EventTitle, &m.EventDate, &m.WeightCategory, &m.Placing); err != nil {
+			return nil, err
+		}
+		m.Medal = medalName(m.Placing)
+		switch m.Medal {
+		case "gold":
+			record.Gold++
+		case "silver":
+			record.Silver++
+		default:
+			record.Bronze++
+		}
+		record.Medals = append(record.Medals, m)
+	}
+	if err := medals.Err(); err != nil {
+		return nil, err
+	}
+	medals.Close()
+
+	err = r.db.QueryRow(`SELECT rating FROM athlete_ratings WHERE athlete_id = $1`, athleteID).Scan(&record.Rating)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	history, err := r.db.Query(`
+		SELECT athlete_id, bout_id, rating_before, rating_after, rated_at
+		FROM rating_history
+		WHERE athlete_id = $1
+		ORDER BY rated_at, bout_id
+	`, athleteID)
+	if err != nil {
+		return nil, err
+	}
+	defer history.Close()
+	for history.Next() {
+		var c models.RatingChange
+		if err := history.Scan(&c.AthleteID, &c.BoutID, &c.Before, &c.After, &c.RatedAt); err != nil {
+			return nil, err
+		}
+		record.History = append(record.History, c)
+	}
+	return record, history.Err()
+}
+
+// GetRankings ranks rated athletes by rating within their current weight
+// category; category filters to one category when not empty. Wins, losses
+// and draws count rated bouts only, like bouts: walkovers are left out.
+func (r *RankingRepository) GetRankings(category, gender string) ([]*models.Ranking, error) {
+	rows, err := r.db.Query(`
+		SELECT a.id, a.first_name || ' ' || a.last_name, COALESCE(a.weight_category, ''), COALESCE(a.gender, ''),
+		       ar.rating, ar.bouts,
+		       COUNT(o.id) FILTER (WHERE o.winner_athlete_id = a.id),
+		       COUNT(o.id) FILTER (WHERE o.winner_athlete_id <> a.id),
+		       COUNT(o.id) FILTER (WHERE o.winner_athlete_id IS NULL),
+		       (SELECT COUNT(*) FROM event_registrations reg WHERE reg.athlete_id = a.id AND reg.status = 'registered' AND reg.placing = 1),
+		       (SELECT COUNT(*) FROM event_registrations reg WHERE reg.athlete_id = a.id AND reg.status = 'registered' AND reg.placing = 2),
+		       (SELECT COUNT(*) FROM event_registrations reg WHERE reg.athlete_id = a.id AND reg.status = 'registered' AND reg.placing = 3)
+		FROM athlete_ratings ar
+		JOIN athletes a ON a.id = ar.athlete_id
+		LEFT JOIN bouts o ON o.status = 'completed' AND o.method <> 'walkover'
+		                 AND a.id IN (o.red_athlete_id, o.blue_athlete_id)
+		WHERE a.is_active
+		  AND ($1 = '' OR a.weight_category = $1)
+		  AND ($2 = '' OR a.gender = $2)
+		GROUP BY a.id, ar.rating, ar.bouts
+		ORDER BY COALESCE(a.weight_category, ''), ar.rating DESC, ar.bouts DESC, a.last_name
+	`, category, gender)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rankings := []*models.Ranking{}
+	var current *models.Ranking
+	for rows.Next() {
+		e := &models.RankingEntry{}
+		var weightCategory string
+		if err := rows.Scan(&e.AthleteID, &e.AthleteName, &weightCategory, &e.Gender, &e.Rating, &e.Bouts,
+			&e.Wins, &e.Losses, &e.Draws, &e.Gold, &e.Silver, &e.Bronze); err != nil {
+			return nil, err
+		}
+		if current == nil || current.WeightCategory != weightCategory {
+			current = &models.Ranking{WeightCategory: weightCategory, Athletes: []*models.RankingEntry{}}
+			rankings = append(rankings, current)
+		}
+		e.Rank = len(current.Athletes) + 1
+		current.Athletes = append(current.Athletes, e)
+	}
+	return rankings, rows.Err()
+}
@@ -0,0 +1,300 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"time"
+
+	"east-eagles/backend/internal/models"
+)
+
+// seasonStartMonth is the month a sports season starts (September)
+const seasonStartMonth = 9
+
+// activeAthlete restricts a query on athletes (aliased a) to active members
+const activeAthlete = `a.is_active = true AND a.membership_status = 'approved'`
+
+// athleteBalances sums, per athlete, the price of active periods minus the
+// installments and refunds collected on them
+const athleteBalances = `
+	SELECT pay.athlete_id,
+	       SUM(CASE WHEN pay.id = parent.id THEN parent.amount_due ELSE 0 END) - SUM(pay.amount) AS balance
+	FROM payments pay
+	JOIN payments parent ON parent.id = COALESCE(pay.parent_payment_id, pay.id)
+	WHERE parent.status = 'active'
+	GROUP BY pay.athlete_id`
+
+type ReportRepository struct {
+	db *sql.DB
+}
+
+func NewReportRepository(db *sql.DB) *ReportRepository {
+	return &ReportRepository{db: db}
+}
+
+// AverageMonthlyFee derives the monthly price from period payments made in [from, to]
+func (r *ReportRepository) AverageMonthlyFee(from, to time.Time) (float64, error) {
+	var fee float64
+	err := r.db.QueryRow(`
+		SELECT COALESCE(AVG(amount_due / months_covered), 0)
+		FROM payments
+		WHERE kind = 'period' AND months_covered > 0
+		  AND payment_date >= $1 AND payment_date < $2::date + 1
+	`, from, to).Scan(&fee)
+	return roundAmount(fee), err
+}
+
+// RevenueByMonth returns money received per calendar month, including empty months
+func (r *ReportRepository) RevenueByMonth(from, to time.Time) ([]models.RevenuePeriod, error) {
+	query := `
+		SELECT to_char(m, 'YYYY-MM'),
+		       COUNT(p.id) FILTER (WHERE p.kind <> 'refund'),
+		       COALESCE(SUM(p.amount) FILTER (WHERE p.amount > 0), 0),
+		       COALESCE(-SUM(p.amount) FILTER (WHERE p.amount < 0), 0),
+		       COALESCE(SUM(p.amount), 0)
+		FROM generate_series(date_trunc('month', $1::date), date_trunc('month', $2::date), interval '1 month') m
+		LEFT JOIN payments p ON p.payment_date >= m AND p.payment_date < m + interval '1 month'
+		     AND p.payment_date >= $1 AND p.payment_date < $2::date + 1
+		GROUP BY m
+		ORDER BY m
+	`
+	return r.queryRevenue(query, from, to)
+}
+
+// RevenueBySeason returns money received per season (September to August)
+func (r *ReportRepository) RevenueBySeason(from, to time.Time) ([]models.RevenuePeriod, error) {
+	query := fmt.Sprintf(`
+		SELECT EXTRACT(YEAR FROM p.payment_date - interval '%d months')::INTEGER AS season,
+		       COUNT(p.id) FILTER (WHERE p.kind <> 'refund'),
+		       COALESCE(SUM(p.amount) FILTER (WHERE p.amount > 0), 0),
+		       COALESCE(-SUM(p.amount) FILTER (WHERE p.amount < 0), 0),
+		       COALESCE(SUM(p.amount), 0)
+		FROM payments p
+		WHERE p.payment_date >= $1 AND p.payment_date < $2::date + 1
+		GROUP BY season
+		ORDER BY season
+	`, seasonStartMonth-1)
+
+	rows, err := r.db.Query(query, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	periods := []models.RevenuePeriod{}
+	for rows.Next() {
+		var season int
+		var p models.RevenuePeriod
+		if err := rows.Scan(&season, &p.Payments, &p.Collected, &p.Refunded, &p.Net); err != nil {
+			return nil, err
+		}
+		p.Period = fmt.Sprintf("%d/%d", season, season+1)
+		periods = append(periods, p)
+	}
+	return periods, rows.Err()
+}
+
+func (r *ReportRepository) queryRevenue(query string, args ...interface{}) ([]models.RevenuePeriod, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	periods := []models.RevenuePeriod{}
+	for rows.Next() {
+		var p models.RevenuePeriod
+		if err := rows.Scan(&p.Period, &p.Payments, &p.Collected, &p.Refunded, &p.Net); err != nil {
+			return nil, err
+		}
+		periods = append(periods, p)
+	}
+	return periods, rows.Err()
+}
+
+// CollectionByMonth compares expected fees of active athletes with net collections per month.
+// Athletes count from the month they registered in.
+func (r *ReportRepository) CollectionByMonth(from, to time.Time, monthlyFee float64) ([]models.CollectionPeriod, error) {
+	query := `
+		SELECT to_char(m, 'YYYY-MM'),
+		       (SELECT COUNT(*) FROM athletes a
+		        WHERE ` + activeAthlete + ` AND a.registration_date < m + interval '1 month'),
+		       (SELECT COALESCE(SUM(p.amount), 0) FROM payments p
+		        WHERE p.payment_date >= m AND p.payment_date < m + interval '1 month')
+		FROM generate_series(date_trunc('month', $1::date), date_trunc('month', $2::date), interval '1 month') m
+		ORDER BY m
+	`
+	rows, err := r.db.Query(query, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	periods := []models.CollectionPeriod{}
+	for rows.Next() {
+		var p models.CollectionPeriod
+		if err := rows.Scan(&p.Period, &p.ActiveAthletes, &p.Collected); err != nil {
+			return nil, err
+		}
+		p.Expected = roundAmount(float64(p.ActiveAthletes) * monthlyFee)
+		if p.Expected > 0 {
+			p.CollectionRate = math.Round(p.Collected/p.Expected*1000) / 1000
+		}
+		periods = append(periods, p)
+	}
+	return periods, rows.Err()
+}
+
+// ArrearsAging buckets active athletes by days past their last covered end_date as of asOf.
+// Covered athletes who still owe part of a period land in 'current'.
+func (r *ReportRepository) ArrearsAging(asOf time.Time, monthlyFee float64) ([]models.ArrearsBucket, error) {
+	query := `
+		SELECT a.id, a.first_name || ' ' || a.last_name, a.registration_date,
+		       c.last_end, COALESCE(b.balance, 0)
+		FROM athletes a
+		LEFT JOIN LATERAL (
+		    SELECT MAX(end_date) AS last_end
+		    FROM payments
+		    WHERE athlete_id = a.id AND kind = 'period' AND status = 'active'
+		) c ON true
+		LEFT JOIN (` + athleteBalances + `) b ON b.athlete_id = a.id
+		WHERE ` + activeAthlete + `
+		ORDER BY c.last_end NULLS FIRST, a.last_name
+	`
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	order := []string{"current", "0-30", "31-60", "61-90", "90+", "never_paid"}
+	buckets := make(map[string]*models.ArrearsBucket)
+	for _, name := range order {
+		buckets[name] = &models.ArrearsBucket{Bucket: name, Details: []models.ArrearsAthlete{}}
+	}
+
+	asOf = truncateDate(asOf)
+	for rows.Next() {
+		var d models.ArrearsAthlete
+		var registered time.Time
+		var lastEnd sql.NullTime
+		if err := rows.Scan(&d.AthleteID, &d.AthleteName, &registered, &lastEnd, &d.UnpaidBalance); err != nil {
+			return nil, err
+		}
+		d.UnpaidBalance = roundAmount(d.UnpaidBalance)
+
+		if lastEnd.Valid {
+			last := lastEnd.Time.Format("2006-01-02")
+			d.LastCoveredDate = &last
+			d.DaysOverdue = int(asOf.Sub(truncateDate(lastEnd.Time)).Hours() / 24)
+		} else {
+			d.DaysOverdue = int(asOf.Sub(truncateDate(registered)).Hours() / 24)
+		}
+		bucket, ok := agingBucket(lastEnd.Valid, d.DaysOverdue, d.UnpaidBalance)
+		if !ok {
+			continue
+		}
+		if d.DaysOverdue < 0 {
+			d.DaysOverdue = 0
+		}
+
+		monthsOverdue := math.Ceil(float64(d.DaysOverdue) / 30)
+		d.Outstanding = roundAmount(monthsOverdue*monthlyFee + d.UnpaidBalance)
+
+		b := buckets[bucket]
+		b.Athletes++
+		b.Outstanding = roundAmount(b.Outstanding + d.Outstanding)
+		b.Details = append(b.Details, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	result := make([]models.ArrearsBucket, 0, len(order))
+	for _, name := range order {
+		result = append(result, *buckets[name])
+	}
+	return result, nil
+}
+
+// agingBucket returns the arrears bucket of an athlete daysOverdue days past
+// their last covered day. Covered athletes only appear, in 'current', while
+// they still owe part of a period; ok is false for the others.
+func agingBucket(covered bool, daysOverdue int, unpaidBalance float64) (bucket string, ok bool) {
+	switch {
+	case !covered:
+		return "never_paid", true
+	case daysOverdue <= 0:
+		return "current", unpaidBalance > 0
+	case daysOverdue <= 30:
+		return "0-30", true
+	case daysOverdue <= 60:
+		return "31-60", true
+	case daysOverdue <= 90:
+		return "61-90", true
+	}
+	return "90+", true
+}
+
+// breakdownColumns whitelists the athlete columns a finance breakdown can group by
+var breakdownColumns = map[string]string{
+	"weight_category": "a.weight_category",
+	"skill_level":     "a.skill_level",
+}
+
+// FinanceBreakdown groups collections, balances and arrears by weight category or skill level
+func (r *ReportRepository) FinanceBreakdown(groupBy string, from, to time.Time) ([]models.FinanceBreakdownRow, error) {
+	column, ok := breakdownColumns[groupBy]
+	if !ok {
+		return nil, fmt.Errorf("invalid group_by: %s", groupBy)
+	}
+
+	query := `
+		WITH pay AS (
+		    SELECT athlete_id,
+		           SUM(amount) FILTER (WHERE amount > 0) AS collected,
+		           -SUM(amount) FILTER (WHERE amount < 0) AS refunded
+		    FROM payments
+		    WHERE payment_date >= $1 AND payment_date < $2::date + 1
+		    GROUP BY athlete_id
+		), cov AS (
+		    SELECT athlete_id, MAX(end_date) AS last_end
+		    FROM payments
+		    WHERE kind = 'period' AND status = 'active'
+		    GROUP BY athlete_id
+		), bal AS (` + athleteBalances + `)
+		SELECT COALESCE(NULLIF(` + column + `, ''), 'non_renseigne') AS grp,
+		       COUNT(*) FILTER (WHERE ` + activeAthlete + `),
+		       COALESCE(SUM(pay.collected), 0),
+		       COALESCE(SUM(pay.refunded), 0),
+		       COALESCE(SUM(bal.balance) FILTER (WHERE ` + activeAthlete + `), 0),
+		       COUNT(*) FILTER (WHERE ` + activeAthlete + ` AND (cov.last_end IS NULL OR cov.last_end < $2::date))
+		FROM athletes a
+		LEFT JOIN pay ON pay.athlete_id = a.id
+		LEFT JOIN cov ON cov.athlete_id = a.id
+		LEFT JOIN bal ON bal.athlete_id = a.id
+		WHERE (` + activeAthlete + `) OR pay.athlete_id IS NOT NULL
+		GROUP BY grp
+		ORDER BY grp
+	`
+	rows, err := r.db.Query(query, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []models.FinanceBreakdownRow{}
+	for rows.Next() {
+		var row models.FinanceBreakdownRow
+		if err := rows.Scan(
+			&row.Group, &row.ActiveAthletes, &row.Collected, &row.Refunded, &row.UnpaidBalance, &row.InArrears,
+		); err != nil {
+			return nil, err
+		}
+		row.Net = roundAmount(row.Collected - row.Refunded)
+		row.UnpaidBalance = roundAmount(row.UnpaidBalance)
+		result = append(result, row)
+	}
+	return result, rows.Err()
+}
@@ -0,0 +1,37 @@
+package repository
+
+import "testing"
+
+func TestAgingBucket(t *testing.T) {
+	tests := []struct {
+		name    string
+		covered bool
+		days    int
+		unpaid  float64
+		bucket  string
+		ok      bool
+	}{
+		{"never paid", false, 12, 0, "never_paid", true},
+		{"never paid, registered today", false, 0, 0, "never_paid", true},
+		{"covered today, settled", true, 0, 0, "current", false},
+		{"covered today, balance due", true, 0, 15, "current", true},
+		{"covered ahead", true, -20, 0, "current", false},
+		{"first day overdue", true, 1, 0, "0-30", true},
+		{"30 days", true, 30, 0, "0-30", true},
+		{"31 days", true, 31, 0, "31-60", true},
+		{"60 days", true, 60, 0, "31-60", true},
+		{"61 days", true, 61, 0, "61-90", true},
+		{"90 days", true, 90, 0, "61-90", true},
+		{"91 days", true, 91, 0, "90+", true},
+		{"a year", true, 365, 40, "90+", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bucket, ok := agingBucket(tt.covered, tt.days, tt.unpaid)
+			if bucket != tt.bucket || ok != tt.ok {
+				t.Errorf("agingBucket(%v, %d, %.2f) = %q, %v; want %q, %v",
+					tt.covered, tt.days, tt.unpaid, bucket, ok, tt.bucket, tt.ok)
+			}
+		})
+	}
+}
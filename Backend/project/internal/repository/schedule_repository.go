@@ -1,10 +1,14 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"log"
+	"time"
 
 	"east-eagles/backend/internal/models"
+
+	"github.com/lib/pq"
 )
 
 type ScheduleRepository struct {
@@ -15,13 +19,21 @@ func NewScheduleRepository(db *sql.DB) *ScheduleRepository {
 	return &ScheduleRepository{db: db}
 }
 
+const scheduleRecurrenceColumns = `COALESCE(rrule, ''), to_char(valid_from, 'YYYY-MM-DD'), to_char(valid_until, 'YYYY-MM-DD'), exdates`
+
+// weekdayOrder sorts day_of_week Monday first instead of alphabetically
+const weekdayOrder = `array_position(
+	ARRAY['Monday', 'Tuesday', 'Wednesday', 'Thursday', 'Friday', 'Saturday', 'Sunday']::varchar[], day_of_week)`
+
 func (r *ScheduleRepository) Create(req *models.CreateScheduleRequest) (*models.TrainingSchedule, error) {
 	query := `
 		INSERT INTO training_schedules (
-			day_of_week, start_time, duration_minutes, title, location, description
+			day_of_week, start_time, duration_minutes, title, location, description, level, max_participants,
+			rrule, valid_from, valid_until, exdates, venue_id, coach_id
 		)
-		VALUES ($1, $2, $3, $4, $5, $6)
-		RETURNING id, day_of_week, start_time, duration_minutes, title, location, description, created_at
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		RETURNING id, day_of_week, start_time, duration_minutes, title, location, description, level, max_participants,
+		          ` + scheduleRecurrenceColumns + `, venue_id, coach_id, created_at
 	`
 
 	schedule := &models.TrainingSchedule{}
@@ -35,6 +47,14 @@ func (r *ScheduleRepository) Create(req *models.CreateScheduleRequest) (*models.
 		req.Title,
 		req.Location,
 		req.Description,
+		scheduleLevel(req.Level),
+		req.MaxParticipants,
+		req.RRule,
+		req.ValidFrom,
+		req.ValidUntil,
+		pq.Array(req.ExDates),
+		req.VenueID,
+		req.CoachID,
 	).Scan(
 		&schedule.ID,
 		&schedule.DayOfWeek,
@@ -43,6 +63,14 @@ func (r *ScheduleRepository) Create(req *models.CreateScheduleRequest) (*models.
 		&schedule.Title,
 		&schedule.Location,
 		&schedule.Description,
+		&schedule.Level,
+		&schedule.MaxParticipants,
+		&schedule.RRule,
+		&schedule.ValidFrom,
+		&schedule.ValidUntil,
+		pq.Array(&schedule.ExDates),
+		&schedule.VenueID,
+		&schedule.CoachID,
 		&schedule.CreatedAt,
 	)
 
@@ -72,7 +100,12 @@ func (r *ScheduleRepository) Create(req *models.CreateScheduleRequest) (*models.
 }
 
 func (r *ScheduleRepository) GetAll() ([]*models.TrainingSchedule, error) {
-	query := `SELECT id, day_of_week, start_time, duration_minutes, title, location, description, created_at FROM training_schedules ORDER BY day_of_week, start_time`
+	query := `
+		SELECT id, day_of_week, start_time, duration_minutes, title, location, description, level, max_participants,
+		       ` + scheduleRecurrenceColumns + `, venue_id, coach_id, created_at
+		FROM training_schedules
+		ORDER BY ` + weekdayOrder + `, start_time, id
+	`
 	rows, err := r.db.Query(query)
 	if err != nil {
 		return nil, err
@@ -84,7 +117,8 @@ func (r *ScheduleRepository) GetAll() ([]*models.TrainingSchedule, error) {
 		s := &models.TrainingSchedule{}
 		var startTimeStr sql.NullString // Use NullString to handle potential NULL values
 		if err := rows.Scan(
-			&s.ID, &s.DayOfWeek, &startTimeStr, &s.DurationMinutes, &s.Title, &s.Location, &s.Description, &s.CreatedAt,
+			&s.ID, &s.DayOfWeek, &startTimeStr, &s.DurationMinutes, &s.Title, &s.Location, &s.Description, &s.Level, &s.MaxParticipants,
+			&s.RRule, &s.ValidFrom, &s.ValidUntil, pq.Array(&s.ExDates), &s.VenueID, &s.CoachID, &s.CreatedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -128,18 +162,104 @@ func (r *ScheduleRepository) GetAll() ([]*models.TrainingSchedule, error) {
 	return schedules, nil
 }
 
-func (r *ScheduleRepository) Delete(id int) error {
-	query := `DELETE FROM training_schedules WHERE id = $1`
-	_, err := r.db.Exec(query, id)
-	return err
+// Delete removes a schedule and its future sessions that nobody attended yet.
+// Past sessions are kept and lose their schedule link. The removed sessions
+// that had bookings are returned so their athletes can be told.
+func (r *ScheduleRepository) Delete(ctx context.Context, id int) ([]models.CancelledSession, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT t.id FROM training_sessions t
+		WHERE t.schedule_id = $1 AND NOT t.detached AND t.session_date > $2
+		  AND NOT EXISTS (SELECT 1 FROM attendance a WHERE a.training_session_id = t.id)
+		FOR UPDATE
+	`, id, models.WallClockNow())
+	if err != nil {
+		return nil, err
+	}
+	var sessionIDs []int
+	for rows.Next() {
+		var sessionID int
+		if err := rows.Scan(&sessionID); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		sessionIDs = append(sessionIDs, sessionID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	cancelled, err := removeSessions(ctx, tx, sessionIDs)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM training_schedules WHERE id = $1`, id); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return cancelled, nil
+}
+
+// removeSessions deletes the given sessions and returns those that still had
+// athletes booked or waitlisted; their bookings go with them
+func removeSessions(ctx context.Context, tx *sql.Tx, ids []int) ([]models.CancelledSession, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	rows, err := tx.QueryContext(ctx, `
+		SELECT t.id, t.title, t.session_date, b.athlete_id
+		FROM training_sessions t
+		JOIN session_bookings b ON b.training_session_id = t.id AND b.status <> 'cancelled'
+		WHERE t.id = ANY($1)
+		ORDER BY t.session_date, t.id, b.athlete_id
+	`, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	var cancelled []models.CancelledSession
+	for rows.Next() {
+		var s models.CancelledSession
+		var athleteID int
+		if err := rows.Scan(&s.SessionID, &s.Title, &s.SessionDate, &athleteID); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if n := len(cancelled); n > 0 && cancelled[n-1].SessionID == s.SessionID {
+			cancelled[n-1].AthleteIDs = append(cancelled[n-1].AthleteIDs, athleteID)
+			continue
+		}
+		s.AthleteIDs = []int{athleteID}
+		cancelled = append(cancelled, s)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM training_sessions WHERE id = ANY($1)`, pq.Array(ids)); err != nil {
+		return nil, err
+	}
+	return cancelled, nil
 }
 
 func (r *ScheduleRepository) Update(id int, req *models.CreateScheduleRequest) (*models.TrainingSchedule, error) {
 	query := `
 		UPDATE training_schedules
-		SET day_of_week = $1, start_time = $2, duration_minutes = $3, title = $4, location = $5, description = $6
-		WHERE id = $7
-		RETURNING id, day_of_week, start_time, duration_minutes, title, location, description, created_at
+		SET day_of_week = $1, start_time = $2, duration_minutes = $3, title = $4, location = $5, description = $6,
+		    level = $7, max_participants = $8, rrule = $9, valid_from = $10, valid_until = $11, exdates = $12,
+		    venue_id = $13, coach_id = $14
+		WHERE id = $15
+		RETURNING id, day_of_week, start_time, duration_minutes, title, location, description, level, max_participants,
+		          ` + scheduleRecurrenceColumns + `, venue_id, coach_id, created_at
 	`
 
 	schedule := &models.TrainingSchedule{
@@ -155,6 +275,14 @@ func (r *ScheduleRepository) Update(id int, req *models.CreateScheduleRequest) (
 		req.Title,
 		req.Location,
 		req.Description,
+		scheduleLevel(req.Level),
+		req.MaxParticipants,
+		req.RRule,
+		req.ValidFrom,
+		req.ValidUntil,
+		pq.Array(req.ExDates),
+		req.VenueID,
+		req.CoachID,
 		id,
 	).Scan(
 		&schedule.ID,
@@ -164,6 +292,14 @@ func (r *ScheduleRepository) Update(id int, req *models.CreateScheduleRequest) (
 		&schedule.Title,
 		&schedule.Location,
 		&schedule.Description,
+		&schedule.Level,
+		&schedule.MaxParticipants,
+		&schedule.RRule,
+		&schedule.ValidFrom,
+		&schedule.ValidUntil,
+		pq.Array(&schedule.ExDates),
+		&schedule.VenueID,
+		&schedule.CoachID,
 		&schedule.CreatedAt,
 	)
 
@@ -191,3 +327,421 @@ func (r *ScheduleRepository) Update(id int, req *models.CreateScheduleRequest) (
 
 	return schedule, nil
 }
+
+// scheduleColumns is the column list read by scanSchedule, with start_time as HH:MM
+const scheduleColumns = `
+	id, day_of_week, to_char(start_time, 'HH24:MI'), duration_minutes, title, location,
+	COALESCE(description, ''), level, max_participants, ` + scheduleRecurrenceColumns + `,
+	venue_id, coach_id, created_at`
+
+func scanSchedule(row rowScanner) (*models.TrainingSchedule, error) {
+	s := &models.TrainingSchedule{}
+	err := row.Scan(
+		&s.ID, &s.DayOfWeek, &s.StartTime, &s.DurationMinutes, &s.Title, &s.Location,
+		&s.Description, &s.Level, &s.MaxParticipants, &s.RRule, &s.ValidFrom, &s.ValidUntil, pq.Array(&s.ExDates),
+		&s.VenueID, &s.CoachID, &s.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// GetByID returns a schedule
+func (r *ScheduleRepository) GetByID(id int) (*models.TrainingSchedule, error) {
+	return scanSchedule(r.db.QueryRow(`SELECT `+scheduleColumns+` FROM training_schedules WHERE id = $1`, id))
+}
+
+func sameID(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+// scheduleLevel defaults an empty level to 'all'
+func scheduleLevel(level string) string {
+	if level == "" {
+		return "all"
+	}
+	return level
+}
+
+// SyncSessions materialises one schedule into dated sessions between from and
+// to (inclusive dates)
+func (r *ScheduleRepository) SyncSessions(ctx context.Context, id int, from, to time.Time) (*models.SessionSyncResult, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	result := &models.SessionSyncResult{}
+	if err := syncSchedule(ctx, tx, id, from, to, result); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// SyncAllSessions materialises every schedule between from and to (inclusive dates)
+func (r *ScheduleRepository) SyncAllSessions(ctx context.Context, from, to time.Time) (*models.SessionSyncResult, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `SELECT id FROM training_schedules ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	result := &models.SessionSyncResult{}
+	for _, id := range ids {
+		if err := syncSchedule(ctx, tx, id, from, to, result); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// syncSchedule brings the schedule's future sessions in line with it: missing
+// occurrences are created, changed ones updated, and sessions on closed or
+// no-longer-scheduled dates removed, booked ones landing in result.Cancelled.
+// Past sessions, sessions with attendance and detached sessions are never
+// touched.
+func syncSchedule(ctx context.Context, tx *sql.Tx, id int, from, to time.Time, result *models.SessionSyncResult) error {
+	schedule, err := scanSchedule(tx.QueryRowContext(ctx, `
+		SELECT `+scheduleColumns+`
+		FROM training_schedules
+		WHERE id = $1
+		FOR UPDATE
+	`, id))
+	if err != nil {
+		return err
+	}
+
+	times, err := schedule.Occurrences(from, to)
+	if err != nil {
+		return err
+	}
+	closures, err := closedRanges(ctx, tx, schedule.ID, from, to)
+	if err != nil {
+		return err
+	}
+
+	// Occurrences still to come, keyed by date
+	now := models.WallClockNow()
+	occurrences := map[string]time.Time{}
+	for _, at := range times {
+		if at.After(now) && !isClosed(closures, at) {
+			occurrences[at.Format("2006-01-02")] = at
+		}
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT `+sessionColumns+`
+		FROM training_sessions t
+		WHERE t.schedule_id = $1 AND NOT t.detached AND t.session_date > $2
+		  AND NOT EXISTS (SELECT 1 FROM attendance a WHERE a.training_session_id = t.id)
+		FOR UPDATE
+	`, schedule.ID, now)
+	if err != nil {
+		return err
+	}
+	var existing []*models.TrainingSession
+	for rows.Next() {
+		s, err := scanSession(rows)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		existing = append(existing, s)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	maxParticipants := 0
+	if schedule.MaxParticipants != nil {
+		maxParticipants = *schedule.MaxParticipants
+	}
+
+	var unscheduled []int
+	for _, s := range existing {
+		key := ""
+		if s.OccurrenceDate != nil {
+			key = s.OccurrenceDate.Format("2006-01-02")
+		}
+		at, scheduled := occurrences[key]
+		if !scheduled {
+			unscheduled = append(unscheduled, s.ID)
+			continue
+		}
+		delete(occurrences, key)
+
+		if s.Title == schedule.Title && s.Description == schedule.Description && s.SessionDate.Equal(at) &&
+			s.DurationMinutes == schedule.DurationMinutes && s.Location == schedule.Location &&
+			s.MaxParticipants == maxParticipants && s.Level == schedule.Level &&
+			sameID(s.VenueID, schedule.VenueID) && sameID(s.CoachID, schedule.CoachID) {
+			continue
+		}
+		_, err := tx.ExecContext(ctx, `
+			UPDATE training_sessions
+			SET title = $1, description = $2, session_date = $3, duration_minutes = $4,
+			    location = $5, max_participants = $6, level = $7, venue_id = $8, coach_id = $9
+			WHERE id = $10
+		`, schedule.Title, schedule.Description, at, schedule.DurationMinutes,
+			schedule.Location, schedule.MaxParticipants, schedule.Level, schedule.VenueID, schedule.CoachID, s.ID)
+		if err != nil {
+			return err
+		}
+		result.Updated++
+	}
+
+	cancelled, err := removeSessions(ctx, tx, unscheduled)
+	if err != nil {
+		return err
+	}
+	result.Removed += len(unscheduled)
+	result.Cancelled = append(result.Cancelled, cancelled...)
+
+	// Whatever is left has no session yet. Held, attended or detached sessions
+	// keep their slot through the unique (schedule_id, occurrence_date) index.
+	for key, at := range occurrences {
+		res, err := tx.ExecContext(ctx, `
+			INSERT INTO training_sessions (
+				title, description, session_date, duration_minutes, location,
+				max_participants, level, schedule_id, occurrence_date, venue_id, coach_id
+			)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+			ON CONFLICT (schedule_id, occurrence_date) DO NOTHING
+		`, schedule.Title, schedule.Description, at, schedule.DurationMinutes, schedule.Location,
+			schedule.MaxParticipants, schedule.Level, schedule.ID, key, schedule.VenueID, schedule.CoachID)
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n > 0 {
+			result.Created++
+		}
+	}
+
+	return nil
+}
+
+type dateRange struct {
+	start, end time.Time
+}
+
+// FindConflicts compares the schedule's occurrences between from and to with
+// other schedules and with sessions that do not follow a schedule, in the
+// same venue or with the same coach. s.ID is excluded (0 for a new schedule).
+func (r *ScheduleRepository) FindConflicts(s *models.TrainingSchedule, from, to time.Time) ([]models.BookingConflict, error) {
+	if s.VenueID == nil && s.CoachID == nil {
+		return nil, nil
+	}
+	times, err := s.Occurrences(from, to)
+	if err != nil || len(times) == 0 {
+		return nil, err
+	}
+	duration := time.Duration(s.DurationMinutes) * time.Minute
+	overlapping := func(start time.Time, minutes int) bool {
+		end := start.Add(time.Duration(minutes) * time.Minute)
+		for _, at := range times {
+			if at.Before(end) && start.Before(at.Add(duration)) {
+				return true
+			}
+		}
+		return false
+	}
+	kinds := func(venueID, coachID *int) []string {
+		var k []string
+		if s.VenueID != nil && sameID(venueID, s.VenueID) {
+			k = append(k, "venue")
+		}
+		if s.CoachID != nil && sameID(coachID, s.CoachID) {
+			k = append(k, "coach")
+		}
+		return k
+	}
+
+	var conflicts []models.BookingConflict
+
+	rows, err := r.db.Query(`
+		SELECT `+scheduleColumns+`
+		FROM training_schedules
+		WHERE id <> $1 AND (venue_id = $2 OR coach_id = $3)
+		ORDER BY id
+	`, s.ID, s.VenueID, s.CoachID)
+	if err != nil {
+		return nil, err
+	}
+	var others []*models.TrainingSchedule
+	for rows.Next() {
+		other, err := scanSchedule(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		others = append(others, other)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Report the first clash with each schedule
+	for _, other := range others {
+		otherTimes, err := other.Occurrences(from, to)
+		if err != nil {
+			return nil, err
+		}
+		for _, at := range otherTimes {
+			if !overlapping(at, other.DurationMinutes) {
+				continue
+			}
+			for _, kind := range kinds(other.VenueID, other.CoachID) {
+				id := other.ID
+				conflicts = append(conflicts, models.BookingConflict{
+					Kind: kind, ScheduleID: &id, Title: other.Title,
+					Start: at, End: at.Add(time.Duration(other.DurationMinutes) * time.Minute),
+				})
+			}
+			break
+		}
+	}
+
+	rows, err = r.db.Query(`
+		SELECT `+sessionColumns+`
+		FROM training_sessions
+		WHERE (schedule_id IS NULL OR detached) AND COALESCE(schedule_id, 0) <> $1
+		  AND session_date >= $2 AND session_date < $3
+		  AND (venue_id = $4 OR coach_id = $5)
+		ORDER BY session_date
+	`, s.ID, from, to.AddDate(0, 0, 1), s.VenueID, s.CoachID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		if !overlapping(session.SessionDate, session.DurationMinutes) {
+			continue
+		}
+		for _, kind := range kinds(session.VenueID, session.CoachID) {
+			id := session.ID
+			conflicts = append(conflicts, models.BookingConflict{
+				Kind: kind, SessionID: &id, Title: session.Title, Start: session.SessionDate,
+				End: session.SessionDate.Add(time.Duration(session.DurationMinutes) * time.Minute),
+			})
+		}
+	}
+	return conflicts, rows.Err()
+}
+
+// closedRanges returns the holidays and the schedule's exception dates overlapping [from, to]
+func closedRanges(ctx context.Context, tx *sql.Tx, scheduleID int, from, to time.Time) ([]dateRange, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT start_date, end_date
+		FROM training_closures
+		WHERE (schedule_id IS NULL OR schedule_id = $1) AND end_date >= $2 AND start_date <= $3
+	`, scheduleID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ranges []dateRange
+	for rows.Next() {
+		var d dateRange
+		if err := rows.Scan(&d.start, &d.end); err != nil {
+			return nil, err
+		}
+		ranges = append(ranges, d)
+	}
+	return ranges, rows.Err()
+}
+
+// isClosed reports whether the day (any time on it) falls in one of the ranges
+func isClosed(ranges []dateRange, day time.Time) bool {
+	day = time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC)
+	for _, d := range ranges {
+		if !day.Before(d.start) && !day.After(d.end) {
+			return true
+		}
+	}
+	return false
+}
+
+// CreateClosure records a holiday, or an exception period for one schedule
+func (r *ScheduleRepository) CreateClosure(req *models.CreateClosureRequest, createdBy int) (*models.TrainingClosure, error) {
+	c := &models.TrainingClosure{}
+	err := r.db.QueryRow(`
+		INSERT INTO training_closures (schedule_id, start_date, end_date, reason, created_by)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, schedule_id, to_char(start_date, 'YYYY-MM-DD'), to_char(end_date, 'YYYY-MM-DD'),
+		          COALESCE(reason, ''), created_at
+	`, req.ScheduleID, req.StartDate, req.EndDate, req.Reason, createdBy).Scan(
+		&c.ID, &c.ScheduleID, &c.StartDate, &c.EndDate, &c.Reason, &c.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// GetClosures returns closures that end on or after the given date
+func (r *ScheduleRepository) GetClosures(from time.Time) ([]*models.TrainingClosure, error) {
+	rows, err := r.db.Query(`
+		SELECT id, schedule_id, to_char(start_date, 'YYYY-MM-DD'), to_char(end_date, 'YYYY-MM-DD'),
+		       COALESCE(reason, ''), created_at
+		FROM training_closures
+		WHERE end_date >= $1
+		ORDER BY start_date, id
+	`, from)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	closures := []*models.TrainingClosure{}
+	for rows.Next() {
+		c := &models.TrainingClosure{}
+		if err := rows.Scan(&c.ID, &c.ScheduleID, &c.StartDate, &c.EndDate, &c.Reason, &c.CreatedAt); err != nil {
+			return nil, err
+		}
+		closures = append(closures, c)
+	}
+	return closures, rows.Err()
+}
+
+// DeleteClosure removes a closure
+func (r *ScheduleRepository) DeleteClosure(id int) error {
+	_, err := r.db.Exec(`DELETE FROM training_closures WHERE id = $1`, id)
+	return err
+}
@@ -0,0 +1,657 @@
+package repository
+
+// TableColumns lists the columns of a table that the repositories read or
+// write, with their type: integer, numeric, text, boolean, date, time,
+// timestamp, timestamptz, jsonb, or one of those followed by [] for arrays.
+// Column names differ from the model fields in places, so cmd/schema-check
+// compares this list, not the models, with the database.
+//
+// The list is written by hand. TestSchemaCoversQueries reads the queries of
+// this package and fails when one inserts, updates, returns or selects a
+// column missing here; TestSchemaMatchesMigrations checks the list against a
+// freshly migrated database when TEST_DATABASE_URL is set, as CI does.
+type TableColumns struct {
+	Repository string // The repository owning the table
+	Table      string
+	Columns    map[string]string
+}
+
+// Schema is every table the repositories use
+var Schema = []TableColumns{
+	{
+		Repository: "UserRepository",
+		Table:      "users",
+		Columns: map[string]string{
+			"id":            "integer",
+			"email":         "text",
+			"password_hash": "text",
+			"role":          "text",
+			"first_name":    "text",
+			"last_name":     "text",
+			"is_active":     "boolean",
+			"created_at":    "timestamp",
+			"updated_at":    "timestamp",
+			"athlete_id":    "integer",
+		},
+	},
+	{
+		Repository: "AthleteRepository",
+		Table:      "athletes",
+		Columns: map[string]string{
+			"id":                         "integer",
+			"first_name":                 "text",
+			"last_name":                  "text",
+			"birth_date":                 "date", // Athlete.DateOfBirth
+			"gender":                     "text",
+			"nationality":                "text",
+			"photo_url":                  "text",
+			"email":                      "text",
+			"phone":                      "text",
+			"address":                    "text",
+			"city":                       "text",
+			"postal_code":                "text",
+			"emergency_contact_name":     "text",
+			"emergency_contact_phone":    "text",
+			"emergency_contact_relation": "text",
+			"weight":                     "numeric", // Athlete.WeightKG
+			"weight_category":            "text",
+			"belt_level":                 "text",
+			"skill_level":                "text",
+			"experience_years":           "integer", // Athlete.YearsOfExperience
+			"registration_date":          "date",
+			"membership_status":          "text",
+			"approved_by":                "integer",
+			"approved_at":                "timestamp",
+			"rejection_reason":           "text",
+			"medical_conditions":         "text",
+			"allergies":                  "text",
+			"blood_type":                 "text",
+			"created_at":                 "timestamp",
+			"updated_at":                 "timestamp",
+			"is_active":                  "boolean",
+		},
+	},
+	{
+		Repository: "GuardianRepository",
+		Table:      "athlete_guardians",
+		Columns: map[string]string{
+			"athlete_id": "integer",
+			"user_id":    "integer",
+			"relation":   "text",
+			"is_primary": "boolean",
+			"created_at": "timestamp",
+		},
+	},
+	{
+		Repository: "DocumentRepository",
+		Table:      "documents",
+		Columns: map[string]string{
+			"id":                "integer",
+			"athlete_id":        "integer",
+			"document_type":     "text",
+			"file_name":         "text",
+			"file_path":         "text",
+			"file_url":          "text",
+			"file_size_bytes":   "integer",
+			"mime_type":         "text",
+			"validation_status": "text",
+			"validated_by":      "integer",
+			"validated_at":      "timestamp",
+			"rejection_reason":  "text",
+			"expiry_date":       "date",
+			"uploaded_at":       "timestamp",
+			"notes":             "text",
+			"category_id":       "integer",
+			"updated_at":        "timestamp",
+		},
+	},
+	{
+		Repository: "DocumentRepository",
+		Table:      "document_tag_relations",
+		Columns: map[string]string{
+			"document_id": "integer",
+			"tag_id":      "integer",
+		},
+	},
+	{
+		Repository: "DocumentRepository",
+		Table:      "document_versions",
+		Columns: map[string]string{
+			"id":              "integer",
+			"document_id":     "integer",
+			"version_number":  "integer",
+			"file_name":       "text",
+			"file_path":       "text",
+			"file_url":        "text",
+			"file_size_bytes": "integer",
+			"mime_type":       "text",
+			"notes":           "text",
+			"uploaded_by":     "integer",
+			"uploaded_at":     "timestamp",
+		},
+	},
+	{
+		Repository: "DocumentRepository",
+		Table:      "document_shares",
+		Columns: map[string]string{
+			"id":               "integer",
+			"document_id":      "integer",
+			"shared_by":        "integer",
+			"shared_with":      "integer",
+			"permission_level": "text",
+			"notes":            "text",
+			"shared_at":        "timestamp",
+			"expires_at":       "timestamp",
+		},
+	},
+	{
+		Repository: "DocumentRepository",
+		Table:      "document_categories",
+		Columns: map[string]string{
+			"id":          "integer",
+			"name":        "text",
+			"description": "text",
+			"color":       "text",
+			"created_at":  "timestamp",
+		},
+	},
+	{
+		Repository: "DocumentRepository",
+		Table:      "document_tags",
+		Columns: map[string]string{
+			"id":         "integer",
+			"name":       "text",
+			"color":      "text",
+			"created_at": "timestamp",
+		},
+	},
+	{
+		Repository: "TrainingRepository",
+		Table:      "training_sessions",
+		Columns: map[string]string{
+			"id":               "integer",
+			"title":            "text",
+			"description":      "text",
+			"session_date":     "timestamp",
+			"duration_minutes": "integer",
+			"location":         "text",
+			"coach_id":         "integer",
+			"max_participants": "integer",
+			"level":            "text",
+			"created_at":       "timestamp",
+			"schedule_id":      "integer",
+			"occurrence_date":  "date",
+			"detached":         "boolean",
+			"venue_id":         "integer",
+		},
+	},
+	{
+		Repository: "TrainingRepository",
+		Table:      "attendance",
+		Columns: map[string]string{
+			"id":                  "integer",
+			"training_session_id": "integer",
+			"athlete_id":          "integer",
+			"attended":            "boolean",
+			"notes":               "text",
+			"marked_at":           "timestamp",
+			"marked_by":           "integer",
+			"check_in_method":     "text",
+		},
+	},
+	{
+		Repository: "ScheduleRepository",
+		Table:      "training_schedules",
+		Columns: map[string]string{
+			"id":               "integer",
+			"day_of_week":      "text",
+			"start_time":       "time",
+			"duration_minutes": "integer",
+			"title":            "text",
+			"location":         "text",
+			"description":      "text",
+			"created_at":       "timestamptz",
+			"level":            "text",
+			"max_participants": "integer",
+			"rrule":            "text",
+			"valid_from":       "date",
+			"valid_until":      "date",
+			"exdates":          "date[]",
+			"venue_id":         "integer",
+			"coach_id":         "integer",
+		},
+	},
+	{
+		Repository: "ScheduleRepository",
+		Table:      "training_closures",
+		Columns: map[string]string{
+			"id":          "integer",
+			"schedule_id": "integer",
+			"start_date":  "date",
+			"end_date":    "date",
+			"reason":      "text",
+			"created_by":  "integer",
+			"created_at":  "timestamptz",
+		},
+	},
+	{
+		Repository: "VenueRepository",
+		Table:      "venues",
+		Columns: map[string]string{
+			"id":               "integer",
+			"name":             "text",
+			"capacity":         "integer",
+			"address":          "text",
+			"is_active":        "boolean",
+			"created_at":       "timestamptz",
+			"latitude":         "numeric",
+			"longitude":        "numeric",
+			"checkin_radius_m": "integer",
+		},
+	},
+	{
+		Repository: "BookingRepository",
+		Table:      "session_bookings",
+		Columns: map[string]string{
+			"id":                  "integer",
+			"training_session_id": "integer",
+			"athlete_id":          "integer",
+			"status":              "text",
+			"booked_at":           "timestamp",
+			"promoted_at":         "timestamp",
+			"cancelled_at":        "timestamp",
+		},
+	},
+	{
+		Repository: "CalendarRepository",
+		Table:      "calendar_tokens",
+		Columns: map[string]string{
+			"user_id":    "integer",
+			"token":      "text",
+			"created_at": "timestamptz",
+		},
+	},
+	{
+		Repository: "PaymentRepository",
+		Table:      "payments",
+		Columns: map[string]string{
+			"id":                  "integer",
+			"athlete_id":          "integer",
+			"amount":              "numeric",
+			"months_covered":      "integer",
+			"start_date":          "date",
+			"end_date":            "date",
+			"payment_date":        "timestamptz",
+			"notes":               "text",
+			"recorded_by":         "integer",
+			"receipt_year":        "integer",
+			"receipt_seq":         "integer",
+			"receipt_number":      "text",
+			"method":              "text",
+			"external_reference":  "text",
+			"kind":                "text",
+			"parent_payment_id":   "integer",
+			"status":              "text",
+			"amount_due":          "numeric",
+			"refunded_payment_id": "integer",
+		},
+	},
+	{
+		Repository: "PaymentRepository",
+		Table:      "receipt_sequences",
+		Columns: map[string]string{
+			"year":        "integer",
+			"last_number": "integer",
+		},
+	},
+	{
+		Repository: "CheckoutRepository",
+		Table:      "payment_checkouts",
+		Columns: map[string]string{
+			"id":              "integer",
+			"athlete_id":      "integer",
+			"user_id":         "integer",
+			"provider":        "text",
+			"session_id":      "text",
+			"checkout_url":    "text",
+			"idempotency_key": "text",
+			"amount":          "numeric",
+			"months_covered":  "integer",
+			"status":          "text",
+			"success_url":     "text",
+			"cancel_url":      "text",
+			"payment_id":      "integer",
+			"created_at":      "timestamptz",
+			"completed_at":    "timestamptz",
+		},
+	},
+	{
+		Repository: "CheckoutRepository",
+		Table:      "payment_webhook_events",
+		Columns: map[string]string{
+			"provider":   "text",
+			"event_id":   "text",
+			"session_id": "text",
+			"status":     "text",
+		},
+	},
+	{
+		Repository: "EventRepository",
+		Table:      "events",
+		Columns: map[string]string{
+			"id":                    "integer",
+			"title":                 "text",
+			"description":           "text",
+			"date":                  "timestamp",
+			"location":              "text",
+			"image_url":             "text",
+			"max_participants":      "integer",
+			"created_at":            "timestamp",
+			"event_type":            "text",
+			"status":                "text",
+			"registration_deadline": "timestamp",
+			"weight_categories":     "text[]",
+			"belt_levels":           "text[]",
+			"required_documents":    "text[]",
+			"created_by":            "integer",
+		},
+	},
+	{
+		Repository: "EventRepository",
+		Table:      "event_registrations",
+		Columns: map[string]string{
+			"id":              "integer",
+			"event_id":        "integer",
+			"athlete_id":      "integer",
+			"registered_at":   "timestamp",
+			"weight_category": "text",
+			"belt_level":      "text",
+			"status":          "text",
+			"nominated_by":    "integer",
+			"withdrawn_at":    "timestamp",
+			"placing":         "integer",
+		},
+	},
+	{
+		Repository: "BracketRepository",
+		Table:      "brackets",
+		Columns: map[string]string{
+			"id":              "integer",
+			"event_id":        "integer",
+			"weight_category": "text",
+			"gender":          "text",
+			"format":          "text",
+			"status":          "text",
+			"created_at":      "timestamp",
+		},
+	},
+	{
+		Repository: "BracketRepository",
+		Table:      "bracket_entries",
+		Columns: map[string]string{
+			"bracket_id": "integer",
+			"athlete_id": "integer",
+			"seed":       "integer",
+			"placing":    "integer",
+		},
+	},
+	{
+		Repository: "BracketRepository",
+		Table:      "bouts",
+		Columns: map[string]string{
+			"id":                "integer",
+			"bracket_id":        "integer",
+			"round":             "integer",
+			"position":          "integer",
+			"red_athlete_id":    "integer",
+			"blue_athlete_id":   "integer",
+			"status":            "text",
+			"winner_athlete_id": "integer",
+			"method":            "text",
+			"red_rounds_won":    "integer",
+			"blue_rounds_won":   "integer",
+			"red_points":        "integer",
+			"blue_points":       "integer",
+			"next_bout_id":      "integer",
+			"next_slot":         "text",
+			"completed_at":      "timestamp",
+		},
+	},
+	{
+		Repository: "RankingRepository",
+		Table:      "athlete_ratings",
+		Columns: map[string]string{
+			"athlete_id": "integer",
+			"rating":     "integer",
+			"bouts":      "integer",
+		},
+	},
+	{
+		Repository: "RankingRepository",
+		Table:      "rating_history",
+		Columns: map[string]string{
+			"athlete_id":    "integer",
+			"bout_id":       "integer",
+			"rating_before": "integer",
+			"rating_after":  "integer",
+			"rated_at":      "timestamp",
+		},
+	},
+	{
+		Repository: "WeighInRepository",
+		Table:      "age_groups",
+		Columns: map[string]string{
+			"name":    "text",
+			"min_age": "integer",
+			"max_age": "integer",
+		},
+	},
+	{
+		Repository: "WeighInRepository",
+		Table:      "weight_classes",
+		Columns: map[string]string{
+			"id":        "integer",
+			"gender":    "text",
+			"age_group": "text",
+			"name":      "text",
+			"max_kg":    "numeric",
+		},
+	},
+	{
+		Repository: "WeighInRepository",
+		Table:      "weigh_ins",
+		Columns: map[string]string{
+			"id":              "integer",
+			"athlete_id":      "integer",
+			"weight_kg":       "numeric",
+			"weighed_at":      "timestamp",
+			"official":        "boolean",
+			"event_id":        "integer",
+			"weight_category": "text",
+			"recorded_by":     "integer",
+			"notes":           "text",
+			"created_at":      "timestamp",
+		},
+	},
+	{
+		Repository: "GradingRepository",
+		Table:      "belts",
+		Columns: map[string]string{
+			"id":                  "integer",
+			"name":                "text",
+			"rank":                "integer",
+			"color":               "text",
+			"min_months":          "integer",
+			"min_attendance_rate": "numeric",
+		},
+	},
+	{
+		Repository: "GradingRepository",
+		Table:      "grading_sessions",
+		Columns: map[string]string{
+			"id":         "integer",
+			"title":      "text",
+			"exam_date":  "timestamp",
+			"location":   "text",
+			"status":     "text",
+			"notes":      "text",
+			"created_by": "integer",
+			"created_at": "timestamp",
+		},
+	},
+	{
+		Repository: "GradingRepository",
+		Table:      "grading_candidates",
+		Columns: map[string]string{
+			"session_id":     "integer",
+			"athlete_id":     "integer",
+			"current_belt":   "text",
+			"target_belt":    "text",
+			"result":         "text",
+			"score":          "numeric",
+			"examiner_id":    "integer",
+			"examiner_notes": "text",
+			"graded_at":      "timestamp",
+			"created_at":     "timestamp",
+		},
+	},
+	{
+		Repository: "GradingRepository",
+		Table:      "belt_promotions",
+		Columns: map[string]string{
+			"id":          "integer",
+			"athlete_id":  "integer",
+			"from_belt":   "text",
+			"to_belt":     "text",
+			"promoted_at": "timestamp",
+			"session_id":  "integer",
+			"promoted_by": "integer",
+			"notes":       "text",
+			"created_at":  "timestamp",
+		},
+	},
+	{
+		Repository: "AnnouncementRepository",
+		Table:      "announcements",
+		Columns: map[string]string{
+			"id":                       "integer",
+			"title":                    "text",
+			"content":                  "text",
+			"published_date":           "timestamp",
+			"is_pinned":                "boolean",
+			"created_at":               "timestamp",
+			"expires_at":               "timestamp",
+			"target_roles":             "text[]",
+			"target_skill_levels":      "text[]",
+			"target_weight_categories": "text[]",
+			"target_athlete_ids":       "integer[]",
+			"created_by":               "integer",
+			"updated_at":               "timestamp",
+		},
+	},
+	{
+		Repository: "AnnouncementRepository",
+		Table:      "announcement_attachments",
+		Columns: map[string]string{
+			"id":              "integer",
+			"announcement_id": "integer",
+			"file_name":       "text",
+			"file_url":        "text",
+			"mime_type":       "text",
+			"file_size_bytes": "integer",
+			"uploaded_by":     "integer",
+			"uploaded_at":     "timestamp",
+		},
+	},
+	{
+		Repository: "AnnouncementRepository",
+		Table:      "announcement_reads",
+		Columns: map[string]string{
+			"announcement_id": "integer",
+			"user_id":         "integer",
+			"read_at":         "timestamp",
+		},
+	},
+	{
+		Repository: "NotificationRepository",
+		Table:      "notification_preferences",
+		Columns: map[string]string{
+			"user_id":       "integer",
+			"language":      "text",
+			"email_enabled": "boolean",
+			"sms_enabled":   "boolean",
+			"phone":         "text",
+			"muted":         "text[]",
+			"updated_at":    "timestamp",
+		},
+	},
+	{
+		Repository: "NotificationRepository",
+		Table:      "notification_outbox",
+		Columns: map[string]string{
+			"id":              "integer",
+			"user_id":         "integer",
+			"athlete_id":      "integer",
+			"channel":         "text",
+			"recipient":       "text",
+			"template":        "text",
+			"language":        "text",
+			"subject":         "text",
+			"body":            "text",
+			"status":          "text",
+			"attempts":        "integer",
+			"next_attempt_at": "timestamp",
+			"last_error":      "text",
+			"dedupe_key":      "text",
+			"created_at":      "timestamp",
+			"sent_at":         "timestamp",
+		},
+	},
+	{
+		Repository: "NotificationRepository",
+		Table:      "notifications",
+		Columns: map[string]string{
+			"id":         "integer",
+			"user_id":    "integer",
+			"athlete_id": "integer",
+			"type":       "text",
+			"title":      "text",
+			"message":    "text",
+			"dedupe_key": "text",
+			"read_at":    "timestamp",
+			"created_at": "timestamp",
+		},
+	},
+	{
+		Repository: "JobRepository",
+		Table:      "jobs",
+		Columns: map[string]string{
+			"id":            "integer",
+			"type":          "text",
+			"payload":       "jsonb",
+			"status":        "text",
+			"attempts":      "integer",
+			"max_attempts":  "integer",
+			"run_at":        "timestamptz",
+			"locked_by":     "text",
+			"locked_until":  "timestamptz",
+			"last_error":    "text",
+			"schedule_name": "text",
+			"created_at":    "timestamptz",
+			"started_at":    "timestamptz",
+			"finished_at":   "timestamptz",
+		},
+	},
+	{
+		Repository: "JobRepository",
+		Table:      "job_schedules",
+		Columns: map[string]string{
+			"name":        "text",
+			"job_type":    "text",
+			"cron":        "text",
+			"next_run_at": "timestamptz",
+			"last_run_at": "timestamptz",
+			"updated_at":  "timestamptz",
+		},
+	},
+}
@@ -0,0 +1,189 @@
+package repository
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// The queries are read from the repository sources, so these patterns only
+// need to cover how this package writes SQL: upper-case keywords, lower-case
+// snake_case names
+var (
+	insertColumns = regexp.MustCompile(`\bINSERT INTO (\w+) ?\(([^)]*)\)`)
+	updateSet     = regexp.MustCompile(`\bUPDATE (\w+)(?: (?:AS )?(\w+))? SET (.*?)(?: WHERE | FROM | RETURNING |$)`)
+	setColumn     = regexp.MustCompile(`(?:^|, )(\w+) = `)
+	returning     = regexp.MustCompile(`\b(INSERT INTO|UPDATE|DELETE FROM) (\w+)\b.*? RETURNING (.*)$`)
+	tableRef      = regexp.MustCompile(`\b(?:FROM|JOIN|UPDATE|INTO) (\w+)(?: (?:AS )?(\w+))?([.(]?)`)
+	qualified     = regexp.MustCompile(`\b([a-z_][a-z0-9_]*)\.([a-z_][a-z0-9_]*|\*)`)
+	cteName       = regexp.MustCompile(`\b(\w+) AS \(`)
+	singleSelect  = regexp.MustCompile(`^SELECT (.*?) FROM (\w+)\b`)
+	bareColumn    = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+	whitespace    = regexp.MustCompile(`\s+`)
+)
+
+// sqlKeywords may follow a table name where an alias could; they are not aliases
+var sqlKeywords = map[string]bool{
+	"WHERE": true, "JOIN": true, "LEFT": true, "RIGHT": true, "INNER": true, "FULL": true,
+	"CROSS": true, "ON": true, "ORDER": true, "GROUP": true, "LIMIT": true, "OFFSET": true,
+	"SET": true, "VALUES": true, "RETURNING": true, "USING": true, "HAVING": true,
+	"UNION": true, "FOR": true, "AND": true, "OR": true, "WINDOW": true, "DO": true,
+	"SELECT": true, "DEFAULT": true,
+}
+
+// sqlWords are the identifiers that look like columns in a select list but are not
+var sqlWords = map[string]bool{"true": true, "false": true, "null": true}
+
+type query struct {
+	pos token.Position
+	sql string
+}
+
+// repositoryQueries returns the string literals of the package's sources
+// that hold SQL, with the whitespace collapsed
+func repositoryQueries(t *testing.T) []query {
+	t.Helper()
+	files, err := filepath.Glob("*.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fset := token.NewFileSet()
+	var queries []query
+	for _, name := range files {
+		if strings.HasSuffix(name, "_test.go") || name == "schema.go" {
+			continue
+		}
+		f, err := parser.ParseFile(fset, name, nil, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ast.Inspect(f, func(n ast.Node) bool {
+			lit, ok := n.(*ast.BasicLit)
+			if !ok || lit.Kind != token.STRING {
+				return true
+			}
+			s, err := strconv.Unquote(lit.Value)
+			if err != nil {
+				return true
+			}
+			s = strings.TrimSpace(whitespace.ReplaceAllString(s, " "))
+			if strings.Contains(s, "SELECT ") || strings.Contains(s, "INSERT INTO ") ||
+				strings.Contains(s, "UPDATE ") || strings.Contains(s, "DELETE FROM ") {
+				queries = append(queries, query{fset.Position(lit.Pos()), s})
+			}
+			return true
+		})
+	}
+	return queries
+}
+
+// splitList splits a column list on the commas outside parentheses
+func splitList(list string) []string {
+	var items []string
+	depth, start := 0, 0
+	for i, r := range list {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				items = append(items, strings.TrimSpace(list[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	return append(items, strings.TrimSpace(list[start:]))
+}
+
+// TestSchemaCoversQueries ties Schema to the queries: every column a
+// repository inserts, updates, returns or reads through a table alias, and
+// every column of a plain single-table select, must be listed for its table.
+// Queries assembled at run time are only checked for the literal parts.
+func TestSchemaCoversQueries(t *testing.T) {
+	tables := map[string]map[string]string{}
+	for _, table := range Schema {
+		tables[table.Table] = table.Columns
+	}
+
+	for _, q := range repositoryQueries(t) {
+		check := func(table, column string) {
+			columns, ok := tables[table]
+			switch {
+			case !ok:
+				t.Errorf("%s: table %s is not in Schema", q.pos, table)
+			case column != "*" && columns[column] == "":
+				t.Errorf("%s: %s.%s is not in Schema", q.pos, table, column)
+			}
+		}
+
+		ctes := map[string]bool{}
+		for _, m := range cteName.FindAllStringSubmatch(q.sql, -1) {
+			ctes[m[1]] = true
+		}
+		known := func(table string) bool {
+			return !ctes[table] && strings.ToUpper(table) != table
+		}
+
+		aliases := map[string][]string{}
+		for _, m := range tableRef.FindAllStringSubmatch(q.sql, -1) {
+			table, alias, next := m[1], m[2], m[3]
+			if next != "" && alias == "" || !known(table) {
+				// A function call or a qualified name, not a table
+				continue
+			}
+			aliases[table] = append(aliases[table], table)
+			if alias != "" && !sqlKeywords[alias] && !sqlKeywords[strings.ToUpper(alias)] {
+				aliases[alias] = append(aliases[alias], table)
+			}
+		}
+
+		for _, m := range insertColumns.FindAllStringSubmatch(q.sql, -1) {
+			for _, column := range splitList(m[2]) {
+				check(m[1], column)
+			}
+		}
+		for _, m := range updateSet.FindAllStringSubmatch(q.sql, -1) {
+			for _, c := range setColumn.FindAllStringSubmatch(m[3], -1) {
+				check(m[1], c[1])
+			}
+		}
+		if m := returning.FindStringSubmatch(q.sql); m != nil {
+			for _, column := range splitList(m[3]) {
+				if bareColumn.MatchString(column) {
+					check(m[2], column)
+				}
+			}
+		}
+		for _, m := range qualified.FindAllStringSubmatch(q.sql, -1) {
+			candidates, ok := aliases[m[1]]
+			if !ok {
+				continue
+			}
+			found := false
+			for _, table := range candidates {
+				if _, listed := tables[table]; !listed || m[2] == "*" || tables[table][m[2]] != "" {
+					found = true
+				}
+			}
+			if !found {
+				check(candidates[0], m[2])
+			}
+		}
+		if m := singleSelect.FindStringSubmatch(q.sql); m != nil && known(m[2]) &&
+			strings.Count(q.sql, "SELECT ") == 1 && !strings.Contains(q.sql, " JOIN ") {
+			for _, item := range splitList(m[1]) {
+				item = strings.SplitN(item, " AS ", 2)[0]
+				if bareColumn.MatchString(item) && !sqlWords[item] {
+					check(m[2], item)
+				}
+			}
+		}
+	}
+}
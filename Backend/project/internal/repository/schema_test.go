@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"strings"
+	"testing"
+
+	"east-eagles/backend/internal/database"
+	"east-eagles/backend/migrations"
+)
+
+// openTestDB migrates the database at TEST_DATABASE_URL and returns it,
+// skipping the test when the variable is not set
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := database.NewMigrator(db, migrations.FS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(context.Background(), ""); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+var schemaTypes = map[string]bool{
+	"integer": true, "numeric": true, "text": true, "boolean": true, "date": true,
+	"time": true, "timestamp": true, "timestamptz": true, "jsonb": true,
+}
+
+func TestSchemaIsWellFormed(t *testing.T) {
+	seen := map[string]string{}
+	for _, table := range Schema {
+		if other, ok := seen[table.Table]; ok {
+			t.Errorf("%s is listed by both %s and %s", table.Table, other, table.Repository)
+		}
+		seen[table.Table] = table.Repository
+		if len(table.Columns) == 0 {
+			t.Errorf("%s has no columns", table.Table)
+		}
+		for name, typ := range table.Columns {
+			if !schemaTypes[strings.TrimSuffix(typ, "[]")] {
+				t.Errorf("%s.%s: unknown type %q", table.Table, name, typ)
+			}
+		}
+	}
+}
+
+// TestSchemaMatchesMigrations applies the migrations to the empty database
+// at TEST_DATABASE_URL and checks that it has every column of Schema, with
+// the listed type. It is the check cmd/schema-check runs against a live
+// database; Schema is kept by hand, so this is what ties it to the files.
+func TestSchemaMatchesMigrations(t *testing.T) {
+	db := openTestDB(t)
+
+	tables, err := database.Columns(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, table := range Schema {
+		actual := map[string]database.Column{}
+		for _, c := range tables[table.Table] {
+			actual[c.Name] = c
+		}
+		if len(actual) == 0 {
+			t.Errorf("%s (%s): table missing", table.Table, table.Repository)
+			continue
+		}
+		for name, typ := range table.Columns {
+			c, ok := actual[name]
+			switch {
+			case !ok:
+				t.Errorf("%s.%s (%s): missing", table.Table, name, table.Repository)
+			case c.Type != typ:
+				t.Errorf("%s.%s (%s): %s expected, %s in the database", table.Table, name, table.Repository, typ, c.SQLType)
+			}
+		}
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"time"
 
 	"east-eagles/backend/internal/models"
+
+	"github.com/lib/pq"
 )
 
 type TrainingRepository struct {
@@ -15,17 +17,39 @@ func NewTrainingRepository(db *sql.DB) *TrainingRepository {
 	return &TrainingRepository{db: db}
 }
 
-// Create creates a new training session
+const sessionColumns = `
+	id, title, COALESCE(description, ''), session_date, duration_minutes, COALESCE(location, ''),
+	venue_id, coach_id, COALESCE(max_participants, 0), COALESCE(level, 'all'),
+	schedule_id, occurrence_date, detached, created_at`
+
+func scanSession(row rowScanner) (*models.TrainingSession, error) {
+	s := &models.TrainingSession{}
+	err := row.Scan(
+		&s.ID, &s.Title, &s.Description, &s.SessionDate, &s.DurationMinutes, &s.Location,
+		&s.VenueID, &s.CoachID, &s.MaxParticipants, &s.Level,
+		&s.ScheduleID, &s.OccurrenceDate, &s.Detached, &s.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Create creates a new training session. coachID is used unless the request names a coach.
 func (r *TrainingRepository) Create(session *models.CreateTrainingSessionRequest, coachID int) (*models.TrainingSession, error) {
 	query := `
 		INSERT INTO training_sessions (
 			title, description, session_date, duration_minutes, location, 
-			coach_id, max_participants, level
+			coach_id, max_participants, level, venue_id
 		)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 		RETURNING id, created_at
 	`
 
+	if session.CoachID != nil {
+		coachID = *session.CoachID
+	}
+
 	// Parse date string to time.Time
 	// Assuming format "2006-01-02 15:04" from frontend
 	sessionDate, err := time.Parse("2006-01-02 15:04", session.SessionDate)
@@ -39,6 +63,7 @@ func (r *TrainingRepository) Create(session *models.CreateTrainingSessionRequest
 		SessionDate:     sessionDate,
 		DurationMinutes: session.DurationMinutes,
 		Location:        session.Location,
+		VenueID:         session.VenueID,
 		CoachID:         &coachID,
 		MaxParticipants: session.MaxParticipants,
 		Level:           session.Level,
@@ -54,6 +79,7 @@ func (r *TrainingRepository) Create(session *models.CreateTrainingSessionRequest
 		coachID,
 		newSession.MaxParticipants,
 		newSession.Level,
+		newSession.VenueID,
 	).Scan(&newSession.ID, &newSession.CreatedAt)
 
 	if err != nil {
@@ -66,8 +92,7 @@ func (r *TrainingRepository) Create(session *models.CreateTrainingSessionRequest
 // GetAll returns all training sessions
 func (r *TrainingRepository) GetAll() ([]*models.TrainingSession, error) {
 	query := `
-		SELECT id, title, description, session_date, duration_minutes, location, 
-		       coach_id, max_participants, level, created_at
+		SELECT ` + sessionColumns + `
 		FROM training_sessions
 		ORDER BY session_date DESC
 	`
@@ -79,11 +104,8 @@ func (r *TrainingRepository) GetAll() ([]*models.TrainingSession, error) {
 
 	var sessions []*models.TrainingSession
 	for rows.Next() {
-		s := &models.TrainingSession{}
-		if err := rows.Scan(
-			&s.ID, &s.Title, &s.Description, &s.SessionDate, &s.DurationMinutes,
-			&s.Location, &s.CoachID, &s.MaxParticipants, &s.Level, &s.CreatedAt,
-		); err != nil {
+		s, err := scanSession(rows)
+		if err != nil {
 			return nil, err
 		}
 		sessions = append(sessions, s)
@@ -94,8 +116,7 @@ func (r *TrainingRepository) GetAll() ([]*models.TrainingSession, error) {
 // GetUpcoming returns upcoming training sessions
 func (r *TrainingRepository) GetUpcoming() ([]*models.TrainingSession, error) {
 	query := `
-		SELECT id, title, description, session_date, duration_minutes, location, 
-		       coach_id, max_participants, level, created_at
+		SELECT ` + sessionColumns + `
 		FROM training_sessions
 		WHERE session_date >= CURRENT_TIMESTAMP
 		ORDER BY session_date ASC
@@ -108,11 +129,8 @@ func (r *TrainingRepository) GetUpcoming() ([]*models.TrainingSession, error) {
 
 	var sessions []*models.TrainingSession
 	for rows.Next() {
-		s := &models.TrainingSession{}
-		if err := rows.Scan(
-			&s.ID, &s.Title, &s.Description, &s.SessionDate, &s.DurationMinutes,
-			&s.Location, &s.CoachID, &s.MaxParticipants, &s.Level, &s.CreatedAt,
-		); err != nil {
+		s, err := scanSession(rows)
+		if err != nil {
 			return nil, err
 		}
 		sessions = append(sessions, s)
@@ -122,21 +140,12 @@ func (r *TrainingRepository) GetUpcoming() ([]*models.TrainingSession, error) {
 
 // GetByID returns a training session by ID
 func (r *TrainingRepository) GetByID(id int) (*models.TrainingSession, error) {
-	s := &models.TrainingSession{}
 	query := `
-		SELECT id, title, description, session_date, duration_minutes, location, 
-		       coach_id, max_participants, level, created_at
+		SELECT ` + sessionColumns + `
 		FROM training_sessions
 		WHERE id = $1
 	`
-	err := r.db.QueryRow(query, id).Scan(
-		&s.ID, &s.Title, &s.Description, &s.SessionDate, &s.DurationMinutes,
-		&s.Location, &s.CoachID, &s.MaxParticipants, &s.Level, &s.CreatedAt,
-	)
-	if err != nil {
-		return nil, err
-	}
-	return s, nil
+	return scanSession(r.db.QueryRow(query, id))
 }
 
 // Update updates a training session
@@ -146,12 +155,14 @@ func (r *TrainingRepository) Update(id int, session *models.CreateTrainingSessio
 		return nil, err
 	}
 
+	// A generated session edited by hand stops following its schedule
 	query := `
 		UPDATE training_sessions
 		SET title = $1, description = $2, session_date = $3, duration_minutes = $4,
-		    location = $5, max_participants = $6, level = $7
-		WHERE id = $8
-		RETURNING id, coach_id, created_at
+		    location = $5, max_participants = $6, level = $7, detached = schedule_id IS NOT NULL,
+		    venue_id = $8, coach_id = COALESCE($9, coach_id)
+		WHERE id = $10
+		RETURNING id, coach_id, schedule_id, occurrence_date, detached, created_at
 	`
 
 	updatedSession := &models.TrainingSession{
@@ -161,6 +172,7 @@ func (r *TrainingRepository) Update(id int, session *models.CreateTrainingSessio
 		SessionDate:     sessionDate,
 		DurationMinutes: session.DurationMinutes,
 		Location:        session.Location,
+		VenueID:         session.VenueID,
 		MaxParticipants: session.MaxParticipants,
 		Level:           session.Level,
 	}
@@ -174,8 +186,13 @@ func (r *TrainingRepository) Update(id int, session *models.CreateTrainingSessio
 		updatedSession.Location,
 		updatedSession.MaxParticipants,
 		updatedSession.Level,
+		updatedSession.VenueID,
+		session.CoachID,
 		id,
-	).Scan(&updatedSession.ID, &updatedSession.CoachID, &updatedSession.CreatedAt)
+	).Scan(
+		&updatedSession.ID, &updatedSession.CoachID, &updatedSession.ScheduleID,
+		&updatedSession.OccurrenceDate, &updatedSession.Detached, &updatedSession.CreatedAt,
+	)
 
 	if err != nil {
 		return nil, err
@@ -184,21 +201,92 @@ func (r *TrainingRepository) Update(id int, session *models.CreateTrainingSessio
 	return updatedSession, nil
 }
 
-// Delete deletes a training session
+// FindConflicts returns sessions overlapping [start, start+duration) in the
+// same venue or with the same coach, other than excludeID
+func (r *TrainingRepository) FindConflicts(start time.Time, durationMinutes int, venueID, coachID *int, excludeID int) ([]models.BookingConflict, error) {
+	end := start.Add(time.Duration(durationMinutes) * time.Minute)
+	rows, err := r.db.Query(`
+		SELECT id, title, session_date, duration_minutes, venue_id = $4, coach_id = $5
+		FROM training_sessions
+		WHERE id <> $1
+		  AND session_date < $3 AND session_date + duration_minutes * INTERVAL '1 minute' > $2
+		  AND (venue_id = $4 OR coach_id = $5)
+		ORDER BY session_date
+	`, excludeID, start, end, venueID, coachID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var conflicts []models.BookingConflict
+	for rows.Next() {
+		var id, duration int
+		var title string
+		var at time.Time
+		var sameVenue, sameCoach sql.NullBool
+		if err := rows.Scan(&id, &title, &at, &duration, &sameVenue, &sameCoach); err != nil {
+			return nil, err
+		}
+		c := models.BookingConflict{
+			SessionID: &id, Title: title, Start: at, End: at.Add(time.Duration(duration) * time.Minute),
+		}
+		if sameVenue.Bool {
+			c.Kind = "venue"
+			conflicts = append(conflicts, c)
+		}
+		if sameCoach.Bool {
+			c.Kind = "coach"
+			conflicts = append(conflicts, c)
+		}
+	}
+	return conflicts, rows.Err()
+}
+
+// Delete deletes a training session. Deleting a generated session records an
+// exception date so the generator does not bring it back.
 func (r *TrainingRepository) Delete(id int) error {
-	query := `DELETE FROM training_sessions WHERE id = $1`
-	_, err := r.db.Exec(query, id)
-	return err
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var scheduleID sql.NullInt64
+	var occurrence sql.NullTime
+	err = tx.QueryRow(
+		`DELETE FROM training_sessions WHERE id = $1 RETURNING schedule_id, occurrence_date`, id,
+	).Scan(&scheduleID, &occurrence)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if scheduleID.Valid && occurrence.Valid {
+		_, err = tx.Exec(`
+			INSERT INTO training_closures (schedule_id, start_date, end_date, reason)
+			VALUES ($1, $2, $2, 'Séance annulée')
+		`, scheduleID.Int64, occurrence.Time)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
 }
 
+// markAttendanceQuery upserts a staff attendance mark; it overrides a self check-in
+const markAttendanceQuery = `
+	INSERT INTO attendance (training_session_id, athlete_id, attended, notes, marked_by, check_in_method)
+	VALUES ($1, $2, $3, $4, $5, 'coach')
+	ON CONFLICT (training_session_id, athlete_id)
+	DO UPDATE SET attended = $3, notes = $4, marked_by = $5, marked_at = CURRENT_TIMESTAMP, check_in_method = 'coach'
+`
+
 // MarkAttendance marks an athlete's attendance
 func (r *TrainingRepository) MarkAttendance(req *models.MarkAttendanceRequest, markedBy int) error {
-	query := `
-		INSERT INTO attendance (training_session_id, athlete_id, attended, notes, marked_by)
-		VALUES ($1, $2, $3, $4, $5)
-		ON CONFLICT (training_session_id, athlete_id) 
-		DO UPDATE SET attended = $3, notes = $4, marked_by = $5, marked_at = CURRENT_TIMESTAMP
-	`
+	query := markAttendanceQuery
 	_, err := r.db.Exec(
 		query,
 		req.TrainingSessionID,
@@ -210,10 +298,70 @@ func (r *TrainingRepository) MarkAttendance(req *models.MarkAttendanceRequest, m
 	return err
 }
 
+// MarkAttendanceBatch marks a whole roster in one transaction: either every
+// record is saved or none
+func (r *TrainingRepository) MarkAttendanceBatch(sessionID int, records []models.MarkAttendanceRequest, markedBy int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	if err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM training_sessions WHERE id = $1)`, sessionID).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return sql.ErrNoRows
+	}
+
+	ids := make([]int64, len(records))
+	for i, rec := range records {
+		ids[i] = int64(rec.AthleteID)
+	}
+	var known int
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM athletes WHERE id = ANY($1)`, pq.Array(ids)).Scan(&known); err != nil {
+		return err
+	}
+	if known != len(records) {
+		return &BookingValidationError{Message: "records reference unknown athletes"}
+	}
+
+	stmt, err := tx.Prepare(markAttendanceQuery)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, rec := range records {
+		if _, err := stmt.Exec(sessionID, rec.AthleteID, rec.Attended, rec.Notes, markedBy); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// CheckIn marks the athlete present from a QR self check-in. It returns false
+// when a coach already marked the athlete: staff decisions are not overwritten.
+func (r *TrainingRepository) CheckIn(sessionID, athleteID, userID int) (bool, error) {
+	res, err := r.db.Exec(`
+		INSERT INTO attendance (training_session_id, athlete_id, attended, notes, marked_by, check_in_method)
+		VALUES ($1, $2, true, '', $3, 'qr')
+		ON CONFLICT (training_session_id, athlete_id)
+		DO UPDATE SET attended = true, marked_by = $3, marked_at = CURRENT_TIMESTAMP
+		WHERE attendance.check_in_method = 'qr'
+	`, sessionID, athleteID, userID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
 // GetAttendance returns attendance for a session
 func (r *TrainingRepository) GetAttendance(sessionID int) ([]*models.Attendance, error) {
 	query := `
-		SELECT id, training_session_id, athlete_id, attended, notes, marked_at
+		SELECT id, training_session_id, athlete_id, attended, COALESCE(notes, ''), check_in_method, marked_at
 		FROM attendance
 		WHERE training_session_id = $1
 	`
@@ -229,7 +377,7 @@ func (r *TrainingRepository) GetAttendance(sessionID int) ([]*models.Attendance,
 		// Use marked_at from database which maps to CreatedAt in model
 		var markedAt time.Time
 		if err := rows.Scan(
-			&a.ID, &a.TrainingSessionID, &a.AthleteID, &a.Attended, &a.Notes, &markedAt,
+			&a.ID, &a.TrainingSessionID, &a.AthleteID, &a.Attended, &a.Notes, &a.CheckInMethod, &markedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -271,3 +419,41 @@ func (r *TrainingRepository) GetAttendanceByAthlete(athleteID int) ([]*models.At
 	}
 	return attendances, nil
 }
+
+// GetAttendanceRecords returns the past sessions between from and to (inclusive
+// dates) that athletes booked or had attendance marked for, oldest first.
+// athleteID 0 returns every athlete.
+func (r *TrainingRepository) GetAttendanceRecords(athleteID int, from, to time.Time) ([]models.AttendanceRecord, error) {
+	rows, err := r.db.Query(`
+		WITH expected AS (
+			SELECT training_session_id, athlete_id FROM attendance
+			UNION
+			SELECT training_session_id, athlete_id FROM session_bookings WHERE status = 'booked'
+		)
+		SELECT e.athlete_id, a.first_name || ' ' || a.last_name, t.id, t.session_date,
+		       COALESCE(NULLIF(t.level, ''), 'all'), COALESCE(att.attended, false)
+		FROM expected e
+		JOIN training_sessions t ON t.id = e.training_session_id
+		JOIN athletes a ON a.id = e.athlete_id
+		LEFT JOIN attendance att ON att.training_session_id = e.training_session_id AND att.athlete_id = e.athlete_id
+		WHERE ($1 = 0 OR e.athlete_id = $1)
+		  AND t.session_date >= $2 AND t.session_date < $3 AND t.session_date < $4
+		ORDER BY e.athlete_id, t.session_date, t.id
+	`, athleteID, from, to.AddDate(0, 0, 1), models.WallClockNow())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []models.AttendanceRecord
+	for rows.Next() {
+		var rec models.AttendanceRecord
+		if err := rows.Scan(
+			&rec.AthleteID, &rec.AthleteName, &rec.SessionID, &rec.SessionDate, &rec.Level, &rec.Attended,
+		); err != nil {
+			return nil, err
+		}
+		records = append(records, rec)
+	}
+	return records, rows.Err()
+}
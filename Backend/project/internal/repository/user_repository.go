@@ -5,8 +5,13 @@ import (
 	"errors"
 
 	"east-eagles/backend/internal/models"
+
+	"github.com/lib/pq"
 )
 
+// ErrAthleteAlreadyLinked is returned when an athlete profile already belongs to another account
+var ErrAthleteAlreadyLinked = errors.New("athlete is already linked to another account")
+
 type UserRepository struct {
 	db *sql.DB
 }
@@ -37,7 +42,7 @@ func (r *UserRepository) Create(user *models.User) error {
 func (r *UserRepository) GetByEmail(email string) (*models.User, error) {
 	user := &models.User{}
 	query := `
-		SELECT id, email, password_hash, role, first_name, last_name, is_active, created_at, updated_at
+		SELECT id, email, password_hash, role, first_name, last_name, athlete_id, is_active, created_at, updated_at
 		FROM users
 		WHERE email = $1
 	`
@@ -48,6 +53,7 @@ func (r *UserRepository) GetByEmail(email string) (*models.User, error) {
 		&user.Role,
 		&user.FirstName,
 		&user.LastName,
+		&user.AthleteID,
 		&user.IsActive,
 		&user.CreatedAt,
 		&user.UpdatedAt,
@@ -65,7 +71,7 @@ func (r *UserRepository) GetByEmail(email string) (*models.User, error) {
 func (r *UserRepository) GetByID(id int) (*models.User, error) {
 	user := &models.User{}
 	query := `
-		SELECT id, email, password_hash, role, first_name, last_name, is_active, created_at, updated_at
+		SELECT id, email, password_hash, role, first_name, last_name, athlete_id, is_active, created_at, updated_at
 		FROM users
 		WHERE id = $1
 	`
@@ -76,6 +82,7 @@ func (r *UserRepository) GetByID(id int) (*models.User, error) {
 		&user.Role,
 		&user.FirstName,
 		&user.LastName,
+		&user.AthleteID,
 		&user.IsActive,
 		&user.CreatedAt,
 		&user.UpdatedAt,
@@ -99,7 +106,7 @@ func (r *UserRepository) UpdatePassword(id int, newHash string) error {
 // GetByRole returns all users with a specific role
 func (r *UserRepository) GetByRole(role models.UserRole) ([]*models.User, error) {
 	query := `
-		SELECT id, email, role, first_name, last_name, is_active, created_at, updated_at
+		SELECT id, email, role, first_name, last_name, athlete_id, is_active, created_at, updated_at
 		FROM users
 		WHERE role = $1
 		ORDER BY last_name, first_name
@@ -119,6 +126,7 @@ func (r *UserRepository) GetByRole(role models.UserRole) ([]*models.User, error)
 			&user.Role,
 			&user.FirstName,
 			&user.LastName,
+			&user.AthleteID,
 			&user.IsActive,
 			&user.CreatedAt,
 			&user.UpdatedAt,
@@ -136,3 +144,51 @@ func (r *UserRepository) Delete(id int) error {
 	_, err := r.db.Exec(query, id)
 	return err
 }
+
+// SetAthleteID links the user to an athlete profile
+func (r *UserRepository) SetAthleteID(userID, athleteID int) error {
+	res, err := r.db.Exec(`UPDATE users SET athlete_id = $1 WHERE id = $2`, athleteID, userID)
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+		return ErrAthleteAlreadyLinked
+	}
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// GetUnlinked returns athlete accounts without an athlete profile, with the
+// athletes sharing their email as candidates
+func (r *UserRepository) GetUnlinked() ([]*models.UnlinkedUser, error) {
+	rows, err := r.db.Query(`
+		SELECT u.id, u.email, COALESCE(u.first_name, ''), COALESCE(u.last_name, ''),
+		       COALESCE(array_agg(a.id ORDER BY a.id) FILTER (WHERE a.id IS NOT NULL), '{}')
+		FROM users u
+		LEFT JOIN athletes a ON lower(trim(a.email)) = lower(trim(u.email))
+		WHERE u.role = 'athlete' AND u.athlete_id IS NULL
+		GROUP BY u.id
+		ORDER BY u.id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []*models.UnlinkedUser{}
+	for rows.Next() {
+		u := &models.UnlinkedUser{}
+		var candidates []int64
+		if err := rows.Scan(&u.UserID, &u.Email, &u.FirstName, &u.LastName, pq.Array(&candidates)); err != nil {
+			return nil, err
+		}
+		u.Candidates = make([]int, len(candidates))
+		for i, id := range candidates {
+			u.Candidates[i] = int(id)
+		}
+		users = append(users, u)
+	}
+	return users, rows.Err()
+}
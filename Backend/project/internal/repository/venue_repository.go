@@ -0,0 +1,170 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"east-eagles/backend/internal/models"
+)
+
+// BookingConflictError is returned when a session or schedule overlaps
+// another one in the same venue or with the same coach
+type BookingConflictError struct {
+	Conflicts []models.BookingConflict
+}
+
+func (e *BookingConflictError) Error() string {
+	return fmt.Sprintf("booking overlaps %d existing session(s)", len(e.Conflicts))
+}
+
+// BookingValidationError is returned when a session or schedule request
+// breaks a rule (unknown venue, more participants than the venue holds...)
+type BookingValidationError struct {
+	Message string
+}
+
+func (e *BookingValidationError) Error() string {
+	return e.Message
+}
+
+type VenueRepository struct {
+	db *sql.DB
+}
+
+func NewVenueRepository(db *sql.DB) *VenueRepository {
+	return &VenueRepository{db: db}
+}
+
+const venueColumns = `id, name, capacity, COALESCE(address, ''), latitude, longitude, checkin_radius_m, is_active, created_at`
+
+func scanVenue(row rowScanner) (*models.Venue, error) {
+	v := &models.Venue{}
+	if err := row.Scan(&v.ID, &v.Name, &v.Capacity, &v.Address, &v.Latitude, &v.Longitude, &v.Radius, &v.IsActive, &v.CreatedAt); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// GetAll returns venues by name, active ones only unless includeInactive is set
+func (r *VenueRepository) GetAll(includeInactive bool) ([]*models.Venue, error) {
+	rows, err := r.db.Query(`
+		SELECT `+venueColumns+`
+		FROM venues
+		WHERE is_active OR $1
+		ORDER BY name
+	`, includeInactive)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	venues := []*models.Venue{}
+	for rows.Next() {
+		v, err := scanVenue(rows)
+		if err != nil {
+			return nil, err
+		}
+		venues = append(venues, v)
+	}
+	return venues, rows.Err()
+}
+
+// GetByID returns a venue
+func (r *VenueRepository) GetByID(id int) (*models.Venue, error) {
+	return scanVenue(r.db.QueryRow(`SELECT `+venueColumns+` FROM venues WHERE id = $1`, id))
+}
+
+// Resolve finds the venue of a schedule or session: by id, or by name for
+// clients that still send the location as text. A location that matches no
+// venue (or none at all) returns nil: the slot keeps its free-text location
+// and is only checked for coach conflicts.
+func (r *VenueRepository) Resolve(venueID *int, location string) (*models.Venue, error) {
+	var v *models.Venue
+	var err error
+	if venueID != nil {
+		v, err = r.GetByID(*venueID)
+		if err == sql.ErrNoRows {
+			return nil, &BookingValidationError{Message: fmt.Sprintf("unknown venue: %d", *venueID)}
+		}
+	} else {
+		name := strings.TrimSpace(location)
+		if name == "" {
+			return nil, nil
+		}
+		v, err = scanVenue(r.db.QueryRow(`SELECT `+venueColumns+` FROM venues WHERE lower(name) = lower($1)`, name))
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !v.IsActive {
+		return nil, &BookingValidationError{Message: fmt.Sprintf("venue %s is no longer in use", v.Name)}
+	}
+	return v, nil
+}
+
+func checkinRadius(req *models.CreateVenueRequest) int {
+	if req.Radius == nil {
+		return 150
+	}
+	return *req.Radius
+}
+
+// Create adds a venue
+func (r *VenueRepository) Create(req *models.CreateVenueRequest) (*models.Venue, error) {
+	active := req.IsActive == nil || *req.IsActive
+	return scanVenue(r.db.QueryRow(`
+		INSERT INTO venues (name, capacity, address, latitude, longitude, checkin_radius_m, is_active)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING `+venueColumns,
+		strings.TrimSpace(req.Name), req.Capacity, req.Address, req.Latitude, req.Longitude, checkinRadius(req), active,
+	))
+}
+
+// Update changes a venue; schedules and sessions keep showing its new name
+func (r *VenueRepository) Update(id int, req *models.CreateVenueRequest) (*models.Venue, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	active := req.IsActive == nil || *req.IsActive
+	v, err := scanVenue(tx.QueryRow(`
+		UPDATE venues
+		SET name = $1, capacity = $2, address = $3, latitude = $4, longitude = $5, checkin_radius_m = $6, is_active = $7
+		WHERE id = $8
+		RETURNING `+venueColumns,
+		strings.TrimSpace(req.Name), req.Capacity, req.Address, req.Latitude, req.Longitude, checkinRadius(req), active, id,
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.Exec(`UPDATE training_schedules SET location = $1 WHERE venue_id = $2`, v.Name, id); err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(`UPDATE training_sessions SET location = $1 WHERE venue_id = $2`, v.Name, id); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// Deactivate hides a venue from new bookings; existing sessions keep it
+func (r *VenueRepository) Deactivate(id int) error {
+	res, err := r.db.Exec(`UPDATE venues SET is_active = false WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
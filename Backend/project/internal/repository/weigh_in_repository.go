@@ -0,0 +1,244 @@
+package repository
+
+import (
+	"database/sql"
+
+	"east-eagles/backend/internal/models"
+)
+
+type WeighInRepository struct {
+	db *sql.DB
+}
+
+func NewWeighInRepository(db *sql.DB) *WeighInRepository {
+	return &WeighInRepository{db: db}
+}
+
+const weighInColumns = `
+	w.id, w.athlete_id, w.weight_kg, w.weighed_at, w.official, w.event_id, w.weight_category,
+	w.recorded_by, COALESCE(u.first_name || ' ' || u.last_name, ''), COALESCE(w.notes, ''), w.created_at
+`
+
+func scanWeighIn(row rowScanner) (*models.WeighIn, error) {
+	var w models.WeighIn
+	err := row.Scan(&w.ID, &w.AthleteID, &w.WeightKG, &w.WeighedAt, &w.Official, &w.EventID, &w.WeightCategory,
+		&w.RecordedBy, &w.RecordedByName, &w.Notes, &w.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+// GetTable returns the weight class configuration
+func (r *WeighInRepository) GetTable() (*models.WeightClassTable, error) {
+	table := &models.WeightClassTable{AgeGroups: []models.AgeGroup{}, Classes: []models.WeightClass{}}
+
+	groups, err := r.db.Query(`SELECT name, min_age, max_age FROM age_groups ORDER BY min_age`)
+	if err != nil {
+		return nil, err
+	}
+	defer groups.Close()
+	for groups.Next() {
+		var g models.AgeGroup
+		if err := groups.Scan(&g.Name, &g.MinAge, &g.MaxAge); err != nil {
+			return nil, err
+		}
+		table.AgeGroups = append(table.AgeGroups, g)
+	}
+	if err := groups.Err(); err != nil {
+		return nil, err
+	}
+	groups.Close()
+
+	classes, err := r.db.Query(`
+		SELECT id, gender, age_group, name, max_kg
+		FROM weight_classes
+		ORDER BY gender, age_group, max_kg NULLS LAST
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer classes.Close()
+	for classes.Next() {
+		var c models.WeightClass
+		if err := classes.Scan(&c.ID, &c.Gender, &c.AgeGroup, &c.Name, &c.MaxKG); err != nil {
+			return nil, err
+		}
+		table.Classes = append(table.Classes, c)
+	}
+	return table, classes.Err()
+}
+
+// ReplaceTable replaces the whole weight class configuration. Categories
+// already derived from past weigh-ins are kept.
+func (r *WeighInRepository) ReplaceTable(table *models.WeightClassTable) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM weight_classes`); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM age_groups`); err != nil {
+		return err
+	}
+	for _, g := range table.AgeGroups {
+		if _, err := tx.Exec(`
+			INSERT INTO age_groups (name, min_age, max_age) VALUES ($1, $2, $3)
+		`, g.Name, g.MinAge, g.MaxAge); err != nil {
+			return err
+		}
+	}
+	for _, c := range table.Classes {
+		if _, err := tx.Exec(`
+			INSERT INTO weight_classes (gender, age_group, name, max_kg) VALUES ($1, $2, $3, $4)
+		`, c.Gender, c.AgeGroup, c.Name, c.MaxKG); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// applyLatestWeighIn copies the athlete's latest official weigh-in onto their
+// profile. Without one the profile is left as it is.
+func applyLatestWeighIn(tx *sql.Tx, athleteID int) error {
+	_, err := tx.Exec(`
+		UPDATE athletes a
+		SET weight = w.weight_kg, weight_category = NULLIF(w.weight_category, '')
+		FROM (
+			SELECT weight_kg, weight_category
+			FROM weigh_ins
+			WHERE athlete_id = $1 AND official
+			ORDER BY weighed_at DESC, id DESC
+			LIMIT 1
+		) w
+		WHERE a.id = $1
+	`, athleteID)
+	return err
+}
+
+// Create records a weigh-in with the category derived for it, and updates
+// the athlete's weight and category when it is their latest official one
+func (r *WeighInRepository) Create(w *models.WeighIn) (*models.WeighIn, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var id int
+	err = tx.QueryRow(`
+		INSERT INTO weigh_ins (athlete_id, weight_kg, weighed_at, official, event_id, weight_category, recorded_by, notes)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, ''))
+		RETURNING id
+	`, w.AthleteID, w.WeightKG, w.WeighedAt, w.Official, w.EventID, w.WeightCategory, w.RecordedBy, w.Notes).Scan(&id)
+	if err != nil {
+		return nil, err
+	}
+	if w.Official {
+		if err := applyLatestWeighIn(tx, w.AthleteID); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return scanWeighIn(r.db.QueryRow(`
+		SELECT `+weighInColumns+`
+		FROM weigh_ins w
+		LEFT JOIN users u ON u.id = w.recorded_by
+		WHERE w.id = $1
+	`, id))
+}
+
+// Delete removes a weigh-in recorded by mistake. The athlete falls back to
+// their previous official weigh-in.
+func (r *WeighInRepository) Delete(id int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var athleteID int
+	var official bool
+	err = tx.QueryRow(`
+		DELETE FROM weigh_ins WHERE id = $1 RETURNING athlete_id, official
+	`, id).Scan(&athleteID, &official)
+	if err != nil {
+		return err
+	}
+	if official {
+		if err := applyLatestWeighIn(tx, athleteID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetByAthlete returns an athlete's weigh-ins, latest first
+func (r *WeighInRepository) GetByAthlete(athleteID int) ([]*models.WeighIn, error) {
+	rows, err := r.db.Query(`
+		SELECT `+weighInColumns+`
+		FROM weigh_ins w
+		LEFT JOIN users u ON u.id = w.recorded_by
+		WHERE w.athlete_id = $1
+		ORDER BY w.weighed_at DESC, w.id DESC
+	`, athleteID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	weighIns := []*models.WeighIn{}
+	for rows.Next() {
+		w, err := scanWeighIn(rows)
+		if err != nil {
+			return nil, err
+		}
+		weighIns = append(weighIns, w)
+	}
+	return weighIns, rows.Err()
+}
+
+// GetDriftCandidates returns athletes registered in a weight category for an
+// event within the next days, with their latest official weigh-in.
+// CurrentCategory is left for the caller to derive.
+func (r *WeighInRepository) GetDriftCandidates(days int) ([]*models.WeightDrift, error) {
+	now := models.WallClockNow()
+	rows, err := r.db.Query(`
+		SELECT e.id, e.title, e.date, a.id, a.first_name || ' ' || a.last_name,
+		       COALESCE(a.gender, ''), a.birth_date, reg.weight_category, w.weight_kg, w.weighed_at
+		FROM event_registrations reg
+		JOIN events e ON e.id = reg.event_id
+		JOIN athletes a ON a.id = reg.athlete_id
+		JOIN LATERAL (
+			SELECT weight_kg, weighed_at
+			FROM weigh_ins
+			WHERE athlete_id = a.id AND official
+			ORDER BY weighed_at DESC, id DESC
+			LIMIT 1
+		) w ON TRUE
+		WHERE reg.status = 'registered' AND reg.weight_category <> ''
+		  AND e.status <> 'cancelled' AND e.date >= $1 AND e.date < $2
+		ORDER BY e.date, a.last_name, a.first_name
+	`, now, now.AddDate(0, 0, days))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	drifts := []*models.WeightDrift{}
+	for rows.Next() {
+		d := &models.WeightDrift{}
+		if err := rows.Scan(&d.EventID, &d.EventTitle, &d.EventDate, &d.AthleteID, &d.AthleteName,
+			&d.Gender, &d.DateOfBirth, &d.RegisteredCategory, &d.WeightKG, &d.WeighedAt); err != nil {
+			return nil, err
+		}
+		drifts = append(drifts, d)
+	}
+	return drifts, rows.Err()
+}
@@ -0,0 +1,198 @@
+package services
+
+import "unicode"
+
+// arabicForms maps an Arabic letter to its presentation forms:
+// isolated, final, initial, medial. Letters that do not join to the
+// following letter only have isolated and final forms.
+var arabicForms = map[rune][4]rune{
+	0x0621: {0xFE80, 0, 0, 0},
+	0x0622: {0xFE81, 0xFE82, 0, 0},
+	0x0623: {0xFE83, 0xFE84, 0, 0},
+	0x0624: {0xFE85, 0xFE86, 0, 0},
+	0x0625: {0xFE87, 0xFE88, 0, 0},
+	0x0626: {0xFE89, 0xFE8A, 0xFE8B, 0xFE8C},
+	0x0627: {0xFE8D, 0xFE8E, 0, 0},
+	0x0628: {0xFE8F, 0xFE90, 0xFE91, 0xFE92},
+	0x0629: {0xFE93, 0xFE94, 0, 0},
+	0x062A: {0xFE95, 0xFE96, 0xFE97, 0xFE98},
+	0x062B: {0xFE99, 0xFE9A, 0xFE9B, 0xFE9C},
+	0x062C: {0xFE9D, 0xFE9E, 0xFE9F, 0xFEA0},
+	0x062D: {0xFEA1, 0xFEA2, 0xFEA3, 0xFEA4},
+	0x062E: {0xFEA5, 0xFEA6, 0xFEA7, 0xFEA8},
+	0x062F: {0xFEA9, 0xFEAA, 0, 0},
+	0x0630: {0xFEAB, 0xFEAC, 0, 0},
+	0x0631: {0xFEAD, 0xFEAE, 0, 0},
+	0x0632: {0xFEAF, 0xFEB0, 0, 0},
+	0x0633: {0xFEB1, 0xFEB2, 0xFEB3, 0xFEB4},
+	0x0634: {0xFEB5, 0xFEB6, 0xFEB7, 0xFEB8},
+	0x0635: {0xFEB9, 0xFEBA, 0xFEBB, 0xFEBC},
+	0x0636: {0xFEBD, 0xFEBE, 0xFEBF, 0xFEC0},
+	0x0637: {0xFEC1, 0xFEC2, 0xFEC3, 0xFEC4},
+	0x0638: {0xFEC5, 0xFEC6, 0xFEC7, 0xFEC8},
+	0x0639: {0xFEC9, 0xFECA, 0xFECB, 0xFECC},
+	0x063A: {0xFECD, 0xFECE, 0xFECF, 0xFED0},
+	0x0640: {0x0640, 0x0640, 0x0640, 0x0640},
+	0x0641: {0xFED1, 0xFED2, 0xFED3, 0xFED4},
+	0x0642: {0xFED5, 0xFED6, 0xFED7, 0xFED8},
+	0x0643: {0xFED9, 0xFEDA, 0xFEDB, 0xFEDC},
+	0x0644: {0xFEDD, 0xFEDE, 0xFEDF, 0xFEE0},
+	0x0645: {0xFEE1, 0xFEE2, 0xFEE3, 0xFEE4},
+	0x0646: {0xFEE5, 0xFEE6, 0xFEE7, 0xFEE8},
+	0x0647: {0xFEE9, 0xFEEA, 0xFEEB, 0xFEEC},
+	0x0648: {0xFEED, 0xFEEE, 0, 0},
+	0x0649: {0xFEEF, 0xFEF0, 0, 0},
+	0x064A: {0xFEF1, 0xFEF2, 0xFEF3, 0xFEF4},
+}
+
+// lamAlef maps the alef variant following a lam to the ligature's isolated and final forms
+var lamAlef = map[rune][2]rune{
+	0x0622: {0xFEF5, 0xFEF6},
+	0x0623: {0xFEF7, 0xFEF8},
+	0x0625: {0xFEF9, 0xFEFA},
+	0x0627: {0xFEFB, 0xFEFC},
+}
+
+// isArabicMark reports combining marks (harakat) that do not affect joining
+func isArabicMark(r rune) bool {
+	return (r >= 0x064B && r <= 0x065F) || r == 0x0670
+}
+
+func joinsNext(r rune) bool {
+	forms, ok := arabicForms[r]
+	return ok && forms[2] != 0
+}
+
+// shapeArabic replaces Arabic letters with their contextual presentation forms.
+// The result is still in logical order.
+func shapeArabic(s string) []rune {
+	in := []rune(s)
+	out := make([]rune, 0, len(in))
+
+	// neighbour finds the closest non-mark rune in direction step
+	neighbour := func(i, step int) rune {
+		for j := i + step; j >= 0 && j < len(in); j += step {
+			if !isArabicMark(in[j]) {
+				return in[j]
+			}
+		}
+		return 0
+	}
+
+	for i := 0; i < len(in); i++ {
+		r := in[i]
+		forms, ok := arabicForms[r]
+		if !ok {
+			out = append(out, r)
+			continue
+		}
+
+		prev := neighbour(i, -1)
+		joinPrev := joinsNext(prev)
+
+		// Lam followed by alef becomes a single ligature
+		if r == 0x0644 {
+			if next := neighbour(i, 1); next != 0 {
+				if lig, ok := lamAlef[next]; ok {
+					if joinPrev {
+						out = append(out, lig[1])
+					} else {
+						out = append(out, lig[0])
+					}
+					for i++; i < len(in) && in[i] != next; i++ {
+						out = append(out, in[i])
+					}
+					continue
+				}
+			}
+		}
+
+		_, nextIsArabic := arabicForms[neighbour(i, 1)]
+		joinNext := forms[2] != 0 && nextIsArabic
+
+		switch {
+		case joinPrev && joinNext:
+			out = append(out, forms[3])
+		case joinPrev && forms[1] != 0:
+			out = append(out, forms[1])
+		case joinNext:
+			out = append(out, forms[2])
+		default:
+			out = append(out, forms[0])
+		}
+	}
+
+	return out
+}
+
+// isRTL reports runes that belong to a right-to-left run
+func isRTL(r rune) bool {
+	return (r >= 0x0590 && r <= 0x08FF) || (r >= 0xFB1D && r <= 0xFEFC)
+}
+
+func isLTR(r rune) bool {
+	return !isRTL(r) && (unicode.IsLetter(r) || unicode.IsDigit(r))
+}
+
+var mirrored = map[rune]rune{'(': ')', ')': '(', '[': ']', ']': '[', '<': '>', '>': '<'}
+
+// visualRTL shapes s and reorders it for display in a right-to-left paragraph.
+// Runs of Latin letters and digits keep their left-to-right order.
+func visualRTL(s string) []rune {
+	runes := shapeArabic(s)
+	n := len(runes)
+
+	// Resolve each rune to LTR or RTL; neutrals between two LTR runes stay LTR
+	ltr := make([]bool, n)
+	for i, r := range runes {
+		ltr[i] = isLTR(r)
+	}
+	for i := 0; i < n; i++ {
+		if ltr[i] || isRTL(runes[i]) {
+			continue
+		}
+		j := i
+		for j < n && !ltr[j] && !isRTL(runes[j]) {
+			j++
+		}
+		if i > 0 && ltr[i-1] && j < n && ltr[j] {
+			for k := i; k < j; k++ {
+				ltr[k] = true
+			}
+		}
+		i = j - 1
+	}
+
+	// Split into runs, then lay them out right to left
+	type run struct {
+		start, end int
+		ltr        bool
+	}
+	var runs []run
+	for i := 0; i < n; {
+		j := i
+		for j < n && ltr[j] == ltr[i] {
+			j++
+		}
+		runs = append(runs, run{i, j, ltr[i]})
+		i = j
+	}
+
+	out := make([]rune, 0, n)
+	for k := len(runs) - 1; k >= 0; k-- {
+		rn := runs[k]
+		if rn.ltr {
+			out = append(out, runes[rn.start:rn.end]...)
+			continue
+		}
+		for i := rn.end - 1; i >= rn.start; i-- {
+			r := runes[i]
+			if m, ok := mirrored[r]; ok {
+				r = m
+			}
+			out = append(out, r)
+		}
+	}
+
+	return out
+}
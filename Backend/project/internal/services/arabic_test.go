@@ -0,0 +1,45 @@
+package services
+
+import "testing"
+
+func TestShapeArabic(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []rune
+	}{
+		// Initial seen, lam-alef ligature in its final form, isolated meem
+		{"سلام", []rune{0xFEB3, 0xFEFC, 0xFEE1}},
+		{"محمد", []rune{0xFEE3, 0xFEA4, 0xFEE4, 0xFEAA}},
+		// Non-joining reh breaks the word: isolated reh, then initial qaf
+		{"رقم", []rune{0xFEAD, 0xFED7, 0xFEE2}},
+		// Isolated lam-alef at the start of a word
+		{"لا", []rune{0xFEFB}},
+		// Harakat are kept and do not break joining
+		{"بَب", []rune{0xFE91, 0x064E, 0xFE90}},
+		{"abc 12", []rune("abc 12")},
+	}
+	for _, tt := range tests {
+		if got := shapeArabic(tt.in); string(got) != string(tt.want) {
+			t.Errorf("shapeArabic(%q) = %U, want %U", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestVisualRTL(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []rune
+	}{
+		// Digits keep their order; the Arabic run is reversed
+		{"رقم 12", []rune{'1', '2', ' ', 0xFEE2, 0xFED7, 0xFEAD}},
+		// Brackets are mirrored inside a right-to-left run
+		{"(لا)", []rune{'(', 0xFEFB, ')'}},
+		// Spaces between two Latin words stay in the Latin run
+		{"رقم ab cd", []rune{'a', 'b', ' ', 'c', 'd', ' ', 0xFEE2, 0xFED7, 0xFEAD}},
+	}
+	for _, tt := range tests {
+		if got := visualRTL(tt.in); string(got) != string(tt.want) {
+			t.Errorf("visualRTL(%q) = %U, want %U", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"sort"
+	"time"
+
+	"east-eagles/backend/internal/models"
+)
+
+// AttendanceStats summarises one athlete's records (oldest first) between
+// from and to
+func AttendanceStats(athleteID int, records []models.AttendanceRecord, from, to time.Time) *models.AttendanceStats {
+	stats := &models.AttendanceStats{
+		AthleteID: athleteID,
+		From:      from.Format("2006-01-02"),
+		To:        to.Format("2006-01-02"),
+		ByLevel:   []models.AttendancePeriod{},
+		ByMonth:   []models.AttendancePeriod{},
+	}
+
+	// Every month of the range appears, even without sessions
+	months := map[string]*models.AttendancePeriod{}
+	var monthOrder []string
+	for m := time.Date(from.Year(), from.Month(), 1, 0, 0, 0, 0, time.UTC); !m.After(to); m = m.AddDate(0, 1, 0) {
+		key := m.Format("2006-01")
+		months[key] = &models.AttendancePeriod{Group: key}
+		monthOrder = append(monthOrder, key)
+	}
+	levels := map[string]*models.AttendancePeriod{}
+
+	for _, rec := range records {
+		stats.AthleteName = rec.AthleteName
+		stats.Sessions++
+		if rec.Attended {
+			stats.Attended++
+			stats.CurrentStreak++
+			stats.MissedInARow = 0
+			date := rec.SessionDate
+			stats.LastAttended = &date
+		} else {
+			stats.CurrentStreak = 0
+			stats.MissedInARow++
+		}
+
+		if levels[rec.Level] == nil {
+			levels[rec.Level] = &models.AttendancePeriod{Group: rec.Level}
+		}
+		countAttendance(levels[rec.Level], rec.Attended)
+		if m := months[rec.SessionDate.Format("2006-01")]; m != nil {
+			countAttendance(m, rec.Attended)
+		}
+	}
+	stats.Rate = attendanceRate(stats.Attended, stats.Sessions)
+
+	for _, l := range levels {
+		l.Rate = attendanceRate(l.Attended, l.Sessions)
+		stats.ByLevel = append(stats.ByLevel, *l)
+	}
+	sort.Slice(stats.ByLevel, func(i, j int) bool { return stats.ByLevel[i].Group < stats.ByLevel[j].Group })
+	for _, key := range monthOrder {
+		m := months[key]
+		m.Rate = attendanceRate(m.Attended, m.Sessions)
+		stats.ByMonth = append(stats.ByMonth, *m)
+	}
+	return stats
+}
+
+// AttendanceStatsByAthlete splits records sorted by athlete and summarises each athlete
+func AttendanceStatsByAthlete(records []models.AttendanceRecord, from, to time.Time) []*models.AttendanceStats {
+	var all []*models.AttendanceStats
+	for start := 0; start < len(records); {
+		end := start
+		for end < len(records) && records[end].AthleteID == records[start].AthleteID {
+			end++
+		}
+		all = append(all, AttendanceStats(records[start].AthleteID, records[start:end], from, to))
+		start = end
+	}
+	return all
+}
+
+func countAttendance(p *models.AttendancePeriod, attended bool) {
+	p.Sessions++
+	if attended {
+		p.Attended++
+	}
+}
+
+func attendanceRate(attended, sessions int) float64 {
+	if sessions == 0 {
+		return 0
+	}
+	return float64(attended) / float64(sessions)
+}
@@ -11,23 +11,26 @@ import (
 )
 
 type AuthService struct {
-	userRepo    *repository.UserRepository
-	athleteRepo *repository.AthleteRepository
-	jwtSecret   []byte
+	userRepo     *repository.UserRepository
+	athleteRepo  *repository.AthleteRepository
+	guardianRepo *repository.GuardianRepository
+	jwtSecret    []byte
 }
 
 type CustomClaims struct {
-	UserID int             `json:"user_id"`
-	Role   models.UserRole `json:"role"`
-	Email  string          `json:"email"`
+	UserID    int             `json:"user_id"`
+	Role      models.UserRole `json:"role"`
+	Email     string          `json:"email"`
+	AthleteID int             `json:"athlete_id,omitempty"` // 0 when the account has no athlete profile
 	jwt.RegisteredClaims
 }
 
-func NewAuthService(userRepo *repository.UserRepository, athleteRepo *repository.AthleteRepository, jwtSecret string) *AuthService {
+func NewAuthService(userRepo *repository.UserRepository, athleteRepo *repository.AthleteRepository, guardianRepo *repository.GuardianRepository, jwtSecret string) *AuthService {
 	return &AuthService{
-		userRepo:    userRepo,
-		athleteRepo: athleteRepo,
-		jwtSecret:   []byte(jwtSecret),
+		userRepo:     userRepo,
+		athleteRepo:  athleteRepo,
+		guardianRepo: guardianRepo,
+		jwtSecret:    []byte(jwtSecret),
 	}
 }
 
@@ -70,11 +73,18 @@ func (s *AuthService) Register(req *models.CreateUserRequest) (*models.User, err
 			Address:                  "Non renseigné",
 			SkillLevel:               "beginner", // Default skill level to satisfy CHECK constraint
 		}
-		if _, err := s.athleteRepo.Create(athleteReq); err != nil {
+		athlete, err := s.athleteRepo.Create(athleteReq)
+		if err != nil {
 			// Rollback: Delete the user we just created
 			s.userRepo.Delete(user.ID)
 			return nil, err
 		}
+		if err := s.userRepo.SetAthleteID(user.ID, athlete.ID); err != nil {
+			s.athleteRepo.Delete(athlete.ID)
+			s.userRepo.Delete(user.ID)
+			return nil, err
+		}
+		user.AthleteID = &athlete.ID
 	}
 
 	return user, nil
@@ -116,6 +126,10 @@ func (s *AuthService) GenerateToken(user *models.User) (string, error) {
 		},
 	}
 
+	if user.AthleteID != nil {
+		claims.AthleteID = *user.AthleteID
+	}
+
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return token.SignedString(s.jwtSecret)
 }
@@ -153,8 +167,11 @@ func (s *AuthService) GetCurrentUser(userID int) (map[string]interface{}, error)
 	}
 
 	if user.Role == models.RoleAthlete {
-		athlete, err := s.athleteRepo.GetByEmail(user.Email)
-		if err == nil {
+		var athlete *models.Athlete
+		if user.AthleteID != nil {
+			athlete, err = s.athleteRepo.GetByID(*user.AthleteID)
+		}
+		if athlete != nil && err == nil {
 			response["status"] = athlete.MembershipStatus
 			response["athlete_id"] = athlete.ID
 		} else {
@@ -162,5 +179,31 @@ func (s *AuthService) GetCurrentUser(userID int) (map[string]interface{}, error)
 		}
 	}
 
+	if user.Role == models.RoleGuardian {
+		wards, err := s.guardianRepo.GetWards(user.ID)
+		if err != nil {
+			return nil, err
+		}
+		response["children"] = wards
+	}
+
 	return response, nil
 }
+
+// WardIDs returns the athletes a guardian account manages
+func (s *AuthService) WardIDs(userID int) ([]int, error) {
+	return s.guardianRepo.WardIDs(userID)
+}
+
+// AthleteIDForUser returns the athlete currently linked to a user, 0 if none.
+// The athlete_id claim only reflects the link when the token was issued.
+func (s *AuthService) AthleteIDForUser(userID int) (int, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return 0, err
+	}
+	if user.AthleteID == nil {
+		return 0, nil
+	}
+	return *user.AthleteID, nil
+}
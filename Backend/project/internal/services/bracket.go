@@ -0,0 +1,266 @@
+package services
+
+import (
+	"math/rand"
+	"slices"
+	"sort"
+
+	"east-eagles/backend/internal/models"
+)
+
+// RoundRobinMaxSize is the largest pool fought as a round-robin when no
+// format is requested
+const RoundRobinMaxSize = 4
+
+// DrawBracket orders a pool (seeded athletes first, the rest at random) and
+// plans its bouts. Bouts reference their next bout through NextIndex, which
+// always points further down the slice.
+func DrawBracket(pool models.DrawPool, format string, seeds []int, rng *rand.Rand) *models.Bracket {
+	if format == "" {
+		format = models.FormatSingleElimination
+		if len(pool.AthleteIDs) <= RoundRobinMaxSize {
+			format = models.FormatRoundRobin
+		}
+	}
+
+	var seeded, unseeded []int
+	for _, id := range seeds {
+		if slices.Contains(pool.AthleteIDs, id) && !slices.Contains(seeded, id) {
+			seeded = append(seeded, id)
+		}
+	}
+	for _, id := range pool.AthleteIDs {
+		if !slices.Contains(seeded, id) {
+			unseeded = append(unseeded, id)
+		}
+	}
+	rng.Shuffle(len(unseeded), func(i, j int) { unseeded[i], unseeded[j] = unseeded[j], unseeded[i] })
+	order := append(seeded, unseeded...)
+
+	bracket := &models.Bracket{
+		WeightCategory: pool.WeightCategory,
+		Gender:         pool.Gender,
+		Format:         format,
+		Status:         models.BracketPending,
+	}
+	for i, id := range order {
+		bracket.Entries = append(bracket.Entries, models.BracketEntry{AthleteID: id, Seed: i + 1})
+	}
+	if format == models.FormatRoundRobin {
+		bracket.Bouts = roundRobinBouts(order)
+	} else {
+		bracket.Bouts = eliminationBouts(order)
+	}
+	return bracket
+}
+
+// seedPositions returns the seeds in bracket order for a draw of size (a
+// power of two), so that 1 meets size, 2 meets size-1... and the top seeds
+// only meet in the last rounds
+func seedPositions(size int) []int {
+	positions := []int{1}
+	for len(positions) < size {
+		next := make([]int, 0, len(positions)*2)
+		for _, seed := range positions {
+			next = append(next, seed, 2*len(positions)+1-seed)
+		}
+		positions = next
+	}
+	return positions
+}
+
+// eliminationBouts plans a single-elimination draw. Missing opponents are byes
+// given to the top seeds; the seeded athlete advances straight away.
+func eliminationBouts(order []int) []models.Bout {
+	size := 1
+	for size < len(order) {
+		size *= 2
+	}
+	if size < 2 {
+		size = 2
+	}
+
+	athleteAt := func(seed int) *int {
+		if seed > len(order) {
+			return nil
+		}
+		id := order[seed-1]
+		return &id
+	}
+
+	var bouts []models.Bout
+	positions := seedPositions(size)
+	roundStart := 0
+	round := 1
+	for count := size / 2; count >= 1; count /= 2 {
+		for p := 0; p < count; p++ {
+			bout := models.Bout{Round: round, Position: p + 1, Status: models.BoutPending, NextIndex: -1}
+			if round == 1 {
+				bout.RedAthleteID = athleteAt(positions[2*p])
+				bout.BlueAthleteID = athleteAt(positions[2*p+1])
+			}
+			if count > 1 {
+				bout.NextIndex = roundStart + count + p/2
+				slot := "red"
+				if p%2 == 1 {
+					slot = "blue"
+				}
+				bout.NextSlot = &slot
+			}
+			bouts = append(bouts, bout)
+		}
+		roundStart += count
+		round++
+	}
+
+	// Byes: the lone athlete of a first-round bout advances
+	for i := range bouts {
+		b := &bouts[i]
+		if b.Round != 1 || (b.RedAthleteID != nil && b.BlueAthleteID != nil) {
+			continue
+		}
+		winner := b.RedAthleteID
+		if winner == nil {
+			winner = b.BlueAthleteID
+		}
+		b.Status = models.BoutBye
+		b.WinnerAthleteID = winner
+		if b.NextIndex >= 0 {
+			next := &bouts[b.NextIndex]
+			if *b.NextSlot == "red" {
+				next.RedAthleteID = winner
+			} else {
+				next.BlueAthleteID = winner
+			}
+		}
+	}
+	return bouts
+}
+
+// roundRobinBouts plans every athlete against every other with the circle
+// method, so each round has each athlete fight at most once
+func roundRobinBouts(order []int) []models.Bout {
+	slots := make([]*int, len(order))
+	for i := range order {
+		id := order[i]
+		slots[i] = &id
+	}
+	if len(slots)%2 == 1 {
+		slots = append(slots, nil) // Rests this round
+	}
+
+	var bouts []models.Bout
+	n := len(slots)
+	for round := 1; round < n; round++ {
+		position := 1
+		for i := 0; i < n/2; i++ {
+			red, blue := slots[i], slots[n-1-i]
+			if red == nil || blue == nil {
+				continue
+			}
+			bouts = append(bouts, models.Bout{
+				Round: round, Position: position, RedAthleteID: red, BlueAthleteID: blue,
+				Status: models.BoutPending, NextIndex: -1,
+			})
+			position++
+		}
+		// Keep the first slot, rotate the others
+		last := slots[n-1]
+		copy(slots[2:], slots[1:n-1])
+		slots[1] = last
+	}
+	return bouts
+}
+
+// BracketPlacings returns each athlete's final placing once every bout is
+// decided, nil before. In elimination, both losing semi-finalists are third.
+func BracketPlacings(b *models.Bracket) map[int]int {
+	for _, bout := range b.Bouts {
+		if bout.Status == models.BoutPending {
+			return nil
+		}
+	}
+	if b.Format == models.FormatRoundRobin {
+		return roundRobinPlacings(b)
+	}
+
+	finalRound := 0
+	for _, bout := range b.Bouts {
+		if bout.Round > finalRound {
+			finalRound = bout.Round
+		}
+	}
+	placings := map[int]int{}
+	for _, bout := range b.Bouts {
+		if bout.Status == models.BoutBye && bout.Round == finalRound && bout.WinnerAthleteID != nil {
+			placings[*bout.WinnerAthleteID] = 1 // Alone in the category
+		}
+		if bout.Status != models.BoutCompleted || bout.WinnerAthleteID == nil {
+			continue
+		}
+		loser := bout.RedAthleteID
+		if loser != nil && *loser == *bout.WinnerAthleteID {
+			loser = bout.BlueAthleteID
+		}
+		if loser != nil {
+			// Losers of the final are 2nd, semi-finals 3rd, quarter-finals 5th...
+			placings[*loser] = 1<<(finalRound-bout.Round) + 1
+		}
+		if bout.Round == finalRound {
+			placings[*bout.WinnerAthleteID] = 1
+		}
+	}
+	return placings
+}
+
+// roundRobinPlacings ranks by wins, then rounds won minus lost, then points
+// scored minus conceded, then seed
+func roundRobinPlacings(b *models.Bracket) map[int]int {
+	type standing struct {
+		athleteID, seed, wins, rounds, points int
+	}
+	standings := map[int]*standing{}
+	for _, e := range b.Entries {
+		standings[e.AthleteID] = &standing{athleteID: e.AthleteID, seed: e.Seed}
+	}
+	for _, bout := range b.Bouts {
+		if bout.RedAthleteID == nil || bout.BlueAthleteID == nil {
+			continue
+		}
+		red, blue := standings[*bout.RedAthleteID], standings[*bout.BlueAthleteID]
+		if red == nil || blue == nil {
+			continue
+		}
+		if bout.WinnerAthleteID != nil {
+			standings[*bout.WinnerAthleteID].wins++
+		}
+		red.rounds += bout.RedRoundsWon - bout.BlueRoundsWon
+		blue.rounds += bout.BlueRoundsWon - bout.RedRoundsWon
+		red.points += bout.RedPoints - bout.BluePoints
+		blue.points += bout.BluePoints - bout.RedPoints
+	}
+
+	ranked := make([]*standing, 0, len(standings))
+	for _, s := range standings {
+		ranked = append(ranked, s)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		a, b := ranked[i], ranked[j]
+		if a.wins != b.wins {
+			return a.wins > b.wins
+		}
+		if a.rounds != b.rounds {
+			return a.rounds > b.rounds
+		}
+		if a.points != b.points {
+			return a.points > b.points
+		}
+		return a.seed < b.seed
+	})
+
+	placings := map[int]int{}
+	for i, s := range ranked {
+		placings[s.athleteID] = i + 1
+	}
+	return placings
+}
@@ -0,0 +1,225 @@
+package services
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"east-eagles/backend/internal/models"
+)
+
+func pool(ids ...int) models.DrawPool {
+	return models.DrawPool{WeightCategory: "-60kg", Gender: "M", AthleteIDs: ids}
+}
+
+func idOf(p *int) int {
+	if p == nil {
+		return 0
+	}
+	return *p
+}
+
+// win records a result the way the repository does: the winner advances to
+// the next bout's slot
+func win(b *models.Bracket, i, winner int) {
+	bout := &b.Bouts[i]
+	bout.Status = models.BoutCompleted
+	bout.WinnerAthleteID = &winner
+	if bout.NextIndex >= 0 {
+		next := &b.Bouts[bout.NextIndex]
+		if *bout.NextSlot == "red" {
+			next.RedAthleteID = &winner
+		} else {
+			next.BlueAthleteID = &winner
+		}
+	}
+}
+
+func TestSeedPositions(t *testing.T) {
+	tests := map[int][]int{
+		2: {1, 2},
+		4: {1, 4, 2, 3},
+		8: {1, 8, 4, 5, 2, 7, 3, 6},
+	}
+	for size, want := range tests {
+		if got := seedPositions(size); !reflect.DeepEqual(got, want) {
+			t.Errorf("seedPositions(%d) = %v, want %v", size, got, want)
+		}
+	}
+}
+
+func TestDrawBracketSeedingAndFormat(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+
+	b := DrawBracket(pool(10, 11, 12, 13, 14), "", []int{13, 99, 11, 13}, rng)
+	if b.Format != models.FormatSingleElimination {
+		t.Errorf("5 athletes drawn as %s", b.Format)
+	}
+	if len(b.Entries) != 5 || b.Entries[0].AthleteID != 13 || b.Entries[1].AthleteID != 11 {
+		t.Fatalf("seeded athletes must come first in order, unknown and repeated seeds ignored: %+v", b.Entries)
+	}
+	seen := map[int]bool{}
+	for i, e := range b.Entries {
+		if e.Seed != i+1 {
+			t.Errorf("entry %d has seed %d", i, e.Seed)
+		}
+		seen[e.AthleteID] = true
+	}
+	if len(seen) != 5 {
+		t.Errorf("athletes lost in the draw: %+v", b.Entries)
+	}
+
+	if b := DrawBracket(pool(1, 2, 3, 4), "", nil, rng); b.Format != models.FormatRoundRobin {
+		t.Errorf("4 athletes drawn as %s", b.Format)
+	}
+	if b := DrawBracket(pool(1, 2, 3), models.FormatSingleElimination, nil, rng); b.Format != models.FormatSingleElimination {
+		t.Errorf("requested format ignored: %s", b.Format)
+	}
+}
+
+func TestEliminationByes(t *testing.T) {
+	// Seeds 1-3 get byes against the missing 6-8; 4 meets 5
+	bouts := eliminationBouts([]int{1, 2, 3, 4, 5})
+	if len(bouts) != 7 {
+		t.Fatalf("%d bouts for a draw of 8, want 7", len(bouts))
+	}
+
+	want := []struct {
+		round, red, blue int
+		status           string
+		winner           int
+	}{
+		{1, 1, 0, models.BoutBye, 1},
+		{1, 4, 5, models.BoutPending, 0},
+		{1, 2, 0, models.BoutBye, 2},
+		{1, 3, 0, models.BoutBye, 3},
+		{2, 1, 0, models.BoutPending, 0},
+		{2, 2, 3, models.BoutPending, 0},
+		{3, 0, 0, models.BoutPending, 0},
+	}
+	for i, w := range want {
+		b := bouts[i]
+		got := fmt.Sprint(b.Round, idOf(b.RedAthleteID), idOf(b.BlueAthleteID), b.Status, idOf(b.WinnerAthleteID))
+		if exp := fmt.Sprint(w.round, w.red, w.blue, w.status, w.winner); got != exp {
+			t.Errorf("bout %d = %s, want %s", i, got, exp)
+		}
+		if b.NextIndex >= 0 && b.NextIndex <= i {
+			t.Errorf("bout %d points back to bout %d", i, b.NextIndex)
+		}
+	}
+	if bouts[6].NextIndex != -1 || bouts[6].NextSlot != nil {
+		t.Errorf("the final has a next bout")
+	}
+}
+
+func TestRoundRobinBouts(t *testing.T) {
+	for _, n := range []int{2, 3, 4, 5} {
+		order := make([]int, n)
+		for i := range order {
+			order[i] = i + 1
+		}
+		bouts := roundRobinBouts(order)
+		if len(bouts) != n*(n-1)/2 {
+			t.Errorf("%d athletes: %d bouts, want %d", n, len(bouts), n*(n-1)/2)
+		}
+
+		pairs := map[[2]int]bool{}
+		perRound := map[[2]int]bool{}
+		for _, b := range bouts {
+			red, blue := *b.RedAthleteID, *b.BlueAthleteID
+			if red > blue {
+				red, blue = blue, red
+			}
+			if pairs[[2]int{red, blue}] {
+				t.Errorf("%d athletes: %d and %d meet twice", n, red, blue)
+			}
+			pairs[[2]int{red, blue}] = true
+			for _, id := range []int{red, blue} {
+				if perRound[[2]int{b.Round, id}] {
+					t.Errorf("%d athletes: %d fights twice in round %d", n, id, b.Round)
+				}
+				perRound[[2]int{b.Round, id}] = true
+			}
+		}
+	}
+}
+
+func TestBracketPlacingsElimination(t *testing.T) {
+	b := DrawBracket(pool(1, 2, 3), models.FormatSingleElimination, []int{1, 2, 3}, rand.New(rand.NewSource(1)))
+	// 1 has a bye, 2 meets 3 in the other semi-final
+	if BracketPlacings(b) != nil {
+		t.Fatal("placings before the bouts are fought")
+	}
+	win(b, 1, 2)
+	if BracketPlacings(b) != nil {
+		t.Fatal("placings before the final")
+	}
+	win(b, 2, 1)
+
+	want := map[int]int{1: 1, 2: 2, 3: 3}
+	if got := BracketPlacings(b); !reflect.DeepEqual(got, want) {
+		t.Errorf("placings = %v, want %v", got, want)
+	}
+}
+
+func TestBracketPlacingsEightAthletes(t *testing.T) {
+	order := []int{1, 2, 3, 4, 5, 6, 7, 8}
+	b := &models.Bracket{Format: models.FormatSingleElimination, Bouts: eliminationBouts(order)}
+	// Higher seeds win every bout
+	for i := range b.Bouts {
+		red, blue := idOf(b.Bouts[i].RedAthleteID), idOf(b.Bouts[i].BlueAthleteID)
+		win(b, i, min(red, blue))
+	}
+
+	want := map[int]int{1: 1, 2: 2, 3: 3, 4: 3, 5: 5, 6: 5, 7: 5, 8: 5}
+	if got := BracketPlacings(b); !reflect.DeepEqual(got, want) {
+		t.Errorf("placings = %v, want %v", got, want)
+	}
+}
+
+func TestBracketPlacingsAlone(t *testing.T) {
+	for _, format := range []string{models.FormatSingleElimination, models.FormatRoundRobin} {
+		b := DrawBracket(pool(7), format, nil, rand.New(rand.NewSource(1)))
+		if got := BracketPlacings(b); !reflect.DeepEqual(got, map[int]int{7: 1}) {
+			t.Errorf("%s: a lone athlete is placed %v", format, got)
+		}
+	}
+}
+
+func TestBracketPlacingsRoundRobin(t *testing.T) {
+	b := &models.Bracket{
+		Format:  models.FormatRoundRobin,
+		Entries: []models.BracketEntry{{AthleteID: 1, Seed: 1}, {AthleteID: 2, Seed: 2}, {AthleteID: 3, Seed: 3}},
+	}
+	bout := func(red, blue, winner, redRounds, blueRounds, redPoints, bluePoints int) models.Bout {
+		b := models.Bout{RedAthleteID: &red, BlueAthleteID: &blue, Status: models.BoutCompleted, NextIndex: -1,
+			RedRoundsWon: redRounds, BlueRoundsWon: blueRounds, RedPoints: redPoints, BluePoints: bluePoints}
+		if winner != 0 {
+			b.WinnerAthleteID = &winner
+		}
+		return b
+	}
+	// Each athlete wins once, so rounds won minus lost decide
+	b.Bouts = []models.Bout{
+		bout(1, 2, 1, 2, 1, 10, 4),
+		bout(2, 3, 2, 2, 1, 12, 5),
+		bout(3, 1, 3, 2, 0, 8, 2),
+	}
+
+	want := map[int]int{3: 1, 2: 2, 1: 3}
+	if got := BracketPlacings(b); !reflect.DeepEqual(got, want) {
+		t.Errorf("placings = %v, want %v", got, want)
+	}
+
+	// A draw gives no win: points decide, then the seed
+	b.Entries = b.Entries[:2]
+	b.Bouts = []models.Bout{bout(2, 1, 0, 1, 1, 6, 5)}
+	if got := BracketPlacings(b); !reflect.DeepEqual(got, map[int]int{2: 1, 1: 2}) {
+		t.Errorf("placings after a draw = %v", got)
+	}
+	b.Bouts = []models.Bout{bout(2, 1, 0, 1, 1, 5, 5)}
+	if got := BracketPlacings(b); !reflect.DeepEqual(got, map[int]int{1: 1, 2: 2}) {
+		t.Errorf("tied placings = %v", got)
+	}
+}
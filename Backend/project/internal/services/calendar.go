@@ -0,0 +1,294 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"east-eagles/backend/internal/models"
+)
+
+// NewCalendarToken returns a random, unguessable feed token
+func NewCalendarToken() (string, error) {
+	return randomHex(24)
+}
+
+// Calendar builds an iCalendar (RFC 5545) feed. Times are club wall-clock
+// times, matching how session dates are stored; they carry the TZID of the
+// club timezone, described by a VTIMEZONE block. An unknown timezone falls
+// back to floating times.
+type Calendar struct {
+	lines []string
+	stamp string
+	tzid  string
+	loc   *time.Location
+}
+
+const (
+	icsDateTime = "20060102T150405"
+	icsUIDHost  = "east-eagles"
+)
+
+func NewCalendar(name, timezone string) *Calendar {
+	c := &Calendar{stamp: time.Now().UTC().Format(icsDateTime) + "Z"}
+	c.add("BEGIN", "VCALENDAR")
+	c.add("VERSION", "2.0")
+	c.add("PRODID", "-//East Eagles//Sanda Club//FR")
+	c.add("CALSCALE", "GREGORIAN")
+	c.add("METHOD", "PUBLISH")
+	c.add("X-WR-CALNAME", escapeText(name))
+	if timezone != "" {
+		c.add("X-WR-TIMEZONE", timezone)
+		if loc, err := time.LoadLocation(timezone); err == nil {
+			c.tzid, c.loc = timezone, loc
+			c.addTimezone(time.Now().In(loc).Year())
+		}
+	}
+	return c
+}
+
+// addTimezone writes the VTIMEZONE of the calendar's location. Daylight
+// saving changes found in year are assumed to repeat every year on the same
+// weekday of the month, as they do in the zones the club may use.
+func (c *Calendar) addTimezone(year int) {
+	type transition struct {
+		at       time.Time // Wall time before the change
+		from, to int
+		name     string
+		dst      bool
+	}
+	var transitions []transition
+	t := time.Date(year, 1, 1, 0, 0, 0, 0, c.loc)
+	name, offset := t.Zone()
+	for end := t.AddDate(1, 0, 0); t.Before(end); t = t.Add(time.Hour) {
+		next, nextOffset := t.Add(time.Hour).Zone()
+		if nextOffset != offset {
+			transitions = append(transitions, transition{
+				at:   t.Add(time.Hour).In(time.FixedZone("", offset)),
+				from: offset, to: nextOffset, name: next, dst: nextOffset > offset,
+			})
+			offset = nextOffset
+		}
+	}
+
+	c.add("BEGIN", "VTIMEZONE")
+	c.add("TZID", c.tzid)
+	if len(transitions) == 0 {
+		c.add("BEGIN", "STANDARD")
+		c.add("DTSTART", "19700101T000000")
+		c.add("TZOFFSETFROM", icsOffset(offset))
+		c.add("TZOFFSETTO", icsOffset(offset))
+		c.add("TZNAME", name)
+		c.add("END", "STANDARD")
+	}
+	for _, tr := range transitions {
+		kind := "STANDARD"
+		if tr.dst {
+			kind = "DAYLIGHT"
+		}
+		ordinal := (tr.at.Day()-1)/7 + 1
+		if tr.at.AddDate(0, 0, 7).Month() != tr.at.Month() {
+			ordinal = -1
+		}
+		c.add("BEGIN", kind)
+		c.add("DTSTART", time.Date(1970, tr.at.Month(), tr.at.Day(), tr.at.Hour(), tr.at.Minute(), 0, 0, time.UTC).Format(icsDateTime))
+		c.add("RRULE", fmt.Sprintf("FREQ=YEARLY;BYMONTH=%d;BYDAY=%d%s", tr.at.Month(), ordinal, icsDays[tr.at.Weekday()]))
+		c.add("TZOFFSETFROM", icsOffset(tr.from))
+		c.add("TZOFFSETTO", icsOffset(tr.to))
+		c.add("TZNAME", tr.name)
+		c.add("END", kind)
+	}
+	c.add("END", "VTIMEZONE")
+}
+
+var icsDays = [...]string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+// icsOffset formats a UTC offset in seconds as +HHMM
+func icsOffset(seconds int) string {
+	sign := "+"
+	if seconds < 0 {
+		sign, seconds = "-", -seconds
+	}
+	return fmt.Sprintf("%s%02d%02d", sign, seconds/3600, seconds%3600/60)
+}
+
+// addTime adds a date-time property holding club wall-clock times
+func (c *Calendar) addTime(name string, times ...time.Time) {
+	values := make([]string, len(times))
+	for i, t := range times {
+		values[i] = t.Format(icsDateTime)
+	}
+	if c.tzid != "" {
+		name += ";TZID=" + c.tzid
+	}
+	c.add(name, strings.Join(values, ","))
+}
+
+// AddSchedule adds a schedule as a recurring event. Closures and exception
+// dates become EXDATEs; detached sessions generated from the schedule
+// override their occurrence.
+func (c *Calendar) AddSchedule(s *models.TrainingSchedule, closures []*models.TrainingClosure, detached []*models.TrainingSession) error {
+	rule, err := s.Rule()
+	if err != nil {
+		return err
+	}
+	start, err := time.Parse("15:04", s.StartTime)
+	if err != nil {
+		return err
+	}
+	at := func(d time.Time) time.Time {
+		return time.Date(d.Year(), d.Month(), d.Day(), start.Hour(), start.Minute(), 0, 0, time.UTC)
+	}
+
+	anchor := s.CreatedAt
+	if s.ValidFrom != nil {
+		if anchor, err = time.Parse("2006-01-02", *s.ValidFrom); err != nil {
+			return err
+		}
+	}
+
+	// DTSTART must be the first occurrence
+	first := rule.Occurrences(anchor, anchor, anchor.AddDate(1, 0, 0))
+	if len(first) == 0 {
+		return nil
+	}
+	if s.ValidUntil != nil && rule.Count == 0 {
+		until, err := time.Parse("2006-01-02", *s.ValidUntil)
+		if err != nil {
+			return err
+		}
+		if rule.Until == nil || until.Before(*rule.Until) {
+			rule.Until = &until
+		}
+	}
+
+	var exdates []time.Time
+	for _, d := range s.ExDates {
+		if day, err := time.Parse("2006-01-02", d); err == nil {
+			exdates = append(exdates, at(day))
+		}
+	}
+	for _, cl := range closures {
+		if cl.ScheduleID != nil && *cl.ScheduleID != s.ID {
+			continue
+		}
+		from, err1 := time.Parse("2006-01-02", cl.StartDate)
+		to, err2 := time.Parse("2006-01-02", cl.EndDate)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		for _, d := range rule.Occurrences(anchor, from, to) {
+			exdates = append(exdates, at(d))
+		}
+	}
+
+	uid := fmt.Sprintf("schedule-%d@%s", s.ID, icsUIDHost)
+	c.add("BEGIN", "VEVENT")
+	c.add("UID", uid)
+	c.add("DTSTAMP", c.stamp)
+	c.addTime("DTSTART", at(first[0]))
+	c.add("DURATION", fmt.Sprintf("PT%dM", s.DurationMinutes))
+	c.add("RRULE", c.icsRule(rule))
+	if len(exdates) > 0 {
+		c.addTime("EXDATE", exdates...)
+	}
+	c.add("SUMMARY", escapeText(s.Title))
+	c.add("LOCATION", escapeText(s.Location))
+	if s.Description != "" {
+		c.add("DESCRIPTION", escapeText(s.Description))
+	}
+	c.add("END", "VEVENT")
+
+	for _, session := range detached {
+		if session.OccurrenceDate == nil {
+			continue
+		}
+		c.add("BEGIN", "VEVENT")
+		c.add("UID", uid)
+		c.addTime("RECURRENCE-ID", at(*session.OccurrenceDate))
+		c.addSessionFields(session)
+		c.add("END", "VEVENT")
+	}
+	return nil
+}
+
+// AddSession adds a single dated training session
+func (c *Calendar) AddSession(s *models.TrainingSession) {
+	c.add("BEGIN", "VEVENT")
+	c.add("UID", fmt.Sprintf("session-%d@%s", s.ID, icsUIDHost))
+	c.addSessionFields(s)
+	c.add("END", "VEVENT")
+}
+
+func (c *Calendar) addSessionFields(s *models.TrainingSession) {
+	c.add("DTSTAMP", c.stamp)
+	c.addTime("DTSTART", s.SessionDate)
+	c.add("DURATION", fmt.Sprintf("PT%dM", s.DurationMinutes))
+	c.add("SUMMARY", escapeText(s.Title))
+	if s.Location != "" {
+		c.add("LOCATION", escapeText(s.Location))
+	}
+	if s.Description != "" {
+		c.add("DESCRIPTION", escapeText(s.Description))
+	}
+}
+
+// AddEvent adds a club event (competition, seminar...)
+func (c *Calendar) AddEvent(e *models.Event) {
+	c.add("BEGIN", "VEVENT")
+	c.add("UID", fmt.Sprintf("event-%d@%s", e.ID, icsUIDHost))
+	c.add("DTSTAMP", c.stamp)
+	c.addTime("DTSTART", e.Date)
+	c.add("SUMMARY", escapeText(e.Title))
+	if e.Location != "" {
+		c.add("LOCATION", escapeText(e.Location))
+	}
+	if e.Description != "" {
+		c.add("DESCRIPTION", escapeText(e.Description))
+	}
+	c.add("END", "VEVENT")
+}
+
+// Bytes closes the calendar and returns it with CRLF line endings
+func (c *Calendar) Bytes() []byte {
+	c.add("END", "VCALENDAR")
+	return []byte(strings.Join(c.lines, "\r\n") + "\r\n")
+}
+
+// add appends a content line, folded at 75 octets without splitting UTF-8 characters
+func (c *Calendar) add(name, value string) {
+	line := name + ":" + value
+	for len(line) > 75 {
+		cut := 75
+		for cut > 0 && line[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		c.lines = append(c.lines, line[:cut])
+		line = " " + line[cut:]
+	}
+	c.lines = append(c.lines, line)
+}
+
+func escapeText(s string) string {
+	s = strings.ReplaceAll(s, "\\", "\\\\")
+	s = strings.ReplaceAll(s, ";", "\\;")
+	s = strings.ReplaceAll(s, ",", "\\,")
+	s = strings.ReplaceAll(s, "\r\n", "\\n")
+	return strings.ReplaceAll(s, "\n", "\\n")
+}
+
+// icsRule formats a recurrence rule for the calendar's DTSTART: UNTIL is the
+// end of the day in UTC when DTSTART has a TZID, a local date-time otherwise
+func (c *Calendar) icsRule(rule *models.RecurrenceRule) string {
+	r := *rule
+	r.Until = nil
+	s := r.String()
+	if rule.Until == nil {
+		return s
+	}
+	if c.loc == nil {
+		return s + ";UNTIL=" + rule.Until.Format("20060102") + "T235959"
+	}
+	end := time.Date(rule.Until.Year(), rule.Until.Month(), rule.Until.Day(), 23, 59, 59, 0, c.loc)
+	return s + ";UNTIL=" + end.UTC().Format(icsDateTime) + "Z"
+}
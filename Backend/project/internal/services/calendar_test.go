@@ -0,0 +1,95 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"east-eagles/backend/internal/models"
+)
+
+func vtimezone(t *testing.T, timezone string, year int) string {
+	t.Helper()
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		t.Skipf("timezone database unavailable: %v", err)
+	}
+	c := &Calendar{tzid: timezone, loc: loc}
+	c.addTimezone(year)
+	return strings.Join(c.lines, "\n")
+}
+
+func TestCalendarTimezone(t *testing.T) {
+	tests := []struct {
+		timezone string
+		want     string
+	}{
+		{"Africa/Algiers", `BEGIN:VTIMEZONE
+TZID:Africa/Algiers
+BEGIN:STANDARD
+DTSTART:19700101T000000
+TZOFFSETFROM:+0100
+TZOFFSETTO:+0100
+TZNAME:CET
+END:STANDARD
+END:VTIMEZONE`},
+		{"Europe/Paris", `BEGIN:VTIMEZONE
+TZID:Europe/Paris
+BEGIN:DAYLIGHT
+DTSTART:19700329T020000
+RRULE:FREQ=YEARLY;BYMONTH=3;BYDAY=-1SU
+TZOFFSETFROM:+0100
+TZOFFSETTO:+0200
+TZNAME:CEST
+END:DAYLIGHT
+BEGIN:STANDARD
+DTSTART:19701025T030000
+RRULE:FREQ=YEARLY;BYMONTH=10;BYDAY=-1SU
+TZOFFSETFROM:+0200
+TZOFFSETTO:+0100
+TZNAME:CET
+END:STANDARD
+END:VTIMEZONE`},
+	}
+	for _, tt := range tests {
+		if got := vtimezone(t, tt.timezone, 2026); got != tt.want {
+			t.Errorf("%s:\n%s\nwant\n%s", tt.timezone, got, tt.want)
+		}
+	}
+}
+
+func TestCalendarScheduleTimes(t *testing.T) {
+	from := "2026-01-05"
+	schedule := &models.TrainingSchedule{
+		ID: 3, StartTime: "18:30", DurationMinutes: 90, Title: "Sanda",
+		RRule: "FREQ=WEEKLY;BYDAY=MO;UNTIL=20260330", ValidFrom: &from, ExDates: []string{"2026-01-12"},
+	}
+
+	c := NewCalendar("Club", "Africa/Algiers")
+	if c.loc == nil {
+		t.Skip("timezone database unavailable")
+	}
+	if err := c.AddSchedule(schedule, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	ics := string(c.Bytes())
+	for _, line := range []string{
+		"DTSTART;TZID=Africa/Algiers:20260105T183000",
+		"EXDATE;TZID=Africa/Algiers:20260112T183000",
+		"RRULE:FREQ=WEEKLY;BYDAY=MO;UNTIL=20260330T225959Z",
+	} {
+		if !strings.Contains(ics, line+"\r\n") {
+			t.Errorf("missing %q in\n%s", line, ics)
+		}
+	}
+
+	floating := NewCalendar("Club", "")
+	if err := floating.AddSchedule(schedule, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	ics = string(floating.Bytes())
+	if strings.Contains(ics, "VTIMEZONE") || !strings.Contains(ics, "DTSTART:20260105T183000\r\n") ||
+		!strings.Contains(ics, "UNTIL=20260330T235959\r\n") {
+		t.Errorf("floating calendar:\n%s", ics)
+	}
+}
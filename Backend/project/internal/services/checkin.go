@@ -0,0 +1,60 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"time"
+)
+
+// CheckinTokens issues the rotating tokens a session shows as a QR code for
+// self check-in. Tokens are derived from the session and the current time
+// step, so nothing is stored and every server replica agrees on them.
+type CheckinTokens struct {
+	secret []byte
+	period time.Duration
+}
+
+func NewCheckinTokens(secret string, period time.Duration) *CheckinTokens {
+	if period < 10*time.Second {
+		period = 10 * time.Second
+	}
+	return &CheckinTokens{secret: []byte(secret), period: period}
+}
+
+func (c *CheckinTokens) token(sessionID int, step int64) string {
+	mac := hmac.New(sha256.New, c.secret)
+	fmt.Fprintf(mac, "checkin:%d:%d", sessionID, step)
+	return hex.EncodeToString(mac.Sum(nil))[:20]
+}
+
+// Issue returns the token valid now and when it stops being shown
+func (c *CheckinTokens) Issue(sessionID int, now time.Time) (string, time.Time) {
+	step := now.UnixNano() / int64(c.period)
+	return c.token(sessionID, step), time.Unix(0, (step+1)*int64(c.period))
+}
+
+// Verify accepts the current token and the previous one, so a code scanned
+// just before it rotates still works
+func (c *CheckinTokens) Verify(sessionID int, token string, now time.Time) bool {
+	step := now.UnixNano() / int64(c.period)
+	for _, s := range []int64{step, step - 1} {
+		if hmac.Equal([]byte(token), []byte(c.token(sessionID, s))) {
+			return true
+		}
+	}
+	return false
+}
+
+// DistanceMeters returns the great-circle distance between two coordinates
+func DistanceMeters(lat1, lon1, lat2, lon2 float64) float64 {
+	const earthRadius = 6371000.0
+	rad := func(d float64) float64 { return d * math.Pi / 180 }
+	dLat := rad(lat2 - lat1)
+	dLon := rad(lon2 - lon1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(rad(lat1))*math.Cos(rad(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadius * math.Asin(math.Sqrt(a))
+}
@@ -0,0 +1,122 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCronErrors(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+		"1-x * * * *",
+		"@yearly",
+	} {
+		if _, err := ParseCron(spec, time.UTC); err == nil {
+			t.Errorf("ParseCron(%q) accepted an invalid expression", spec)
+		}
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	tests := []struct {
+		spec string
+		from string
+		want string // "" when nothing matches
+	}{
+		{"*/15 * * * *", "2026-01-05 10:07:30", "2026-01-05 10:15"},
+		{"*/15 * * * *", "2026-01-05 10:15:00", "2026-01-05 10:30"},
+		{"5/20 * * * *", "2026-01-05 10:26:00", "2026-01-05 10:45"},
+		{"0,30 8-9 * * *", "2026-01-05 09:30:00", "2026-01-06 08:00"},
+		{"@hourly", "2026-01-05 23:59:00", "2026-01-06 00:00"},
+		{"0 3 * * *", "2026-01-31 04:00:00", "2026-02-01 03:00"},
+		{"@monthly", "2026-12-15 12:00:00", "2027-01-01 00:00"},
+		{"0 0 31 * *", "2026-01-31 01:00:00", "2026-03-31 00:00"},
+		{"30 8 * 6 *", "2026-07-01 00:00:00", "2027-06-01 08:30"},
+		{"0 0 29 2 *", "2026-03-01 00:00:00", "2028-02-29 00:00"},
+		{"0 0 30 2 *", "2026-01-01 00:00:00", ""},
+		// 2026-01-09 is a Friday
+		{"0 9 * * 1-5", "2026-01-09 10:00:00", "2026-01-12 09:00"},
+		{"0 0 * * 7", "2026-01-05 00:00:00", "2026-01-11 00:00"},
+		{"@weekly", "2026-01-11 00:00:00", "2026-01-18 00:00"},
+		// Day of month and day of week both restricted: either one matches
+		{"0 0 13 * 5", "2026-02-01 00:00:00", "2026-02-06 00:00"},
+		{"0 0 13 * 5", "2026-02-07 00:00:00", "2026-02-13 00:00"},
+	}
+
+	for _, tt := range tests {
+		c, err := ParseCron(tt.spec, time.UTC)
+		if err != nil {
+			t.Errorf("ParseCron(%q): %v", tt.spec, err)
+			continue
+		}
+		from, err := time.Parse("2006-01-02 15:04:05", tt.from)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := ""
+		if next := c.Next(from); !next.IsZero() {
+			got = next.Format("2006-01-02 15:04")
+		}
+		if got != tt.want {
+			t.Errorf("%q after %s = %q, want %q", tt.spec, tt.from, got, tt.want)
+		}
+	}
+}
+
+func TestCronNextLocation(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Skipf("timezone database unavailable: %v", err)
+	}
+
+	tests := []struct {
+		spec string
+		from time.Time
+		want time.Time
+	}{
+		// Evaluated in the schedule's zone whatever the zone of from
+		{"0 3 * * *", time.Date(2026, 1, 1, 2, 30, 0, 0, time.UTC), time.Date(2026, 1, 2, 3, 0, 0, 0, paris)},
+		// 02:30 does not exist when clocks go forward: skipped to the next day
+		{"30 2 * * *", time.Date(2026, 3, 29, 0, 0, 0, 0, paris), time.Date(2026, 3, 30, 2, 30, 0, 0, paris)},
+		{"0 9 * * *", time.Date(2026, 3, 28, 10, 0, 0, 0, paris), time.Date(2026, 3, 29, 9, 0, 0, 0, paris)},
+	}
+	for _, tt := range tests {
+		c, err := ParseCron(tt.spec, paris)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := c.Next(tt.from); !got.Equal(tt.want) {
+			t.Errorf("%q after %v = %v, want %v", tt.spec, tt.from, got, tt.want)
+		}
+	}
+}
+
+func TestJobBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{0, 30 * time.Second},
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{7, 32 * time.Minute},
+		{8, jobMaxBackoff},
+		{1000, jobMaxBackoff},
+	}
+	for _, tt := range tests {
+		if got := jobBackoff(tt.attempts); got != tt.want {
+			t.Errorf("jobBackoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
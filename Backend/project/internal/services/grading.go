@@ -0,0 +1,75 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"east-eagles/backend/internal/models"
+)
+
+// GradingAttendanceDays is the period before an exam over which a
+// candidate's attendance rate is measured
+const GradingAttendanceDays = 90
+
+// NextBelt returns the belt after current in belts (sorted by rank), matching
+// names case-insensitively. An unknown or empty current belt grades towards
+// the first belt; nil means current is already the highest.
+func NextBelt(belts []models.Belt, current string) *models.Belt {
+	for i, b := range belts {
+		if strings.EqualFold(b.Name, strings.TrimSpace(current)) {
+			if i+1 < len(belts) {
+				return &belts[i+1]
+			}
+			return nil
+		}
+	}
+	if len(belts) == 0 {
+		return nil
+	}
+	return &belts[0]
+}
+
+// monthsBetween returns the number of full months from from to to
+func monthsBetween(from, to time.Time) int {
+	months := (to.Year()-from.Year())*12 + int(to.Month()-from.Month())
+	if to.Before(from.AddDate(0, months, 0)) {
+		months--
+	}
+	if months < 0 {
+		return 0
+	}
+	return months
+}
+
+// CheckGradingEligibility fills in c's target belt and whether they meet its
+// requirements for an exam on examDate. attendance covers the
+// GradingAttendanceDays before the exam; nil means no sessions.
+func CheckGradingEligibility(c *models.GradingCandidate, belts []models.Belt, attendance *models.AttendanceStats, examDate time.Time) {
+	c.Reasons = []string{}
+	if attendance != nil {
+		c.AttendanceRate = math.Round(attendance.Rate*1000) / 1000
+	}
+	if c.GradeSince != nil {
+		c.MonthsAtGrade = monthsBetween(*c.GradeSince, examDate)
+	}
+
+	next := NextBelt(belts, c.CurrentBelt)
+	if next == nil {
+		c.TargetBelt = ""
+		c.Reasons = append(c.Reasons, "Grade le plus élevé déjà atteint")
+		c.Eligible = false
+		return
+	}
+	c.TargetBelt = next.Name
+
+	if c.MonthsAtGrade < next.MinMonths {
+		c.Reasons = append(c.Reasons, fmt.Sprintf("Ancienneté insuffisante : %d mois sur %d", c.MonthsAtGrade, next.MinMonths))
+	}
+	if c.AttendanceRate < next.MinAttendanceRate {
+		c.Reasons = append(c.Reasons, fmt.Sprintf("Assiduité insuffisante : %.0f%% sur %.0f%%",
+			c.AttendanceRate*100, next.MinAttendanceRate*100))
+	}
+	c.Eligible = len(c.Reasons) == 0
+}
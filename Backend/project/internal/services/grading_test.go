@@ -0,0 +1,96 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"east-eagles/backend/internal/models"
+)
+
+var testBelts = []models.Belt{
+	{Name: "Blanche", Rank: 1},
+	{Name: "Jaune", Rank: 2, MinMonths: 3, MinAttendanceRate: 0.5},
+	{Name: "Orange", Rank: 3, MinMonths: 6, MinAttendanceRate: 0.75},
+}
+
+func TestNextBelt(t *testing.T) {
+	tests := []struct {
+		current string
+		want    string
+	}{
+		{"Blanche", "Jaune"},
+		{" jaune ", "Orange"},
+		{"", "Blanche"},
+		{"Noire", "Blanche"},
+		{"Orange", ""},
+	}
+	for _, tt := range tests {
+		got := ""
+		if next := NextBelt(testBelts, tt.current); next != nil {
+			got = next.Name
+		}
+		if got != tt.want {
+			t.Errorf("NextBelt(%q) = %q, want %q", tt.current, got, tt.want)
+		}
+	}
+	if NextBelt(nil, "") != nil {
+		t.Error("a belt was found without belts")
+	}
+}
+
+func TestMonthsBetween(t *testing.T) {
+	tests := []struct {
+		from, to string
+		want     int
+	}{
+		{"2026-01-15", "2026-04-14", 2},
+		{"2026-01-15", "2026-04-15", 3},
+		{"2026-01-31", "2026-02-28", 0},
+		{"2025-11-01", "2026-02-01", 3},
+		{"2026-05-01", "2026-04-01", 0},
+	}
+	for _, tt := range tests {
+		if got := monthsBetween(*day(tt.from), *day(tt.to)); got != tt.want {
+			t.Errorf("monthsBetween(%s, %s) = %d, want %d", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestCheckGradingEligibility(t *testing.T) {
+	exam := *day("2026-06-30")
+	tests := []struct {
+		name       string
+		belt       string
+		since      string
+		attendance *models.AttendanceStats
+		target     string
+		eligible   bool
+		reasons    []string
+	}{
+		{"meets both requirements", "Blanche", "2026-03-30", &models.AttendanceStats{Rate: 0.5},
+			"Jaune", true, []string{}},
+		{"too recent", "Blanche", "2026-04-01", &models.AttendanceStats{Rate: 0.9},
+			"Jaune", false, []string{"Ancienneté insuffisante : 2 mois sur 3"}},
+		{"not enough attendance", "Jaune", "2025-01-01", &models.AttendanceStats{Rate: 0.7449},
+			"Orange", false, []string{"Assiduité insuffisante : 74% sur 75%"}},
+		{"no sessions and no date", "Jaune", "", nil,
+			"Orange", false, []string{"Ancienneté insuffisante : 0 mois sur 6", "Assiduité insuffisante : 0% sur 75%"}},
+		{"highest belt", "Orange", "2020-01-01", &models.AttendanceStats{Rate: 1},
+			"", false, []string{"Grade le plus élevé déjà atteint"}},
+		{"first belt has no requirement", "", "", nil,
+			"Blanche", true, []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &models.GradingCandidate{CurrentBelt: tt.belt, TargetBelt: "stale"}
+			if tt.since != "" {
+				c.GradeSince = day(tt.since)
+			}
+			CheckGradingEligibility(c, testBelts, tt.attendance, exam)
+			if c.TargetBelt != tt.target || c.Eligible != tt.eligible || !reflect.DeepEqual(c.Reasons, tt.reasons) {
+				t.Errorf("got target %q eligible %v reasons %q", c.TargetBelt, c.Eligible, c.Reasons)
+			}
+		})
+	}
+}
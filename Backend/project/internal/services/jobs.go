@@ -0,0 +1,250 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"east-eagles/backend/internal/models"
+	"east-eagles/backend/internal/repository"
+)
+
+// JobHandler runs a job; an error schedules a retry
+type JobHandler func(ctx context.Context, job *models.Job) error
+
+// Job runner tuning
+const (
+	jobLeaseMargin    = time.Minute      // Lease beyond the job timeout before a job counts as abandoned
+	jobSchedulerEvery = 30 * time.Second // How often due schedules and abandoned jobs are checked
+	jobMaxBackoff     = time.Hour
+)
+
+type registeredJob struct {
+	handler     JobHandler
+	maxAttempts int
+	timeout     time.Duration
+}
+
+type periodicJob struct {
+	jobType string
+	cron    *CronSchedule
+	spec    string
+}
+
+// JobRunner runs the queued jobs of its registered types with a pool of
+// workers and queues the periodic ones. Any number of runners, on any number
+// of replicas, can share the queue.
+type JobRunner struct {
+	repo      *repository.JobRepository
+	workerID  string
+	workers   int
+	poll      time.Duration
+	loc       *time.Location
+	types     map[string]registeredJob
+	schedules map[string]periodicJob
+}
+
+// NewJobRunner builds a runner; workerID identifies it in locked jobs and
+// cron schedules are evaluated in loc
+func NewJobRunner(repo *repository.JobRepository, workerID string, workers int, poll time.Duration, loc *time.Location) *JobRunner {
+	if workers < 1 {
+		workers = 1
+	}
+	return &JobRunner{
+		repo:      repo,
+		workerID:  workerID,
+		workers:   workers,
+		poll:      poll,
+		loc:       loc,
+		types:     map[string]registeredJob{},
+		schedules: map[string]periodicJob{},
+	}
+}
+
+// Register adds a job type, tried up to maxAttempts times, each attempt
+// cancelled after timeout
+func (r *JobRunner) Register(jobType string, maxAttempts int, timeout time.Duration, handler JobHandler) {
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	r.types[jobType] = registeredJob{handler: handler, maxAttempts: maxAttempts, timeout: timeout}
+}
+
+// Schedule queues a job of a registered type each time the cron expression
+// matches
+func (r *JobRunner) Schedule(name, spec, jobType string) error {
+	if _, ok := r.types[jobType]; !ok {
+		return fmt.Errorf("schedule %s: unknown job type %s", name, jobType)
+	}
+	cron, err := ParseCron(spec, r.loc)
+	if err != nil {
+		return fmt.Errorf("schedule %s: %w", name, err)
+	}
+	r.schedules[name] = periodicJob{jobType: jobType, cron: cron, spec: spec}
+	return nil
+}
+
+// Types returns the registered job types
+func (r *JobRunner) Types() []string {
+	types := make([]string, 0, len(r.types))
+	for t := range r.types {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// Enqueue queues a job of a registered type to run from runAt
+func (r *JobRunner) Enqueue(jobType string, payload interface{}, runAt time.Time) (*models.Job, error) {
+	registered, ok := r.types[jobType]
+	if !ok {
+		return nil, fmt.Errorf("unknown job type: %s", jobType)
+	}
+	data := []byte("{}")
+	if payload != nil {
+		var err error
+		if data, err = json.Marshal(payload); err != nil {
+			return nil, err
+		}
+	}
+	return r.repo.Enqueue(jobType, data, runAt, registered.maxAttempts)
+}
+
+// jobBackoff is the delay before retrying a job after its nth failed attempt
+func jobBackoff(attempts int) time.Duration {
+	delay := 30 * time.Second
+	for i := 1; i < attempts && delay < jobMaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > jobMaxBackoff {
+		delay = jobMaxBackoff
+	}
+	return delay
+}
+
+// Run saves the schedules, then runs jobs until ctx is cancelled. It returns
+// once the jobs in progress have finished; a job cut short by the process
+// exiting is run again when its lease runs out.
+func (r *JobRunner) Run(ctx context.Context) error {
+	for name, s := range r.schedules {
+		if err := r.repo.SaveSchedule(name, s.jobType, s.spec, s.cron.Next(time.Now())); err != nil {
+			return err
+		}
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(r.workers + 1)
+	go func() {
+		defer wg.Done()
+		r.runScheduler(ctx)
+	}()
+	for i := 0; i < r.workers; i++ {
+		go func() {
+			defer wg.Done()
+			r.runWorker(ctx)
+		}()
+	}
+	log.Printf("⚙️ Job runner %s started with %d workers", r.workerID, r.workers)
+	wg.Wait()
+	log.Printf("⚙️ Job runner %s stopped", r.workerID)
+	return nil
+}
+
+// sleep waits for d, and reports false when ctx was cancelled meanwhile
+func sleep(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
+
+func (r *JobRunner) runScheduler(ctx context.Context) {
+	names := make([]string, 0, len(r.schedules))
+	for name := range r.schedules {
+		names = append(names, name)
+	}
+	next := func(name string, now time.Time) time.Time {
+		return r.schedules[name].cron.Next(now)
+	}
+	maxAttempts := func(jobType string) int {
+		return r.types[jobType].maxAttempts
+	}
+
+	for {
+		if n, err := r.repo.RequeueAbandoned(); err != nil {
+			log.Printf("❌ Requeuing abandoned jobs failed: %v", err)
+		} else if n > 0 {
+			log.Printf("⚠️ Requeued %d abandoned jobs", n)
+		}
+		if _, err := r.repo.FireDueSchedules(names, next, maxAttempts); err != nil {
+			log.Printf("❌ Queuing periodic jobs failed: %v", err)
+		}
+		if !sleep(ctx, jobSchedulerEvery) {
+			return
+		}
+	}
+}
+
+func (r *JobRunner) runWorker(ctx context.Context) {
+	types := r.Types()
+	maxTimeout := time.Duration(0)
+	for _, t := range r.types {
+		if t.timeout > maxTimeout {
+			maxTimeout = t.timeout
+		}
+	}
+
+	for ctx.Err() == nil {
+		job, err := r.repo.Claim(r.workerID, types, maxTimeout+jobLeaseMargin)
+		if err == sql.ErrNoRows {
+			sleep(ctx, r.poll)
+			continue
+		}
+		if err != nil {
+			log.Printf("❌ Claiming a job failed: %v", err)
+			sleep(ctx, r.poll)
+			continue
+		}
+		r.execute(job)
+	}
+}
+
+// execute runs a claimed job to completion, even during shutdown, and
+// records the outcome
+func (r *JobRunner) execute(job *models.Job) {
+	registered := r.types[job.Type]
+	ctx, cancel := context.WithTimeout(context.Background(), registered.timeout)
+	defer cancel()
+
+	started := time.Now()
+	err := func() (err error) {
+		defer func() {
+			if p := recover(); p != nil {
+				err = fmt.Errorf("panic: %v", p)
+			}
+		}()
+		return registered.handler(ctx, job)
+	}()
+
+	if err != nil {
+		log.Printf("⚠️ Job %d (%s) failed, attempt %d/%d: %v", job.ID, job.Type, job.Attempts, job.MaxAttempts, err)
+		if err := r.repo.Fail(job.ID, r.workerID, err.Error(), jobBackoff(job.Attempts)); err != nil {
+			log.Printf("❌ Recording job %d failure failed: %v", job.ID, err)
+		}
+		return
+	}
+	if err := r.repo.Complete(job.ID, r.workerID); err != nil {
+		log.Printf("❌ Recording job %d completion failed: %v", job.ID, err)
+		return
+	}
+	log.Printf("✅ Job %d (%s) done in %s", job.ID, job.Type, time.Since(started).Round(time.Millisecond))
+}
@@ -0,0 +1,103 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"east-eagles/backend/internal/models"
+	"east-eagles/backend/internal/repository"
+
+	"github.com/lib/pq"
+)
+
+// NotificationHub delivers new in-app notifications to the live streams of
+// their users. New notifications are announced through Postgres NOTIFY, so a
+// stream receives them whichever server instance created them.
+type NotificationHub struct {
+	repo        *repository.NotificationRepository
+	mu          sync.Mutex
+	subscribers map[int]map[chan *models.Notification]struct{} // user → streams
+}
+
+func NewNotificationHub(repo *repository.NotificationRepository) *NotificationHub {
+	return &NotificationHub{repo: repo, subscribers: map[int]map[chan *models.Notification]struct{}{}}
+}
+
+// Subscribe opens a stream of a user's new notifications; the returned
+// function closes it
+func (h *NotificationHub) Subscribe(userID int) (<-chan *models.Notification, func()) {
+	ch := make(chan *models.Notification, 16)
+	h.mu.Lock()
+	if h.subscribers[userID] == nil {
+		h.subscribers[userID] = map[chan *models.Notification]struct{}{}
+	}
+	h.subscribers[userID][ch] = struct{}{}
+	h.mu.Unlock()
+
+	return ch, func() {
+		h.mu.Lock()
+		delete(h.subscribers[userID], ch)
+		if len(h.subscribers[userID]) == 0 {
+			delete(h.subscribers, userID)
+		}
+		h.mu.Unlock()
+	}
+}
+
+func (h *NotificationHub) subscribed(userID int) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subscribers[userID]) > 0
+}
+
+// publish hands a notification to the user's streams. A stream that is not
+// keeping up misses it; the client catches up from the list endpoint.
+func (h *NotificationHub) publish(n *models.Notification) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subscribers[n.UserID] {
+		select {
+		case ch <- n:
+		default:
+		}
+	}
+}
+
+// Listen receives the NOTIFY announcements on a dedicated connection to
+// dsn and publishes the notifications, forever
+func (h *NotificationHub) Listen(dsn string) {
+	listener := pq.NewListener(dsn, 10*time.Second, time.Minute, func(ev pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Printf("⚠️ Notification listener: %v", err)
+		}
+	})
+	if err := listener.Listen(repository.NotificationChannel); err != nil {
+		log.Printf("❌ Notification listener failed: %v", err)
+		return
+	}
+
+	for {
+		select {
+		case ev := <-listener.Notify:
+			// nil after a reconnect: announcements made meanwhile are lost,
+			// clients resume from their last event id
+			if ev == nil {
+				continue
+			}
+			var userID, id int
+			if _, err := fmt.Sscanf(ev.Extra, "%d:%d", &userID, &id); err != nil || !h.subscribed(userID) {
+				continue
+			}
+			n, err := h.repo.GetNotification(userID, id)
+			if err != nil {
+				log.Printf("⚠️ Notification %d not delivered live: %v", id, err)
+				continue
+			}
+			h.publish(n)
+		case <-time.After(90 * time.Second):
+			go listener.Ping()
+		}
+	}
+}
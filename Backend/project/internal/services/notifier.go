@@ -0,0 +1,275 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"east-eagles/backend/internal/models"
+	"east-eagles/backend/internal/repository"
+)
+
+// Outbox worker tuning
+const (
+	notifyBatchSize   = 20
+	notifySendTimeout = time.Minute
+	notifyMaxBackoff  = 6 * time.Hour
+)
+
+// Notifier renders notifications for their recipients into the outbox and
+// delivers the outbox through the configured drivers
+type Notifier struct {
+	repo        *repository.NotificationRepository
+	templates   *TemplateRegistry
+	drivers     map[string]NotificationDriver // channel → driver, only configured ones
+	maxAttempts int
+}
+
+// NewNotifier builds a notifier; a nil driver disables its channel
+func NewNotifier(repo *repository.NotificationRepository, templates *TemplateRegistry,
+	email, sms NotificationDriver, maxAttempts int) *Notifier {
+	drivers := map[string]NotificationDriver{}
+	if email != nil {
+		drivers[models.ChannelEmail] = email
+	}
+	if sms != nil {
+		drivers[models.ChannelSMS] = sms
+	}
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	return &Notifier{repo: repo, templates: templates, drivers: drivers, maxAttempts: maxAttempts}
+}
+
+// Templates returns the template registry
+func (n *Notifier) Templates() *TemplateRegistry {
+	return n.templates
+}
+
+// NotifyUser queues a notification for a user and returns the number of
+// messages queued. Like NotifyAthlete, it runs after the change it reports
+// was committed, so it is not cut short with the caller's request.
+func (n *Notifier) NotifyUser(userID int, key string, params map[string]string) (int, error) {
+	rc, err := n.repo.GetUserRecipient(userID)
+	if err != nil {
+		return 0, err
+	}
+	return n.queue(context.Background(), []*models.NotificationRecipient{rc}, key, params, "")
+}
+
+// NotifyAthlete queues a notification about an athlete for the athlete and
+// their guardians
+func (n *Notifier) NotifyAthlete(athleteID int, key string, params map[string]string) (int, error) {
+	ctx := context.Background()
+	recipients, err := n.repo.GetAthleteRecipients(ctx, athleteID)
+	if err != nil {
+		return 0, err
+	}
+	return n.queue(ctx, recipients, key, params, "")
+}
+
+// queue renders a template for each recipient into their in-app inbox and
+// on every channel they enabled, and returns the number of messages queued.
+// With a dedupe key, a message already queued for the same recipient is not
+// queued again.
+func (n *Notifier) queue(ctx context.Context, recipients []*models.NotificationRecipient, key string, params map[string]string, dedupeKey string) (int, error) {
+	if !n.templates.Has(key) {
+		return 0, fmt.Errorf("unknown notification template: %s", key)
+	}
+	var dedupe *string
+	if dedupeKey != "" {
+		dedupe = &dedupeKey
+	}
+
+	var messages []*models.OutboxMessage
+	var inbox []*models.Notification
+	for _, rc := range recipients {
+		vars := map[string]string{"name": rc.Name}
+		for k, v := range params {
+			vars[k] = v
+		}
+		subject, body, err := n.templates.Render(rc.Language, key, vars)
+		if err != nil {
+			return 0, err
+		}
+
+		// Muting only silences email and SMS, the inbox keeps everything
+		if rc.UserID != nil {
+			inbox = append(inbox, &models.Notification{
+				UserID:    *rc.UserID,
+				AthleteID: rc.AthleteID,
+				Type:      key,
+				Title:     subject,
+				Message:   body,
+				DedupeKey: dedupe,
+			})
+		}
+		if muted(rc.Muted, key) {
+			continue
+		}
+
+		addresses := map[string]string{}
+		if rc.EmailEnabled && rc.Email != "" {
+			addresses[models.ChannelEmail] = rc.Email
+		}
+		if rc.SMSEnabled && rc.Phone != "" {
+			addresses[models.ChannelSMS] = rc.Phone
+		}
+		for channel, to := range addresses {
+			if n.drivers[channel] == nil {
+				continue
+			}
+			messages = append(messages, &models.OutboxMessage{
+				UserID:    rc.UserID,
+				AthleteID: rc.AthleteID,
+				Channel:   channel,
+				Recipient: to,
+				Template:  key,
+				Language:  rc.Language,
+				Subject:   subject,
+				Body:      body,
+				DedupeKey: dedupe,
+			})
+		}
+	}
+	if err := n.repo.CreateNotifications(ctx, inbox); err != nil {
+		return 0, err
+	}
+	return n.repo.Enqueue(ctx, messages)
+}
+
+func muted(keys []string, key string) bool {
+	for _, k := range keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// QueueReminders queues reminders for documents expiring within expiryDays
+// and for payments overdue by more than graceDays. Each reminder is queued
+// once per document expiry date and per lapsed period.
+func (n *Notifier) QueueReminders(ctx context.Context, expiryDays, graceDays int) (*models.ReminderResult, error) {
+	result := &models.ReminderResult{}
+
+	docs, err := n.repo.GetExpiringDocuments(ctx, expiryDays)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range docs {
+		recipients, err := n.repo.GetAthleteRecipients(ctx, d.AthleteID)
+		if err != nil {
+			return nil, err
+		}
+		queued, err := n.queue(ctx, recipients, "document_expiring", map[string]string{
+			"document": d.DocumentType,
+			"date":     d.ExpiryDate.Format("02/01/2006"),
+		}, fmt.Sprintf("document_expiring:%d:%s", d.DocumentID, d.ExpiryDate.Format("2006-01-02")))
+		if err != nil {
+			return nil, err
+		}
+		result.DocumentsExpiring++
+		result.Queued += queued
+	}
+
+	overdue, err := n.repo.GetOverdueAthletes(ctx, graceDays)
+	if err != nil {
+		return nil, err
+	}
+	for _, o := range overdue {
+		recipients, err := n.repo.GetAthleteRecipients(ctx, o.AthleteID)
+		if err != nil {
+			return nil, err
+		}
+		queued, err := n.queue(ctx, recipients, "payment_overdue", map[string]string{
+			"date": o.CoveredUntil.Format("02/01/2006"),
+		}, fmt.Sprintf("payment_overdue:%d:%s", o.AthleteID, o.CoveredUntil.Format("2006-01-02")))
+		if err != nil {
+			return nil, err
+		}
+		result.PaymentsOverdue++
+		result.Queued += queued
+	}
+	return result, nil
+}
+
+// retryDelay is the exponential backoff after a failed attempt
+func retryDelay(attempts int) time.Duration {
+	delay := time.Minute
+	for i := 1; i < attempts && delay < notifyMaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > notifyMaxBackoff {
+		delay = notifyMaxBackoff
+	}
+	return delay
+}
+
+// ProcessOutbox sends the due messages and returns how many were sent.
+// Several workers can run at once: each message is claimed by one of them.
+// Once ctx is done it stops; the messages it had claimed but not sent become
+// due again when their lease runs out.
+func (n *Notifier) ProcessOutbox(ctx context.Context) (int, error) {
+	sent := 0
+	for ctx.Err() == nil {
+		messages, err := n.repo.ClaimDue(notifyBatchSize, 2*notifySendTimeout)
+		if err != nil {
+			return sent, err
+		}
+		if len(messages) == 0 {
+			return sent, nil
+		}
+
+		for _, m := range messages {
+			if ctx.Err() != nil {
+				return sent, nil
+			}
+			if err := n.send(ctx, m); err != nil {
+				if ctx.Err() != nil {
+					return sent, nil
+				}
+				var retryIn time.Duration
+				if m.Attempts < n.maxAttempts {
+					retryIn = retryDelay(m.Attempts)
+				}
+				log.Printf("⚠️ Notification %d to %s failed (attempt %d/%d): %v", m.ID, m.Recipient, m.Attempts, n.maxAttempts, err)
+				if err := n.repo.MarkFailed(m.ID, err.Error(), retryIn); err != nil {
+					return sent, err
+				}
+				continue
+			}
+			if err := n.repo.MarkSent(m.ID); err != nil {
+				return sent, err
+			}
+			sent++
+		}
+	}
+	return sent, nil
+}
+
+func (n *Notifier) send(ctx context.Context, m *models.OutboxMessage) error {
+	driver := n.drivers[m.Channel]
+	if driver == nil {
+		return fmt.Errorf("%s notifications are disabled", m.Channel)
+	}
+	ctx, cancel := context.WithTimeout(ctx, notifySendTimeout)
+	defer cancel()
+	return driver.Send(ctx, &OutgoingMessage{To: m.Recipient, Subject: m.Subject, Body: m.Body})
+}
+
+// Run processes the outbox every interval until ctx is cancelled
+func (n *Notifier) Run(ctx context.Context, interval time.Duration) {
+	for {
+		sent, err := n.ProcessOutbox(ctx)
+		if err != nil {
+			log.Printf("❌ Notification outbox failed: %v", err)
+		} else if sent > 0 {
+			log.Printf("📨 Notifications sent: %d", sent)
+		}
+		if !sleep(ctx, interval) {
+			return
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"east-eagles/backend/internal/models"
+)
+
+func TestInterpolate(t *testing.T) {
+	params := map[string]string{"name": "Amine", "date": "01/03/2026"}
+	tests := []struct{ in, want string }{
+		{"Bonjour {{name}}", "Bonjour Amine"},
+		{"{{ name }}, le {{date}}", "Amine, le 01/03/2026"},
+		{"Motif : {{reason}}.", "Motif : ."},
+		{"{name} {{na me}}", "{name} {{na me}}"},
+		{"{{name}}{{name}}", "AmineAmine"},
+	}
+	for _, tt := range tests {
+		if got := interpolate(tt.in, params); got != tt.want {
+			t.Errorf("interpolate(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{0, time.Minute},
+		{1, time.Minute},
+		{2, 2 * time.Minute},
+		{5, 16 * time.Minute},
+		{9, 256 * time.Minute},
+		{10, notifyMaxBackoff},
+		{1000, notifyMaxBackoff},
+	}
+	for _, tt := range tests {
+		if got := retryDelay(tt.attempts); got != tt.want {
+			t.Errorf("retryDelay(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+// blockingDriver holds every message until the send is given up
+type blockingDriver struct{}
+
+func (blockingDriver) Name() string { return "blocking" }
+
+func (blockingDriver) Send(ctx context.Context, msg *OutgoingMessage) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestOutboxStopsWithContext(t *testing.T) {
+	// No repository: a stopped worker must not claim anything
+	n := NewNotifier(nil, nil, blockingDriver{}, nil, 3)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := n.send(ctx, &models.OutboxMessage{Channel: models.ChannelEmail, Recipient: "a@example.com"})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("send = %v, want the caller's deadline", err)
+	}
+
+	sent, err := n.ProcessOutbox(ctx)
+	if sent != 0 || err != nil {
+		t.Errorf("ProcessOutbox after the deadline = %d, %v; want 0, nil", sent, err)
+	}
+}
+
+func TestBuildEmailHeaderInjection(t *testing.T) {
+	email := string(buildEmail("club@example.com", &OutgoingMessage{
+		To:      "athlete@example.com\r\nBcc: victim@example.com",
+		Subject: "Hello\nBcc: victim@example.com",
+		Body:    "line 1\nline 2",
+	}))
+	headers, body, _ := strings.Cut(email, "\r\n\r\n")
+	for _, line := range strings.Split(headers, "\r\n") {
+		if strings.HasPrefix(line, "Bcc:") {
+			t.Errorf("injected header %q in\n%s", line, email)
+		}
+	}
+	if body != "line 1\r\nline 2\r\n" {
+		t.Errorf("body = %q", body)
+	}
+
+	if err := (&SMTPDriver{}).Send(context.Background(), &OutgoingMessage{To: "a@example.com\nRCPT TO:<b@example.com>"}); err == nil {
+		t.Error("an address with a line break was accepted")
+	}
+}
+
+// The templates are a copy of the "notifications" section of the frontend
+// locales; both must change together
+func TestTemplatesMatchFrontendLocales(t *testing.T) {
+	frontend := filepath.Join("..", "..", "..", "..", "frontend", "src", "locales")
+	files, err := filepath.Glob(filepath.Join(frontend, "*.json"))
+	if err != nil || len(files) == 0 {
+		t.Skip("frontend locales not found next to the backend")
+	}
+
+	reg, err := NewTemplateRegistry()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		lang := strings.TrimSuffix(filepath.Base(file), ".json")
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var locale struct {
+			Notifications map[string]MessageTemplate `json:"notifications"`
+		}
+		if err := json.Unmarshal(data, &locale); err != nil {
+			t.Fatalf("%s: %v", file, err)
+		}
+		if !reflect.DeepEqual(locale.Notifications, reg.templates[lang]) {
+			t.Errorf("notification templates in %s differ from internal/services/locales/%s.json", file, lang)
+		}
+	}
+}
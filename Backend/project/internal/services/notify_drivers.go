@@ -0,0 +1,215 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// OutgoingMessage is what a driver delivers
+type OutgoingMessage struct {
+	To      string // Email address or phone number
+	Subject string // Ignored by SMS drivers
+	Body    string
+}
+
+// NotificationDriver is implemented by the email and SMS gateways
+type NotificationDriver interface {
+	// Name identifies the driver in logs
+	Name() string
+	// Send delivers a message, giving up when ctx is done
+	Send(ctx context.Context, msg *OutgoingMessage) error
+}
+
+// SMTPConfig configures the SMTP email driver
+type SMTPConfig struct {
+	Host     string
+	Port     string // 465 uses implicit TLS, other ports STARTTLS when offered
+	Username string // Empty for servers without authentication
+	Password string
+	From     string
+}
+
+// NewEmailDriver returns the email driver configured by name ("" disables email)
+func NewEmailDriver(name string, cfg SMTPConfig) (NotificationDriver, error) {
+	switch name {
+	case "":
+		return nil, nil
+	case "stub":
+		return &StubDriver{channel: "email"}, nil
+	case "smtp":
+		if cfg.Host == "" || cfg.From == "" {
+			return nil, errors.New("smtp email driver needs SMTP_HOST and SMTP_FROM")
+		}
+		return &SMTPDriver{cfg: cfg}, nil
+	default:
+		return nil, fmt.Errorf("unknown email driver: %s", name)
+	}
+}
+
+// HTTPSMSConfig configures the generic HTTP SMS driver
+type HTTPSMSConfig struct {
+	URL    string // Endpoint receiving {"from", "to", "text"} as JSON
+	Token  string // Sent as a bearer token when set
+	Sender string
+}
+
+// NewSMSDriver returns the SMS driver configured by name ("" disables SMS)
+func NewSMSDriver(name string, cfg HTTPSMSConfig) (NotificationDriver, error) {
+	switch name {
+	case "":
+		return nil, nil
+	case "stub":
+		return &StubDriver{channel: "sms"}, nil
+	case "http":
+		if cfg.URL == "" {
+			return nil, errors.New("http sms driver needs SMS_HTTP_URL")
+		}
+		return &HTTPSMSDriver{cfg: cfg, client: &http.Client{Timeout: 30 * time.Second}}, nil
+	default:
+		return nil, fmt.Errorf("unknown sms driver: %s", name)
+	}
+}
+
+// StubDriver logs messages instead of sending them, for local testing
+type StubDriver struct {
+	channel string
+}
+
+func (d *StubDriver) Name() string { return "stub-" + d.channel }
+
+func (d *StubDriver) Send(ctx context.Context, msg *OutgoingMessage) error {
+	log.Printf("📨 [%s] to %s: %s | %s", d.channel, msg.To, msg.Subject, msg.Body)
+	return nil
+}
+
+// SMTPDriver sends emails through an SMTP server
+type SMTPDriver struct {
+	cfg SMTPConfig
+}
+
+func (d *SMTPDriver) Name() string { return "smtp" }
+
+func (d *SMTPDriver) Send(ctx context.Context, msg *OutgoingMessage) error {
+	if strings.ContainsAny(msg.To, "\r\n") {
+		return fmt.Errorf("invalid email address: %q", msg.To)
+	}
+	addr := net.JoinHostPort(d.cfg.Host, d.cfg.Port)
+	dialer := &net.Dialer{Timeout: 30 * time.Second}
+	var conn net.Conn
+	var err error
+	if d.cfg.Port == "465" {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: d.cfg.Host}}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	c, err := smtp.NewClient(conn, d.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: d.cfg.Host}); err != nil {
+			return err
+		}
+	}
+	if d.cfg.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", d.cfg.Username, d.cfg.Password, d.cfg.Host)); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(d.cfg.From); err != nil {
+		return err
+	}
+	if err := c.Rcpt(msg.To); err != nil {
+		return err
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(buildEmail(d.cfg.From, msg)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// headerValue turns line breaks into spaces, so a value cannot inject headers
+var headerValue = strings.NewReplacer("\r\n", " ", "\r", " ", "\n", " ")
+
+// buildEmail formats a UTF-8 plain text email
+func buildEmail(from string, msg *OutgoingMessage) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", headerValue.Replace(from))
+	fmt.Fprintf(&b, "To: %s\r\n", headerValue.Replace(msg.To))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", headerValue.Replace(msg.Subject)))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(strings.ReplaceAll(msg.Body, "\r\n", "\n"), "\n", "\r\n"))
+	b.WriteString("\r\n")
+	return b.Bytes()
+}
+
+// HTTPSMSDriver posts SMS to a generic HTTP gateway
+type HTTPSMSDriver struct {
+	cfg    HTTPSMSConfig
+	client *http.Client
+}
+
+func (d *HTTPSMSDriver) Name() string { return "http-sms" }
+
+func (d *HTTPSMSDriver) Send(ctx context.Context, msg *OutgoingMessage) error {
+	payload, err := json.Marshal(map[string]string{
+		"from": d.cfg.Sender,
+		"to":   msg.To,
+		"text": msg.Body,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.cfg.URL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if d.cfg.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+d.cfg.Token)
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("sms gateway returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ErrInvalidSignature is returned when a webhook payload fails verification
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+// CheckoutRequest describes what the athlete is about to pay online
+type CheckoutRequest struct {
+	CheckoutID  int     // Local payment_checkouts row, echoed back in webhooks
+	Amount      float64 // In dinars
+	Currency    string  // ISO 4217, e.g. "DZD"
+	Description string
+	SuccessURL  string
+	CancelURL   string
+}
+
+// CheckoutSession is the provider-side checkout the athlete is redirected to
+type CheckoutSession struct {
+	SessionID string `json:"session_id"`
+	URL       string `json:"url"`
+}
+
+// PaymentEvent is a verified webhook notification
+type PaymentEvent struct {
+	EventID   string  `json:"event_id"`   // Unique per delivery, used to ignore replays
+	SessionID string  `json:"session_id"` // CheckoutSession.SessionID
+	Status    string  `json:"status"`     // 'paid', 'failed', 'canceled'
+	Amount    float64 `json:"amount"`
+	Reference string  `json:"reference"` // Provider transaction reference
+}
+
+// PaymentProvider is implemented by online payment gateways
+type PaymentProvider interface {
+	// Name identifies the provider in URLs and stored checkouts
+	Name() string
+	// CreateCheckout opens a checkout session for the request
+	CreateCheckout(req *CheckoutRequest) (*CheckoutSession, error)
+	// VerifyWebhook authenticates a webhook delivery and decodes its event
+	VerifyWebhook(payload []byte, header http.Header) (*PaymentEvent, error)
+}
+
+// NewPaymentProvider returns the provider configured by name ("" disables online payments)
+func NewPaymentProvider(name, webhookSecret, publicURL string) (PaymentProvider, error) {
+	switch name {
+	case "":
+		return nil, nil
+	case "fake":
+		if webhookSecret == "" {
+			return nil, errors.New("fake payment provider needs PAYMENT_WEBHOOK_SECRET")
+		}
+		return NewFakePaymentProvider(webhookSecret, publicURL), nil
+	default:
+		return nil, fmt.Errorf("unknown payment provider: %s", name)
+	}
+}
+
+// FakeSignatureHeader carries the hex HMAC-SHA256 of a fake provider webhook body
+const FakeSignatureHeader = "X-Fake-Signature"
+
+// FakePaymentProvider simulates a gateway for local testing. Its checkout URL
+// points to a page served by this API that emits signed webhooks.
+type FakePaymentProvider struct {
+	secret    []byte
+	publicURL string
+}
+
+func NewFakePaymentProvider(secret, publicURL string) *FakePaymentProvider {
+	return &FakePaymentProvider{secret: []byte(secret), publicURL: strings.TrimRight(publicURL, "/")}
+}
+
+func (p *FakePaymentProvider) Name() string {
+	return "fake"
+}
+
+// CreateCheckout generates a random session id; nothing leaves the process
+func (p *FakePaymentProvider) CreateCheckout(req *CheckoutRequest) (*CheckoutSession, error) {
+	id, err := randomHex(12)
+	if err != nil {
+		return nil, err
+	}
+	sessionID := fmt.Sprintf("fake_%d_%s", req.CheckoutID, id)
+	return &CheckoutSession{
+		SessionID: sessionID,
+		URL:       fmt.Sprintf("%s/api/payments/providers/fake/checkout/%s", p.publicURL, sessionID),
+	}, nil
+}
+
+// SignedEvent builds a webhook body and its signature, as the real gateway would send it
+func (p *FakePaymentProvider) SignedEvent(event *PaymentEvent) ([]byte, http.Header, error) {
+	if event.EventID == "" {
+		id, err := randomHex(12)
+		if err != nil {
+			return nil, nil, err
+		}
+		event.EventID = "evt_" + id
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, nil, err
+	}
+	header := http.Header{}
+	header.Set(FakeSignatureHeader, p.sign(payload))
+	return payload, header, nil
+}
+
+// VerifyWebhook checks the HMAC signature before decoding the event
+func (p *FakePaymentProvider) VerifyWebhook(payload []byte, header http.Header) (*PaymentEvent, error) {
+	signature, err := hex.DecodeString(header.Get(FakeSignatureHeader))
+	if err != nil || !hmac.Equal(signature, p.mac(payload)) {
+		return nil, ErrInvalidSignature
+	}
+
+	var event PaymentEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return nil, err
+	}
+	if event.EventID == "" || event.SessionID == "" {
+		return nil, errors.New("webhook event is missing event_id or session_id")
+	}
+	return &event, nil
+}
+
+func (p *FakePaymentProvider) mac(payload []byte) []byte {
+	m := hmac.New(sha256.New, p.secret)
+	m.Write(payload)
+	return m.Sum(nil)
+}
+
+func (p *FakePaymentProvider) sign(payload []byte) string {
+	return hex.EncodeToString(p.mac(payload))
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,234 @@
+package services
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// pdfPage is a minimal single-page PDF builder.
+// With a TrueType font it embeds the font (Identity-H) and can render any
+// script the font covers; without one it falls back to Helvetica/WinAnsi.
+type pdfPage struct {
+	width, height float64
+	font          *trueTypeFont
+	content       bytes.Buffer
+	used          map[uint16]rune
+}
+
+func newPDFPage(width, height float64, font *trueTypeFont) *pdfPage {
+	return &pdfPage{
+		width:  width,
+		height: height,
+		font:   font,
+		used:   make(map[uint16]rune),
+	}
+}
+
+// textWidth returns the width of s in points at the given size
+func (p *pdfPage) textWidth(s []rune, size float64) float64 {
+	total := 0
+	for _, r := range s {
+		if p.font != nil {
+			total += p.font.width(p.font.glyph(r))
+		} else {
+			total += helveticaWidth(r)
+		}
+	}
+	return float64(total) * size / 1000
+}
+
+// text draws s with its baseline starting at (x, y)
+func (p *pdfPage) text(x, y, size float64, s []rune) {
+	fmt.Fprintf(&p.content, "BT /F1 %.2f Tf %.2f %.2f Td ", size, x, y)
+	if p.font != nil {
+		p.content.WriteString("<")
+		for _, r := range s {
+			g := p.font.glyph(r)
+			p.used[g] = r
+			fmt.Fprintf(&p.content, "%04X", g)
+		}
+		p.content.WriteString("> Tj ET\n")
+		return
+	}
+	p.content.WriteString("(")
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			p.content.WriteByte('\\')
+			p.content.WriteByte(byte(r))
+		case r < 256:
+			p.content.WriteByte(byte(r))
+		default:
+			p.content.WriteByte('?')
+		}
+	}
+	p.content.WriteString(") Tj ET\n")
+}
+
+// textRight draws s so that it ends at x
+func (p *pdfPage) textRight(x, y, size float64, s []rune) {
+	p.text(x-p.textWidth(s, size), y, size, s)
+}
+
+// textCenter draws s centred on x
+func (p *pdfPage) textCenter(x, y, size float64, s []rune) {
+	p.text(x-p.textWidth(s, size)/2, y, size, s)
+}
+
+// line draws a straight line
+func (p *pdfPage) line(x1, y1, x2, y2, width float64) {
+	fmt.Fprintf(&p.content, "%.2f w %.2f %.2f m %.2f %.2f l S\n", width, x1, y1, x2, y2)
+}
+
+// rect strokes a rectangle
+func (p *pdfPage) rect(x, y, w, h, width float64) {
+	fmt.Fprintf(&p.content, "%.2f w %.2f %.2f %.2f %.2f re S\n", width, x, y, w, h)
+}
+
+// bytes serialises the page as a complete PDF document
+func (p *pdfPage) bytes() ([]byte, error) {
+	var objects []string
+	add := func(obj string) int {
+		objects = append(objects, obj)
+		return len(objects)
+	}
+
+	catalog := add("")
+	pages := add("")
+	page := add("")
+	contents := add(stream("", p.content.Bytes()))
+
+	var fontRef int
+	if p.font != nil {
+		var err error
+		fontRef, err = p.embedFont(add)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		fontRef = add("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	}
+
+	objects[catalog-1] = fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pages)
+	objects[pages-1] = fmt.Sprintf("<< /Type /Pages /Kids [%d 0 R] /Count 1 >>", page)
+	objects[page-1] = fmt.Sprintf(
+		"<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %.0f %.0f] /Contents %d 0 R /Resources << /Font << /F1 %d 0 R >> >> >>",
+		pages, p.width, p.height, contents, fontRef,
+	)
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n%\xE2\xE3\xCF\xD3\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, catalog, xref)
+
+	return buf.Bytes(), nil
+}
+
+// embedFont writes the Type0/CIDFontType2 objects and returns the font reference
+func (p *pdfPage) embedFont(add func(string) int) (int, error) {
+	f := p.font
+
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	if _, err := zw.Write(f.data); err != nil {
+		return 0, err
+	}
+	if err := zw.Close(); err != nil {
+		return 0, err
+	}
+	fontFile := add(stream(
+		fmt.Sprintf("/Length1 %d /Filter /FlateDecode", len(f.data)),
+		compressed.Bytes(),
+	))
+
+	descriptor := add(fmt.Sprintf(
+		"<< /Type /FontDescriptor /FontName /EmbeddedFont /Flags 32 /FontBBox [%d %d %d %d] "+
+			"/ItalicAngle 0 /Ascent %d /Descent %d /CapHeight %d /StemV 80 /FontFile2 %d 0 R >>",
+		f.scale(f.bbox[0]), f.scale(f.bbox[1]), f.scale(f.bbox[2]), f.scale(f.bbox[3]),
+		f.scale(f.ascent), f.scale(f.descent), f.scale(f.capHeight), fontFile,
+	))
+
+	glyphs := make([]int, 0, len(p.used))
+	for g := range p.used {
+		glyphs = append(glyphs, int(g))
+	}
+	sort.Ints(glyphs)
+
+	var widths strings.Builder
+	for _, g := range glyphs {
+		fmt.Fprintf(&widths, "%d [%d] ", g, f.width(uint16(g)))
+	}
+
+	cidFont := add(fmt.Sprintf(
+		"<< /Type /Font /Subtype /CIDFontType2 /BaseFont /EmbeddedFont "+
+			"/CIDSystemInfo << /Registry (Adobe) /Ordering (Identity) /Supplement 0 >> "+
+			"/FontDescriptor %d 0 R /W [%s] /CIDToGIDMap /Identity >>",
+		descriptor, widths.String(),
+	))
+
+	toUnicode := add(stream("", p.toUnicodeCMap(glyphs)))
+
+	return add(fmt.Sprintf(
+		"<< /Type /Font /Subtype /Type0 /BaseFont /EmbeddedFont /Encoding /Identity-H "+
+			"/DescendantFonts [%d 0 R] /ToUnicode %d 0 R >>",
+		cidFont, toUnicode,
+	)), nil
+}
+
+// toUnicodeCMap lets viewers copy and search the embedded text
+func (p *pdfPage) toUnicodeCMap(glyphs []int) []byte {
+	var b bytes.Buffer
+	b.WriteString("/CIDInit /ProcSet findresource begin\n12 dict begin\nbegincmap\n")
+	b.WriteString("/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def\n")
+	b.WriteString("/CMapName /Adobe-Identity-UCS def\n/CMapType 2 def\n")
+	b.WriteString("1 begincodespacerange\n<0000> <FFFF>\nendcodespacerange\n")
+	for start := 0; start < len(glyphs); start += 100 {
+		end := start + 100
+		if end > len(glyphs) {
+			end = len(glyphs)
+		}
+		fmt.Fprintf(&b, "%d beginbfchar\n", end-start)
+		for _, g := range glyphs[start:end] {
+			r := p.used[uint16(g)]
+			if r > 0xFFFF {
+				r = 0xFFFD
+			}
+			fmt.Fprintf(&b, "<%04X> <%04X>\n", g, r)
+		}
+		b.WriteString("endbfchar\n")
+	}
+	b.WriteString("endcmap\nCMapName currentdict /CMap defineresource pop\nend\nend\n")
+	return b.Bytes()
+}
+
+func stream(dict string, data []byte) string {
+	return fmt.Sprintf("<< /Length %d %s>>\nstream\n%s\nendstream", len(data), dict, data)
+}
+
+// helveticaWidth approximates Helvetica advance widths (1/1000 em)
+func helveticaWidth(r rune) int {
+	switch {
+	case r == ' ' || r == '.' || r == ',' || r == ':' || r == ';' || r == 'i' || r == 'l' || r == 'j':
+		return 278
+	case r >= '0' && r <= '9':
+		return 556
+	case r >= 'A' && r <= 'Z':
+		return 667
+	case r == 'm' || r == 'w':
+		return 833
+	default:
+		return 556
+	}
+}
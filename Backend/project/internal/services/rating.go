@@ -0,0 +1,62 @@
+package services
+
+import (
+	"math"
+
+	"east-eagles/backend/internal/models"
+	"east-eagles/backend/internal/repository"
+)
+
+// RatingK is how many points a single bout can move a rating
+const RatingK = 32
+
+// ReplayRatings rates bouts in order, everyone starting at InitialRating,
+// and returns every athlete's rating change. Replaying from scratch keeps
+// ratings right when an earlier result is corrected.
+func ReplayRatings(bouts []models.RatedBout) []models.RatingChange {
+	ratings := map[int]float64{}
+	current := func(id int) float64 {
+		if r, ok := ratings[id]; ok {
+			return r
+		}
+		return models.InitialRating
+	}
+
+	var changes []models.RatingChange
+	for _, b := range bouts {
+		red, blue := current(b.RedAthleteID), current(b.BlueAthleteID)
+		expectedRed := 1 / (1 + math.Pow(10, (blue-red)/400))
+
+		scoreRed := 0.5
+		if b.WinnerAthleteID != nil {
+			scoreRed = 0
+			if *b.WinnerAthleteID == b.RedAthleteID {
+				scoreRed = 1
+			}
+		}
+		delta := RatingK * (scoreRed - expectedRed)
+		ratings[b.RedAthleteID] = red + delta
+		ratings[b.BlueAthleteID] = blue - delta
+
+		changes = append(changes,
+			models.RatingChange{AthleteID: b.RedAthleteID, BoutID: b.ID, Before: int(math.Round(red)), After: int(math.Round(red + delta)), RatedAt: b.CompletedAt},
+			models.RatingChange{AthleteID: b.BlueAthleteID, BoutID: b.ID, Before: int(math.Round(blue)), After: int(math.Round(blue - delta)), RatedAt: b.CompletedAt},
+		)
+	}
+	return changes
+}
+
+// RatingService rebuilds athlete ratings from recorded bouts
+type RatingService struct {
+	repo *repository.RankingRepository
+}
+
+func NewRatingService(repo *repository.RankingRepository) *RatingService {
+	return &RatingService{repo: repo}
+}
+
+// Recalculate replays every fought bout and stores the resulting ratings.
+// Concurrent calls are serialised by the repository.
+func (s *RatingService) Recalculate() error {
+	return s.repo.RebuildRatings(ReplayRatings)
+}
@@ -0,0 +1,63 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"east-eagles/backend/internal/models"
+)
+
+func ratedBout(id, red, blue, winner int) models.RatedBout {
+	b := models.RatedBout{ID: id, RedAthleteID: red, BlueAthleteID: blue,
+		CompletedAt: time.Date(2026, 3, 1, 10, id, 0, 0, time.UTC)}
+	if winner != 0 {
+		b.WinnerAthleteID = &winner
+	}
+	return b
+}
+
+func TestReplayRatings(t *testing.T) {
+	changes := ReplayRatings([]models.RatedBout{
+		ratedBout(1, 1, 2, 1), // Even ratings: the winner takes half of K
+		ratedBout(2, 1, 3, 3), // The favourite loses a little more than half
+		ratedBout(3, 4, 5, 0), // A draw between even ratings changes nothing
+	})
+
+	type change struct{ athlete, bout, before, after int }
+	var got []change
+	for _, c := range changes {
+		got = append(got, change{c.AthleteID, c.BoutID, c.Before, c.After})
+		if want := time.Date(2026, 3, 1, 10, c.BoutID, 0, 0, time.UTC); !c.RatedAt.Equal(want) {
+			t.Errorf("bout %d rated at %v, want its completion time", c.BoutID, c.RatedAt)
+		}
+	}
+	want := []change{
+		{1, 1, 1500, 1516}, {2, 1, 1500, 1484},
+		{1, 2, 1516, 1499}, {3, 2, 1500, 1517},
+		{4, 3, 1500, 1500}, {5, 3, 1500, 1500},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("changes = %v\nwant      %v", got, want)
+	}
+}
+
+func TestReplayRatingsUpset(t *testing.T) {
+	// 2 lost their first bout; beating the now higher rated 1 earns more than
+	// 1 earned for the first win
+	changes := ReplayRatings([]models.RatedBout{ratedBout(1, 1, 2, 1), ratedBout(2, 2, 1, 2)})
+	first := changes[0].After - changes[0].Before
+	upset := changes[2].After - changes[2].Before
+	if changes[2].AthleteID != 2 || upset <= first {
+		t.Errorf("upset gain %d, first win %d", upset, first)
+	}
+	if changes[2].After+changes[3].After != 3000 {
+		t.Errorf("ratings are not zero-sum: %+v", changes)
+	}
+}
+
+func TestReplayRatingsEmpty(t *testing.T) {
+	if changes := ReplayRatings(nil); len(changes) != 0 {
+		t.Errorf("changes without bouts: %v", changes)
+	}
+}
@@ -0,0 +1,221 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"east-eagles/backend/internal/models"
+)
+
+// receiptLabels holds the translated strings printed on a receipt.
+// They follow the wording used in frontend/src/locales.
+type receiptLabels struct {
+	club      string
+	subtitle  string
+	title     string
+	credit    string
+	number    string
+	date      string
+	athlete   string
+	amount    string
+	period    string
+	periodFmt string
+	recorded  string
+	notes     string
+	method    string
+	reference string
+	methods   map[models.PaymentMethod]string
+	currency  string
+	footer    string
+}
+
+var receiptTranslations = map[string]receiptLabels{
+	"fr": {
+		club:      "East Eagles",
+		subtitle:  "Club de Sanda",
+		title:     "Reçu de paiement",
+		credit:    "Avoir (remboursement)",
+		number:    "N° de reçu",
+		date:      "Date",
+		athlete:   "Athlète",
+		amount:    "Montant",
+		period:    "Période",
+		periodFmt: "du %s au %s",
+		recorded:  "Enregistré par",
+		notes:     "Notes",
+		method:    "Mode de paiement",
+		reference: "Référence",
+		methods: map[models.PaymentMethod]string{
+			models.PaymentMethodCash:         "Espèces",
+			models.PaymentMethodBankTransfer: "Virement bancaire",
+			models.PaymentMethodCCP:          "CCP",
+			models.PaymentMethodCard:         "Carte",
+		},
+		currency: "DA",
+		footer:   "Merci pour votre confiance.",
+	},
+	"ar": {
+		club:      "East Eagles",
+		subtitle:  "نادي الساندا",
+		title:     "وصل دفع",
+		credit:    "إشعار دائن (استرداد)",
+		number:    "رقم الوصل",
+		date:      "التاريخ",
+		athlete:   "الرياضي",
+		amount:    "المبلغ",
+		period:    "الفترة",
+		periodFmt: "من %s إلى %s",
+		recorded:  "سجّل بواسطة",
+		notes:     "ملاحظات",
+		method:    "طريقة الدفع",
+		reference: "المرجع",
+		methods: map[models.PaymentMethod]string{
+			models.PaymentMethodCash:         "نقدا",
+			models.PaymentMethodBankTransfer: "تحويل بنكي",
+			models.PaymentMethodCCP:          "CCP",
+			models.PaymentMethodCard:         "بطاقة",
+		},
+		currency: "دج",
+		footer:   "شكرا على ثقتكم.",
+	},
+}
+
+// ReceiptService renders payment receipts as PDF documents
+type ReceiptService struct {
+	font *trueTypeFont
+}
+
+// NewReceiptService loads the TrueType font used to render receipts.
+// Without a font, French receipts fall back to Helvetica and Arabic ones are unavailable.
+func NewReceiptService(fontPath string) *ReceiptService {
+	font, err := loadTrueType(fontPath)
+	if err != nil {
+		log.Printf("⚠️  Receipt font not loaded (%v), Arabic receipts disabled", err)
+		return &ReceiptService{}
+	}
+	return &ReceiptService{font: font}
+}
+
+// SupportsLanguage reports whether receipts can be rendered in lang
+func (s *ReceiptService) SupportsLanguage(lang string) bool {
+	if _, ok := receiptTranslations[lang]; !ok {
+		return false
+	}
+	return lang != "ar" || s.font != nil
+}
+
+// Render builds the PDF receipt for a payment in the given language ("fr" or "ar")
+func (s *ReceiptService) Render(p *models.Payment, lang string) ([]byte, error) {
+	labels, ok := receiptTranslations[lang]
+	if !ok {
+		return nil, fmt.Errorf("unsupported receipt language: %s", lang)
+	}
+	rtl := lang == "ar"
+	if rtl && s.font == nil {
+		return nil, errors.New("arabic receipts require a TrueType font (RECEIPT_FONT_PATH)")
+	}
+
+	const (
+		width  = 595.0 // A4 in points
+		height = 842.0
+		margin = 56.0
+	)
+	page := newPDFPage(width, height, s.font)
+
+	// shape converts a logical string to the glyph order drawn on the page
+	shape := func(str string) []rune {
+		if rtl {
+			return visualRTL(str)
+		}
+		return []rune(str)
+	}
+	// draw places a line at the reading-start edge of the page
+	draw := func(y, size float64, str string) {
+		if rtl {
+			page.textRight(width-margin, y, size, shape(str))
+		} else {
+			page.text(margin, y, size, shape(str))
+		}
+	}
+
+	// Header
+	page.textCenter(width/2, height-margin-10, 22, shape(labels.club))
+	page.textCenter(width/2, height-margin-32, 12, shape(labels.subtitle))
+	page.line(margin, height-margin-46, width-margin, height-margin-46, 1)
+
+	title := labels.title
+	if p.Kind == models.PaymentKindRefund {
+		title = labels.credit
+	}
+	page.textCenter(width/2, height-margin-84, 18, shape(title))
+
+	number := p.ReceiptNumber
+	if number == "" {
+		number = fmt.Sprintf("#%d", p.ID)
+	}
+
+	rows := [][2]string{
+		{labels.number, number},
+		{labels.date, p.PaymentDate.Format("02/01/2006")},
+		{labels.athlete, p.AthleteName},
+		{labels.amount, fmt.Sprintf("%s %s", formatAmount(math.Abs(p.Amount)), labels.currency)},
+		{labels.period, fmt.Sprintf(labels.periodFmt, displayDate(p.StartDate), displayDate(p.EndDate))},
+	}
+	if method, ok := labels.methods[p.Method]; ok {
+		rows = append(rows, [2]string{labels.method, method})
+	}
+	if p.ExternalReference != "" {
+		rows = append(rows, [2]string{labels.reference, p.ExternalReference})
+	}
+	if p.RecordedByName != "" {
+		rows = append(rows, [2]string{labels.recorded, p.RecordedByName})
+	}
+	if p.Notes != "" {
+		rows = append(rows, [2]string{labels.notes, p.Notes})
+	}
+
+	y := height - margin - 130
+	top := y + 24
+	for _, row := range rows {
+		draw(y, 12, row[0]+" : "+row[1])
+		y -= 26
+	}
+	page.rect(margin-10, y+10, width-2*margin+20, top-y-10, 0.5)
+
+	page.textCenter(width/2, margin+20, 10, shape(labels.footer))
+
+	return page.bytes()
+}
+
+// formatAmount prints an amount with a space as thousands separator (e.g. 12 500.00)
+func formatAmount(amount float64) string {
+	s := fmt.Sprintf("%.2f", amount)
+	intPart, frac := s[:len(s)-3], s[len(s)-3:]
+	neg := strings.HasPrefix(intPart, "-")
+	intPart = strings.TrimPrefix(intPart, "-")
+
+	var b strings.Builder
+	for i, c := range intPart {
+		if i > 0 && (len(intPart)-i)%3 == 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteRune(c)
+	}
+	if neg {
+		return "-" + b.String() + frac
+	}
+	return b.String() + frac
+}
+
+// displayDate converts YYYY-MM-DD to DD/MM/YYYY
+func displayDate(date string) string {
+	t, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return date
+	}
+	return t.Format("02/01/2006")
+}
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"east-eagles/backend/internal/models"
+	"east-eagles/backend/internal/repository"
+)
+
+// SessionGenerator keeps dated training sessions materialised from the weekly
+// schedules for a rolling horizon. Athletes booked on a session it removes
+// are told the session is cancelled.
+type SessionGenerator struct {
+	repo        *repository.ScheduleRepository
+	notifier    *Notifier
+	horizonDays int
+}
+
+func NewSessionGenerator(repo *repository.ScheduleRepository, notifier *Notifier, horizonDays int) *SessionGenerator {
+	if horizonDays < 1 {
+		horizonDays = 1
+	}
+	return &SessionGenerator{repo: repo, notifier: notifier, horizonDays: horizonDays}
+}
+
+// window returns today and the last day of the horizon
+func (g *SessionGenerator) window() (time.Time, time.Time) {
+	now := models.WallClockNow()
+	from := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	return from, from.AddDate(0, 0, g.horizonDays)
+}
+
+// Sync materialises a single schedule, e.g. right after it was created or edited
+func (g *SessionGenerator) Sync(ctx context.Context, scheduleID int) (*models.SessionSyncResult, error) {
+	from, to := g.window()
+	result, err := g.repo.SyncSessions(ctx, scheduleID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	g.notifyCancelled(result.Cancelled)
+	return result, nil
+}
+
+// SyncAll materialises every schedule
+func (g *SessionGenerator) SyncAll(ctx context.Context) (*models.SessionSyncResult, error) {
+	from, to := g.window()
+	result, err := g.repo.SyncAllSessions(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	g.notifyCancelled(result.Cancelled)
+	return result, nil
+}
+
+// Remove deletes a schedule with its upcoming sessions
+func (g *SessionGenerator) Remove(ctx context.Context, scheduleID int) error {
+	cancelled, err := g.repo.Delete(ctx, scheduleID)
+	if err != nil {
+		return err
+	}
+	g.notifyCancelled(cancelled)
+	return nil
+}
+
+func (g *SessionGenerator) notifyCancelled(sessions []models.CancelledSession) {
+	for _, s := range sessions {
+		for _, athleteID := range s.AthleteIDs {
+			_, err := g.notifier.NotifyAthlete(athleteID, "session_cancelled", map[string]string{
+				"session": s.Title,
+				"date":    s.SessionDate.Format("02/01/2006 15:04"),
+			})
+			if err != nil {
+				log.Printf("⚠️ Could not queue session_cancelled notification for athlete %d: %v", athleteID, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Notification templates are a copy of the "notifications" section of
+// frontend/src/locales, so they use the same {{var}} placeholders; a test
+// fails when the two differ
+//
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+// DefaultLanguage is used for recipients without a known language and for
+// templates missing from a translation
+const DefaultLanguage = "fr"
+
+// MessageTemplate is the subject and body of one notification
+type MessageTemplate struct {
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+}
+
+// TemplateRegistry holds the notification templates of every language
+type TemplateRegistry struct {
+	templates map[string]map[string]MessageTemplate // language → key → template
+}
+
+// NewTemplateRegistry loads the embedded locale files
+func NewTemplateRegistry() (*TemplateRegistry, error) {
+	files, err := localeFiles.ReadDir("locales")
+	if err != nil {
+		return nil, err
+	}
+
+	reg := &TemplateRegistry{templates: map[string]map[string]MessageTemplate{}}
+	for _, f := range files {
+		data, err := localeFiles.ReadFile(path.Join("locales", f.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var locale struct {
+			Notifications map[string]MessageTemplate `json:"notifications"`
+		}
+		if err := json.Unmarshal(data, &locale); err != nil {
+			return nil, fmt.Errorf("locale %s: %w", f.Name(), err)
+		}
+		reg.templates[strings.TrimSuffix(f.Name(), ".json")] = locale.Notifications
+	}
+	if _, ok := reg.templates[DefaultLanguage]; !ok {
+		return nil, fmt.Errorf("missing %s notification templates", DefaultLanguage)
+	}
+	return reg, nil
+}
+
+// Languages returns the languages templates exist in
+func (t *TemplateRegistry) Languages() []string {
+	langs := make([]string, 0, len(t.templates))
+	for lang := range t.templates {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// Has reports whether a template exists in the default language
+func (t *TemplateRegistry) Has(key string) bool {
+	_, ok := t.templates[DefaultLanguage][key]
+	return ok
+}
+
+var placeholder = regexp.MustCompile(`\{\{\s*(\w+)\s*\}\}`)
+
+// interpolate replaces {{var}} placeholders with params; unknown ones are
+// left empty
+func interpolate(text string, params map[string]string) string {
+	return placeholder.ReplaceAllStringFunc(text, func(m string) string {
+		return params[placeholder.FindStringSubmatch(m)[1]]
+	})
+}
+
+// Render fills in a template in lang, falling back to the default language
+func (t *TemplateRegistry) Render(lang, key string, params map[string]string) (string, string, error) {
+	tmpl, ok := t.templates[lang][key]
+	if !ok {
+		if tmpl, ok = t.templates[DefaultLanguage][key]; !ok {
+			return "", "", fmt.Errorf("unknown notification template: %s", key)
+		}
+	}
+	return interpolate(tmpl.Subject, params), interpolate(tmpl.Body, params), nil
+}
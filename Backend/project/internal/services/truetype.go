@@ -0,0 +1,216 @@
+package services
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"unicode"
+)
+
+// trueTypeFont holds the metrics needed to embed a TrueType font in a PDF
+type trueTypeFont struct {
+	data       []byte
+	unitsPerEm int
+	ascent     int
+	descent    int
+	capHeight  int
+	bbox       [4]int
+	advances   []int
+	cmap       map[rune]uint16
+}
+
+// loadTrueType reads and parses a .ttf file
+func loadTrueType(path string) (*trueTypeFont, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseTrueType(data)
+}
+
+func parseTrueType(data []byte) (*trueTypeFont, error) {
+	if len(data) < 12 {
+		return nil, errors.New("font file too short")
+	}
+
+	tables := make(map[string][]byte)
+	numTables := int(binary.BigEndian.Uint16(data[4:]))
+	for i := 0; i < numTables; i++ {
+		rec := 12 + i*16
+		if rec+16 > len(data) {
+			return nil, errors.New("truncated table directory")
+		}
+		tag := string(data[rec : rec+4])
+		offset := int(binary.BigEndian.Uint32(data[rec+8:]))
+		length := int(binary.BigEndian.Uint32(data[rec+12:]))
+		if offset < 0 || length < 0 || offset+length > len(data) {
+			return nil, fmt.Errorf("table %s out of bounds", tag)
+		}
+		tables[tag] = data[offset : offset+length]
+	}
+
+	// Smallest size of each table for the fields read below
+	minSizes := map[string]int{"head": 44, "hhea": 36, "hmtx": 0, "cmap": 4, "maxp": 6}
+	for tag, size := range minSizes {
+		table, ok := tables[tag]
+		if !ok {
+			return nil, fmt.Errorf("missing %s table", tag)
+		}
+		if len(table) < size {
+			return nil, fmt.Errorf("%s table too short", tag)
+		}
+	}
+
+	f := &trueTypeFont{data: data}
+
+	head := tables["head"]
+	f.unitsPerEm = int(binary.BigEndian.Uint16(head[18:]))
+	if f.unitsPerEm == 0 {
+		return nil, errors.New("invalid unitsPerEm")
+	}
+	for i := 0; i < 4; i++ {
+		f.bbox[i] = int(int16(binary.BigEndian.Uint16(head[36+i*2:])))
+	}
+
+	hhea := tables["hhea"]
+	f.ascent = int(int16(binary.BigEndian.Uint16(hhea[4:])))
+	f.descent = int(int16(binary.BigEndian.Uint16(hhea[6:])))
+	numHMetrics := int(binary.BigEndian.Uint16(hhea[34:]))
+
+	f.capHeight = f.ascent
+	if os2, ok := tables["OS/2"]; ok && len(os2) >= 90 && binary.BigEndian.Uint16(os2) >= 2 {
+		f.capHeight = int(int16(binary.BigEndian.Uint16(os2[88:])))
+	}
+
+	numGlyphs := int(binary.BigEndian.Uint16(tables["maxp"][4:]))
+	hmtx := tables["hmtx"]
+	f.advances = make([]int, numGlyphs)
+	last := 0
+	for i := 0; i < numGlyphs; i++ {
+		if i < numHMetrics && i*4+2 <= len(hmtx) {
+			last = int(binary.BigEndian.Uint16(hmtx[i*4:]))
+		}
+		f.advances[i] = last
+	}
+
+	cmap, err := parseCmap(tables["cmap"])
+	if err != nil {
+		return nil, err
+	}
+	f.cmap = cmap
+
+	return f, nil
+}
+
+// parseCmap reads the Unicode character map (format 12 preferred, then format 4)
+func parseCmap(table []byte) (map[rune]uint16, error) {
+	if len(table) < 4 {
+		return nil, errors.New("cmap table too short")
+	}
+	numTables := int(binary.BigEndian.Uint16(table[2:]))
+	var format4, format12 []byte
+	for i := 0; i < numTables; i++ {
+		rec := 4 + i*8
+		if rec+8 > len(table) {
+			return nil, errors.New("truncated cmap directory")
+		}
+		platform := binary.BigEndian.Uint16(table[rec:])
+		encoding := binary.BigEndian.Uint16(table[rec+2:])
+		offset := int(binary.BigEndian.Uint32(table[rec+4:]))
+		if offset+2 > len(table) {
+			continue
+		}
+		sub := table[offset:]
+		isUnicode := platform == 0 || (platform == 3 && (encoding == 1 || encoding == 10))
+		if !isUnicode {
+			continue
+		}
+		switch binary.BigEndian.Uint16(sub) {
+		case 4:
+			if len(sub) >= 14 {
+				format4 = sub
+			}
+		case 12:
+			if len(sub) >= 16 {
+				format12 = sub
+			}
+		}
+	}
+
+	m := make(map[rune]uint16)
+	switch {
+	case format12 != nil:
+		nGroups := int(binary.BigEndian.Uint32(format12[12:]))
+		for g := 0; g < nGroups; g++ {
+			rec := 16 + g*12
+			if rec+12 > len(format12) {
+				return nil, errors.New("truncated cmap format 12")
+			}
+			start := binary.BigEndian.Uint32(format12[rec:])
+			end := binary.BigEndian.Uint32(format12[rec+4:])
+			glyph := binary.BigEndian.Uint32(format12[rec+8:])
+			if start > end || end > unicode.MaxRune {
+				continue
+			}
+			for c := start; c <= end; c++ {
+				m[rune(c)] = uint16(glyph + c - start)
+			}
+		}
+	case format4 != nil:
+		segCount := int(binary.BigEndian.Uint16(format4[6:])) / 2
+		endCodes := 14
+		startCodes := endCodes + segCount*2 + 2
+		idDeltas := startCodes + segCount*2
+		idRangeOffsets := idDeltas + segCount*2
+		if idRangeOffsets+segCount*2 > len(format4) {
+			return nil, errors.New("truncated cmap format 4")
+		}
+		for s := 0; s < segCount; s++ {
+			end := int(binary.BigEndian.Uint16(format4[endCodes+s*2:]))
+			start := int(binary.BigEndian.Uint16(format4[startCodes+s*2:]))
+			delta := int(binary.BigEndian.Uint16(format4[idDeltas+s*2:]))
+			rangeOffset := int(binary.BigEndian.Uint16(format4[idRangeOffsets+s*2:]))
+			for c := start; c <= end && c != 0xFFFF; c++ {
+				var glyph int
+				if rangeOffset == 0 {
+					glyph = (c + delta) & 0xFFFF
+				} else {
+					pos := idRangeOffsets + s*2 + rangeOffset + (c-start)*2
+					if pos+2 > len(format4) {
+						continue
+					}
+					glyph = int(binary.BigEndian.Uint16(format4[pos:]))
+					if glyph != 0 {
+						glyph = (glyph + delta) & 0xFFFF
+					}
+				}
+				if glyph != 0 {
+					m[rune(c)] = uint16(glyph)
+				}
+			}
+		}
+	default:
+		return nil, errors.New("no unicode cmap found")
+	}
+
+	return m, nil
+}
+
+// glyph returns the glyph index for r, or 0 (.notdef) if unsupported
+func (f *trueTypeFont) glyph(r rune) uint16 {
+	return f.cmap[r]
+}
+
+// width returns the advance width of glyph g in 1/1000 text space units
+func (f *trueTypeFont) width(g uint16) int {
+	if int(g) >= len(f.advances) {
+		return 0
+	}
+	return f.advances[g] * 1000 / f.unitsPerEm
+}
+
+// scale converts font units to 1/1000 text space units
+func (f *trueTypeFont) scale(v int) int {
+	return v * 1000 / f.unitsPerEm
+}
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+func be16(v int) []byte {
+	return []byte{byte(v >> 8), byte(v)}
+}
+
+func be32(v int) []byte {
+	return []byte{byte(v >> 24), byte(v >> 16), byte(v >> 8), byte(v)}
+}
+
+func concat(parts ...[]byte) []byte {
+	var out []byte
+	for _, p := range parts {
+		out = append(out, p...)
+	}
+	return out
+}
+
+// cmapFormat4 maps A-C to glyphs 1-3 through idDelta and alef to glyph 7
+// through the glyph id array
+func cmapFormat4() []byte {
+	segCount := 3
+	return concat(
+		be16(4), be16(0), be16(0), be16(segCount*2), be16(0), be16(0), be16(0),
+		be16(0x43), be16(0x0627), be16(0xFFFF), // endCode
+		be16(0),                                // reservedPad
+		be16(0x41), be16(0x0627), be16(0xFFFF), // startCode
+		be16((1-0x41)&0xFFFF), be16(0), be16(1), // idDelta
+		be16(0), be16(4), be16(0), // idRangeOffset: the second points at the glyph id array
+		be16(7), // glyphIdArray
+	)
+}
+
+// cmapFormat12 maps U+1F600-U+1F601 to glyphs 10-11
+func cmapFormat12() []byte {
+	return concat(be16(12), be16(0), be32(28), be32(0), be32(1), be32(0x1F600), be32(0x1F601), be32(10))
+}
+
+func cmapTable(platform, encoding int, sub []byte) []byte {
+	return concat(be16(0), be16(1), be16(platform), be16(encoding), be32(12), sub)
+}
+
+// buildFont assembles a minimal font: 12 glyphs, 1000 units per em, every
+// glyph 500 units wide
+func buildFont(cmap []byte) []byte {
+	head := make([]byte, 54)
+	copy(head[18:], be16(1000))
+	copy(head[36:], concat(be16(0), be16(0xFF38), be16(1000), be16(900))) // bbox 0 -200 1000 900
+	hhea := make([]byte, 36)
+	copy(hhea[4:], be16(800))
+	copy(hhea[6:], be16(0xFF38))
+	copy(hhea[34:], be16(1))
+	maxp := concat(be32(0x5000), be16(12))
+	hmtx := concat(be16(500), be16(0))
+
+	tables := []struct {
+		tag  string
+		data []byte
+	}{{"cmap", cmap}, {"head", head}, {"hhea", hhea}, {"hmtx", hmtx}, {"maxp", maxp}}
+	offset := 12 + 16*len(tables)
+	font := concat(be32(0x00010000), be16(len(tables)), be16(0), be16(0), be16(0))
+	var body []byte
+	for _, t := range tables {
+		font = append(font, t.tag...)
+		font = append(font, concat(be32(0), be32(offset+len(body)), be32(len(t.data)))...)
+		body = append(body, t.data...)
+	}
+	return append(font, body...)
+}
+
+func TestParseTrueTypeCmapFormat4(t *testing.T) {
+	f, err := parseTrueType(buildFont(cmapTable(3, 1, cmapFormat4())))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for r, want := range map[rune]uint16{'A': 1, 'B': 2, 'C': 3, 'ا': 7, 'D': 0, 0xFFFF: 0} {
+		if got := f.glyph(r); got != want {
+			t.Errorf("glyph(%U) = %d, want %d", r, got, want)
+		}
+	}
+	if f.unitsPerEm != 1000 || f.ascent != 800 || f.descent != -200 || f.bbox != [4]int{0, -200, 1000, 900} {
+		t.Errorf("metrics = %d %d %d %v", f.unitsPerEm, f.ascent, f.descent, f.bbox)
+	}
+	if got := f.width(11); got != 500 {
+		t.Errorf("width(11) = %d, want 500 (last hmtx advance)", got)
+	}
+	if got := f.width(12); got != 0 {
+		t.Errorf("width(12) = %d, want 0 past the last glyph", got)
+	}
+}
+
+func TestParseTrueTypeCmapFormat12(t *testing.T) {
+	f, err := parseTrueType(buildFont(cmapTable(0, 4, cmapFormat12())))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.glyph(0x1F600) != 10 || f.glyph(0x1F601) != 11 || f.glyph(0x1F602) != 0 {
+		t.Errorf("format 12 lookup: %v", f.cmap)
+	}
+}
+
+func TestParseTrueTypeRejectsNonUnicodeCmap(t *testing.T) {
+	if _, err := parseTrueType(buildFont(cmapTable(1, 0, cmapFormat4()))); err == nil {
+		t.Error("a Macintosh-only cmap was accepted")
+	}
+}
+
+// Every truncation and corrupted length must fail cleanly rather than panic
+func TestParseTrueTypeMalformed(t *testing.T) {
+	font := buildFont(cmapTable(3, 1, cmapFormat4()))
+	for n := 0; n < len(font); n++ {
+		parseTrueType(font[:n])
+	}
+
+	for i := 0; i+1 < len(font); i++ {
+		corrupt := append([]byte(nil), font...)
+		binary.BigEndian.PutUint16(corrupt[i:], 0xFFFF)
+		parseTrueType(corrupt)
+	}
+}
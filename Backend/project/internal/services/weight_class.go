@@ -0,0 +1,65 @@
+package services
+
+import (
+	"sort"
+	"time"
+
+	"east-eagles/backend/internal/models"
+)
+
+// ageAt returns the age in full years of someone born on dob at t
+func ageAt(dob time.Time, t time.Time) int {
+	age := t.Year() - dob.Year()
+	if t.Before(dob.AddDate(age, 0, 0)) {
+		age--
+	}
+	return age
+}
+
+// AgeGroupFor returns the age group someone born on dob belongs to at t, ""
+// when no group covers their age
+func AgeGroupFor(groups []models.AgeGroup, dob *time.Time, t time.Time) string {
+	if dob == nil {
+		return ""
+	}
+	age := ageAt(*dob, t)
+	for _, g := range groups {
+		if age >= g.MinAge && (g.MaxAge == nil || age <= *g.MaxAge) {
+			return g.Name
+		}
+	}
+	return ""
+}
+
+// WeightClassFor derives the weight class of an athlete weighing weight kg at
+// t: the lightest class of their gender and age group that the weight fits,
+// the open class above the limits. It returns "" when the table does not
+// cover the athlete (unknown gender or birth date, no matching age group).
+func WeightClassFor(table *models.WeightClassTable, gender string, dob *time.Time, weight float64, t time.Time) string {
+	group := AgeGroupFor(table.AgeGroups, dob, t)
+	if group == "" {
+		return ""
+	}
+
+	var classes []models.WeightClass
+	for _, c := range table.Classes {
+		if c.Gender == gender && c.AgeGroup == group {
+			classes = append(classes, c)
+		}
+	}
+	// Lightest first, the open class last
+	sort.Slice(classes, func(i, j int) bool {
+		a, b := classes[i].MaxKG, classes[j].MaxKG
+		if a == nil || b == nil {
+			return b == nil && a != nil
+		}
+		return *a < *b
+	})
+
+	for _, c := range classes {
+		if c.MaxKG == nil || weight <= *c.MaxKG {
+			return c.Name
+		}
+	}
+	return ""
+}
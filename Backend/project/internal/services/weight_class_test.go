@@ -0,0 +1,82 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"east-eagles/backend/internal/models"
+)
+
+func kg(v float64) *float64 { return &v }
+
+func years(v int) *int { return &v }
+
+func day(s string) *time.Time {
+	d, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		panic(err)
+	}
+	return &d
+}
+
+func TestAgeAt(t *testing.T) {
+	tests := []struct {
+		dob, at string
+		want    int
+	}{
+		{"2010-06-15", "2026-06-14", 15},
+		{"2010-06-15", "2026-06-15", 16},
+		{"2008-02-29", "2026-02-28", 17},
+		{"2008-02-29", "2026-03-01", 18},
+	}
+	for _, tt := range tests {
+		if got := ageAt(*day(tt.dob), *day(tt.at)); got != tt.want {
+			t.Errorf("ageAt(%s, %s) = %d, want %d", tt.dob, tt.at, got, tt.want)
+		}
+	}
+}
+
+func TestWeightClassFor(t *testing.T) {
+	table := &models.WeightClassTable{
+		AgeGroups: []models.AgeGroup{
+			{Name: "junior", MinAge: 15, MaxAge: years(17)},
+			{Name: "senior", MinAge: 18},
+		},
+		// Deliberately out of order: the open class and heavier classes first
+		Classes: []models.WeightClass{
+			{Gender: "male", AgeGroup: "senior", Name: "+90kg"},
+			{Gender: "male", AgeGroup: "senior", Name: "-90kg", MaxKG: kg(90)},
+			{Gender: "male", AgeGroup: "senior", Name: "-60kg", MaxKG: kg(60)},
+			{Gender: "male", AgeGroup: "senior", Name: "-70kg", MaxKG: kg(70)},
+			{Gender: "female", AgeGroup: "senior", Name: "-52kg", MaxKG: kg(52)},
+			{Gender: "female", AgeGroup: "senior", Name: "-60kg", MaxKG: kg(60)},
+			{Gender: "male", AgeGroup: "junior", Name: "-56kg", MaxKG: kg(56)},
+		},
+	}
+	on := *day("2026-05-01")
+	senior, junior, child := day("1995-01-01"), day("2010-01-01"), day("2016-01-01")
+
+	tests := []struct {
+		name   string
+		gender string
+		dob    *time.Time
+		weight float64
+		want   string
+	}{
+		{"lightest class that fits", "male", senior, 58.2, "-60kg"},
+		{"limit is inclusive", "male", senior, 60, "-60kg"},
+		{"just over the limit", "male", senior, 60.1, "-70kg"},
+		{"open class above the limits", "male", senior, 104, "+90kg"},
+		{"classes of the gender only", "female", senior, 55, "-60kg"},
+		{"no open class", "female", senior, 75, ""},
+		{"age group from the birth date", "male", junior, 50, "-56kg"},
+		{"no age group", "male", child, 30, ""},
+		{"unknown birth date", "male", nil, 70, ""},
+		{"unknown gender", "", senior, 70, ""},
+	}
+	for _, tt := range tests {
+		if got := WeightClassFor(table, tt.gender, tt.dob, tt.weight, on); got != tt.want {
+			t.Errorf("%s: WeightClassFor(%s, %.1f) = %q, want %q", tt.name, tt.gender, tt.weight, got, tt.want)
+		}
+	}
+}
@@ -1,8 +1,10 @@
 -- Migration to add document tagging system
 -- This migration adds support for document categories and tags
+-- Every statement is guarded so the file also runs where the original
+-- 003_documents_schema.sql or a manual run created some of these first.
 
 -- Create document_categories table
-CREATE TABLE document_categories (
+CREATE TABLE IF NOT EXISTS document_categories (
     id SERIAL PRIMARY KEY,
     name VARCHAR(100) NOT NULL UNIQUE,
     description TEXT,
@@ -11,7 +13,7 @@ CREATE TABLE document_categories (
 );
 
 -- Create document_tags table
-CREATE TABLE document_tags (
+CREATE TABLE IF NOT EXISTS document_tags (
     id SERIAL PRIMARY KEY,
     name VARCHAR(50) NOT NULL UNIQUE,
     color VARCHAR(7), -- HEX color code for UI
@@ -19,7 +21,7 @@ CREATE TABLE document_tags (
 );
 
 -- Create junction table for document-tag relationships
-CREATE TABLE document_tag_relations (
+CREATE TABLE IF NOT EXISTS document_tag_relations (
     document_id INTEGER NOT NULL REFERENCES documents(id) ON DELETE CASCADE,
     tag_id INTEGER NOT NULL REFERENCES document_tags(id) ON DELETE CASCADE,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
@@ -28,14 +30,14 @@ CREATE TABLE document_tag_relations (
 
 -- Add category_id to documents table
 ALTER TABLE documents 
-ADD COLUMN category_id INTEGER REFERENCES document_categories(id) ON DELETE SET NULL;
+ADD COLUMN IF NOT EXISTS category_id INTEGER REFERENCES document_categories(id) ON DELETE SET NULL;
 
 -- Add indexes for better performance
-CREATE INDEX idx_document_categories_name ON document_categories(name);
-CREATE INDEX idx_document_tags_name ON document_tags(name);
-CREATE INDEX idx_document_tag_relations_document_id ON document_tag_relations(document_id);
-CREATE INDEX idx_document_tag_relations_tag_id ON document_tag_relations(tag_id);
-CREATE INDEX idx_documents_category_id ON documents(category_id);
+CREATE INDEX IF NOT EXISTS idx_document_categories_name ON document_categories(name);
+CREATE INDEX IF NOT EXISTS idx_document_tags_name ON document_tags(name);
+CREATE INDEX IF NOT EXISTS idx_document_tag_relations_document_id ON document_tag_relations(document_id);
+CREATE INDEX IF NOT EXISTS idx_document_tag_relations_tag_id ON document_tag_relations(tag_id);
+CREATE INDEX IF NOT EXISTS idx_documents_category_id ON documents(category_id);
 
 -- Insert default categories
 INSERT INTO document_categories (name, description, color) VALUES
@@ -44,7 +46,8 @@ INSERT INTO document_categories (name, description, color) VALUES
 ('Personnel', 'Documents personnels et photos', '#45B7D1'),
 ('Financier', 'Documents liés aux paiements', '#96CEB4'),
 ('Formation', 'Documents de formation et certificats', '#FFEAA7'),
-('Autre', 'Autres documents', '#DDA0DD');
+('Autre', 'Autres documents', '#DDA0DD')
+ON CONFLICT (name) DO NOTHING;
 
 -- Insert default tags
 INSERT INTO document_tags (name, color) VALUES
@@ -53,4 +56,5 @@ INSERT INTO document_tags (name, color) VALUES
 ('Confidentiel', '#800080'),
 ('À vérifier', '#FFD700'),
 ('Complet', '#008000'),
-('Incomplet', '#FF0000');
\ No newline at end of file
+('Incomplet', '#FF0000')
+ON CONFLICT (name) DO NOTHING;
\ No newline at end of file
@@ -0,0 +1,8 @@
+-- Migration: 015_payment_coverage.down.sql
+-- Description: Revert 015_payment_coverage.sql; end_date is the day the next period starts again
+
+DROP INDEX IF EXISTS idx_payments_athlete_period;
+
+UPDATE payments
+SET end_date = end_date + 1
+WHERE end_date = (start_date + make_interval(months => months_covered))::date - 1;
@@ -0,0 +1,10 @@
+-- Migration: 015_payment_coverage.sql
+-- Description: Make payment end_date the last covered day (inclusive) and index coverage lookups
+
+-- Periods used to end on the same day the next one started (start + N months),
+-- which reads as a one-day overlap now that overlaps are rejected.
+UPDATE payments
+SET end_date = end_date - 1
+WHERE end_date = (start_date + make_interval(months => months_covered))::date;
+
+CREATE INDEX IF NOT EXISTS idx_payments_athlete_period ON payments(athlete_id, start_date, end_date);
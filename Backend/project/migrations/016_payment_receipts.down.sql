@@ -0,0 +1,10 @@
+-- Migration: 016_payment_receipts.down.sql
+-- Description: Revert 016_payment_receipts.sql; receipt numbers are lost
+
+DROP INDEX IF EXISTS idx_payments_receipt;
+
+ALTER TABLE payments DROP COLUMN IF EXISTS receipt_number;
+ALTER TABLE payments DROP COLUMN IF EXISTS receipt_seq;
+ALTER TABLE payments DROP COLUMN IF EXISTS receipt_year;
+
+DROP TABLE IF EXISTS receipt_sequences;
@@ -0,0 +1,35 @@
+-- Migration: 016_payment_receipts.sql
+-- Description: Sequential, gap-free receipt numbers per calendar year
+
+CREATE TABLE IF NOT EXISTS receipt_sequences (
+    year INTEGER PRIMARY KEY,
+    last_number INTEGER NOT NULL DEFAULT 0
+);
+
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS receipt_year INTEGER;
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS receipt_seq INTEGER;
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS receipt_number VARCHAR(20);
+
+-- Number existing payments in chronological order within their year
+WITH numbered AS (
+    SELECT id,
+           EXTRACT(YEAR FROM payment_date)::INTEGER AS year,
+           ROW_NUMBER() OVER (PARTITION BY EXTRACT(YEAR FROM payment_date) ORDER BY payment_date, id) AS seq
+    FROM payments
+    WHERE receipt_number IS NULL
+)
+UPDATE payments p
+SET receipt_year = n.year,
+    receipt_seq = n.seq,
+    receipt_number = n.year || '-' || LPAD(n.seq::TEXT, 5, '0')
+FROM numbered n
+WHERE p.id = n.id;
+
+INSERT INTO receipt_sequences (year, last_number)
+SELECT receipt_year, MAX(receipt_seq)
+FROM payments
+WHERE receipt_year IS NOT NULL
+GROUP BY receipt_year
+ON CONFLICT (year) DO UPDATE SET last_number = GREATEST(receipt_sequences.last_number, EXCLUDED.last_number);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_payments_receipt ON payments(receipt_year, receipt_seq);
@@ -0,0 +1,22 @@
+-- Migration: 017_payment_methods_refunds.down.sql
+-- Description: Revert 017_payment_methods_refunds.sql
+
+-- Installments and refunds would turn into full payments without their kind
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM payments WHERE kind <> 'period' OR status <> 'active') THEN
+        RAISE EXCEPTION 'payments contain installments or refunds; remove them before reverting 017';
+    END IF;
+END $$;
+
+DROP INDEX IF EXISTS idx_payments_method;
+DROP INDEX IF EXISTS idx_payments_parent;
+
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS payments_parent_kind_check;
+
+ALTER TABLE payments DROP COLUMN IF EXISTS amount_due;
+ALTER TABLE payments DROP COLUMN IF EXISTS status;
+ALTER TABLE payments DROP COLUMN IF EXISTS parent_payment_id;
+ALTER TABLE payments DROP COLUMN IF EXISTS kind;
+ALTER TABLE payments DROP COLUMN IF EXISTS external_reference;
+ALTER TABLE payments DROP COLUMN IF EXISTS method;
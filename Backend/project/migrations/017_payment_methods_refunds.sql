@@ -0,0 +1,27 @@
+-- Migration: 017_payment_methods_refunds.sql
+-- Description: Payment methods, external references, partial payments (installments) and refunds
+
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS method VARCHAR(20) NOT NULL DEFAULT 'cash'
+    CHECK (method IN ('cash', 'bank_transfer', 'ccp', 'card'));
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS external_reference VARCHAR(100);
+
+-- 'period' opens a coverage period; 'installment' and 'refund' rows point to it
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS kind VARCHAR(20) NOT NULL DEFAULT 'period'
+    CHECK (kind IN ('period', 'installment', 'refund'));
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS parent_payment_id INTEGER REFERENCES payments(id) ON DELETE RESTRICT;
+
+-- A refunded period no longer grants coverage
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'active'
+    CHECK (status IN ('active', 'refunded'));
+
+-- Full price of a period; amount is what was collected with the first payment.
+-- Refund rows store a negative amount.
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS amount_due DECIMAL(10, 2);
+UPDATE payments SET amount_due = amount WHERE kind = 'period' AND amount_due IS NULL;
+
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS payments_parent_kind_check;
+ALTER TABLE payments ADD CONSTRAINT payments_parent_kind_check
+    CHECK ((kind = 'period') = (parent_payment_id IS NULL));
+
+CREATE INDEX IF NOT EXISTS idx_payments_parent ON payments(parent_payment_id);
+CREATE INDEX IF NOT EXISTS idx_payments_method ON payments(method);
@@ -0,0 +1,5 @@
+-- Migration: 018_payment_checkouts.down.sql
+-- Description: Revert 018_payment_checkouts.sql; payments made online are kept
+
+DROP TABLE IF EXISTS payment_webhook_events;
+DROP TABLE IF EXISTS payment_checkouts;
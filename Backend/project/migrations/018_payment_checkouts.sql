@@ -0,0 +1,34 @@
+-- Migration: 018_payment_checkouts.sql
+-- Description: Online payment checkouts and processed provider webhooks
+
+CREATE TABLE IF NOT EXISTS payment_checkouts (
+    id SERIAL PRIMARY KEY,
+    athlete_id INTEGER NOT NULL REFERENCES athletes(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    provider VARCHAR(50) NOT NULL,
+    session_id VARCHAR(255),
+    checkout_url TEXT,
+    idempotency_key VARCHAR(255) NOT NULL UNIQUE,
+    amount DECIMAL(10, 2) NOT NULL,
+    months_covered INTEGER NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'paid', 'failed', 'canceled')),
+    success_url TEXT,
+    cancel_url TEXT,
+    payment_id INTEGER REFERENCES payments(id),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    completed_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_payment_checkouts_session ON payment_checkouts(provider, session_id);
+CREATE INDEX IF NOT EXISTS idx_payment_checkouts_user ON payment_checkouts(user_id);
+
+-- Each provider event is applied once, even if the webhook is delivered again
+CREATE TABLE IF NOT EXISTS payment_webhook_events (
+    provider VARCHAR(50) NOT NULL,
+    event_id VARCHAR(255) NOT NULL,
+    session_id VARCHAR(255) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    received_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (provider, event_id)
+);
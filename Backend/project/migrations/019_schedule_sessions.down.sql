@@ -0,0 +1,13 @@
+-- Migration: 019_schedule_sessions.down.sql
+-- Description: Revert 019_schedule_sessions.sql; generated sessions stay as one-off sessions
+
+DROP TABLE IF EXISTS training_closures;
+
+DROP INDEX IF EXISTS idx_training_sessions_schedule_occurrence;
+
+ALTER TABLE training_sessions DROP COLUMN IF EXISTS detached;
+ALTER TABLE training_sessions DROP COLUMN IF EXISTS occurrence_date;
+ALTER TABLE training_sessions DROP COLUMN IF EXISTS schedule_id;
+
+ALTER TABLE training_schedules DROP COLUMN IF EXISTS max_participants;
+ALTER TABLE training_schedules DROP COLUMN IF EXISTS level;
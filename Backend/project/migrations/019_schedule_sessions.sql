@@ -0,0 +1,30 @@
+-- Migration: 019_schedule_sessions.sql
+-- Description: Link training sessions to the weekly schedule they were generated from, and add closures
+
+-- Defaults copied onto generated sessions
+ALTER TABLE training_schedules ADD COLUMN IF NOT EXISTS level VARCHAR(50) NOT NULL DEFAULT 'all';
+ALTER TABLE training_schedules ADD COLUMN IF NOT EXISTS max_participants INTEGER;
+
+-- schedule_id + occurrence_date identify the slot a session materialises.
+-- detached sessions were edited by hand and no longer follow their schedule.
+ALTER TABLE training_sessions ADD COLUMN IF NOT EXISTS schedule_id INTEGER REFERENCES training_schedules(id) ON DELETE SET NULL;
+ALTER TABLE training_sessions ADD COLUMN IF NOT EXISTS occurrence_date DATE;
+ALTER TABLE training_sessions ADD COLUMN IF NOT EXISTS detached BOOLEAN NOT NULL DEFAULT false;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_training_sessions_schedule_occurrence
+    ON training_sessions(schedule_id, occurrence_date);
+
+-- Days without training: club-wide holidays (schedule_id NULL) or
+-- exception dates for a single schedule
+CREATE TABLE IF NOT EXISTS training_closures (
+    id SERIAL PRIMARY KEY,
+    schedule_id INTEGER REFERENCES training_schedules(id) ON DELETE CASCADE,
+    start_date DATE NOT NULL,
+    end_date DATE NOT NULL,
+    reason VARCHAR(200),
+    created_by INTEGER REFERENCES users(id),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CHECK (end_date >= start_date)
+);
+
+CREATE INDEX IF NOT EXISTS idx_training_closures_dates ON training_closures(start_date, end_date);
@@ -0,0 +1,9 @@
+-- Migration: 020_schedule_recurrence.down.sql
+-- Description: Revert 020_schedule_recurrence.sql; day names stay canonical
+
+ALTER TABLE training_schedules DROP CONSTRAINT IF EXISTS training_schedules_validity_check;
+
+ALTER TABLE training_schedules DROP COLUMN IF EXISTS exdates;
+ALTER TABLE training_schedules DROP COLUMN IF EXISTS valid_until;
+ALTER TABLE training_schedules DROP COLUMN IF EXISTS valid_from;
+ALTER TABLE training_schedules DROP COLUMN IF EXISTS rrule;
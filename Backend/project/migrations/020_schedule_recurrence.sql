@@ -0,0 +1,27 @@
+-- Migration: 020_schedule_recurrence.sql
+-- Description: RFC 5545 recurrence rules, validity windows and exception dates for schedules
+
+ALTER TABLE training_schedules ADD COLUMN IF NOT EXISTS rrule TEXT;
+ALTER TABLE training_schedules ADD COLUMN IF NOT EXISTS valid_from DATE;
+ALTER TABLE training_schedules ADD COLUMN IF NOT EXISTS valid_until DATE;
+ALTER TABLE training_schedules ADD COLUMN IF NOT EXISTS exdates DATE[] NOT NULL DEFAULT '{}';
+
+ALTER TABLE training_schedules DROP CONSTRAINT IF EXISTS training_schedules_validity_check;
+ALTER TABLE training_schedules ADD CONSTRAINT training_schedules_validity_check
+    CHECK (valid_until IS NULL OR valid_from IS NULL OR valid_until >= valid_from);
+
+-- Canonical day names ("monday " -> "Monday")
+UPDATE training_schedules SET day_of_week = initcap(lower(trim(day_of_week)));
+
+-- Existing slots become plain weekly rules
+UPDATE training_schedules
+SET rrule = 'FREQ=WEEKLY;BYDAY=' || CASE day_of_week
+    WHEN 'Monday' THEN 'MO'
+    WHEN 'Tuesday' THEN 'TU'
+    WHEN 'Wednesday' THEN 'WE'
+    WHEN 'Thursday' THEN 'TH'
+    WHEN 'Friday' THEN 'FR'
+    WHEN 'Saturday' THEN 'SA'
+    WHEN 'Sunday' THEN 'SU'
+END
+WHERE rrule IS NULL;
@@ -0,0 +1,4 @@
+-- Migration: 021_calendar_tokens.down.sql
+-- Description: Revert 021_calendar_tokens.sql
+
+DROP TABLE IF EXISTS calendar_tokens;
@@ -0,0 +1,8 @@
+-- Migration: 021_calendar_tokens.sql
+-- Description: Per-user secret tokens for subscribing to iCalendar feeds without a JWT
+
+CREATE TABLE IF NOT EXISTS calendar_tokens (
+    user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    token VARCHAR(64) NOT NULL UNIQUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,11 @@
+-- Migration: 022_venues.down.sql
+-- Description: Revert 022_venues.sql; the location text of schedules and sessions is kept
+
+DROP INDEX IF EXISTS idx_training_sessions_coach;
+DROP INDEX IF EXISTS idx_training_sessions_venue;
+
+ALTER TABLE training_sessions DROP COLUMN IF EXISTS venue_id;
+ALTER TABLE training_schedules DROP COLUMN IF EXISTS coach_id;
+ALTER TABLE training_schedules DROP COLUMN IF EXISTS venue_id;
+
+DROP TABLE IF EXISTS venues;
@@ -0,0 +1,36 @@
+-- Migration: 022_venues.sql
+-- Description: Venues with capacity, referenced by schedules and sessions, and coaches on schedules
+
+CREATE TABLE IF NOT EXISTS venues (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(200) NOT NULL,
+    capacity INTEGER CHECK (capacity IS NULL OR capacity > 0),
+    address TEXT,
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_venues_name ON venues(lower(name));
+
+-- Every location typed so far becomes a venue
+INSERT INTO venues (name)
+SELECT DISTINCT trim(location) FROM (
+    SELECT location FROM training_schedules
+    UNION
+    SELECT location FROM training_sessions
+) l
+WHERE location IS NOT NULL AND trim(location) <> ''
+ON CONFLICT DO NOTHING;
+
+ALTER TABLE training_schedules ADD COLUMN IF NOT EXISTS venue_id INTEGER REFERENCES venues(id) ON DELETE SET NULL;
+ALTER TABLE training_schedules ADD COLUMN IF NOT EXISTS coach_id INTEGER REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE training_sessions ADD COLUMN IF NOT EXISTS venue_id INTEGER REFERENCES venues(id) ON DELETE SET NULL;
+
+UPDATE training_schedules s SET venue_id = v.id
+FROM venues v WHERE s.venue_id IS NULL AND lower(trim(s.location)) = lower(v.name);
+
+UPDATE training_sessions s SET venue_id = v.id
+FROM venues v WHERE s.venue_id IS NULL AND lower(trim(s.location)) = lower(v.name);
+
+CREATE INDEX IF NOT EXISTS idx_training_sessions_venue ON training_sessions(venue_id, session_date);
+CREATE INDEX IF NOT EXISTS idx_training_sessions_coach ON training_sessions(coach_id, session_date);
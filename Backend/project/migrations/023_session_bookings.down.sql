@@ -0,0 +1,4 @@
+-- Migration: 023_session_bookings.down.sql
+-- Description: Revert 023_session_bookings.sql
+
+DROP TABLE IF EXISTS session_bookings;
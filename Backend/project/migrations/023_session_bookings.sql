@@ -0,0 +1,17 @@
+-- Migration: 023_session_bookings.sql
+-- Description: Athlete bookings for training sessions, with a waitlist once a session is full
+
+CREATE TABLE IF NOT EXISTS session_bookings (
+    id SERIAL PRIMARY KEY,
+    training_session_id INTEGER NOT NULL REFERENCES training_sessions(id) ON DELETE CASCADE,
+    athlete_id INTEGER NOT NULL REFERENCES athletes(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL DEFAULT 'booked'
+        CHECK (status IN ('booked', 'waitlisted', 'cancelled')),
+    booked_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP, -- Also orders the waitlist
+    promoted_at TIMESTAMP,
+    cancelled_at TIMESTAMP,
+    UNIQUE (training_session_id, athlete_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_session_bookings_session ON session_bookings(training_session_id, status, booked_at);
+CREATE INDEX IF NOT EXISTS idx_session_bookings_athlete ON session_bookings(athlete_id, status);
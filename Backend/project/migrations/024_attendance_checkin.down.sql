@@ -0,0 +1,8 @@
+-- Migration: 024_attendance_checkin.down.sql
+-- Description: Revert 024_attendance_checkin.sql; self check-ins stay as plain attendance
+
+ALTER TABLE attendance DROP COLUMN IF EXISTS check_in_method;
+
+ALTER TABLE venues DROP COLUMN IF EXISTS checkin_radius_m;
+ALTER TABLE venues DROP COLUMN IF EXISTS longitude;
+ALTER TABLE venues DROP COLUMN IF EXISTS latitude;
@@ -0,0 +1,11 @@
+-- Migration: 024_attendance_checkin.sql
+-- Description: Venue coordinates for QR self check-in, and how each attendance was recorded
+
+ALTER TABLE venues ADD COLUMN IF NOT EXISTS latitude DOUBLE PRECISION;
+ALTER TABLE venues ADD COLUMN IF NOT EXISTS longitude DOUBLE PRECISION;
+ALTER TABLE venues ADD COLUMN IF NOT EXISTS checkin_radius_m INTEGER NOT NULL DEFAULT 150
+    CHECK (checkin_radius_m > 0);
+
+-- 'coach' rows were marked by staff and are never overwritten by a self check-in
+ALTER TABLE attendance ADD COLUMN IF NOT EXISTS check_in_method VARCHAR(20) NOT NULL DEFAULT 'coach'
+    CHECK (check_in_method IN ('coach', 'qr'));
@@ -0,0 +1,7 @@
+-- Migration: 025_user_athlete_link.down.sql
+-- Description: Revert 025_user_athlete_link.sql; accounts are matched to athletes by email again
+-- users.athlete_id is kept: databases built with 002_sanda_club_schema.sql
+-- had it before 025, which only added it where it was missing. The links
+-- backfilled by 025 stay in place.
+
+DROP INDEX IF EXISTS idx_users_athlete_id;
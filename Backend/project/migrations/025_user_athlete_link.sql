@@ -0,0 +1,49 @@
+-- Migration: 025_user_athlete_link.sql
+-- Description: Link user accounts to their athlete profile by id instead of matching emails
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS athlete_id INTEGER REFERENCES athletes(id) ON DELETE SET NULL;
+
+-- An athlete profile belongs to at most one account
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_athlete_id ON users(athlete_id) WHERE athlete_id IS NOT NULL;
+
+-- Backfill: link each account to the athlete with the same email, but only
+-- when the match is one-to-one
+WITH matches AS (
+    SELECT u.id AS user_id, a.id AS athlete_id
+    FROM users u
+    JOIN athletes a ON lower(trim(a.email)) = lower(trim(u.email))
+    WHERE u.athlete_id IS NULL
+      AND NOT EXISTS (SELECT 1 FROM users o WHERE o.athlete_id = a.id)
+),
+unique_matches AS (
+    SELECT m.user_id, m.athlete_id
+    FROM matches m
+    WHERE (SELECT COUNT(*) FROM matches x WHERE x.user_id = m.user_id) = 1
+      AND (SELECT COUNT(*) FROM matches x WHERE x.athlete_id = m.athlete_id) = 1
+)
+UPDATE users u
+SET athlete_id = um.athlete_id
+FROM unique_matches um
+WHERE u.id = um.user_id;
+
+-- Report athlete accounts left unlinked; admins resolve them with
+-- POST /api/admin/users/{id}/link (GET /api/admin/users/unlinked lists them)
+DO $$
+DECLARE
+    r RECORD;
+BEGIN
+    FOR r IN
+        SELECT u.id, u.email,
+               array_agg(a.id ORDER BY a.id) FILTER (WHERE a.id IS NOT NULL) AS candidates
+        FROM users u
+        LEFT JOIN athletes a ON lower(trim(a.email)) = lower(trim(u.email))
+        WHERE u.role = 'athlete' AND u.athlete_id IS NULL
+        GROUP BY u.id, u.email
+    LOOP
+        IF r.candidates IS NULL THEN
+            RAISE WARNING 'user % (%) has no athlete profile with this email', r.id, r.email;
+        ELSE
+            RAISE WARNING 'user % (%) is ambiguous: athletes % share this email or are already linked', r.id, r.email, r.candidates;
+        END IF;
+    END LOOP;
+END $$;
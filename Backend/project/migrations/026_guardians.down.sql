@@ -0,0 +1,15 @@
+-- Migration: 026_guardians.down.sql
+-- Description: Revert 026_guardians.sql
+
+-- Guardian accounts have no role to fall back to
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM users WHERE role = 'guardian') THEN
+        RAISE EXCEPTION 'guardian accounts exist; remove them before reverting 026';
+    END IF;
+END $$;
+
+DROP TABLE IF EXISTS athlete_guardians;
+
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_role_check;
+ALTER TABLE users ADD CONSTRAINT users_role_check CHECK (role IN ('admin', 'coach', 'athlete'));
@@ -0,0 +1,17 @@
+-- Migration: 026_guardians.sql
+-- Description: Guardian accounts managing one or more minor athletes
+
+-- Allow the guardian role on databases created with the role CHECK constraint
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_role_check;
+ALTER TABLE users ADD CONSTRAINT users_role_check CHECK (role IN ('admin', 'coach', 'athlete', 'guardian'));
+
+CREATE TABLE IF NOT EXISTS athlete_guardians (
+    athlete_id INTEGER NOT NULL REFERENCES athletes(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    relation VARCHAR(50) NOT NULL DEFAULT 'parent', -- 'parent', 'legal_guardian', 'other'
+    is_primary BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (athlete_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_athlete_guardians_user ON athlete_guardians(user_id);
@@ -0,0 +1,5 @@
+-- Migration: 027_competitions.down.sql
+-- Description: Revert 027_competitions.sql; events and registrations are dropped, as before 027
+
+DROP TABLE IF EXISTS event_registrations;
+DROP TABLE IF EXISTS events;
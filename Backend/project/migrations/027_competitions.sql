@@ -0,0 +1,57 @@
+-- Migration: 027_competitions.sql
+-- Description: Revive events as Sanda competitions with eligibility rules and athlete registrations
+
+CREATE TABLE IF NOT EXISTS events (
+    id SERIAL PRIMARY KEY,
+    title VARCHAR(200) NOT NULL,
+    description TEXT,
+    date TIMESTAMP NOT NULL,
+    location VARCHAR(200),
+    image_url TEXT,
+    max_participants INTEGER DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE events ADD COLUMN IF NOT EXISTS event_type VARCHAR(20) NOT NULL DEFAULT 'competition'
+    CHECK (event_type IN ('competition', 'seminar', 'other'));
+ALTER TABLE events ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'open'
+    CHECK (status IN ('open', 'closed', 'cancelled'));
+ALTER TABLE events ADD COLUMN IF NOT EXISTS registration_deadline TIMESTAMP; -- NULL: until the event starts
+-- Empty lists accept every category / belt and require no document
+ALTER TABLE events ADD COLUMN IF NOT EXISTS weight_categories TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE events ADD COLUMN IF NOT EXISTS belt_levels TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE events ADD COLUMN IF NOT EXISTS required_documents TEXT[] NOT NULL DEFAULT '{}'; -- document_type values
+ALTER TABLE events ADD COLUMN IF NOT EXISTS created_by INTEGER REFERENCES users(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_events_date ON events(date);
+
+-- The legacy registrations point at the dropped members table through member_id
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name='event_registrations' AND column_name='member_id') THEN
+        ALTER TABLE event_registrations DROP CONSTRAINT IF EXISTS event_registrations_member_id_fkey;
+        ALTER TABLE event_registrations RENAME COLUMN member_id TO athlete_id;
+        DELETE FROM event_registrations r WHERE NOT EXISTS (SELECT 1 FROM athletes a WHERE a.id = r.athlete_id);
+        ALTER TABLE event_registrations ADD CONSTRAINT event_registrations_athlete_id_fkey
+            FOREIGN KEY (athlete_id) REFERENCES athletes(id) ON DELETE CASCADE;
+    END IF;
+END $$;
+
+CREATE TABLE IF NOT EXISTS event_registrations (
+    id SERIAL PRIMARY KEY,
+    event_id INTEGER NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+    athlete_id INTEGER NOT NULL REFERENCES athletes(id) ON DELETE CASCADE,
+    registered_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (event_id, athlete_id)
+);
+
+ALTER TABLE event_registrations ADD COLUMN IF NOT EXISTS weight_category VARCHAR(50) NOT NULL DEFAULT '';
+ALTER TABLE event_registrations ADD COLUMN IF NOT EXISTS belt_level VARCHAR(50) NOT NULL DEFAULT '';
+ALTER TABLE event_registrations ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'registered'
+    CHECK (status IN ('registered', 'withdrawn'));
+ALTER TABLE event_registrations ADD COLUMN IF NOT EXISTS nominated_by INTEGER REFERENCES users(id) ON DELETE SET NULL; -- Coach who entered the athlete
+ALTER TABLE event_registrations ADD COLUMN IF NOT EXISTS withdrawn_at TIMESTAMP;
+
+ALTER INDEX IF EXISTS idx_event_registrations_member_id RENAME TO idx_event_registrations_athlete_id;
+CREATE INDEX IF NOT EXISTS idx_event_registrations_athlete_id ON event_registrations(athlete_id);
+CREATE INDEX IF NOT EXISTS idx_event_registrations_event_id ON event_registrations(event_id, status);
@@ -0,0 +1,8 @@
+-- Migration: 028_brackets.down.sql
+-- Description: Revert 028_brackets.sql
+
+ALTER TABLE event_registrations DROP COLUMN IF EXISTS placing;
+
+DROP TABLE IF EXISTS bouts;
+DROP TABLE IF EXISTS bracket_entries;
+DROP TABLE IF EXISTS brackets;
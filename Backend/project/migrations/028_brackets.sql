@@ -0,0 +1,46 @@
+-- Migration: 028_brackets.sql
+-- Description: Competition brackets per weight category and gender, bouts and final placings
+
+CREATE TABLE IF NOT EXISTS brackets (
+    id SERIAL PRIMARY KEY,
+    event_id INTEGER NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+    weight_category VARCHAR(50) NOT NULL DEFAULT '',
+    gender VARCHAR(20) NOT NULL DEFAULT '',
+    format VARCHAR(20) NOT NULL CHECK (format IN ('single_elimination', 'round_robin')),
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'completed')),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (event_id, weight_category, gender)
+);
+
+CREATE TABLE IF NOT EXISTS bracket_entries (
+    bracket_id INTEGER NOT NULL REFERENCES brackets(id) ON DELETE CASCADE,
+    athlete_id INTEGER NOT NULL REFERENCES athletes(id) ON DELETE CASCADE,
+    seed INTEGER NOT NULL,
+    placing INTEGER, -- Set once every bout of the bracket is decided
+    PRIMARY KEY (bracket_id, athlete_id)
+);
+
+CREATE TABLE IF NOT EXISTS bouts (
+    id SERIAL PRIMARY KEY,
+    bracket_id INTEGER NOT NULL REFERENCES brackets(id) ON DELETE CASCADE,
+    round INTEGER NOT NULL,
+    position INTEGER NOT NULL,
+    red_athlete_id INTEGER REFERENCES athletes(id) ON DELETE SET NULL,
+    blue_athlete_id INTEGER REFERENCES athletes(id) ON DELETE SET NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'completed', 'bye')),
+    winner_athlete_id INTEGER REFERENCES athletes(id) ON DELETE SET NULL,
+    method VARCHAR(20) CHECK (method IN ('points', 'ko', 'tko', 'disqualification', 'walkover')),
+    red_rounds_won INTEGER NOT NULL DEFAULT 0,
+    blue_rounds_won INTEGER NOT NULL DEFAULT 0,
+    red_points INTEGER NOT NULL DEFAULT 0,
+    blue_points INTEGER NOT NULL DEFAULT 0,
+    next_bout_id INTEGER REFERENCES bouts(id) ON DELETE SET NULL, -- Where the winner goes
+    next_slot VARCHAR(4) CHECK (next_slot IN ('red', 'blue')),
+    completed_at TIMESTAMP,
+    UNIQUE (bracket_id, round, position)
+);
+
+CREATE INDEX IF NOT EXISTS idx_bouts_bracket ON bouts(bracket_id, round, position);
+
+-- Final placing copied onto the athlete's registration
+ALTER TABLE event_registrations ADD COLUMN IF NOT EXISTS placing INTEGER;
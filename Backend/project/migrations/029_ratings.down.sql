@@ -0,0 +1,17 @@
+-- Migration: 029_ratings.down.sql
+-- Description: Revert 029_ratings.sql
+
+-- The restored method check has no 'draw'
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM bouts WHERE method = 'draw') THEN
+        RAISE EXCEPTION 'bouts were drawn; change their result before reverting 029';
+    END IF;
+END $$;
+
+DROP TABLE IF EXISTS rating_history;
+DROP TABLE IF EXISTS athlete_ratings;
+
+ALTER TABLE bouts DROP CONSTRAINT IF EXISTS bouts_method_check;
+ALTER TABLE bouts ADD CONSTRAINT bouts_method_check
+    CHECK (method IN ('points', 'ko', 'tko', 'disqualification', 'walkover'));
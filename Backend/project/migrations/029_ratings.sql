@@ -0,0 +1,24 @@
+-- Migration: 029_ratings.sql
+-- Description: Draws in round-robin bouts and Elo-style athlete ratings rebuilt from bout results
+
+ALTER TABLE bouts DROP CONSTRAINT IF EXISTS bouts_method_check;
+ALTER TABLE bouts ADD CONSTRAINT bouts_method_check
+    CHECK (method IN ('points', 'ko', 'tko', 'disqualification', 'walkover', 'draw'));
+
+CREATE TABLE IF NOT EXISTS athlete_ratings (
+    athlete_id INTEGER PRIMARY KEY REFERENCES athletes(id) ON DELETE CASCADE,
+    rating INTEGER NOT NULL DEFAULT 1500,
+    bouts INTEGER NOT NULL DEFAULT 0, -- Rated bouts (walkovers and byes do not count)
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS rating_history (
+    athlete_id INTEGER NOT NULL REFERENCES athletes(id) ON DELETE CASCADE,
+    bout_id INTEGER NOT NULL REFERENCES bouts(id) ON DELETE CASCADE,
+    rating_before INTEGER NOT NULL,
+    rating_after INTEGER NOT NULL,
+    rated_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (athlete_id, bout_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_athlete_ratings_rating ON athlete_ratings(rating DESC);
@@ -0,0 +1,6 @@
+-- Migration: 030_weigh_ins.down.sql
+-- Description: Revert 030_weigh_ins.sql
+
+DROP TABLE IF EXISTS weigh_ins;
+DROP TABLE IF EXISTS weight_classes;
+DROP TABLE IF EXISTS age_groups;
@@ -0,0 +1,55 @@
+-- Migration: 030_weigh_ins.sql
+-- Description: Weigh-ins and configurable Sanda weight classes by gender and age group
+
+CREATE TABLE IF NOT EXISTS age_groups (
+    name VARCHAR(30) PRIMARY KEY,
+    min_age INTEGER NOT NULL,
+    max_age INTEGER, -- NULL: no upper limit
+    CHECK (max_age IS NULL OR max_age >= min_age)
+);
+
+CREATE TABLE IF NOT EXISTS weight_classes (
+    id SERIAL PRIMARY KEY,
+    gender VARCHAR(10) NOT NULL CHECK (gender IN ('male', 'female')),
+    age_group VARCHAR(30) NOT NULL REFERENCES age_groups(name) ON UPDATE CASCADE ON DELETE CASCADE,
+    name VARCHAR(50) NOT NULL,
+    max_kg NUMERIC(5,2), -- Upper limit, inclusive; NULL for the open class
+    UNIQUE (gender, age_group, name)
+);
+
+CREATE TABLE IF NOT EXISTS weigh_ins (
+    id SERIAL PRIMARY KEY,
+    athlete_id INTEGER NOT NULL REFERENCES athletes(id) ON DELETE CASCADE,
+    weight_kg NUMERIC(5,2) NOT NULL CHECK (weight_kg > 0),
+    weighed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    official BOOLEAN NOT NULL DEFAULT TRUE, -- Only official weigh-ins set the athlete's category
+    event_id INTEGER REFERENCES events(id) ON DELETE SET NULL,
+    weight_category VARCHAR(50) NOT NULL DEFAULT '', -- Class derived at the time
+    recorded_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    notes TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_weigh_ins_athlete ON weigh_ins(athlete_id, weighed_at DESC);
+
+-- IWUF Sanda classes as a starting point; the club adjusts them in the admin
+INSERT INTO age_groups (name, min_age, max_age) VALUES
+    ('junior', 15, 17),
+    ('senior', 18, NULL)
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO weight_classes (gender, age_group, name, max_kg)
+SELECT g.gender, a.name, c.name, c.max_kg
+FROM (VALUES ('junior'), ('senior')) AS a(name)
+CROSS JOIN (VALUES ('male'), ('female')) AS g(gender)
+CROSS JOIN (VALUES
+    ('48kg', 48), ('52kg', 52), ('56kg', 56), ('60kg', 60), ('65kg', 65),
+    ('70kg', 70), ('75kg', 75), ('80kg', 80), ('85kg', 85), ('90kg', 90)
+) AS c(name, max_kg)
+WHERE g.gender = 'male' OR c.max_kg <= 75
+ON CONFLICT (gender, age_group, name) DO NOTHING;
+
+INSERT INTO weight_classes (gender, age_group, name, max_kg) VALUES
+    ('male', 'junior', '+90kg', NULL), ('male', 'senior', '+90kg', NULL),
+    ('female', 'junior', '+75kg', NULL), ('female', 'senior', '+75kg', NULL)
+ON CONFLICT (gender, age_group, name) DO NOTHING;
@@ -0,0 +1,7 @@
+-- Migration: 031_belt_grading.down.sql
+-- Description: Revert 031_belt_grading.sql
+
+DROP TABLE IF EXISTS belt_promotions;
+DROP TABLE IF EXISTS grading_candidates;
+DROP TABLE IF EXISTS grading_sessions;
+DROP TABLE IF EXISTS belts;
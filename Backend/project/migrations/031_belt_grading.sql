@@ -0,0 +1,64 @@
+-- Migration: 031_belt_grading.sql
+-- Description: Ordered belts, grading exam sessions, candidates and promotion history
+
+CREATE TABLE IF NOT EXISTS belts (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(50) NOT NULL UNIQUE,
+    rank INTEGER NOT NULL UNIQUE, -- 1 is the first grade
+    color VARCHAR(30),
+    min_months INTEGER NOT NULL DEFAULT 0 CHECK (min_months >= 0), -- Time at the previous grade
+    min_attendance_rate NUMERIC(4,3) NOT NULL DEFAULT 0 CHECK (min_attendance_rate BETWEEN 0 AND 1)
+);
+
+CREATE TABLE IF NOT EXISTS grading_sessions (
+    id SERIAL PRIMARY KEY,
+    title VARCHAR(200) NOT NULL,
+    exam_date TIMESTAMP NOT NULL,
+    location VARCHAR(200),
+    status VARCHAR(20) NOT NULL DEFAULT 'planned' CHECK (status IN ('planned', 'completed', 'cancelled')),
+    notes TEXT,
+    created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Belt names are copied so that renaming the belt list keeps the history
+CREATE TABLE IF NOT EXISTS grading_candidates (
+    session_id INTEGER NOT NULL REFERENCES grading_sessions(id) ON DELETE CASCADE,
+    athlete_id INTEGER NOT NULL REFERENCES athletes(id) ON DELETE CASCADE,
+    current_belt VARCHAR(50) NOT NULL DEFAULT '',
+    target_belt VARCHAR(50) NOT NULL,
+    result VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (result IN ('pending', 'passed', 'failed', 'absent')),
+    score NUMERIC(5,2),
+    examiner_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    examiner_notes TEXT,
+    graded_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (session_id, athlete_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_grading_candidates_athlete ON grading_candidates(athlete_id);
+
+CREATE TABLE IF NOT EXISTS belt_promotions (
+    id SERIAL PRIMARY KEY,
+    athlete_id INTEGER NOT NULL REFERENCES athletes(id) ON DELETE CASCADE,
+    from_belt VARCHAR(50) NOT NULL DEFAULT '',
+    to_belt VARCHAR(50) NOT NULL,
+    promoted_at TIMESTAMP NOT NULL,
+    session_id INTEGER REFERENCES grading_sessions(id) ON DELETE SET NULL,
+    promoted_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    notes TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_belt_promotions_athlete ON belt_promotions(athlete_id, promoted_at DESC);
+
+-- A common sash progression; the club adjusts it in the admin
+INSERT INTO belts (name, rank, color, min_months, min_attendance_rate) VALUES
+    ('Blanche', 1, 'white', 0, 0),
+    ('Jaune', 2, 'yellow', 3, 0.6),
+    ('Orange', 3, 'orange', 4, 0.6),
+    ('Verte', 4, 'green', 6, 0.65),
+    ('Bleue', 5, 'blue', 6, 0.7),
+    ('Marron', 6, 'brown', 9, 0.75),
+    ('Noire', 7, 'black', 12, 0.8)
+ON CONFLICT (name) DO NOTHING;
@@ -0,0 +1,16 @@
+-- Migration: 032_announcements.down.sql
+-- Description: Revert 032_announcements.sql; the announcements themselves are kept
+
+DROP TABLE IF EXISTS announcement_reads;
+DROP TABLE IF EXISTS announcement_attachments;
+
+DROP INDEX IF EXISTS idx_announcements_is_pinned;
+DROP INDEX IF EXISTS idx_announcements_published_date;
+
+ALTER TABLE announcements DROP COLUMN IF EXISTS expires_at;
+ALTER TABLE announcements DROP COLUMN IF EXISTS target_roles;
+ALTER TABLE announcements DROP COLUMN IF EXISTS target_skill_levels;
+ALTER TABLE announcements DROP COLUMN IF EXISTS target_weight_categories;
+ALTER TABLE announcements DROP COLUMN IF EXISTS target_athlete_ids;
+ALTER TABLE announcements DROP COLUMN IF EXISTS created_by;
+ALTER TABLE announcements DROP COLUMN IF EXISTS updated_at;
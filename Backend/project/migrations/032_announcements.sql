@@ -0,0 +1,46 @@
+-- Migration: 032_announcements.sql
+-- Description: Revive announcements with audience targeting, scheduling, attachments and read tracking
+
+CREATE TABLE IF NOT EXISTS announcements (
+    id SERIAL PRIMARY KEY,
+    title VARCHAR(200) NOT NULL,
+    content TEXT NOT NULL,
+    published_date TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP, -- Visible from then on; may be in the future
+    is_pinned BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE announcements ADD COLUMN IF NOT EXISTS expires_at TIMESTAMP; -- NULL: never expires
+-- Empty lists do not restrict the audience. An athlete list, when given,
+-- replaces the skill level and weight category filters.
+ALTER TABLE announcements ADD COLUMN IF NOT EXISTS target_roles TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE announcements ADD COLUMN IF NOT EXISTS target_skill_levels TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE announcements ADD COLUMN IF NOT EXISTS target_weight_categories TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE announcements ADD COLUMN IF NOT EXISTS target_athlete_ids INTEGER[] NOT NULL DEFAULT '{}';
+ALTER TABLE announcements ADD COLUMN IF NOT EXISTS created_by INTEGER REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE announcements ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_announcements_is_pinned ON announcements(is_pinned);
+CREATE INDEX IF NOT EXISTS idx_announcements_published_date ON announcements(published_date);
+
+CREATE TABLE IF NOT EXISTS announcement_attachments (
+    id SERIAL PRIMARY KEY,
+    announcement_id INTEGER NOT NULL REFERENCES announcements(id) ON DELETE CASCADE,
+    file_name VARCHAR(255) NOT NULL,
+    file_url TEXT NOT NULL,
+    mime_type VARCHAR(100),
+    file_size_bytes BIGINT NOT NULL DEFAULT 0,
+    uploaded_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    uploaded_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_announcement_attachments_announcement ON announcement_attachments(announcement_id);
+
+CREATE TABLE IF NOT EXISTS announcement_reads (
+    announcement_id INTEGER NOT NULL REFERENCES announcements(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    read_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (announcement_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_announcement_reads_user ON announcement_reads(user_id);
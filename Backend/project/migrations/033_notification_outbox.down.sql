@@ -0,0 +1,5 @@
+-- Migration: 033_notification_outbox.down.sql
+-- Description: Revert 033_notification_outbox.sql
+
+DROP TABLE IF EXISTS notification_outbox;
+DROP TABLE IF EXISTS notification_preferences;
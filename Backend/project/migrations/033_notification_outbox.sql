@@ -0,0 +1,35 @@
+-- Migration: 033_notification_outbox.sql
+-- Description: Notification channel preferences and an outbox of messages sent by a background worker
+
+CREATE TABLE IF NOT EXISTS notification_preferences (
+    user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    language VARCHAR(2) NOT NULL DEFAULT 'fr' CHECK (language IN ('fr', 'ar')),
+    email_enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    sms_enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    phone VARCHAR(30), -- SMS number; athletes default to their profile phone
+    muted TEXT[] NOT NULL DEFAULT '{}', -- Template keys the user opted out of
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS notification_outbox (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER REFERENCES users(id) ON DELETE SET NULL, -- NULL for athletes without an account
+    athlete_id INTEGER REFERENCES athletes(id) ON DELETE SET NULL,
+    channel VARCHAR(10) NOT NULL CHECK (channel IN ('email', 'sms')),
+    recipient VARCHAR(255) NOT NULL, -- Email address or phone number
+    template VARCHAR(50) NOT NULL,
+    language VARCHAR(2) NOT NULL,
+    subject TEXT NOT NULL DEFAULT '',
+    body TEXT NOT NULL,
+    status VARCHAR(10) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'sending', 'sent', 'failed')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_error TEXT,
+    dedupe_key VARCHAR(200), -- Reminders are queued once per key and channel
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    sent_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_notification_outbox_due ON notification_outbox(next_attempt_at) WHERE status = 'pending';
+CREATE UNIQUE INDEX IF NOT EXISTS idx_notification_outbox_dedupe ON notification_outbox(dedupe_key, channel, recipient)
+    WHERE dedupe_key IS NOT NULL;
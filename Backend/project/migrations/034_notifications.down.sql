@@ -0,0 +1,4 @@
+-- Migration: 034_notifications.down.sql
+-- Description: Revert 034_notifications.sql
+
+DROP TABLE IF EXISTS notifications;
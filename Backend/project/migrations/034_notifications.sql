@@ -0,0 +1,19 @@
+-- Migration: 034_notifications.sql
+-- Description: Per-user in-app notifications, announced to live streams with NOTIFY
+
+CREATE TABLE IF NOT EXISTS notifications (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    athlete_id INTEGER REFERENCES athletes(id) ON DELETE SET NULL, -- Athlete the notification is about
+    type VARCHAR(50) NOT NULL, -- Template key, e.g. 'document_validated'
+    title VARCHAR(255) NOT NULL,
+    message TEXT NOT NULL,
+    dedupe_key VARCHAR(200), -- Reminders are stored once per key
+    read_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_notifications_user ON notifications(user_id, id DESC);
+CREATE INDEX IF NOT EXISTS idx_notifications_unread ON notifications(user_id) WHERE read_at IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_notifications_dedupe ON notifications(user_id, dedupe_key)
+    WHERE dedupe_key IS NOT NULL;
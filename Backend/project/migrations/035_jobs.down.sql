@@ -0,0 +1,5 @@
+-- Migration: 035_jobs.down.sql
+-- Description: Revert 035_jobs.sql
+
+DROP TABLE IF EXISTS job_schedules;
+DROP TABLE IF EXISTS jobs;
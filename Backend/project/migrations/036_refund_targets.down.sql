@@ -0,0 +1,6 @@
+-- Migration: 036_refund_targets.down.sql
+-- Description: Revert 036_refund_targets.sql; refunds stay attached to their period
+
+DROP INDEX IF EXISTS idx_payments_refunded;
+
+ALTER TABLE payments DROP COLUMN IF EXISTS refunded_payment_id;
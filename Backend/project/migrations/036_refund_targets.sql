@@ -0,0 +1,9 @@
+-- Migration: 036_refund_targets.sql
+-- Description: Record which payment a refund returns money from, so refunds of one installment add up
+
+-- A refund still belongs to its period through parent_payment_id; this is
+-- the period itself or one of its installments. Earlier refunds are left
+-- NULL: which installment they came from was not recorded.
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS refunded_payment_id INTEGER REFERENCES payments(id) ON DELETE RESTRICT;
+
+CREATE INDEX IF NOT EXISTS idx_payments_refunded ON payments(refunded_payment_id) WHERE refunded_payment_id IS NOT NULL;
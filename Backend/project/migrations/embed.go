@@ -0,0 +1,19 @@
+// Package migrations embeds the SQL migrations into the binaries, so the
+// server and cmd/migrate apply the files they were built with.
+//
+// Timestamps follow one rule in new tables. Club times (sessions, events,
+// bookings, announcements...) are TIMESTAMP holding the wall-clock time in
+// CLUB_TIMEZONE, read and written through models.WallClock. Instants that
+// workers schedule and compare with NOW(), such as when a queued job is due
+// or until when a worker holds it, are TIMESTAMPTZ (035_jobs). Older audit
+// columns (payment_date, checkouts, a few created_at) predate the rule and
+// are TIMESTAMPTZ too.
+package migrations
+
+import "embed"
+
+// FS holds the migrations: NNN_name.sql, optionally NNN_name.down.sql to
+// revert one, and NNN_name.baseline.sql schemas written by cmd/schema-check
+//
+//go:embed *.sql
+var FS embed.FS